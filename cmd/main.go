@@ -4,93 +4,311 @@ import (
 	"context"
 	"encoding/base64"
 	"flag"
-	"github.com/xige-16/stream-read/pkg/util/funcutil"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
 	"time"
 
+	"github.com/cockroachdb/errors"
 	"github.com/golang/protobuf/proto"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
 
 	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
 	"github.com/milvus-io/milvus-proto/go-api/v2/msgpb"
 	"github.com/milvus-io/milvus-sdk-go/v2/client"
-	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"github.com/xige-16/stream-read/internal/replay"
 	"github.com/xige-16/stream-read/pkg/log"
+	"github.com/xige-16/stream-read/pkg/metrics"
 	"github.com/xige-16/stream-read/pkg/mq/msgstream"
-	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper/archive"
+	"github.com/xige-16/stream-read/pkg/util/funcutil"
 	"github.com/xige-16/stream-read/pkg/util/paramtable"
 	"github.com/xige-16/stream-read/pkg/util/tsoutil"
+	"github.com/xige-16/stream-read/pkg/util/typeutil"
+)
+
+const (
+	modeReplay  = "replay"
+	modePITR    = "pitr"
+	modeCDC     = "cdc"
+	modeSubs    = "subs"
+	modeReshard = "reshard"
+	modeArchive = "archive"
+	modeConfig  = "config"
+)
+
+const (
+	subActionList        = "list"
+	subActionDelete      = "delete"
+	subActionDeleteStale = "delete_stale"
+	subActionReset       = "reset"
+)
+
+const (
+	configActionDump     = "dump"
+	configActionDiff     = "diff"
+	configActionValidate = "validate"
+	configActionYaml     = "yaml"
 )
 
+// configPrefixes are the prefixes of the config keys used by the tool.
+var configPrefixes = []string{"mq", "pulsar", "log", "replay"}
+
 func main() {
+	mode := flag.String("mode", modeReplay, "run mode, replay: replay one channel from sub_pos until now, pitr: replay a backup manifest until target_ts, "+
+		"cdc: replicate from sub_pos or a backup manifest continuously, subs: manage the subscriptions of topic_name, "+
+		"reshard: reproduce a collection from sub_pos or a backup manifest onto target_channels, "+
+		"archive: archive the comma separated topic_name into archive_dir continuously, "+
+		"config: inspect the configuration of the tool")
+
 	dbName := flag.String("db_name", "", "database name")
 	collectionName := flag.String("collection_name", "", "collection name")
 	collectionID := flag.Int64("collection_id", 0, "collection id")
 	topic := flag.String("topic_name", "", "topic name")
 	pos := flag.String("sub_pos", "", "sub pos")
 	subName := flag.String("sub_name", "recovery-milvus", "sub name")
+	uniqueSub := flag.Bool("unique_sub", false, "subscribe with a unique name prefixed by sub_name and clean it up on exit")
+
+	subAction := flag.String("sub_action", subActionList, "action of subs mode, list: list subscriptions and backlog, delete: delete sub_name, "+
+		"delete_stale: delete subscriptions prefixed by sub_name without consumers, reset: reset the cursor of sub_name to sub_pos or reset_time")
+	subForce := flag.Bool("sub_force", false, "delete the subscription even if it has active consumers, used in subs mode")
+	resetTime := flag.String("reset_time", "", "time to reset the cursor to in RFC3339 format, used in subs mode if sub_pos is not set")
+
+	configAction := flag.String("config_action", configActionDump, "action of config mode, dump: print the effective configs with their sources, "+
+		"diff: print the configs differing from the defaults, validate: report the invalid values and unknown keys of config_file, yaml: print a documented default yaml")
+	configFile := flag.String("config_file", "", "yaml file validated in config mode, or written by the yaml action instead of stdout")
+
+	manifestPath := flag.String("manifest", "", "path of the backup manifest, used in pitr mode")
+	targetTs := flag.Uint64("target_ts", 0, "hybrid timestamp to restore to, used in pitr mode")
+	targetTime := flag.String("target_time", "", "time to restore to in RFC3339 format, used in pitr mode if target_ts is not set")
 
 	milvusAddress := flag.String("milvus_address", "", "milvus address")
 	milvusUser := flag.String("milvus_user", "", "milvus user")
 	milvusPass := flag.String("milvus_password", "", "milvus password")
 
 	autoIDFieldName := flag.String("auto_id_field_name", "", "auto id field name")
+	pkFieldName := flag.String("pk_field_name", "", "primary key field name, used in reshard mode")
+	sourceSchema := flag.String("source_schema", "", "json schema file of the replayed collection, its inserts are converted to the schema of the target collection, "+
+		"used in replay, pitr and cdc mode")
+
+	rowsPerSecond := flag.Float64("rate_limit_rows", 0, "max rows written to milvus per second, 0 means unlimited")
+	bytesPerSecond := flag.Float64("rate_limit_bytes", 0, "max bytes written to milvus per second, 0 means unlimited")
+	maxBackoff := flag.Duration("rate_limit_max_backoff", 30*time.Second, "max backoff when milvus rejects writes by rate limit")
+	insertBatchRows := flag.Int("insert_batch_rows", 0, "max rows of one insert request, larger insert messages are split, 0 means one request per message")
+	partitions := flag.String("partitions", "", "comma separated partitions replayed, empty means all")
+
+	checkpointPath := flag.String("checkpoint", "replicate_checkpoint.json", "path of the checkpoint file, used in cdc mode")
+	checkpointInterval := flag.Duration("checkpoint_interval", 10*time.Second, "interval of saving checkpoints, used in cdc and archive mode")
+	ddlTypes := flag.String("ddl", "CreatePartition,DropPartition,CreateIndex,DropIndex", "comma separated ddl message types mirrored to the target, used in cdc mode")
+	idleTimeout := flag.Duration("idle_timeout", time.Minute, "reconnect the source if no message is received in it, used in cdc mode")
+	targetChannels := flag.String("target_channels", "", "comma separated pchannels the collection is resharded onto, used in reshard mode")
+	metricsAddress := flag.String("metrics_address", ":9091", "address serving prometheus metrics, used in cdc mode")
+	produceBatching := flag.Bool("produce_batching", false, "batch the messages produced to the target channels, used in reshard mode")
+	badMsgPolicy := flag.String("bad_msg_policy", msgstream.BadMsgPolicyFail, "policy of an undecodable or unreadable message, fail: stop with its message id, skip: log and skip it")
+	archiveDir := flag.String("archive_dir", "", "directory of the channel archives, written in archive mode, and consumed instead of the broker in the other modes if set")
+	archiveBucket := flag.Duration("archive_bucket", archive.DefaultBucketDuration, "time span of the messages in one archive segment file, used in archive mode")
+	archiveSegmentSize := flag.Int64("archive_segment_size", archive.DefaultMaxSegmentSize, "max bytes of one archive segment file, used in archive mode")
 
 	// 解析命令行参数
 	flag.Parse()
-	log.Info("parse args done", zap.String("dbName", *dbName),
+	log.Info("parse args done",
+		zap.String("mode", *mode),
+		zap.String("dbName", *dbName),
 		zap.String("collectionName", *collectionName),
 		zap.Int64("collectionID", *collectionID),
 		zap.String("topic", *topic),
 		zap.String("pos", *pos),
 		zap.String("subName", *subName),
+		zap.Bool("uniqueSub", *uniqueSub),
+		zap.String("subAction", *subAction),
+		zap.String("manifest", *manifestPath),
+		zap.Uint64("target ts", *targetTs),
+		zap.String("target time", *targetTime),
 		zap.String("milvus address", *milvusAddress),
 		zap.String("milvus user", *milvusUser),
 		zap.String("milvus pass", *milvusPass),
-		zap.String("auto id field name", *autoIDFieldName))
+		zap.String("auto id field name", *autoIDFieldName),
+		zap.String("pk field name", *pkFieldName),
+		zap.String("source schema", *sourceSchema),
+		zap.Float64("rate limit rows", *rowsPerSecond),
+		zap.Float64("rate limit bytes", *bytesPerSecond),
+		zap.Duration("rate limit max backoff", *maxBackoff),
+		zap.String("checkpoint", *checkpointPath),
+		zap.Duration("checkpoint interval", *checkpointInterval),
+		zap.String("ddl", *ddlTypes),
+		zap.Duration("idle timeout", *idleTimeout),
+		zap.String("metrics address", *metricsAddress),
+		zap.String("target channels", *targetChannels),
+		zap.String("bad msg policy", *badMsgPolicy),
+		zap.String("archive dir", *archiveDir))
 
-	startTime := time.Now().Unix()
+	// no mode quarantines the bad messages, a stream without a quarantine callback fails anyway
+	if *badMsgPolicy != msgstream.BadMsgPolicyFail && *badMsgPolicy != msgstream.BadMsgPolicySkip {
+		panic("invalid bad_msg_policy " + *badMsgPolicy + ", expect fail or skip")
+	}
 
-	positionByte, err := base64.StdEncoding.DecodeString(*pos)
-	if err != nil {
-		panic("decode pos failed!, " + err.Error())
+	if *mode == modeConfig {
+		paramtable.Init()
+		runConfig(paramtable.Get(), *configAction, *configFile)
+		return
 	}
-	position := &msgpb.MsgPosition{}
-	err = proto.Unmarshal(positionByte, position)
-	if err != nil {
-		panic("unmarshal position failed!, " + err.Error())
+
+	if *mode == modeSubs {
+		paramtable.Init()
+		factory := msgstream.NewPmsFactory(&paramtable.Get().ServiceParam)
+		runSubscriptionAdmin(factory, *subAction, *topic, *subName, *subForce, *pos, *resetTime)
+		return
 	}
-	pChan := funcutil.ToPhysicalChannel(position.ChannelName)
-	if pChan != *topic {
-		panic("topic not consistent with pos, expect = " + *topic + ", actual = " + pChan)
+
+	if *mode == modeArchive {
+		archiveChannels := parseChannels(*topic)
+		if len(archiveChannels) == 0 {
+			panic("topic is required in archive mode")
+		}
+		paramtable.Init()
+		paramtable.Get().Save(paramtable.Get().MQCfg.BadMsgPolicy.Key, *badMsgPolicy)
+		factory := msgstream.NewPmsFactory(&paramtable.Get().ServiceParam)
+		runArchive(context.Background(), factory, *archiveDir, archive.WriterConfig{
+			BucketDuration: *archiveBucket,
+			MaxSegmentSize: *archiveSegmentSize,
+		}, replay.ArchiveConfig{
+			SubName:      *subName,
+			Channels:     archiveChannels,
+			SyncInterval: *checkpointInterval,
+		})
+		return
+	}
+
+	var reshardChannels []string
+	if *mode == modeReshard {
+		reshardChannels = parseChannels(*targetChannels)
+		if len(reshardChannels) == 0 {
+			panic("target_channels is required in reshard mode")
+		}
+	}
+
+	var positions []*msgpb.MsgPosition
+	var stopTs uint64
+	switch {
+	case *mode == modeReplay || ((*mode == modeCDC || *mode == modeReshard) && len(*manifestPath) == 0):
+		stopTs = tsoutil.ComposeTSByTime(time.Now(), 0)
+		if len(*pos) == 0 {
+			panic("empty pos!")
+		}
+		positionByte, err := base64.StdEncoding.DecodeString(*pos)
+		if err != nil {
+			panic("decode pos failed!, " + err.Error())
+		}
+		position := &msgpb.MsgPosition{}
+		err = proto.Unmarshal(positionByte, position)
+		if err != nil {
+			panic("unmarshal position failed!, " + err.Error())
+		}
+		pChan := funcutil.ToPhysicalChannel(position.ChannelName)
+		if pChan != *topic {
+			panic("topic not consistent with pos, expect = " + *topic + ", actual = " + pChan)
+		}
+		position.ChannelName = pChan
+		positions = []*msgpb.MsgPosition{position}
+
+	case *mode == modePITR || *mode == modeCDC || *mode == modeReshard:
+		manifest, err := replay.LoadManifest(*manifestPath)
+		if err != nil {
+			panic("load manifest failed!, " + err.Error())
+		}
+		stopTs = *targetTs
+		if *mode == modeCDC {
+			// replication never stops, the target only validates the manifest
+			stopTs = tsoutil.ComposeTSByTime(time.Now(), 0)
+		} else if stopTs == 0 {
+			t, err := time.Parse(time.RFC3339, *targetTime)
+			if err != nil {
+				panic("parse target time failed!, " + err.Error())
+			}
+			stopTs = tsoutil.ComposeTSByTime(t, 0)
+		}
+		if err := manifest.Validate(stopTs); err != nil {
+			panic("invalid manifest!, " + err.Error())
+		}
+		positions, err = manifest.Positions()
+		if err != nil {
+			panic("invalid manifest!, " + err.Error())
+		}
+		*dbName = manifest.DbName
+		*collectionName = manifest.CollectionName
+		*collectionID = manifest.CollectionID
+
+	default:
+		panic("unknown mode " + *mode)
 	}
-	position.ChannelName = pChan
 
 	ctx := context.Background()
 	paramtable.Init()
 	Params := paramtable.Get()
-	factory := msgstream.NewPmsFactory(&Params.ServiceParam)
-	stream, err := factory.NewTtMsgStream(ctx)
-	if err != nil {
-		panic("init msg stream failed!, " + err.Error())
+	if *mode == modeCDC {
+		// catch up in pursuit mode before tailing
+		Params.Save(Params.MQCfg.EnablePursuitMode.Key, "true")
 	}
-
-	log := log.With(zap.String("topic", *topic), zap.String("subName", *subName))
-	log.Info("creating consumer...")
-	if len(*pos) == 0 {
-		panic("empty pos!")
+	// the replay settings are refreshed from the config at runtime, unless pinned by flags
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "rate_limit_rows":
+			Params.Save(Params.ReplayCfg.RateLimitRows.Key, strconv.FormatFloat(*rowsPerSecond, 'f', -1, 64))
+		case "rate_limit_bytes":
+			Params.Save(Params.ReplayCfg.RateLimitBytes.Key, strconv.FormatFloat(*bytesPerSecond, 'f', -1, 64))
+		case "rate_limit_max_backoff":
+			Params.Save(Params.ReplayCfg.RateLimitMaxBackoff.Key, strconv.FormatFloat(maxBackoff.Seconds(), 'f', -1, 64))
+		case "insert_batch_rows":
+			Params.Save(Params.ReplayCfg.InsertBatchRows.Key, strconv.Itoa(*insertBatchRows))
+		case "partitions":
+			Params.Save(Params.ReplayCfg.Partitions.Key, *partitions)
+		}
+	})
+	// no mode may silently lose the rows of a message it cannot decode, unless told to
+	Params.Save(Params.MQCfg.BadMsgPolicy.Key, *badMsgPolicy)
+	if *mode == modeReshard {
+		Params.Save(Params.MQCfg.EnableProduceBatching.Key, strconv.FormatBool(*produceBatching))
+		// milvus cannot decode the encoded payloads, and pulsar compresses the batches already
+		Params.Save(Params.MQCfg.PayloadCodec.Key, msgstream.CodecNone)
 	}
-	err = stream.AsConsumer(ctx, []string{*topic}, *subName, mqwrapper.SubscriptionPositionUnknown)
-	if err != nil {
-		panic("asConsumer failed!, " + err.Error())
+	var factory msgstream.Factory = msgstream.NewPmsFactory(&Params.ServiceParam)
+	if len(*archiveDir) > 0 {
+		factory = msgstream.NewArchiveFactory(*archiveDir, &Params.ServiceParam)
 	}
 
-	log.Info("start seek", zap.Any("pos", position))
-	err = stream.Seek(ctx, []*msgpb.MsgPosition{position})
-	if err != nil {
-		stream.Close()
-		panic("seek failed!, " + err.Error())
+	if *uniqueSub {
+		channels := make([]string, 0, len(positions))
+		for _, position := range positions {
+			channels = append(channels, position.GetChannelName())
+		}
+		var cleanup func()
+		*subName, cleanup = msgstream.UniqueSubscription(ctx, factory, *subName, channels)
+		defer cleanup()
+	}
+	log := log.With(zap.String("mode", *mode), zap.String("subName", *subName))
+
+	if *mode == modeReshard {
+		if len(*pkFieldName) == 0 {
+			panic("pk_field_name is required in reshard mode")
+		}
+		runReshard(ctx, factory, positions, replay.ReshardConfig{
+			Config: replay.Config{
+				CollectionID:   *collectionID,
+				CollectionName: *collectionName,
+				PKFieldName:    *pkFieldName,
+				StopTs:         stopTs,
+			},
+			TargetChannels: reshardChannels,
+		}, *subName)
+		return
 	}
-	log.Info("seek done!")
 
 	client, err := client.NewClient(ctx, client.Config{
 		Address:  *milvusAddress,
@@ -103,96 +321,258 @@ func main() {
 	}
 	defer client.Close()
 
+	writer := replay.NewWriter(client, replay.WriterConfigFromParams(Params))
+
 	log.Info("init milvus client done!")
-	for {
-		select {
-		case <-ctx.Done():
-			stream.Close()
-			return
-		case msgs, ok := <-stream.Chan():
-			if !ok {
-				return
+	var conversion *typeutil.ConversionPlan
+	if len(*sourceSchema) > 0 {
+		schema, err := replay.LoadSchema(*sourceSchema)
+		if err != nil {
+			panic("load source schema failed!, " + err.Error())
+		}
+		conversion, err = replay.PlanConversion(ctx, client, *collectionName, schema)
+		if err != nil {
+			panic("plan schema conversion failed!, " + err.Error())
+		}
+	}
+	if *mode == modeCDC {
+		runCDC(ctx, factory, writer, client, positions, replay.ReplicateConfig{
+			Config: replay.Config{
+				CollectionID:    *collectionID,
+				CollectionName:  *collectionName,
+				AutoIDFieldName: *autoIDFieldName,
+				Conversion:      conversion,
+			},
+			SubName:            *subName,
+			DDLTypes:           parseDDLTypes(*ddlTypes),
+			CheckpointInterval: *checkpointInterval,
+			IdleTimeout:        *idleTimeout,
+			ReconnectBackoff:   time.Second,
+			SteadyLag:          Params.MQCfg.PursuitLag.GetAsDuration(time.Second),
+		}, *checkpointPath, *metricsAddress)
+		return
+	}
+
+	stream, err := replay.SeekStream(ctx, factory, *subName, positions)
+	if err != nil {
+		panic("seek failed!, " + err.Error())
+	}
+	defer stream.Close()
+
+	replayer := replay.NewReplayer(replay.Config{
+		CollectionID:    *collectionID,
+		CollectionName:  *collectionName,
+		AutoIDFieldName: *autoIDFieldName,
+		StopTs:          stopTs,
+		Conversion:      conversion,
+	}, writer)
+	defer replay.WatchSettings(Params, replayer)()
+	if err := replayer.Run(ctx, stream); err != nil {
+		log.Error("replay failed", zap.Error(err))
+	}
+}
+
+func runCDC(ctx context.Context, factory msgstream.Factory, writer *replay.Writer, ddl replay.DDLTarget,
+	positions []*msgpb.MsgPosition, cfg replay.ReplicateConfig, checkpointPath string, metricsAddress string,
+) {
+	// save the checkpoint before exiting
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	registry := prometheus.NewRegistry()
+	metrics.RegisterReplicateMetrics(registry)
+	go func() {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+		if err := http.ListenAndServe(metricsAddress, mux); err != nil {
+			log.Warn("serve metrics failed", zap.Error(err))
+		}
+	}()
+
+	replicator := replay.NewReplicator(cfg, factory, writer, ddl, replay.NewFileCheckpointStore(checkpointPath), positions)
+	defer replicator.WatchSettings(paramtable.Get())()
+	if err := replicator.Run(ctx); err != nil {
+		log.Error("replicate failed", zap.Error(err))
+	}
+}
+
+func runReshard(ctx context.Context, factory msgstream.Factory, positions []*msgpb.MsgPosition, cfg replay.ReshardConfig, subName string) {
+	producer, err := factory.NewMsgStream(ctx)
+	if err != nil {
+		panic("init producer failed!, " + err.Error())
+	}
+	defer producer.Close()
+	producer.AsProducer(cfg.TargetChannels)
+
+	stream, err := replay.SeekStream(ctx, factory, subName, positions)
+	if err != nil {
+		panic("seek failed!, " + err.Error())
+	}
+	defer stream.Close()
+
+	resharder := replay.NewResharder(cfg, producer)
+	if err := resharder.Run(ctx, stream); err != nil {
+		log.Error("reshard failed", zap.Error(err))
+	}
+}
+
+func runArchive(ctx context.Context, factory msgstream.Factory, dir string, writerCfg archive.WriterConfig, cfg replay.ArchiveConfig) {
+	if len(dir) == 0 {
+		panic("archive_dir is required in archive mode")
+	}
+	// sync the archives before exiting
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	writer, err := archive.NewWriter(dir, writerCfg)
+	if err != nil {
+		panic("init archive writer failed!, " + err.Error())
+	}
+	defer writer.Close()
+
+	archiver := replay.NewArchiver(cfg, writer)
+	stream, err := archiver.OpenStream(ctx, factory)
+	if err != nil {
+		panic("open archive stream failed!, " + err.Error())
+	}
+	defer stream.Close()
+
+	if err := archiver.Run(ctx, stream); err != nil {
+		log.Error("archive failed", zap.Error(err))
+	}
+}
+
+func runSubscriptionAdmin(factory *msgstream.PmsFactory, action, topic, subName string, force bool, pos, resetTime string) {
+	admin, err := factory.NewSubscriptionAdmin()
+	if err != nil {
+		panic("init subscription admin failed, " + err.Error())
+	}
+
+	switch action {
+	case subActionList:
+		subs, err := admin.ListSubscriptions(topic)
+		if err != nil {
+			panic("list subscriptions failed, " + err.Error())
+		}
+		for _, sub := range subs {
+			log.Info("subscription",
+				zap.String("topic", topic),
+				zap.String("name", sub.Name),
+				zap.String("type", sub.Type),
+				zap.Int64("backlog", sub.Backlog),
+				zap.Int("consumers", sub.Consumers))
+		}
+	case subActionDelete:
+		if err := admin.DeleteSubscription(topic, subName, force); err != nil {
+			panic("delete subscription failed, " + err.Error())
+		}
+		log.Info("subscription deleted", zap.String("topic", topic), zap.String("name", subName))
+	case subActionDeleteStale:
+		deleted, err := admin.DeleteStaleSubscriptions(topic, subName)
+		if err != nil {
+			panic("delete stale subscriptions failed, " + err.Error())
+		}
+		log.Info("stale subscriptions deleted", zap.String("topic", topic), zap.Strings("names", deleted))
+	case subActionReset:
+		if len(pos) != 0 {
+			positionByte, err := base64.StdEncoding.DecodeString(pos)
+			if err != nil {
+				panic("decode pos failed!, " + err.Error())
+			}
+			position := &msgpb.MsgPosition{}
+			if err := proto.Unmarshal(positionByte, position); err != nil {
+				panic("unmarshal position failed!, " + err.Error())
 			}
-			timeOfBegin, _ := tsoutil.ParseTS(msgs.BeginTs)
-			log.Info("update recover process", zap.Int64("start time", startTime), zap.Int64("msg time", timeOfBegin.Unix()))
-			if timeOfBegin.Unix() >= startTime {
-				log.Info("recover done!")
-				return
+			err = admin.ResetCursorToMessageID(topic, subName, position.GetMsgID())
+			if err != nil {
+				panic("reset cursor failed, " + err.Error())
 			}
-			for _, msg := range msgs.Msgs {
-				switch msg.Type() {
-				case commonpb.MsgType_Insert:
-					imsg := msg.(*msgstream.InsertMsg)
-					imsgColname := imsg.GetCollectionName()
-					imsgCollID := imsg.GetCollectionID()
-					imsgPartName := imsg.GetPartitionName()
-					imsgFieldDatas := imsg.GetFieldsData()
-					numRows := imsg.GetNumRows()
-
-					if *collectionID != imsgCollID || *collectionName != imsgColname {
-						continue
-					}
-
-					log.Info("receive insert messages",
-						zap.String("coll", imsgColname),
-						zap.String("part", imsgPartName),
-						zap.Uint64("numRows", numRows))
-
-					columes := make([]entity.Column, 0)
-					var convertErr error
-					for _, fd := range imsgFieldDatas {
-						if len(*autoIDFieldName) != 0 && *autoIDFieldName != fd.GetFieldName() {
-							colume, err := entity.FieldDataColumn(fd, 0, int(numRows))
-
-							if err != nil {
-								convertErr = err
-								break
-							}
-							columes = append(columes, colume)
-						}
-					}
-					if convertErr != nil {
-						log.Error("convert inert msg failed", zap.Error(convertErr))
-						continue
-					}
-
-					_, err = client.Insert(ctx, imsgColname, imsgPartName, columes...)
-					if err != nil {
-						log.Error("insert msg failed", zap.Error(err))
-					}
-
-				case commonpb.MsgType_Delete:
-					dmsg := msg.(*msgstream.DeleteMsg)
-					dmsgColname := dmsg.GetCollectionName()
-					dmsgColID := dmsg.GetCollectionID()
-					dmsgPartName := dmsg.GetPartitionName()
-					dmsgIDs := dmsg.GetPrimaryKeys()
-					numRows := dmsg.GetNumRows()
-
-					if *collectionID != dmsgColID || *collectionName != dmsgColname {
-						continue
-					}
-
-					log.Info("receive delete messages", zap.Int64("numRows", dmsg.NumRows))
-
-					colume, err := entity.IDColumns(dmsgIDs, 0, int(numRows))
-					if err != nil {
-						log.Error("convert delete pks failed", zap.Error(err))
-						continue
-					}
-
-					err = client.DeleteByPks(ctx, dmsgColname, dmsgPartName, colume)
-					if err != nil {
-						log.Error("delete msg failed", zap.Error(err))
-					}
-				case commonpb.MsgType_DropCollection:
-					dropmsg := msg.(*msgstream.DropCollectionMsg)
-					if *collectionID == dropmsg.GetCollectionID() {
-						log.Info("collection droped, recovery done!")
-						return
-					}
-				}
+		} else {
+			t, err := time.Parse(time.RFC3339, resetTime)
+			if err != nil {
+				panic("parse reset time failed!, " + err.Error())
 			}
+			if err := admin.ResetCursorToTimestamp(topic, subName, t); err != nil {
+				panic("reset cursor failed, " + err.Error())
+			}
+		}
+		log.Info("subscription cursor reset", zap.String("topic", topic), zap.String("name", subName))
+	default:
+		panic("unknown sub action " + action)
+	}
+}
+
+func runConfig(params *paramtable.ComponentParam, action, configFile string) {
+	switch action {
+	case configActionDump, configActionDiff:
+		infos := params.Dump(configPrefixes...)
+		if action == configActionDiff {
+			infos = params.DiffDefaults(configPrefixes...)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "KEY\tVALUE\tDEFAULT\tSOURCE\tREFRESHABLE")
+		for _, info := range infos {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%t\n", info.Key, info.Value, info.DefaultValue, info.Source, info.Refreshable)
+		}
+		w.Flush()
+	case configActionValidate:
+		if len(configFile) == 0 {
+			panic("config_file is required to validate")
+		}
+		err := params.ValidateFile(configFile)
+		var validationErr *paramtable.ValidationError
+		if errors.As(err, &validationErr) {
+			for _, p := range validationErr.Params {
+				log.Error("invalid config", zap.String("key", p.Key), zap.String("value", p.Value), zap.Error(p.Err))
+			}
+			os.Exit(1)
+		} else if err != nil {
+			panic("validate config failed, " + err.Error())
+		}
+		log.Info("config validated", zap.String("file", configFile))
+	case configActionYaml:
+		out := os.Stdout
+		if len(configFile) > 0 {
+			f, err := os.Create(configFile)
+			if err != nil {
+				panic("create config file failed, " + err.Error())
+			}
+			defer f.Close()
+			out = f
+		}
+		if err := params.WriteYaml(out, configPrefixes...); err != nil {
+			panic("write yaml failed, " + err.Error())
+		}
+	default:
+		panic("unknown config action " + action)
+	}
+}
+
+func parseDDLTypes(s string) []commonpb.MsgType {
+	types := make([]commonpb.MsgType, 0)
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if len(name) == 0 {
+			continue
+		}
+		t, ok := commonpb.MsgType_value[name]
+		if !ok {
+			panic("unknown ddl type " + name)
+		}
+		types = append(types, commonpb.MsgType(t))
+	}
+	return types
+}
+
+// parseChannels parses a comma separated channel list, the empty entries are dropped.
+func parseChannels(s string) []string {
+	channels := make([]string, 0)
+	for _, channel := range strings.Split(s, ",") {
+		channel = strings.TrimSpace(channel)
+		if len(channel) == 0 {
+			continue
 		}
+		channels = append(channels, channel)
 	}
+	return channels
 }
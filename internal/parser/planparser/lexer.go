@@ -0,0 +1,261 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planparser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xige-16/stream-read/pkg/util/merr"
+)
+
+// ParseError is an error of the expression at a position, it matches merr.ErrParameterInvalid.
+type ParseError struct {
+	Expr string
+	// Pos is the 1-based byte offset of the error in Expr
+	Pos int
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("invalid expression %q: %s at position %d", e.Expr, e.Msg, e.Pos)
+}
+
+func (e *ParseError) Unwrap() error {
+	return merr.ErrParameterInvalid
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenInt
+	tokenFloat
+	tokenString
+	// tokenOp is an operator or a punctuation
+	tokenOp
+)
+
+type token struct {
+	kind tokenKind
+	// text is the unquoted value of a string token
+	text string
+	pos  int
+}
+
+// is returns whether t is the operator op, or the keyword op which is case-insensitive.
+func (t token) is(op string) bool {
+	switch t.kind {
+	case tokenOp:
+		return t.text == op
+	case tokenIdent:
+		return strings.EqualFold(t.text, op)
+	default:
+		return false
+	}
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of expression"
+	case tokenString:
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("'%s'", t.text)
+	}
+}
+
+var keywords = map[string]struct{}{
+	"and": {}, "or": {}, "not": {}, "in": {}, "like": {}, "exists": {}, "true": {}, "false": {},
+}
+
+func isKeyword(t token) bool {
+	if t.kind != tokenIdent {
+		return false
+	}
+	_, ok := keywords[strings.ToLower(t.text)]
+	return ok
+}
+
+// the longer operators are matched first
+var operators = []string{
+	"==", "!=", "<=", ">=", "&&", "||",
+	"<", ">", "+", "-", "*", "/", "%", "!", "(", ")", "[", "]", ",",
+}
+
+func tokenize(expr string) ([]token, error) {
+	tokens := make([]token, 0)
+	for pos := 0; pos < len(expr); {
+		c := expr[pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			pos++
+
+		case isIdentStart(c):
+			end := pos + 1
+			for end < len(expr) && isIdentPart(expr[end]) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: expr[pos:end], pos: pos})
+			pos = end
+
+		case isDigit(c) || c == '.' && pos+1 < len(expr) && isDigit(expr[pos+1]),
+			// a negative literal is scanned whole, so the min int64 does not overflow before negated
+			c == '-' && pos+1 < len(expr) && isDigit(expr[pos+1]) && !followsOperand(tokens):
+			t, end, err := scanNumber(expr, pos)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, t)
+			pos = end
+
+		case c == '"' || c == '\'':
+			t, end, err := scanString(expr, pos)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, t)
+			pos = end
+
+		default:
+			matched := false
+			for _, op := range operators {
+				if strings.HasPrefix(expr[pos:], op) {
+					tokens = append(tokens, token{kind: tokenOp, text: op, pos: pos})
+					pos += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				r, _ := utf8.DecodeRuneInString(expr[pos:])
+				return nil, &ParseError{Expr: expr, Pos: pos + 1, Msg: fmt.Sprintf("unexpected character %q", r)}
+			}
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(expr)}), nil
+}
+
+// followsOperand returns whether the last of tokens ends an operand, a '-' after it is a binary operator.
+func followsOperand(tokens []token) bool {
+	if len(tokens) == 0 {
+		return false
+	}
+	last := tokens[len(tokens)-1]
+	switch last.kind {
+	case tokenIdent:
+		return !isKeyword(last) || last.is("true") || last.is("false")
+	case tokenOp:
+		return last.is(")") || last.is("]")
+	default:
+		return true
+	}
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+func scanNumber(expr string, pos int) (token, int, error) {
+	end := pos
+	if expr[end] == '-' {
+		end++
+	}
+	isFloat := false
+	if strings.HasPrefix(expr[end:], "0x") || strings.HasPrefix(expr[end:], "0X") {
+		end += 2
+		for end < len(expr) && strings.IndexByte("0123456789abcdefABCDEF", expr[end]) >= 0 {
+			end++
+		}
+	} else {
+		for end < len(expr) && isDigit(expr[end]) {
+			end++
+		}
+		if end < len(expr) && expr[end] == '.' {
+			isFloat = true
+			end++
+			for end < len(expr) && isDigit(expr[end]) {
+				end++
+			}
+		}
+		if end < len(expr) && (expr[end] == 'e' || expr[end] == 'E') {
+			isFloat = true
+			end++
+			if end < len(expr) && (expr[end] == '+' || expr[end] == '-') {
+				end++
+			}
+			for end < len(expr) && isDigit(expr[end]) {
+				end++
+			}
+		}
+	}
+
+	invalid := end < len(expr) && isIdentPart(expr[end])
+	for end < len(expr) && isIdentPart(expr[end]) {
+		end++
+	}
+	text := expr[pos:end]
+	var err error
+	if isFloat {
+		_, err = strconv.ParseFloat(text, 64)
+	} else {
+		_, err = strconv.ParseInt(text, 0, 64)
+	}
+	if err != nil || invalid {
+		return token{}, 0, &ParseError{Expr: expr, Pos: pos + 1, Msg: fmt.Sprintf("invalid number %s", text)}
+	}
+	if isFloat {
+		return token{kind: tokenFloat, text: text, pos: pos}, end, nil
+	}
+	return token{kind: tokenInt, text: text, pos: pos}, end, nil
+}
+
+// scanString reads a single or double quoted string with the escapes of go.
+func scanString(expr string, pos int) (token, int, error) {
+	quote := expr[pos]
+	var b strings.Builder
+	s := expr[pos+1:]
+	for len(s) > 0 {
+		if s[0] == quote {
+			end := len(expr) - len(s) + 1
+			return token{kind: tokenString, text: b.String(), pos: pos}, end, nil
+		}
+		value, multibyte, tail, err := strconv.UnquoteChar(s, quote)
+		if err != nil {
+			return token{}, 0, &ParseError{Expr: expr, Pos: len(expr) - len(s) + 1, Msg: "invalid escape in string"}
+		}
+		if value < utf8.RuneSelf || multibyte {
+			b.WriteRune(value)
+		} else {
+			b.WriteByte(byte(value))
+		}
+		s = tail
+	}
+	return token{}, 0, &ParseError{Expr: expr, Pos: pos + 1, Msg: "unterminated string"}
+}
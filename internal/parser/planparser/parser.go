@@ -0,0 +1,1020 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package planparser parses the boolean expressions of milvus into planpb.Expr.
+//
+// The grammar is the one of the milvus proxy:
+//
+//	expr      = or
+//	or        = and { ("or" | "||") and }
+//	and       = not { ("and" | "&&") not }
+//	not       = ("not" | "!") not | predicate
+//	predicate = "(" expr ")" | "exists" column | contains | operand [ cmp operand [ cmp operand ] ]
+//	          | operand ["not"] "in" array | operand "like" string
+//	contains  = ("json_contains" | "array_contains" | ...) "(" column "," value ")"
+//	operand   = term { ("+" | "-") term }
+//	term      = unary { ("*" | "/" | "%") unary }
+//	unary     = "-" unary | literal | array | column | "array_length" "(" column ")"
+//	column    = identifier { "[" (string | integer) "]" }
+//
+// The keywords are case-insensitive. A name which is not a field of the schema is a key of the
+// dynamic field, and an arithmetic operand can only be a column with a constant.
+package planparser
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/xige-16/stream-read/internal/proto/planpb"
+	"github.com/xige-16/stream-read/pkg/util/typeutil"
+)
+
+// ParseExpr parses exprStr into a planpb.Expr, the names in it are resolved against schema.
+// An error is a *ParseError pointing at the position of the problem.
+func ParseExpr(schema *typeutil.SchemaHelper, exprStr string) (*planpb.Expr, error) {
+	if strings.TrimSpace(exprStr) == "" {
+		return &planpb.Expr{Expr: &planpb.Expr_AlwaysTrueExpr{AlwaysTrueExpr: &planpb.AlwaysTrueExpr{}}}, nil
+	}
+	tokens, err := tokenize(exprStr)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{schema: schema, exprStr: exprStr, tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf(t, "unexpected %s", t)
+	}
+	return expr, nil
+}
+
+type parser struct {
+	schema  *typeutil.SchemaHelper
+	exprStr string
+	tokens  []token
+	cur     int
+}
+
+// operand is a constant, a column or an arithmetic op on a column.
+type operand struct {
+	pos   token
+	value *planpb.GenericValue
+
+	column *planpb.ColumnInfo
+	// typ is the type of the values read by column, the element type of an indexed array
+	typ schemapb.DataType
+
+	// arithOp is applied to the column with arithRight
+	arithOp    planpb.ArithOpType
+	arithRight *planpb.GenericValue
+}
+
+func (o *operand) isConst() bool {
+	return o.value != nil
+}
+
+func (o *operand) isColumn() bool {
+	return o.column != nil && o.arithOp == planpb.ArithOpType_Unknown
+}
+
+func (o *operand) isArith() bool {
+	return o.column != nil && o.arithOp != planpb.ArithOpType_Unknown
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.cur]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.cur]
+	if t.kind != tokenEOF {
+		p.cur++
+	}
+	return t
+}
+
+// accept consumes the next token if it is one of ops.
+func (p *parser) accept(ops ...string) (token, bool) {
+	t := p.peek()
+	for _, op := range ops {
+		if t.is(op) {
+			p.next()
+			return t, true
+		}
+	}
+	return t, false
+}
+
+func (p *parser) expect(op string) (token, error) {
+	t, ok := p.accept(op)
+	if !ok {
+		return t, p.errorf(t, "expected '%s' but got %s", op, t)
+	}
+	return t, nil
+}
+
+func (p *parser) errorf(t token, format string, args ...any) error {
+	return &ParseError{Expr: p.exprStr, Pos: t.pos + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) parseOr() (*planpb.Expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("or", "||"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr(planpb.BinaryExpr_LogicalOr, left, right)
+	}
+}
+
+func (p *parser) parseAnd() (*planpb.Expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("and", "&&"); !ok {
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryExpr(planpb.BinaryExpr_LogicalAnd, left, right)
+	}
+}
+
+func (p *parser) parseNot() (*planpb.Expr, error) {
+	if _, ok := p.accept("not", "!"); ok {
+		child, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return notExpr(child), nil
+	}
+	return p.parsePredicate()
+}
+
+func (p *parser) parsePredicate() (*planpb.Expr, error) {
+	t := p.peek()
+	if _, ok := p.accept("("); ok {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	}
+	if _, ok := p.accept("exists"); ok {
+		return p.parseExists()
+	}
+	if op, ok := containsOps[strings.ToLower(t.text)]; ok && t.kind == tokenIdent && p.tokens[p.cur+1].is("(") {
+		p.next()
+		return p.parseContains(t, op)
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	t = p.peek()
+	switch {
+	case isCompareOp(t):
+		p.next()
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		if next := p.peek(); isCompareOp(next) {
+			p.next()
+			upper, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			return p.rangeExpr(left, t, right, next, upper)
+		}
+		return p.compareExpr(compareOps[t.text], t, left, right)
+
+	case t.is("in"):
+		p.next()
+		return p.termExpr(left)
+
+	case t.is("not"):
+		p.next()
+		if _, err := p.expect("in"); err != nil {
+			return nil, err
+		}
+		expr, err := p.termExpr(left)
+		if err != nil {
+			return nil, err
+		}
+		return notExpr(expr), nil
+
+	case t.is("like"):
+		p.next()
+		return p.likeExpr(left)
+
+	default:
+		return p.boolExpr(left)
+	}
+}
+
+var compareOps = map[string]planpb.OpType{
+	"<":  planpb.OpType_LessThan,
+	"<=": planpb.OpType_LessEqual,
+	">":  planpb.OpType_GreaterThan,
+	">=": planpb.OpType_GreaterEqual,
+	"==": planpb.OpType_Equal,
+	"!=": planpb.OpType_NotEqual,
+}
+
+// reversedOps is the op with the operands swapped
+var reversedOps = map[planpb.OpType]planpb.OpType{
+	planpb.OpType_LessThan:     planpb.OpType_GreaterThan,
+	planpb.OpType_LessEqual:    planpb.OpType_GreaterEqual,
+	planpb.OpType_GreaterThan:  planpb.OpType_LessThan,
+	planpb.OpType_GreaterEqual: planpb.OpType_LessEqual,
+	planpb.OpType_Equal:        planpb.OpType_Equal,
+	planpb.OpType_NotEqual:     planpb.OpType_NotEqual,
+}
+
+var containsOps = map[string]planpb.JSONContainsExpr_JSONOp{
+	"json_contains":      planpb.JSONContainsExpr_Contains,
+	"json_contains_all":  planpb.JSONContainsExpr_ContainsAll,
+	"json_contains_any":  planpb.JSONContainsExpr_ContainsAny,
+	"array_contains":     planpb.JSONContainsExpr_Contains,
+	"array_contains_all": planpb.JSONContainsExpr_ContainsAll,
+	"array_contains_any": planpb.JSONContainsExpr_ContainsAny,
+}
+
+func isCompareOp(t token) bool {
+	_, ok := compareOps[t.text]
+	return ok && t.kind == tokenOp
+}
+
+func (p *parser) parseOperand() (*operand, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.accept("+", "-")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		op := planpb.ArithOpType_Add
+		if t.text == "-" {
+			op = planpb.ArithOpType_Sub
+		}
+		if left, err = p.arith(t, op, left, right); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) parseTerm() (*operand, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t, ok := p.accept("*", "/", "%")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		op := map[string]planpb.ArithOpType{
+			"*": planpb.ArithOpType_Mul,
+			"/": planpb.ArithOpType_Div,
+			"%": planpb.ArithOpType_Mod,
+		}[t.text]
+		if left, err = p.arith(t, op, left, right); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (p *parser) parseUnary() (*operand, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenInt:
+		v, _ := strconv.ParseInt(t.text, 0, 64)
+		return &operand{pos: t, value: int64Value(v)}, nil
+	case tokenFloat:
+		v, _ := strconv.ParseFloat(t.text, 64)
+		return &operand{pos: t, value: floatValue(v)}, nil
+	case tokenString:
+		return &operand{pos: t, value: stringValue(t.text)}, nil
+	case tokenIdent:
+		switch {
+		case t.is("true"), t.is("false"):
+			return &operand{pos: t, value: boolValue(t.is("true"))}, nil
+		case isKeyword(t):
+			return nil, p.errorf(t, "unexpected keyword %s", t)
+		case strings.EqualFold(t.text, "array_length") && p.peek().is("("):
+			return p.parseArrayLength(t)
+		default:
+			return p.parseColumn(t)
+		}
+	}
+
+	switch {
+	case t.is("-"):
+		o, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		switch v := o.value.GetVal().(type) {
+		case *planpb.GenericValue_Int64Val:
+			return &operand{pos: t, value: int64Value(-v.Int64Val)}, nil
+		case *planpb.GenericValue_FloatVal:
+			return &operand{pos: t, value: floatValue(-v.FloatVal)}, nil
+		default:
+			return nil, p.errorf(t, "'-' can only be applied to a number")
+		}
+	case t.is("["):
+		return p.parseArray(t)
+	default:
+		return nil, p.errorf(t, "unexpected %s", t)
+	}
+}
+
+// parseArray parses the elements of an array literal after '['.
+func (p *parser) parseArray(start token) (*operand, error) {
+	array := &planpb.Array{SameType: true}
+	for !p.peek().is("]") {
+		if len(array.Array) > 0 {
+			if _, err := p.expect(","); err != nil {
+				return nil, err
+			}
+		}
+		element, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if !element.isConst() {
+			return nil, p.errorf(element.pos, "array elements must be constants")
+		}
+		array.Array = append(array.Array, element.value)
+	}
+	p.next()
+	for i, element := range array.Array {
+		if i == 0 {
+			array.ElementType = valueType(element)
+		} else if valueType(element) != array.ElementType {
+			array.SameType = false
+		}
+	}
+	if !array.SameType {
+		array.ElementType = schemapb.DataType_None
+	}
+	return &operand{pos: start, value: &planpb.GenericValue{Val: &planpb.GenericValue_ArrayVal{ArrayVal: array}}}, nil
+}
+
+// parseColumn parses the index path after the name of a column and resolves it.
+func (p *parser) parseColumn(name token) (*operand, error) {
+	var path []string
+	for {
+		if _, ok := p.accept("["); !ok {
+			break
+		}
+		t := p.next()
+		switch t.kind {
+		case tokenString:
+			path = append(path, t.text)
+		case tokenInt:
+			if strings.HasPrefix(t.text, "-") {
+				return nil, p.errorf(t, "negative index %s", t.text)
+			}
+			path = append(path, t.text)
+		default:
+			return nil, p.errorf(t, "expected a string or an integer index but got %s", t)
+		}
+		if _, err := p.expect("]"); err != nil {
+			return nil, err
+		}
+	}
+
+	field, err := p.schema.GetFieldFromNameDefaultJSON(name.text)
+	if err != nil {
+		return nil, p.errorf(name, "field %s not exist", name.text)
+	}
+	if field.GetName() != name.text {
+		// a key of the dynamic field
+		path = append([]string{name.text}, path...)
+	}
+	info := &planpb.ColumnInfo{
+		FieldId:        field.GetFieldID(),
+		DataType:       field.GetDataType(),
+		IsPrimaryKey:   field.GetIsPrimaryKey(),
+		IsAutoID:       field.GetAutoID(),
+		NestedPath:     path,
+		IsPartitionKey: field.GetIsPartitionKey(),
+		ElementType:    field.GetElementType(),
+	}
+
+	typ := field.GetDataType()
+	switch {
+	case typeutil.IsVectorType(typ):
+		return nil, p.errorf(name, "vector field %s can not be filtered on", name.text)
+	case typeutil.IsJSONType(typ):
+	case typeutil.IsArrayType(typ):
+		if len(path) > 1 {
+			return nil, p.errorf(name, "array field %s can only be indexed once", name.text)
+		}
+		if len(path) == 1 {
+			if _, err := strconv.ParseInt(path[0], 0, 64); err != nil {
+				return nil, p.errorf(name, "array field %s must be indexed by an integer", name.text)
+			}
+			typ = field.GetElementType()
+		}
+	case len(path) > 0:
+		return nil, p.errorf(name, "field %s of type %s can not be indexed", name.text, typ)
+	}
+	return &operand{pos: name, column: info, typ: typ}, nil
+}
+
+// parseArrayLength parses array_length(column).
+func (p *parser) parseArrayLength(name token) (*operand, error) {
+	p.next()
+	column, err := p.parseColumnArg()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	if column.typ != schemapb.DataType_Array && column.typ != schemapb.DataType_JSON {
+		return nil, p.errorf(column.pos, "array_length can only be applied to an array or json field")
+	}
+	column.pos = name
+	column.typ = schemapb.DataType_Int64
+	column.arithOp = planpb.ArithOpType_ArrayLength
+	return column, nil
+}
+
+func (p *parser) parseColumnArg() (*operand, error) {
+	t := p.next()
+	if t.kind != tokenIdent || isKeyword(t) {
+		return nil, p.errorf(t, "expected a field but got %s", t)
+	}
+	return p.parseColumn(t)
+}
+
+func (p *parser) parseExists() (*planpb.Expr, error) {
+	column, err := p.parseColumnArg()
+	if err != nil {
+		return nil, err
+	}
+	if column.typ != schemapb.DataType_JSON || len(column.column.GetNestedPath()) == 0 {
+		return nil, p.errorf(column.pos, "exists can only be applied to a key of a json field")
+	}
+	return &planpb.Expr{Expr: &planpb.Expr_ExistsExpr{ExistsExpr: &planpb.ExistsExpr{Info: column.column}}}, nil
+}
+
+// parseContains parses the arguments of a contains function.
+func (p *parser) parseContains(name token, op planpb.JSONContainsExpr_JSONOp) (*planpb.Expr, error) {
+	if _, err := p.expect("("); err != nil {
+		return nil, err
+	}
+	column, err := p.parseColumnArg()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(","); err != nil {
+		return nil, err
+	}
+	arg, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	if column.typ != schemapb.DataType_Array && column.typ != schemapb.DataType_JSON {
+		return nil, p.errorf(column.pos, "%s can only be applied to an array or json field", name.text)
+	}
+	if !arg.isConst() {
+		return nil, p.errorf(arg.pos, "%s expects a constant", name.text)
+	}
+
+	elements := []*planpb.GenericValue{arg.value}
+	if op != planpb.JSONContainsExpr_Contains {
+		array := arg.value.GetArrayVal()
+		if array == nil {
+			return nil, p.errorf(arg.pos, "%s expects an array", name.text)
+		}
+		elements = array.GetArray()
+	}
+	sameType := true
+	for i, element := range elements {
+		if column.typ == schemapb.DataType_Array {
+			if elements[i], err = p.castValue(arg.pos, column.column.GetElementType(), element); err != nil {
+				return nil, err
+			}
+		}
+		if valueType(elements[i]) != valueType(elements[0]) {
+			sameType = false
+		}
+	}
+	return &planpb.Expr{Expr: &planpb.Expr_JsonContainsExpr{JsonContainsExpr: &planpb.JSONContainsExpr{
+		ColumnInfo:       column.column,
+		Elements:         elements,
+		Op:               op,
+		ElementsSameType: sameType,
+	}}}, nil
+}
+
+// arith applies op to the operands, the constants are folded.
+func (p *parser) arith(t token, op planpb.ArithOpType, left, right *operand) (*operand, error) {
+	if left.isConst() && right.isConst() {
+		value, err := p.foldArith(t, op, left.value, right.value)
+		if err != nil {
+			return nil, err
+		}
+		return &operand{pos: left.pos, value: value}, nil
+	}
+	if left.isConst() && (op == planpb.ArithOpType_Add || op == planpb.ArithOpType_Mul) {
+		left, right = right, left
+	}
+	if !left.isColumn() || !right.isConst() {
+		return nil, p.errorf(t, "arithmetic is only supported between a field and a constant")
+	}
+	if !typeutil.IsArithmetic(left.typ) && !typeutil.IsJSONType(left.typ) {
+		return nil, p.errorf(t, "arithmetic is not supported on %s", left.typ)
+	}
+	if isZero(right.value) && (op == planpb.ArithOpType_Div || op == planpb.ArithOpType_Mod) {
+		return nil, p.errorf(right.pos, "division by zero")
+	}
+	value, err := p.castOperand(right.pos, left, right.value)
+	if err != nil {
+		return nil, err
+	}
+	if op == planpb.ArithOpType_Mod && (!isInt(value) || typeutil.IsFloatingType(left.typ)) {
+		return nil, p.errorf(t, "'%%' is only supported on integers")
+	}
+	return &operand{pos: left.pos, column: left.column, typ: left.typ, arithOp: op, arithRight: value}, nil
+}
+
+func (p *parser) foldArith(t token, op planpb.ArithOpType, left, right *planpb.GenericValue) (*planpb.GenericValue, error) {
+	if isInt(left) && isInt(right) {
+		l, r := left.GetInt64Val(), right.GetInt64Val()
+		switch op {
+		case planpb.ArithOpType_Add:
+			return int64Value(l + r), nil
+		case planpb.ArithOpType_Sub:
+			return int64Value(l - r), nil
+		case planpb.ArithOpType_Mul:
+			return int64Value(l * r), nil
+		}
+		if r == 0 {
+			return nil, p.errorf(t, "division by zero")
+		}
+		if op == planpb.ArithOpType_Div {
+			return int64Value(l / r), nil
+		}
+		return int64Value(l % r), nil
+	}
+
+	l, lok := toFloat(left)
+	r, rok := toFloat(right)
+	if !lok || !rok {
+		return nil, p.errorf(t, "arithmetic is only supported on numbers")
+	}
+	switch op {
+	case planpb.ArithOpType_Add:
+		return floatValue(l + r), nil
+	case planpb.ArithOpType_Sub:
+		return floatValue(l - r), nil
+	case planpb.ArithOpType_Mul:
+		return floatValue(l * r), nil
+	case planpb.ArithOpType_Div:
+		if r == 0 {
+			return nil, p.errorf(t, "division by zero")
+		}
+		return floatValue(l / r), nil
+	default:
+		return nil, p.errorf(t, "'%%' is only supported on integers")
+	}
+}
+
+// compareExpr builds left op right.
+func (p *parser) compareExpr(op planpb.OpType, t token, left, right *operand) (*planpb.Expr, error) {
+	if left.isConst() && !right.isConst() {
+		left, right = right, left
+		op = reversedOps[op]
+	}
+
+	switch {
+	case left.isConst():
+		return nil, p.errorf(t, "comparison between two constants is not supported")
+
+	case left.isColumn() && right.isConst():
+		if err := p.checkComparable(t, op, left); err != nil {
+			return nil, err
+		}
+		value, err := p.castOperand(right.pos, left, right.value)
+		if err != nil {
+			return nil, err
+		}
+		return &planpb.Expr{Expr: &planpb.Expr_UnaryRangeExpr{UnaryRangeExpr: &planpb.UnaryRangeExpr{
+			ColumnInfo: left.column,
+			Op:         op,
+			Value:      value,
+		}}}, nil
+
+	case left.isArith() && right.isConst():
+		value, err := p.castOperand(right.pos, left, right.value)
+		if err != nil {
+			return nil, err
+		}
+		return &planpb.Expr{Expr: &planpb.Expr_BinaryArithOpEvalRangeExpr{BinaryArithOpEvalRangeExpr: &planpb.BinaryArithOpEvalRangeExpr{
+			ColumnInfo:   left.column,
+			ArithOp:      left.arithOp,
+			RightOperand: left.arithRight,
+			Op:           op,
+			Value:        value,
+		}}}, nil
+
+	case left.isColumn() && right.isColumn():
+		for _, o := range []*operand{left, right} {
+			if err := p.checkComparable(t, op, o); err != nil {
+				return nil, err
+			}
+			if o.typ == schemapb.DataType_JSON || o.typ == schemapb.DataType_Array {
+				return nil, p.errorf(o.pos, "comparison between two fields is not supported on %s", o.typ)
+			}
+		}
+		if !(typeutil.IsArithmetic(left.typ) && typeutil.IsArithmetic(right.typ)) &&
+			!(typeutil.IsStringType(left.typ) && typeutil.IsStringType(right.typ)) &&
+			!(typeutil.IsBoolType(left.typ) && typeutil.IsBoolType(right.typ)) {
+			return nil, p.errorf(t, "can not compare %s with %s", left.typ, right.typ)
+		}
+		return &planpb.Expr{Expr: &planpb.Expr_CompareExpr{CompareExpr: &planpb.CompareExpr{
+			LeftColumnInfo:  left.column,
+			RightColumnInfo: right.column,
+			Op:              op,
+		}}}, nil
+
+	default:
+		return nil, p.errorf(t, "comparison is only supported between a field and a constant or two fields")
+	}
+}
+
+// checkComparable returns an error if column can not be compared by op.
+func (p *parser) checkComparable(t token, op planpb.OpType, column *operand) error {
+	ordering := op != planpb.OpType_Equal && op != planpb.OpType_NotEqual
+	switch {
+	case column.typ == schemapb.DataType_JSON && len(column.column.GetNestedPath()) == 0:
+		return p.errorf(column.pos, "json field %s can not be compared directly, use a key of it", column.pos.text)
+	case ordering && column.typ == schemapb.DataType_Array:
+		return p.errorf(t, "array field %s can only be compared by == or !=", column.pos.text)
+	case ordering && typeutil.IsBoolType(column.typ):
+		return p.errorf(t, "bool field %s can only be compared by == or !=", column.pos.text)
+	}
+	return nil
+}
+
+// rangeExpr builds lower op1 column op2 upper.
+func (p *parser) rangeExpr(lower *operand, t1 token, column *operand, t2 token, upper *operand) (*planpb.Expr, error) {
+	op1, op2 := compareOps[t1.text], compareOps[t2.text]
+	less := func(op planpb.OpType) bool { return op == planpb.OpType_LessThan || op == planpb.OpType_LessEqual }
+	greater := func(op planpb.OpType) bool {
+		return op == planpb.OpType_GreaterThan || op == planpb.OpType_GreaterEqual
+	}
+	switch {
+	case less(op1) && less(op2):
+	case greater(op1) && greater(op2):
+		lower, upper = upper, lower
+		op1, op2 = reversedOps[op2], reversedOps[op1]
+	default:
+		return nil, p.errorf(t2, "a range must be both '<' or both '>'")
+	}
+	if !lower.isConst() || !column.isColumn() || !upper.isConst() {
+		return nil, p.errorf(t1, "a range must be a field between two constants")
+	}
+	if err := p.checkComparable(t1, op1, column); err != nil {
+		return nil, err
+	}
+	lowerValue, err := p.castOperand(lower.pos, column, lower.value)
+	if err != nil {
+		return nil, err
+	}
+	upperValue, err := p.castOperand(upper.pos, column, upper.value)
+	if err != nil {
+		return nil, err
+	}
+	return &planpb.Expr{Expr: &planpb.Expr_BinaryRangeExpr{BinaryRangeExpr: &planpb.BinaryRangeExpr{
+		ColumnInfo:     column.column,
+		LowerInclusive: op1 == planpb.OpType_LessEqual,
+		UpperInclusive: op2 == planpb.OpType_LessEqual,
+		LowerValue:     lowerValue,
+		UpperValue:     upperValue,
+	}}}, nil
+}
+
+// termExpr parses the array after "in".
+func (p *parser) termExpr(column *operand) (*planpb.Expr, error) {
+	t := p.peek()
+	values, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	array := values.value.GetArrayVal()
+	if array == nil {
+		return nil, p.errorf(t, "expected an array after 'in' but got %s", t)
+	}
+	if !column.isColumn() {
+		return nil, p.errorf(column.pos, "'in' can only be applied to a field")
+	}
+	if err := p.checkComparable(t, planpb.OpType_Equal, column); err != nil {
+		return nil, err
+	}
+	terms := make([]*planpb.GenericValue, 0, len(array.GetArray()))
+	for _, value := range array.GetArray() {
+		value, err := p.castOperand(values.pos, column, value)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, value)
+	}
+	return &planpb.Expr{Expr: &planpb.Expr_TermExpr{TermExpr: &planpb.TermExpr{
+		ColumnInfo: column.column,
+		Values:     terms,
+	}}}, nil
+}
+
+// likeExpr parses the pattern after "like", the patterns of a prefix, a suffix or a whole string
+// are turned into the cheaper ops.
+func (p *parser) likeExpr(column *operand) (*planpb.Expr, error) {
+	t := p.next()
+	if t.kind != tokenString {
+		return nil, p.errorf(t, "expected a string pattern after 'like' but got %s", t)
+	}
+	if !column.isColumn() || !typeutil.IsStringType(column.typ) && column.typ != schemapb.DataType_JSON {
+		return nil, p.errorf(column.pos, "'like' can only be applied to a string or json field")
+	}
+	if err := p.checkComparable(t, planpb.OpType_Match, column); err != nil {
+		return nil, err
+	}
+
+	op, value, ok := likeOp(t.text)
+	if !ok {
+		return nil, p.errorf(t, "like pattern ends with an escape")
+	}
+	return &planpb.Expr{Expr: &planpb.Expr_UnaryRangeExpr{UnaryRangeExpr: &planpb.UnaryRangeExpr{
+		ColumnInfo: column.column,
+		Op:         op,
+		Value:      stringValue(value),
+	}}}, nil
+}
+
+// likeOp returns the op and the value matching pattern, a pattern of a prefix, a suffix or a
+// whole string is turned into the cheaper op.
+func likeOp(pattern string) (planpb.OpType, string, bool) {
+	var b strings.Builder
+	// the offsets of % in the unescaped string
+	var anys []int
+	single, escaped := false, false
+	for _, c := range pattern {
+		switch {
+		case escaped:
+			b.WriteRune(c)
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '%':
+			anys = append(anys, b.Len())
+		case c == '_':
+			single = true
+		default:
+			b.WriteRune(c)
+		}
+	}
+	if escaped {
+		return planpb.OpType_Invalid, "", false
+	}
+	literal := b.String()
+	switch {
+	case single || len(anys) > 1:
+	case len(anys) == 0:
+		return planpb.OpType_Equal, literal, true
+	case anys[0] == len(literal):
+		return planpb.OpType_PrefixMatch, literal, true
+	case anys[0] == 0:
+		return planpb.OpType_PostfixMatch, literal, true
+	}
+	return planpb.OpType_Match, pattern, true
+}
+
+// boolExpr turns a predicate without op into a match of true.
+func (p *parser) boolExpr(o *operand) (*planpb.Expr, error) {
+	switch {
+	case o.isConst() && isBool(o.value):
+		if o.value.GetBoolVal() {
+			return &planpb.Expr{Expr: &planpb.Expr_AlwaysTrueExpr{AlwaysTrueExpr: &planpb.AlwaysTrueExpr{}}}, nil
+		}
+		return notExpr(&planpb.Expr{Expr: &planpb.Expr_AlwaysTrueExpr{AlwaysTrueExpr: &planpb.AlwaysTrueExpr{}}}), nil
+	case o.isColumn() && (typeutil.IsBoolType(o.typ) || o.typ == schemapb.DataType_JSON && len(o.column.GetNestedPath()) > 0):
+		return &planpb.Expr{Expr: &planpb.Expr_UnaryRangeExpr{UnaryRangeExpr: &planpb.UnaryRangeExpr{
+			ColumnInfo: o.column,
+			Op:         planpb.OpType_Equal,
+			Value:      boolValue(true),
+		}}}, nil
+	default:
+		return nil, p.errorf(o.pos, "%s is not a boolean expression", o.pos)
+	}
+}
+
+// castValue checks value can be compared with the values of typ, and converts an integer to
+// a float for a float field.
+func (p *parser) castValue(t token, typ schemapb.DataType, value *planpb.GenericValue) (*planpb.GenericValue, error) {
+	switch {
+	case typeutil.IsJSONType(typ):
+		return value, nil
+	case typeutil.IsBoolType(typ) && isBool(value),
+		typeutil.IsStringType(typ) && isString(value),
+		typeutil.IsFloatingType(typ) && isFloat(value):
+		return value, nil
+	case typeutil.IsFloatingType(typ) && isInt(value):
+		return floatValue(float64(value.GetInt64Val())), nil
+	case typeutil.IsIntegerType(typ) && isInt(value):
+		if err := p.checkIntRange(t, typ, value.GetInt64Val()); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+	return nil, p.errorf(t, "can not compare %s with %s", typ, valueName(value))
+}
+
+// castOperand casts value compared with the values of column, the elements of an array value
+// compared with an array field are cast to the element type.
+func (p *parser) castOperand(t token, column *operand, value *planpb.GenericValue) (*planpb.GenericValue, error) {
+	if column.typ != schemapb.DataType_Array {
+		return p.castValue(t, column.typ, value)
+	}
+	array := value.GetArrayVal()
+	if array == nil {
+		return nil, p.errorf(t, "can not compare %s with %s", column.typ, valueName(value))
+	}
+	elementType := column.column.GetElementType()
+	elements := make([]*planpb.GenericValue, 0, len(array.GetArray()))
+	for _, element := range array.GetArray() {
+		element, err := p.castValue(t, elementType, element)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, element)
+	}
+	return &planpb.GenericValue{Val: &planpb.GenericValue_ArrayVal{ArrayVal: &planpb.Array{
+		Array:       elements,
+		SameType:    true,
+		ElementType: elementType,
+	}}}, nil
+}
+
+func (p *parser) checkIntRange(t token, typ schemapb.DataType, v int64) error {
+	var lower, upper int64
+	switch typ {
+	case schemapb.DataType_Int8:
+		lower, upper = math.MinInt8, math.MaxInt8
+	case schemapb.DataType_Int16:
+		lower, upper = math.MinInt16, math.MaxInt16
+	case schemapb.DataType_Int32:
+		lower, upper = math.MinInt32, math.MaxInt32
+	default:
+		return nil
+	}
+	if v < lower || v > upper {
+		return p.errorf(t, "%d is out of the range of %s", v, typ)
+	}
+	return nil
+}
+
+func binaryExpr(op planpb.BinaryExpr_BinaryOp, left, right *planpb.Expr) *planpb.Expr {
+	return &planpb.Expr{Expr: &planpb.Expr_BinaryExpr{BinaryExpr: &planpb.BinaryExpr{Op: op, Left: left, Right: right}}}
+}
+
+func notExpr(child *planpb.Expr) *planpb.Expr {
+	return &planpb.Expr{Expr: &planpb.Expr_UnaryExpr{UnaryExpr: &planpb.UnaryExpr{Op: planpb.UnaryExpr_Not, Child: child}}}
+}
+
+func boolValue(v bool) *planpb.GenericValue {
+	return &planpb.GenericValue{Val: &planpb.GenericValue_BoolVal{BoolVal: v}}
+}
+
+func int64Value(v int64) *planpb.GenericValue {
+	return &planpb.GenericValue{Val: &planpb.GenericValue_Int64Val{Int64Val: v}}
+}
+
+func floatValue(v float64) *planpb.GenericValue {
+	return &planpb.GenericValue{Val: &planpb.GenericValue_FloatVal{FloatVal: v}}
+}
+
+func stringValue(v string) *planpb.GenericValue {
+	return &planpb.GenericValue{Val: &planpb.GenericValue_StringVal{StringVal: v}}
+}
+
+func isBool(v *planpb.GenericValue) bool {
+	_, ok := v.GetVal().(*planpb.GenericValue_BoolVal)
+	return ok
+}
+
+func isInt(v *planpb.GenericValue) bool {
+	_, ok := v.GetVal().(*planpb.GenericValue_Int64Val)
+	return ok
+}
+
+func isFloat(v *planpb.GenericValue) bool {
+	_, ok := v.GetVal().(*planpb.GenericValue_FloatVal)
+	return ok
+}
+
+func isString(v *planpb.GenericValue) bool {
+	_, ok := v.GetVal().(*planpb.GenericValue_StringVal)
+	return ok
+}
+
+func isZero(v *planpb.GenericValue) bool {
+	return isInt(v) && v.GetInt64Val() == 0 || isFloat(v) && v.GetFloatVal() == 0
+}
+
+func toFloat(v *planpb.GenericValue) (float64, bool) {
+	switch {
+	case isInt(v):
+		return float64(v.GetInt64Val()), true
+	case isFloat(v):
+		return v.GetFloatVal(), true
+	default:
+		return 0, false
+	}
+}
+
+// valueType is the data type of a literal.
+func valueType(v *planpb.GenericValue) schemapb.DataType {
+	switch v.GetVal().(type) {
+	case *planpb.GenericValue_BoolVal:
+		return schemapb.DataType_Bool
+	case *planpb.GenericValue_Int64Val:
+		return schemapb.DataType_Int64
+	case *planpb.GenericValue_FloatVal:
+		return schemapb.DataType_Double
+	case *planpb.GenericValue_StringVal:
+		return schemapb.DataType_VarChar
+	case *planpb.GenericValue_ArrayVal:
+		return schemapb.DataType_Array
+	default:
+		return schemapb.DataType_None
+	}
+}
+
+func valueName(v *planpb.GenericValue) string {
+	switch valueType(v) {
+	case schemapb.DataType_Bool:
+		return "a bool"
+	case schemapb.DataType_Int64:
+		return "an integer"
+	case schemapb.DataType_Double:
+		return "a float"
+	case schemapb.DataType_VarChar:
+		return "a string"
+	case schemapb.DataType_Array:
+		return "an array"
+	default:
+		return "a value"
+	}
+}
@@ -0,0 +1,371 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package planparser
+
+import (
+	"math"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/xige-16/stream-read/internal/proto/planpb"
+	"github.com/xige-16/stream-read/internal/util/exprutil"
+	"github.com/xige-16/stream-read/pkg/util/merr"
+	"github.com/xige-16/stream-read/pkg/util/typeutil"
+)
+
+func newTestSchema(t *testing.T) *typeutil.SchemaHelper {
+	schema := &schemapb.CollectionSchema{
+		Name:               "test",
+		EnableDynamicField: true,
+		Fields: []*schemapb.FieldSchema{
+			{FieldID: 100, Name: "id", DataType: schemapb.DataType_Int64, IsPrimaryKey: true},
+			{FieldID: 101, Name: "age", DataType: schemapb.DataType_Int8},
+			{FieldID: 102, Name: "score", DataType: schemapb.DataType_Float},
+			{FieldID: 103, Name: "tag", DataType: schemapb.DataType_VarChar},
+			{FieldID: 104, Name: "flag", DataType: schemapb.DataType_Bool},
+			{FieldID: 105, Name: "nums", DataType: schemapb.DataType_Array, ElementType: schemapb.DataType_Int64},
+			{FieldID: 106, Name: "json", DataType: schemapb.DataType_JSON},
+			{FieldID: 107, Name: "$meta", DataType: schemapb.DataType_JSON, IsDynamic: true},
+			{FieldID: 108, Name: "vec", DataType: schemapb.DataType_FloatVector},
+		},
+	}
+	helper, err := typeutil.CreateSchemaHelper(schema)
+	require.NoError(t, err)
+	return helper
+}
+
+func columnInfo(fieldID int64, dataType schemapb.DataType, path ...string) *planpb.ColumnInfo {
+	return &planpb.ColumnInfo{FieldId: fieldID, DataType: dataType, NestedPath: path}
+}
+
+func unaryRange(info *planpb.ColumnInfo, op planpb.OpType, value *planpb.GenericValue) *planpb.Expr {
+	return &planpb.Expr{Expr: &planpb.Expr_UnaryRangeExpr{UnaryRangeExpr: &planpb.UnaryRangeExpr{
+		ColumnInfo: info,
+		Op:         op,
+		Value:      value,
+	}}}
+}
+
+func TestParseExpr(t *testing.T) {
+	helper := newTestSchema(t)
+	id := &planpb.ColumnInfo{FieldId: 100, DataType: schemapb.DataType_Int64, IsPrimaryKey: true}
+	age := columnInfo(101, schemapb.DataType_Int8)
+	score := columnInfo(102, schemapb.DataType_Float)
+	tag := columnInfo(103, schemapb.DataType_VarChar)
+	nums := &planpb.ColumnInfo{FieldId: 105, DataType: schemapb.DataType_Array, ElementType: schemapb.DataType_Int64}
+
+	cases := []struct {
+		expr     string
+		expected *planpb.Expr
+	}{
+		{
+			expr:     "",
+			expected: &planpb.Expr{Expr: &planpb.Expr_AlwaysTrueExpr{AlwaysTrueExpr: &planpb.AlwaysTrueExpr{}}},
+		},
+		{
+			expr:     "age > 20",
+			expected: unaryRange(age, planpb.OpType_GreaterThan, int64Value(20)),
+		},
+		{
+			expr:     "20 >= age",
+			expected: unaryRange(age, planpb.OpType_LessEqual, int64Value(20)),
+		},
+		{
+			expr:     "score < 2",
+			expected: unaryRange(score, planpb.OpType_LessThan, floatValue(2)),
+		},
+		{
+			expr:     "age == -2 * 3 + 1",
+			expected: unaryRange(age, planpb.OpType_Equal, int64Value(-5)),
+		},
+		{
+			expr:     "age == -7",
+			expected: unaryRange(age, planpb.OpType_Equal, int64Value(-7)),
+		},
+		{
+			expr:     "id == -9223372036854775808",
+			expected: unaryRange(id, planpb.OpType_Equal, int64Value(math.MinInt64)),
+		},
+		{
+			expr: "id-1 == 2",
+			expected: &planpb.Expr{Expr: &planpb.Expr_BinaryArithOpEvalRangeExpr{BinaryArithOpEvalRangeExpr: &planpb.BinaryArithOpEvalRangeExpr{
+				ColumnInfo:   id,
+				ArithOp:      planpb.ArithOpType_Sub,
+				RightOperand: int64Value(1),
+				Op:           planpb.OpType_Equal,
+				Value:        int64Value(2),
+			}}},
+		},
+		{
+			expr:     `tag != 'it\'s'`,
+			expected: unaryRange(tag, planpb.OpType_NotEqual, stringValue("it's")),
+		},
+		{
+			expr:     "flag",
+			expected: unaryRange(columnInfo(104, schemapb.DataType_Bool), planpb.OpType_Equal, boolValue(true)),
+		},
+		{
+			expr: `age > 20 and tag in ["a", "b"] and json["k"] == 1`,
+			expected: binaryExpr(planpb.BinaryExpr_LogicalAnd,
+				binaryExpr(planpb.BinaryExpr_LogicalAnd,
+					unaryRange(age, planpb.OpType_GreaterThan, int64Value(20)),
+					&planpb.Expr{Expr: &planpb.Expr_TermExpr{TermExpr: &planpb.TermExpr{
+						ColumnInfo: tag,
+						Values:     []*planpb.GenericValue{stringValue("a"), stringValue("b")},
+					}}}),
+				unaryRange(columnInfo(106, schemapb.DataType_JSON, "k"), planpb.OpType_Equal, int64Value(1))),
+		},
+		{
+			expr: "age < 1 OR age > 2 && NOT id == 3",
+			expected: binaryExpr(planpb.BinaryExpr_LogicalOr,
+				unaryRange(age, planpb.OpType_LessThan, int64Value(1)),
+				binaryExpr(planpb.BinaryExpr_LogicalAnd,
+					unaryRange(age, planpb.OpType_GreaterThan, int64Value(2)),
+					notExpr(unaryRange(id, planpb.OpType_Equal, int64Value(3))))),
+		},
+		{
+			expr: "(age < 1 or age > 2) and id not in [1, 2]",
+			expected: binaryExpr(planpb.BinaryExpr_LogicalAnd,
+				binaryExpr(planpb.BinaryExpr_LogicalOr,
+					unaryRange(age, planpb.OpType_LessThan, int64Value(1)),
+					unaryRange(age, planpb.OpType_GreaterThan, int64Value(2))),
+				notExpr(&planpb.Expr{Expr: &planpb.Expr_TermExpr{TermExpr: &planpb.TermExpr{
+					ColumnInfo: id,
+					Values:     []*planpb.GenericValue{int64Value(1), int64Value(2)},
+				}}})),
+		},
+		{
+			expr: "10 > age >= 1",
+			expected: &planpb.Expr{Expr: &planpb.Expr_BinaryRangeExpr{BinaryRangeExpr: &planpb.BinaryRangeExpr{
+				ColumnInfo:     age,
+				LowerInclusive: true,
+				LowerValue:     int64Value(1),
+				UpperValue:     int64Value(10),
+			}}},
+		},
+		{
+			expr: "score >= age",
+			expected: &planpb.Expr{Expr: &planpb.Expr_CompareExpr{CompareExpr: &planpb.CompareExpr{
+				LeftColumnInfo:  score,
+				RightColumnInfo: age,
+				Op:              planpb.OpType_GreaterEqual,
+			}}},
+		},
+		{
+			expr: "2 * id != 4",
+			expected: &planpb.Expr{Expr: &planpb.Expr_BinaryArithOpEvalRangeExpr{BinaryArithOpEvalRangeExpr: &planpb.BinaryArithOpEvalRangeExpr{
+				ColumnInfo:   id,
+				ArithOp:      planpb.ArithOpType_Mul,
+				RightOperand: int64Value(2),
+				Op:           planpb.OpType_NotEqual,
+				Value:        int64Value(4),
+			}}},
+		},
+		{
+			expr: "array_length(nums) == 2",
+			expected: &planpb.Expr{Expr: &planpb.Expr_BinaryArithOpEvalRangeExpr{BinaryArithOpEvalRangeExpr: &planpb.BinaryArithOpEvalRangeExpr{
+				ColumnInfo: nums,
+				ArithOp:    planpb.ArithOpType_ArrayLength,
+				Op:         planpb.OpType_Equal,
+				Value:      int64Value(2),
+			}}},
+		},
+		{
+			expr: "nums[0] > 1",
+			expected: unaryRange(&planpb.ColumnInfo{
+				FieldId:     105,
+				DataType:    schemapb.DataType_Array,
+				ElementType: schemapb.DataType_Int64,
+				NestedPath:  []string{"0"},
+			}, planpb.OpType_GreaterThan, int64Value(1)),
+		},
+		{
+			expr: "nums == [1, 2]",
+			expected: unaryRange(nums, planpb.OpType_Equal, &planpb.GenericValue{Val: &planpb.GenericValue_ArrayVal{ArrayVal: &planpb.Array{
+				Array:       []*planpb.GenericValue{int64Value(1), int64Value(2)},
+				SameType:    true,
+				ElementType: schemapb.DataType_Int64,
+			}}}),
+		},
+		{
+			expr: "array_contains_all(nums, [1, 2])",
+			expected: &planpb.Expr{Expr: &planpb.Expr_JsonContainsExpr{JsonContainsExpr: &planpb.JSONContainsExpr{
+				ColumnInfo:       nums,
+				Elements:         []*planpb.GenericValue{int64Value(1), int64Value(2)},
+				Op:               planpb.JSONContainsExpr_ContainsAll,
+				ElementsSameType: true,
+			}}},
+		},
+		{
+			expr: `json_contains(json["tags"], "red")`,
+			expected: &planpb.Expr{Expr: &planpb.Expr_JsonContainsExpr{JsonContainsExpr: &planpb.JSONContainsExpr{
+				ColumnInfo:       columnInfo(106, schemapb.DataType_JSON, "tags"),
+				Elements:         []*planpb.GenericValue{stringValue("red")},
+				Op:               planpb.JSONContainsExpr_Contains,
+				ElementsSameType: true,
+			}}},
+		},
+		{
+			expr: `exists json["a"][0]`,
+			expected: &planpb.Expr{Expr: &planpb.Expr_ExistsExpr{ExistsExpr: &planpb.ExistsExpr{
+				Info: columnInfo(106, schemapb.DataType_JSON, "a", "0"),
+			}}},
+		},
+		{
+			expr:     `dyn["b"] like "x%"`,
+			expected: unaryRange(columnInfo(107, schemapb.DataType_JSON, "dyn", "b"), planpb.OpType_PrefixMatch, stringValue("x")),
+		},
+		{
+			expr:     `tag like "%x"`,
+			expected: unaryRange(tag, planpb.OpType_PostfixMatch, stringValue("x")),
+		},
+		{
+			expr:     `tag like "100\\%"`,
+			expected: unaryRange(tag, planpb.OpType_Equal, stringValue("100%")),
+		},
+		{
+			expr:     `tag like "a_%"`,
+			expected: unaryRange(tag, planpb.OpType_Match, stringValue("a_%")),
+		},
+		{
+			expr:     `$meta["k"] == true`,
+			expected: unaryRange(columnInfo(107, schemapb.DataType_JSON, "k"), planpb.OpType_Equal, boolValue(true)),
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.expr, func(t *testing.T) {
+			expr, err := ParseExpr(helper, c.expr)
+			require.NoError(t, err)
+			assert.Equal(t, c.expected.String(), expr.String())
+		})
+	}
+}
+
+func TestParseExpr_Error(t *testing.T) {
+	helper := newTestSchema(t)
+	cases := []struct {
+		expr string
+		pos  int
+		msg  string
+	}{
+		{expr: "age >", pos: 6, msg: "unexpected end of expression"},
+		{expr: "age = 1", pos: 5, msg: "unexpected character '='"},
+		{expr: "age > 1 1", pos: 9, msg: "unexpected '1'"},
+		{expr: "(age > 1", pos: 9, msg: "expected ')'"},
+		{expr: `tag == "abc`, pos: 8, msg: "unterminated string"},
+		{expr: "age > 1x", pos: 7, msg: "invalid number 1x"},
+		{expr: "id == 9223372036854775808", pos: 7, msg: "invalid number 9223372036854775808"},
+		{expr: "json[-1] == 1", pos: 6, msg: "negative index -1"},
+		{expr: "vec > 1", pos: 1, msg: "vector field vec can not be filtered on"},
+		{expr: "age > 1000", pos: 7, msg: "1000 is out of the range of Int8"},
+		{expr: "age > 1.5", pos: 7, msg: "can not compare Int8 with a float"},
+		{expr: `tag in ["a", 1]`, pos: 8, msg: "can not compare VarChar with an integer"},
+		{expr: "json > 1", pos: 1, msg: "json field json can not be compared directly"},
+		{expr: "flag < true", pos: 6, msg: "bool field flag can only be compared by == or !="},
+		{expr: "nums > [1]", pos: 6, msg: "array field nums can only be compared by == or !="},
+		{expr: "1 < 2", pos: 3, msg: "comparison between two constants"},
+		{expr: "age + id > 1", pos: 5, msg: "arithmetic is only supported between a field and a constant"},
+		{expr: "age / 0 == 1", pos: 7, msg: "division by zero"},
+		{expr: "score % 2 == 1", pos: 7, msg: "'%' is only supported on integers"},
+		{expr: "1 < age > 2", pos: 9, msg: "a range must be both '<' or both '>'"},
+		{expr: "age", pos: 1, msg: "'age' is not a boolean expression"},
+		{expr: "id[0] == 1", pos: 1, msg: "field id of type Int64 can not be indexed"},
+		{expr: `nums["a"] == 1`, pos: 1, msg: "array field nums must be indexed by an integer"},
+		{expr: "exists age", pos: 8, msg: "exists can only be applied to a key of a json field"},
+		{expr: "array_contains_any(nums, 1)", pos: 26, msg: "array_contains_any expects an array"},
+		{expr: "array_length(tag) == 1", pos: 14, msg: "array_length can only be applied to an array or json field"},
+		{expr: "age like 'a%'", pos: 1, msg: "'like' can only be applied to a string or json field"},
+		{expr: "age in 1", pos: 8, msg: "expected an array after 'in'"},
+		{expr: "and > 1", pos: 1, msg: "unexpected keyword 'and'"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.expr, func(t *testing.T) {
+			_, err := ParseExpr(helper, c.expr)
+			var parseErr *ParseError
+			require.True(t, errors.As(err, &parseErr), "%v", err)
+			assert.Equal(t, c.pos, parseErr.Pos)
+			assert.Contains(t, parseErr.Msg, c.msg)
+			assert.ErrorIs(t, err, merr.ErrParameterInvalid)
+		})
+	}
+
+	t.Run("unknown field without dynamic field", func(t *testing.T) {
+		helper, err := typeutil.CreateSchemaHelper(&schemapb.CollectionSchema{
+			Fields: []*schemapb.FieldSchema{{FieldID: 100, Name: "id", DataType: schemapb.DataType_Int64, IsPrimaryKey: true}},
+		})
+		require.NoError(t, err)
+		_, err = ParseExpr(helper, "id > 1 and other > 1")
+		assert.EqualError(t, err, `invalid expression "id > 1 and other > 1": field other not exist at position 12`)
+	})
+}
+
+func TestParseExpr_Evaluate(t *testing.T) {
+	helper := newTestSchema(t)
+	fields := []*schemapb.FieldData{
+		{
+			Type:    schemapb.DataType_Int64,
+			FieldId: 100,
+			Field: &schemapb.FieldData_Scalars{Scalars: &schemapb.ScalarField{
+				Data: &schemapb.ScalarField_LongData{LongData: &schemapb.LongArray{Data: []int64{1, 2, 3}}},
+			}},
+		},
+		{
+			Type:    schemapb.DataType_VarChar,
+			FieldId: 103,
+			Field: &schemapb.FieldData_Scalars{Scalars: &schemapb.ScalarField{
+				Data: &schemapb.ScalarField_StringData{StringData: &schemapb.StringArray{Data: []string{"a", "b", "c"}}},
+			}},
+		},
+		{
+			Type:      schemapb.DataType_JSON,
+			FieldId:   107,
+			IsDynamic: true,
+			Field: &schemapb.FieldData_Scalars{Scalars: &schemapb.ScalarField{
+				Data: &schemapb.ScalarField_JsonData{JsonData: &schemapb.JSONArray{Data: [][]byte{
+					[]byte(`{"k": 1}`),
+					[]byte(`{"k": 2}`),
+					[]byte(`{}`),
+				}}},
+			}},
+		},
+	}
+
+	for expr, expected := range map[string][]bool{
+		`id > 1 and tag in ["a", "b"]`:     {false, true, false},
+		`id % 2 == 1 or k == 2`:            {true, true, true},
+		`exists k and not (k >= 2)`:        {true, false, false},
+		`tag like "_" and 1 <= id < 3`:     {true, true, false},
+		`tag not in ["c"] && k + 1 == 3`:   {false, true, false},
+		`id in [] or $meta["k"] != 1`:      {false, true, true},
+		`json_contains_any(k, [1, "x"])`:   {false, false, false},
+		`TRUE and (id == 3 || tag == "a")`: {true, false, true},
+	} {
+		t.Run(expr, func(t *testing.T) {
+			plan, err := ParseExpr(helper, expr)
+			require.NoError(t, err)
+			mask, err := exprutil.Evaluate(plan, fields)
+			require.NoError(t, err)
+			assert.Equal(t, expected, mask)
+		})
+	}
+}
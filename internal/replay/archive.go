@@ -0,0 +1,147 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/msgpb"
+	"github.com/xige-16/stream-read/pkg/log"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper/archive"
+)
+
+// ArchiveConfig is the configuration of an Archiver.
+type ArchiveConfig struct {
+	SubName string
+	// Channels are the pchannels archived.
+	Channels []string
+	// SyncInterval is the interval of syncing the archives to disk,
+	// the messages are acked once synced.
+	SyncInterval time.Duration
+}
+
+// Archiver tails channels and appends their raw messages to local archives, which
+// are replayed by msgstream.NewArchiveFactory after the broker drops them.
+type Archiver struct {
+	cfg    ArchiveConfig
+	writer *archive.Writer
+	// pending are the packs archived but not synced yet.
+	pending []*msgstream.MsgPack
+}
+
+// NewArchiver creates an Archiver appending to writer.
+func NewArchiver(cfg ArchiveConfig, writer *archive.Writer) *Archiver {
+	return &Archiver{
+		cfg:    cfg,
+		writer: writer,
+	}
+}
+
+// OpenStream subscribes the channels in manual ack mode, a channel resumes after its last
+// archived message, or starts from the earliest message retained by the broker.
+func (a *Archiver) OpenStream(ctx context.Context, factory msgstream.Factory) (msgstream.MsgStream, error) {
+	stream, err := factory.NewMsgStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stream.SetManualAck(true)
+	if err := stream.AsConsumer(ctx, a.cfg.Channels, a.cfg.SubName, mqwrapper.SubscriptionPositionEarliest); err != nil {
+		stream.Close()
+		return nil, err
+	}
+
+	positions := make([]*msgpb.MsgPosition, 0)
+	for _, channel := range a.cfg.Channels {
+		id, err := a.writer.LastMessageID(channel)
+		if err != nil {
+			stream.Close()
+			return nil, err
+		}
+		if id != nil {
+			positions = append(positions, &msgpb.MsgPosition{ChannelName: channel, MsgID: id})
+		}
+	}
+	log.Info("resume archiving", zap.Strings("channels", a.cfg.Channels), zap.Int("resumed", len(positions)))
+	if err := stream.Seek(ctx, positions); err != nil {
+		stream.Close()
+		return nil, err
+	}
+	return stream, nil
+}
+
+// Run archives the messages of stream until ctx is done, stream must be opened by OpenStream.
+func (a *Archiver) Run(ctx context.Context, stream msgstream.MsgStream) error {
+	ticker := time.NewTicker(a.cfg.SyncInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("archive stopped")
+			return a.sync(stream)
+		case <-ticker.C:
+			if err := a.sync(stream); err != nil {
+				return err
+			}
+		case pack, ok := <-stream.Chan():
+			if !ok {
+				if err := a.sync(stream); err != nil {
+					return err
+				}
+				return stream.Err()
+			}
+			if err := a.archive(pack); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (a *Archiver) archive(pack *msgstream.MsgPack) error {
+	raws := pack.RawMessages()
+	if len(raws) != len(pack.Msgs) {
+		return errors.Newf("pack of %d msgs has %d raw messages, the stream must be in manual ack mode", len(pack.Msgs), len(raws))
+	}
+	for i, msg := range pack.Msgs {
+		if err := a.writer.Write(msg.Position().GetChannelName(), msg.BeginTs(), raws[i]); err != nil {
+			return err
+		}
+	}
+	a.pending = append(a.pending, pack)
+	return nil
+}
+
+// sync syncs the archives and acks the packs archived.
+func (a *Archiver) sync(stream msgstream.MsgStream) error {
+	if len(a.pending) == 0 {
+		return nil
+	}
+	if err := a.writer.Sync(); err != nil {
+		return err
+	}
+	for _, pack := range a.pending {
+		stream.AckPack(pack)
+	}
+	log.Debug("archive synced", zap.Int("packs", len(a.pending)))
+	a.pending = nil
+	return nil
+}
@@ -0,0 +1,130 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xige-16/stream-read/pkg/mq/msgstream"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper/archive"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper/memory"
+	"github.com/xige-16/stream-read/pkg/util/paramtable"
+	"github.com/xige-16/stream-read/pkg/util/tsoutil"
+)
+
+func TestArchiver(t *testing.T) {
+	paramtable.Init()
+	ctx := context.Background()
+	mq := memory.NewClient()
+	defer mq.Close()
+	factory := &msgstream.CommonFactory{
+		Newer: func(context.Context) (mqwrapper.Client, error) {
+			return mq.NewClient(), nil
+		},
+		DispatcherFactory: msgstream.ProtoUDFactory{},
+		ReceiveBufSize:    16,
+		MQBufSize:         16,
+	}
+	channels := []string{"dml_0"}
+	producer, err := factory.NewMsgStream(ctx)
+	require.NoError(t, err)
+	defer producer.Close()
+	producer.AsProducer(channels)
+	produce := func(pks ...int64) {
+		for _, pk := range pks {
+			msg := newInsertMsg(1, tsoutil.ComposeTSByTime(time.Now(), pk), pk)
+			_, err := producer.Broadcast(&msgstream.MsgPack{Msgs: []msgstream.TsMsg{msg}})
+			require.NoError(t, err)
+		}
+	}
+
+	dir := t.TempDir()
+	// archive runs until the last message is synced
+	archiveUntil := func(offset int64) {
+		writer, err := archive.NewWriter(dir, archive.WriterConfig{})
+		require.NoError(t, err)
+		defer writer.Close()
+		archiver := NewArchiver(ArchiveConfig{
+			SubName:      "archive",
+			Channels:     channels,
+			SyncInterval: 10 * time.Millisecond,
+		}, writer)
+		stream, err := archiver.OpenStream(ctx, factory)
+		require.NoError(t, err)
+		defer stream.Close()
+
+		ctx, cancel := context.WithCancel(ctx)
+		done := make(chan error)
+		go func() {
+			done <- archiver.Run(ctx, stream)
+		}()
+		assert.Eventually(t, func() bool {
+			id, err := writer.LastMessageID("dml_0")
+			return err == nil && offset == int64FromID(id)
+		}, 5*time.Second, 10*time.Millisecond)
+		cancel()
+		assert.NoError(t, <-done)
+	}
+
+	produce(0, 1, 2)
+	archiveUntil(2)
+	// resumed after the last archived message
+	produce(3, 4)
+	archiveUntil(4)
+
+	client, err := archive.NewClient(dir)
+	require.NoError(t, err)
+	defer client.Close()
+	consumer, err := client.Subscribe(mqwrapper.ConsumerOptions{
+		Topic:                       "dml_0",
+		SubscriptionName:            "check",
+		SubscriptionInitialPosition: mqwrapper.SubscriptionPositionEarliest,
+		BufSize:                     16,
+	})
+	require.NoError(t, err)
+	defer consumer.Close()
+	for i := int64(0); i < 5; i++ {
+		msg := <-consumer.Chan()
+		assert.Equal(t, i, int64FromID(msg.ID().Serialize()))
+	}
+	// no duplicated message before the end of the archive
+	_, ok := <-consumer.Chan()
+	assert.False(t, ok)
+}
+
+func int64FromID(id []byte) int64 {
+	offset, err := memory.DeserializeMemoryMsgID(id)
+	if err != nil {
+		return -1
+	}
+	return offset
+}
+
+func TestArchiver_NotManualAck(t *testing.T) {
+	writer, err := archive.NewWriter(t.TempDir(), archive.WriterConfig{})
+	require.NoError(t, err)
+	defer writer.Close()
+	archiver := NewArchiver(ArchiveConfig{SyncInterval: time.Second}, writer)
+	pack := &msgstream.MsgPack{Msgs: []msgstream.TsMsg{newInsertMsg(1, 1, 1)}}
+	assert.Error(t, archiver.Run(context.Background(), newMockStream(pack)))
+}
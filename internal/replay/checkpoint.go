@@ -0,0 +1,138 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/golang/protobuf/jsonpb"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/msgpb"
+)
+
+// Checkpoint is the state replication has reached.
+type Checkpoint struct {
+	Positions []*msgpb.MsgPosition
+	// Paused is set once the replicated collection is dropped, until it is created again.
+	Paused bool
+	// CollectionID is the id of the replicated collection, which changes once it is created again,
+	// 0 if not changed.
+	CollectionID int64
+}
+
+// CheckpointStore persists the checkpoint replication has reached.
+type CheckpointStore interface {
+	// Load returns the saved checkpoint, nil if nothing is saved yet.
+	Load() (*Checkpoint, error)
+	Save(checkpoint *Checkpoint) error
+}
+
+var _ CheckpointStore = (*FileCheckpointStore)(nil)
+
+// FileCheckpointStore saves checkpoints into a local json file.
+type FileCheckpointStore struct {
+	path string
+}
+
+// NewFileCheckpointStore creates a FileCheckpointStore on path.
+func NewFileCheckpointStore(path string) *FileCheckpointStore {
+	return &FileCheckpointStore{path: path}
+}
+
+type checkpointJSON struct {
+	Positions    []json.RawMessage `json:"positions"`
+	Paused       bool              `json:"paused,omitempty"`
+	CollectionID int64             `json:"collection_id,omitempty"`
+}
+
+func (s *FileCheckpointStore) Load() (*Checkpoint, error) {
+	data, err := os.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	raw := &checkpointJSON{}
+	if err := json.Unmarshal(data, raw); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s, err %s", s.path, err.Error())
+	}
+	checkpoint := &Checkpoint{
+		Positions:    make([]*msgpb.MsgPosition, 0, len(raw.Positions)),
+		Paused:       raw.Paused,
+		CollectionID: raw.CollectionID,
+	}
+	for _, p := range raw.Positions {
+		pos := &msgpb.MsgPosition{}
+		if err := jsonpb.Unmarshal(bytes.NewReader(p), pos); err != nil {
+			return nil, fmt.Errorf("failed to parse checkpoint %s, err %s", s.path, err.Error())
+		}
+		checkpoint.Positions = append(checkpoint.Positions, pos)
+	}
+	return checkpoint, nil
+}
+
+// Save writes checkpoint to a temporary file and renames it,
+// so a crash never leaves a partially written checkpoint.
+func (s *FileCheckpointStore) Save(checkpoint *Checkpoint) error {
+	sorted := make([]*msgpb.MsgPosition, len(checkpoint.Positions))
+	copy(sorted, checkpoint.Positions)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].GetChannelName() < sorted[j].GetChannelName()
+	})
+
+	raw := &checkpointJSON{
+		Positions:    make([]json.RawMessage, 0, len(sorted)),
+		Paused:       checkpoint.Paused,
+		CollectionID: checkpoint.CollectionID,
+	}
+	marshaler := &jsonpb.Marshaler{}
+	for _, pos := range sorted {
+		str, err := marshaler.MarshalToString(pos)
+		if err != nil {
+			return err
+		}
+		raw.Positions = append(raw.Positions, json.RawMessage(str))
+	}
+	data, err := json.MarshalIndent(raw, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
@@ -0,0 +1,129 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"sort"
+
+	"github.com/samber/lo"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream"
+)
+
+// splitDeletes splits the delete msgs of msgs carrying rows of several timestamps into
+// one msg per timestamp. A batched delete msg is ordered by its first timestamp, so
+// without splitting its later rows would remove the rows inserted before them but
+// after its first one, and the inserts would be cut at a wrong timestamp.
+func splitDeletes(msgs []msgstream.TsMsg) []msgstream.TsMsg {
+	result := make([]msgstream.TsMsg, 0, len(msgs))
+	for _, msg := range msgs {
+		if dmsg, ok := msg.(*msgstream.DeleteMsg); ok {
+			result = append(result, splitDelete(dmsg)...)
+			continue
+		}
+		result = append(result, msg)
+	}
+	return result
+}
+
+func splitDelete(dmsg *msgstream.DeleteMsg) []msgstream.TsMsg {
+	groups := make(map[uint64][]int)
+	for i, ts := range dmsg.GetTimestamps() {
+		groups[ts] = append(groups[ts], i)
+	}
+	if len(groups) <= 1 {
+		return []msgstream.TsMsg{dmsg}
+	}
+
+	order := lo.Keys(groups)
+	sort.Slice(order, func(i, j int) bool { return order[i] < order[j] })
+	msgs := make([]msgstream.TsMsg, 0, len(order))
+	for _, ts := range order {
+		msg := dmsg.SelectMsg(groups[ts])
+		msg.BeginTimestamp = ts
+		msg.EndTimestamp = ts
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+// splitInserts splits the insert msgs of msgs whose rows straddle the timestamp of a
+// deleted row in msgs. A batched insert msg, e.g. repacked by InsertRepackFunc, carries rows
+// of several timestamps while it is ordered by its first one, so without splitting a
+// delete would be applied after the rows inserted after it and remove them.
+func splitInserts(msgs []msgstream.TsMsg) []msgstream.TsMsg {
+	cuts := make([]uint64, 0)
+	for _, msg := range msgs {
+		if dmsg, ok := msg.(*msgstream.DeleteMsg); ok {
+			cuts = append(cuts, dmsg.GetTimestamps()...)
+		}
+	}
+	if len(cuts) == 0 {
+		return msgs
+	}
+	sort.Slice(cuts, func(i, j int) bool { return cuts[i] < cuts[j] })
+	cuts = lo.Uniq(cuts)
+
+	result := make([]msgstream.TsMsg, 0, len(msgs))
+	for _, msg := range msgs {
+		if imsg, ok := msg.(*msgstream.InsertMsg); ok {
+			result = append(result, splitInsert(imsg, cuts)...)
+			continue
+		}
+		result = append(result, msg)
+	}
+	return result
+}
+
+// splitInsert splits the rows of imsg by the sorted timestamps cuts, the rows at or after
+// a cut go to another msg, as a delete goes before the inserts of the same timestamp.
+func splitInsert(imsg *msgstream.InsertMsg, cuts []uint64) []msgstream.TsMsg {
+	groups := make(map[int][]int)
+	for i, ts := range imsg.GetTimestamps() {
+		// the number of cuts at or before ts
+		group := sort.Search(len(cuts), func(j int) bool { return cuts[j] > ts })
+		groups[group] = append(groups[group], i)
+	}
+	if len(groups) <= 1 {
+		return []msgstream.TsMsg{imsg}
+	}
+
+	order := lo.Keys(groups)
+	sort.Ints(order)
+	msgs := make([]msgstream.TsMsg, 0, len(order))
+	for _, group := range order {
+		msg := imsg.SelectMsg(groups[group])
+		msg.BeginTimestamp = lo.Min(msg.GetTimestamps())
+		msg.EndTimestamp = lo.Max(msg.GetTimestamps())
+		msgs = append(msgs, msg)
+	}
+	return msgs
+}
+
+// sortByTs orders msgs by timestamp, so inserts and deletes of different channels
+// in one pack are applied in the order they happened. A delete goes before the
+// inserts of the same timestamp, as an upsert deletes the old row and inserts the
+// new one at one timestamp.
+func sortByTs(msgs []msgstream.TsMsg) {
+	sort.SliceStable(msgs, func(i, j int) bool {
+		if msgs[i].BeginTs() != msgs[j].BeginTs() {
+			return msgs[i].BeginTs() < msgs[j].BeginTs()
+		}
+		return msgs[i].Type() == commonpb.MsgType_Delete && msgs[j].Type() != commonpb.MsgType_Delete
+	})
+}
@@ -0,0 +1,189 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream"
+)
+
+// newBatchedInsertMsg returns an insert msg of pks inserted at timestamps, as one
+// repacked from the inserts of several timestamps.
+func newBatchedInsertMsg(collectionID int64, timestamps []uint64, pks ...int64) *msgstream.InsertMsg {
+	msg := newInsertMsg(collectionID, timestamps[0], pks...)
+	msg.Timestamps = timestamps
+	msg.RowIDs = pks
+	msg.EndTimestamp = timestamps[len(timestamps)-1]
+	return msg
+}
+
+func TestSplitInserts(t *testing.T) {
+	t.Run("no delete", func(t *testing.T) {
+		msgs := []msgstream.TsMsg{newBatchedInsertMsg(100, []uint64{100, 300}, 1, 2)}
+		assert.Equal(t, msgs, splitInserts(msgs))
+	})
+
+	t.Run("split at deletes", func(t *testing.T) {
+		msgs := splitInserts([]msgstream.TsMsg{
+			newBatchedInsertMsg(100, []uint64{100, 300, 200, 150}, 1, 2, 3, 4),
+			newDeleteMsg(100, 200, 3),
+			newDeleteMsg(100, 400, 1),
+			// not straddling any delete
+			newBatchedInsertMsg(100, []uint64{210, 220}, 5, 6),
+		})
+		require.Len(t, msgs, 5)
+		first := msgs[0].(*msgstream.InsertMsg)
+		assert.Equal(t, []int64{1, 4}, first.GetFieldsData()[0].GetScalars().GetLongData().GetData())
+		assert.Equal(t, uint64(100), first.BeginTs())
+		assert.Equal(t, uint64(150), first.EndTs())
+		assert.EqualValues(t, 2, first.GetNumRows())
+		// the rows at the delete timestamp go after it
+		second := msgs[1].(*msgstream.InsertMsg)
+		assert.Equal(t, []int64{2, 3}, second.GetFieldsData()[0].GetScalars().GetLongData().GetData())
+		assert.Equal(t, []uint64{300, 200}, second.GetTimestamps())
+		assert.Equal(t, uint64(200), second.BeginTs())
+		assert.Equal(t, uint64(300), second.EndTs())
+		assert.Equal(t, commonpb.MsgType_Delete, msgs[2].Type())
+		assert.Equal(t, commonpb.MsgType_Delete, msgs[3].Type())
+		assert.Len(t, msgs[4].(*msgstream.InsertMsg).GetTimestamps(), 2)
+	})
+}
+
+func TestSplitDeletes(t *testing.T) {
+	t.Run("one timestamp", func(t *testing.T) {
+		msgs := []msgstream.TsMsg{newDeleteMsg(100, 100, 1, 2)}
+		assert.Equal(t, msgs, splitDeletes(msgs))
+	})
+
+	t.Run("split by row timestamp", func(t *testing.T) {
+		dmsg := newDeleteMsg(100, 100, 1, 2, 3)
+		dmsg.Timestamps = []uint64{300, 100, 300}
+		dmsg.EndTimestamp = 300
+		msgs := splitDeletes([]msgstream.TsMsg{dmsg})
+		require.Len(t, msgs, 2)
+		first := msgs[0].(*msgstream.DeleteMsg)
+		assert.Equal(t, []int64{2}, first.GetPrimaryKeys().GetIntId().GetData())
+		assert.Equal(t, uint64(100), first.BeginTs())
+		assert.Equal(t, uint64(100), first.EndTs())
+		second := msgs[1].(*msgstream.DeleteMsg)
+		assert.Equal(t, []int64{1, 3}, second.GetPrimaryKeys().GetIntId().GetData())
+		assert.Equal(t, uint64(300), second.BeginTs())
+		assert.EqualValues(t, 2, second.GetNumRows())
+	})
+}
+
+func TestSortByTs(t *testing.T) {
+	msgs := []msgstream.TsMsg{
+		newInsertMsg(100, 300, 1),
+		newInsertMsg(100, 200, 2),
+		newDeleteMsg(100, 200, 2),
+		newDeleteMsg(100, 100, 3),
+	}
+	sortByTs(msgs)
+	assert.Equal(t, uint64(100), msgs[0].BeginTs())
+	assert.Equal(t, commonpb.MsgType_Delete, msgs[1].Type())
+	assert.Equal(t, commonpb.MsgType_Insert, msgs[2].Type())
+	assert.Equal(t, uint64(200), msgs[2].BeginTs())
+	assert.Equal(t, uint64(300), msgs[3].BeginTs())
+}
+
+// opTarget records the pks inserted and deleted in order.
+type opTarget struct {
+	ops []string
+}
+
+func (t *opTarget) Insert(ctx context.Context, collName string, partitionName string, columns ...entity.Column) (entity.Column, error) {
+	t.ops = append(t.ops, fmt.Sprintf("insert %v", columns[0].(*entity.ColumnInt64).Data()))
+	return nil, nil
+}
+
+func (t *opTarget) DeleteByPks(ctx context.Context, collName string, partitionName string, ids entity.Column) error {
+	t.ops = append(t.ops, fmt.Sprintf("delete %v", ids.(*entity.ColumnInt64).Data()))
+	return nil
+}
+
+func TestReplayerDeleteOrdering(t *testing.T) {
+	target := &opTarget{}
+	r := NewReplayer(Config{
+		CollectionID:   100,
+		CollectionName: "test",
+		StopTs:         1000,
+	}, NewWriter(target, WriterConfig{}))
+
+	stream := newMockStream(
+		// messages of different channels out of order
+		&msgstream.MsgPack{BeginTs: 0, EndTs: 200, Msgs: []msgstream.TsMsg{
+			newInsertMsg(100, 150, 1),
+			newDeleteMsg(100, 100, 1, 2),
+		}},
+		&msgstream.MsgPack{BeginTs: 200, EndTs: 1000, Msgs: []msgstream.TsMsg{
+			newInsertMsg(100, 300, 3),
+			newDeleteMsg(100, 300, 3),
+			// pk 4 is deleted at 500 and inserted again at 600 in one batched msg
+			newBatchedInsertMsg(100, []uint64{400, 600}, 4, 4),
+			newDeleteMsg(100, 500, 4),
+		}},
+	)
+	assert.NoError(t, r.Run(context.Background(), stream))
+	assert.Equal(t, []string{
+		// the delete at 100 is applied before the insert at 150
+		"delete [1 2]",
+		"insert [1]",
+		// the upsert at 300 deletes before inserting
+		"delete [3]",
+		"insert [3]",
+		"insert [4]",
+		"delete [4]",
+		"insert [4]",
+	}, target.ops)
+}
+
+func TestReplayerBatchedDelete(t *testing.T) {
+	target := &opTarget{}
+	r := NewReplayer(Config{
+		CollectionID:   100,
+		CollectionName: "test",
+		StopTs:         1000,
+	}, NewWriter(target, WriterConfig{}))
+
+	// one delete msg of pk 1 at 100 and pk 2 at 300 spans the insert of pk 2 at 200
+	dmsg := newDeleteMsg(100, 100, 1, 2)
+	dmsg.Timestamps = []uint64{100, 300}
+	dmsg.EndTimestamp = 300
+	stream := newMockStream(
+		&msgstream.MsgPack{BeginTs: 0, EndTs: 1000, Msgs: []msgstream.TsMsg{
+			newBatchedInsertMsg(100, []uint64{50, 200, 400}, 1, 2, 2),
+			dmsg,
+		}},
+	)
+	assert.NoError(t, r.Run(context.Background(), stream))
+	assert.Equal(t, []string{
+		"insert [1]",
+		"delete [1]",
+		"insert [2]",
+		"delete [2]",
+		"insert [2]",
+	}, target.ops)
+}
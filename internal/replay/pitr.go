@@ -0,0 +1,141 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/msgpb"
+	"github.com/xige-16/stream-read/internal/proto/datapb"
+	"github.com/xige-16/stream-read/pkg/util/funcutil"
+	"github.com/xige-16/stream-read/pkg/util/tsoutil"
+)
+
+// Manifest describes a segment backup of one collection,
+// the channels carry the checkpoints the backup was taken at.
+type Manifest struct {
+	DbName         string
+	CollectionName string
+	CollectionID   int64
+	Channels       []*datapb.VchannelInfo
+}
+
+type manifestJSON struct {
+	DbName         string            `json:"db_name"`
+	CollectionName string            `json:"collection_name"`
+	CollectionID   int64             `json:"collection_id"`
+	Channels       []json.RawMessage `json:"channels"`
+}
+
+// LoadManifest reads a backup manifest from a json file, channels are encoded as
+// the json form of datapb.VchannelInfo, e.g.
+//
+//	{
+//	  "db_name": "default",
+//	  "collection_name": "test",
+//	  "collection_id": 449000000000000001,
+//	  "channels": [{
+//	    "collectionID": 449000000000000001,
+//	    "channelName": "by-dev-rootcoord-dml_0_449000000000000001v0",
+//	    "seekPosition": {"channel_name": "by-dev-rootcoord-dml_0", "msgID": "CAEQAxgAIAA=", "timestamp": 449000000000000000}
+//	  }]
+//	}
+func LoadManifest(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseManifest(data)
+}
+
+// ParseManifest parses a backup manifest from json.
+func ParseManifest(data []byte) (*Manifest, error) {
+	raw := &manifestJSON{}
+	if err := json.Unmarshal(data, raw); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest, err %s", err.Error())
+	}
+	m := &Manifest{
+		DbName:         raw.DbName,
+		CollectionName: raw.CollectionName,
+		CollectionID:   raw.CollectionID,
+		Channels:       make([]*datapb.VchannelInfo, 0, len(raw.Channels)),
+	}
+	for _, ch := range raw.Channels {
+		info := &datapb.VchannelInfo{}
+		if err := jsonpb.Unmarshal(bytes.NewReader(ch), info); err != nil {
+			return nil, fmt.Errorf("failed to parse vchannel info, err %s", err.Error())
+		}
+		m.Channels = append(m.Channels, info)
+	}
+	return m, nil
+}
+
+// Positions returns the start position of each physical channel,
+// to which the stream seeks before replaying.
+func (m *Manifest) Positions() ([]*msgpb.MsgPosition, error) {
+	if len(m.Channels) == 0 {
+		return nil, fmt.Errorf("no channel in manifest of collection %d", m.CollectionID)
+	}
+	positions := make(map[string]*msgpb.MsgPosition)
+	result := make([]*msgpb.MsgPosition, 0, len(m.Channels))
+	for _, ch := range m.Channels {
+		if ch.GetCollectionID() != 0 && ch.GetCollectionID() != m.CollectionID {
+			return nil, fmt.Errorf("channel %s belongs to collection %d, expect %d",
+				ch.GetChannelName(), ch.GetCollectionID(), m.CollectionID)
+		}
+		seekPos := ch.GetSeekPosition()
+		if len(seekPos.GetMsgID()) == 0 {
+			return nil, fmt.Errorf("channel %s has no checkpoint", ch.GetChannelName())
+		}
+		channelName := ch.GetChannelName()
+		if len(channelName) == 0 {
+			channelName = seekPos.GetChannelName()
+		}
+		pChan := funcutil.ToPhysicalChannel(channelName)
+		if _, ok := positions[pChan]; ok {
+			return nil, fmt.Errorf("duplicated checkpoint of channel %s", pChan)
+		}
+		pos := proto.Clone(seekPos).(*msgpb.MsgPosition)
+		pos.ChannelName = pChan
+		positions[pChan] = pos
+		result = append(result, pos)
+	}
+	return result, nil
+}
+
+// Validate checks that every checkpoint is before the target timestamp.
+func (m *Manifest) Validate(targetTs uint64) error {
+	positions, err := m.Positions()
+	if err != nil {
+		return err
+	}
+	for _, pos := range positions {
+		if pos.GetTimestamp() >= targetTs {
+			return fmt.Errorf("checkpoint of channel %s (%s) is not before target %s",
+				pos.GetChannelName(),
+				tsoutil.ParseAndFormatHybridTs(pos.GetTimestamp()),
+				tsoutil.ParseAndFormatHybridTs(targetTs))
+		}
+	}
+	return nil
+}
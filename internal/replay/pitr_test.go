@@ -0,0 +1,100 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testManifest = `{
+  "db_name": "default",
+  "collection_name": "test",
+  "collection_id": 100,
+  "channels": [{
+    "collectionID": 100,
+    "channelName": "by-dev-rootcoord-dml_0_100v0",
+    "seekPosition": {"channel_name": "by-dev-rootcoord-dml_0_100v0", "msgID": "AQID", "timestamp": 1000}
+  }, {
+    "collectionID": 100,
+    "channelName": "by-dev-rootcoord-dml_1_100v1",
+    "seekPosition": {"channel_name": "by-dev-rootcoord-dml_1", "msgID": "BAUG", "timestamp": 2000}
+  }]
+}`
+
+func TestManifest(t *testing.T) {
+	t.Run("load", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "manifest.json")
+		assert.NoError(t, os.WriteFile(path, []byte(testManifest), 0o600))
+		m, err := LoadManifest(path)
+		assert.NoError(t, err)
+		assert.Equal(t, "default", m.DbName)
+		assert.Equal(t, "test", m.CollectionName)
+		assert.Equal(t, int64(100), m.CollectionID)
+		assert.Len(t, m.Channels, 2)
+
+		positions, err := m.Positions()
+		assert.NoError(t, err)
+		assert.Len(t, positions, 2)
+		assert.Equal(t, "by-dev-rootcoord-dml_0", positions[0].GetChannelName())
+		assert.Equal(t, []byte{1, 2, 3}, positions[0].GetMsgID())
+		assert.Equal(t, uint64(1000), positions[0].GetTimestamp())
+		assert.Equal(t, "by-dev-rootcoord-dml_1", positions[1].GetChannelName())
+		assert.Equal(t, uint64(2000), positions[1].GetTimestamp())
+		// the manifest is not modified
+		assert.Equal(t, "by-dev-rootcoord-dml_0_100v0", m.Channels[0].GetSeekPosition().GetChannelName())
+
+		assert.NoError(t, m.Validate(3000))
+		assert.Error(t, m.Validate(2000))
+	})
+
+	t.Run("bad manifest", func(t *testing.T) {
+		_, err := LoadManifest(filepath.Join(t.TempDir(), "not_exist.json"))
+		assert.Error(t, err)
+
+		_, err = ParseManifest([]byte("{"))
+		assert.Error(t, err)
+
+		_, err = ParseManifest([]byte(`{"channels": [{"unknown": 1}]}`))
+		assert.Error(t, err)
+
+		m, err := ParseManifest([]byte(`{"collection_id": 100}`))
+		assert.NoError(t, err)
+		_, err = m.Positions()
+		assert.Error(t, err)
+
+		m, err = ParseManifest([]byte(`{"collection_id": 100, "channels": [{"channelName": "dml_0_100v0"}]}`))
+		assert.NoError(t, err)
+		_, err = m.Positions()
+		assert.Error(t, err)
+
+		m, err = ParseManifest([]byte(`{"collection_id": 100, "channels": [{"collectionID": 101, "channelName": "dml_0_101v0", "seekPosition": {"msgID": "AQID"}}]}`))
+		assert.NoError(t, err)
+		_, err = m.Positions()
+		assert.Error(t, err)
+
+		m, err = ParseManifest([]byte(`{"collection_id": 100, "channels": [
+			{"channelName": "dml_0_100v0", "seekPosition": {"msgID": "AQID"}},
+			{"channelName": "dml_0_100v1", "seekPosition": {"msgID": "AQID"}}]}`))
+		assert.NoError(t, err)
+		_, err = m.Positions()
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,316 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/msgpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"github.com/xige-16/stream-read/pkg/log"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper"
+	"github.com/xige-16/stream-read/pkg/util/tsoutil"
+	"github.com/xige-16/stream-read/pkg/util/typeutil"
+)
+
+// Config is the configuration of a Replayer.
+type Config struct {
+	CollectionID    int64
+	CollectionName  string
+	AutoIDFieldName string
+	// PKFieldName is the primary key field, the inserted rows are routed by it in reshard mode.
+	PKFieldName string
+	// StopTs is the hybrid timestamp the replay stops at,
+	// messages after it are not applied.
+	StopTs uint64
+	// Conversion converts the inserted fields of the replayed schema to the one of the target,
+	// see typeutil.DiffSchema, the fields are written as they are if nil.
+	Conversion *typeutil.ConversionPlan
+}
+
+// Replayer applies the DML of one collection read from a msgstream to the target cluster.
+type Replayer struct {
+	cfg    Config
+	writer *Writer
+
+	// insertBatchRows is the max rows of one insert request, 0 means unlimited
+	insertBatchRows atomic.Int64
+	// partitions are the partitions replayed, all if empty
+	partitions atomic.Pointer[[]string]
+}
+
+// NewReplayer creates a Replayer which writes through writer.
+func NewReplayer(cfg Config, writer *Writer) *Replayer {
+	return &Replayer{
+		cfg:    cfg,
+		writer: writer,
+	}
+}
+
+// SetInsertBatchRows splits the insert messages larger than rows into requests of at most rows,
+// 0 means one request per message.
+func (r *Replayer) SetInsertBatchRows(rows int) {
+	if rows < 0 {
+		rows = 0
+	}
+	if old := r.insertBatchRows.Swap(int64(rows)); old != int64(rows) {
+		log.Info("insert batch rows updated", zap.Int64("old", old), zap.Int("new", rows))
+	}
+}
+
+// SetPartitions replays the DML of partitions only, all partitions if empty. A delete without
+// a partition is applied to the whole collection regardless.
+func (r *Replayer) SetPartitions(partitions []string) {
+	filtered := make([]string, 0, len(partitions))
+	for _, partition := range partitions {
+		if partition = strings.TrimSpace(partition); len(partition) > 0 {
+			filtered = append(filtered, partition)
+		}
+	}
+	if old := r.partitions.Swap(&filtered); old == nil || strings.Join(*old, ",") != strings.Join(filtered, ",") {
+		log.Info("replayed partitions updated", zap.Strings("partitions", filtered))
+	}
+}
+
+func (r *Replayer) matchPartition(partitionName string) bool {
+	partitions := r.partitions.Load()
+	if partitions == nil || len(*partitions) == 0 || len(partitionName) == 0 {
+		return true
+	}
+	for _, partition := range *partitions {
+		if partition == partitionName {
+			return true
+		}
+	}
+	return false
+}
+
+// SeekStream subscribes the channels of positions and seeks each of them to its own position.
+func SeekStream(ctx context.Context, factory msgstream.Factory, subName string, positions []*msgpb.MsgPosition) (msgstream.MsgStream, error) {
+	stream, err := factory.NewTtMsgStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	channels := make([]string, 0, len(positions))
+	for _, pos := range positions {
+		channels = append(channels, pos.GetChannelName())
+	}
+	log.Info("creating consumer...", zap.Strings("channels", channels), zap.String("subName", subName))
+	err = stream.AsConsumer(ctx, channels, subName, mqwrapper.SubscriptionPositionUnknown)
+	if err != nil {
+		stream.Close()
+		return nil, err
+	}
+
+	log.Info("start seek", zap.Any("positions", positions))
+	err = stream.Seek(ctx, positions)
+	if err != nil {
+		stream.Close()
+		return nil, err
+	}
+	log.Info("seek done!")
+	return stream, nil
+}
+
+// Run consumes stream until the stop timestamp is reached, the collection is dropped
+// or ctx is done.
+func (r *Replayer) Run(ctx context.Context, stream msgstream.MsgStream) error {
+	stopTime, _ := tsoutil.ParseTS(r.cfg.StopTs)
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case msgs, ok := <-stream.Chan():
+			if !ok {
+				return stream.Err()
+			}
+			timeOfBegin, _ := tsoutil.ParseTS(msgs.BeginTs)
+			log.Info("update recover process", zap.Time("stop time", stopTime), zap.Time("msg time", timeOfBegin))
+			if msgs.BeginTs >= r.cfg.StopTs {
+				log.Info("recover done!")
+				return nil
+			}
+			done, err := r.handleMsgPack(ctx, msgs)
+			if err != nil {
+				return err
+			}
+			if done {
+				return nil
+			}
+			if msgs.EndTs >= r.cfg.StopTs {
+				log.Info("recover done!")
+				return nil
+			}
+		}
+	}
+}
+
+// handleMsgPack applies the messages in pack, returns true if replay is done.
+// It stops at the first write failed, which is retried by the writer already.
+func (r *Replayer) handleMsgPack(ctx context.Context, pack *msgstream.MsgPack) (bool, error) {
+	r.preparePack(pack)
+	for _, msg := range pack.Msgs {
+		if msg.BeginTs() > r.cfg.StopTs {
+			continue
+		}
+		if _, _, err := r.applyDML(ctx, msg, 0); err != nil {
+			log.Error("apply msg failed", zap.String("type", msg.Type().String()), zap.Error(err))
+			return false, errors.Wrapf(err, "replay %s failed", msg.Type().String())
+		}
+		switch msg.Type() {
+		case commonpb.MsgType_DropCollection:
+			dropmsg := msg.(*msgstream.DropCollectionMsg)
+			if r.cfg.CollectionID == dropmsg.GetCollectionID() {
+				log.Info("collection droped, recovery done!")
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// preparePack orders the rows of pack by timestamp before they are applied,
+// so a delete only removes the rows inserted before it, which is the MVCC semantics of milvus.
+// The target is assumed to hold no rows written after the replayed range, a delete
+// has no timestamp to compare with those and removes them as well.
+func (r *Replayer) preparePack(pack *msgstream.MsgPack) {
+	pack.Msgs = splitDeletes(pack.Msgs)
+	pack.Msgs = splitInserts(pack.Msgs)
+	sortByTs(pack.Msgs)
+}
+
+func (r *Replayer) match(collectionID int64, collectionName string) bool {
+	return r.cfg.CollectionID == collectionID && r.cfg.CollectionName == collectionName
+}
+
+// handleInsert inserts the rows of imsg from row skip on, the rows of a large msg are split
+// into batches which are not applied atomically. It returns the number of rows applied,
+// including the skipped ones, so the rows applied before a failed batch could be skipped
+// when imsg is applied again.
+func (r *Replayer) handleInsert(ctx context.Context, imsg *msgstream.InsertMsg, skip int) (int, error) {
+	imsgColname := imsg.GetCollectionName()
+	imsgPartName := imsg.GetPartitionName()
+	numRows := imsg.GetNumRows()
+
+	log.Info("receive insert messages",
+		zap.String("coll", imsgColname),
+		zap.String("part", imsgPartName),
+		zap.Uint64("numRows", numRows),
+		zap.Int("skip", skip))
+
+	total := int(numRows)
+	batchRows := int(r.insertBatchRows.Load())
+	if batchRows <= 0 || batchRows > total {
+		batchRows = total
+	}
+	for begin := skip; begin < total; begin += batchRows {
+		end := begin + batchRows
+		if end > total {
+			end = total
+		}
+		columes, err := r.insertColumns(imsg, begin, end)
+		if err != nil {
+			return begin, err
+		}
+		size := imsg.Size() * (end - begin) / total
+		if err := r.writer.Insert(ctx, imsgColname, imsgPartName, end-begin, size, columes...); err != nil {
+			return begin, err
+		}
+	}
+	return total, nil
+}
+
+// insertColumns converts the rows [begin, end) of imsg to columns.
+func (r *Replayer) insertColumns(imsg *msgstream.InsertMsg, begin, end int) ([]entity.Column, error) {
+	columes := make([]entity.Column, 0, len(imsg.GetFieldsData()))
+	for _, fd := range imsg.GetFieldsData() {
+		if len(r.cfg.AutoIDFieldName) != 0 && r.cfg.AutoIDFieldName == fd.GetFieldName() {
+			continue
+		}
+		if r.cfg.Conversion != nil {
+			var err error
+			if fd, err = r.cfg.Conversion.Convert(fd); err != nil {
+				return nil, errors.Wrap(err, "convert insert msg failed")
+			}
+			// dropped by the conversion
+			if fd == nil {
+				continue
+			}
+		}
+		colume, err := entity.FieldDataColumn(fd, begin, end)
+		if err != nil {
+			return nil, errors.Wrap(err, "convert insert msg failed")
+		}
+		columes = append(columes, colume)
+	}
+	return columes, nil
+}
+
+func (r *Replayer) handleDelete(ctx context.Context, dmsg *msgstream.DeleteMsg) error {
+	log.Info("receive delete messages", zap.Int64("numRows", dmsg.NumRows))
+
+	colume, err := idColumn(dmsg.GetPrimaryKeys())
+	if err != nil {
+		return errors.Wrap(err, "convert delete pks failed")
+	}
+
+	return r.writer.Delete(ctx, dmsg.GetCollectionName(), dmsg.GetPartitionName(), dmsg.Size(), colume)
+}
+
+// idColumn converts the pks of a delete to an unnamed column, which the target
+// matches with the primary key of the collection.
+func idColumn(pks *schemapb.IDs) (entity.Column, error) {
+	switch field := pks.GetIdField().(type) {
+	case *schemapb.IDs_IntId:
+		return entity.NewColumnInt64("", field.IntId.GetData()), nil
+	case *schemapb.IDs_StrId:
+		return entity.NewColumnVarChar("", field.StrId.GetData()), nil
+	default:
+		return nil, errors.Newf("unsupported id type %T", field)
+	}
+}
+
+// applyDML applies msg to the target if it is an insert or delete of the collection, the first
+// skipRows rows of an insert are skipped as they are applied already. It returns whether msg
+// is applied, and the number of rows of an insert applied before it failed.
+func (r *Replayer) applyDML(ctx context.Context, msg msgstream.TsMsg, skipRows int) (bool, int, error) {
+	switch msg.Type() {
+	case commonpb.MsgType_Insert:
+		imsg := msg.(*msgstream.InsertMsg)
+		if !r.match(imsg.GetCollectionID(), imsg.GetCollectionName()) || !r.matchPartition(imsg.GetPartitionName()) {
+			return false, 0, nil
+		}
+		rows, err := r.handleInsert(ctx, imsg, skipRows)
+		return true, rows, err
+	case commonpb.MsgType_Delete:
+		dmsg := msg.(*msgstream.DeleteMsg)
+		if !r.match(dmsg.GetCollectionID(), dmsg.GetCollectionName()) || !r.matchPartition(dmsg.GetPartitionName()) {
+			return false, 0, nil
+		}
+		return true, 0, r.handleDelete(ctx, dmsg)
+	}
+	return false, 0, nil
+}
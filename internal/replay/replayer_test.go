@@ -0,0 +1,350 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/msgpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream"
+	"github.com/xige-16/stream-read/pkg/util/typeutil"
+)
+
+type mockStream struct {
+	msgstream.MsgStream
+	ch chan *msgstream.MsgPack
+}
+
+func newMockStream(packs ...*msgstream.MsgPack) *mockStream {
+	ch := make(chan *msgstream.MsgPack, len(packs))
+	for _, pack := range packs {
+		ch <- pack
+	}
+	close(ch)
+	return &mockStream{ch: ch}
+}
+
+func (s *mockStream) Chan() <-chan *msgstream.MsgPack {
+	return s.ch
+}
+
+func (s *mockStream) Close() {}
+
+func (s *mockStream) Err() error {
+	return nil
+}
+
+func newInsertMsg(collectionID int64, ts uint64, pks ...int64) *msgstream.InsertMsg {
+	timestamps := make([]uint64, len(pks))
+	for i := range timestamps {
+		timestamps[i] = ts
+	}
+	return &msgstream.InsertMsg{
+		BaseMsg: msgstream.BaseMsg{BeginTimestamp: ts, EndTimestamp: ts},
+		InsertRequest: msgpb.InsertRequest{
+			Base:           &commonpb.MsgBase{MsgType: commonpb.MsgType_Insert, Timestamp: ts},
+			CollectionName: "test",
+			CollectionID:   collectionID,
+			Timestamps:     timestamps,
+			NumRows:        uint64(len(pks)),
+			Version:        msgpb.InsertDataVersion_ColumnBased,
+			FieldsData: []*schemapb.FieldData{{
+				Type:      schemapb.DataType_Int64,
+				FieldName: "pk",
+				Field: &schemapb.FieldData_Scalars{Scalars: &schemapb.ScalarField{
+					Data: &schemapb.ScalarField_LongData{LongData: &schemapb.LongArray{Data: pks}},
+				}},
+			}},
+		},
+	}
+}
+
+func newDeleteMsg(collectionID int64, ts uint64, pks ...int64) *msgstream.DeleteMsg {
+	timestamps := make([]uint64, len(pks))
+	for i := range timestamps {
+		timestamps[i] = ts
+	}
+	return &msgstream.DeleteMsg{
+		BaseMsg: msgstream.BaseMsg{BeginTimestamp: ts, EndTimestamp: ts},
+		DeleteRequest: msgpb.DeleteRequest{
+			Base:           &commonpb.MsgBase{MsgType: commonpb.MsgType_Delete, Timestamp: ts},
+			CollectionName: "test",
+			CollectionID:   collectionID,
+			Timestamps:     timestamps,
+			NumRows:        int64(len(pks)),
+			PrimaryKeys:    &schemapb.IDs{IdField: &schemapb.IDs_IntId{IntId: &schemapb.LongArray{Data: pks}}},
+		},
+	}
+}
+
+func TestReplayer(t *testing.T) {
+	cfg := Config{
+		CollectionID:   100,
+		CollectionName: "test",
+		StopTs:         300,
+	}
+
+	t.Run("stop at target", func(t *testing.T) {
+		target := &mockTarget{}
+		r := NewReplayer(cfg, NewWriter(target, WriterConfig{}))
+		stream := newMockStream(
+			&msgstream.MsgPack{BeginTs: 0, EndTs: 200, Msgs: []msgstream.TsMsg{
+				newInsertMsg(100, 100, 1, 2),
+				// other collection
+				newInsertMsg(101, 150, 1, 2),
+				newDeleteMsg(100, 200, 1),
+			}},
+			&msgstream.MsgPack{BeginTs: 200, EndTs: 400, Msgs: []msgstream.TsMsg{
+				newInsertMsg(100, 300, 3),
+				// after the target
+				newInsertMsg(100, 350, 4),
+				newDeleteMsg(100, 350, 2),
+			}},
+			&msgstream.MsgPack{BeginTs: 400, EndTs: 500, Msgs: []msgstream.TsMsg{
+				newInsertMsg(100, 450, 5),
+			}},
+		)
+		assert.NoError(t, r.Run(context.Background(), stream))
+		assert.Equal(t, 2, target.inserted)
+		assert.Equal(t, 1, target.deleted)
+	})
+
+	t.Run("drop collection", func(t *testing.T) {
+		target := &mockTarget{}
+		r := NewReplayer(cfg, NewWriter(target, WriterConfig{}))
+		stream := newMockStream(
+			&msgstream.MsgPack{BeginTs: 0, EndTs: 200, Msgs: []msgstream.TsMsg{
+				newInsertMsg(100, 100, 1, 2),
+				&msgstream.DropCollectionMsg{
+					BaseMsg: msgstream.BaseMsg{BeginTimestamp: 150, EndTimestamp: 150},
+					DropCollectionRequest: msgpb.DropCollectionRequest{
+						Base:         &commonpb.MsgBase{MsgType: commonpb.MsgType_DropCollection},
+						CollectionID: 100,
+					},
+				},
+				newInsertMsg(100, 200, 3),
+			}},
+		)
+		assert.NoError(t, r.Run(context.Background(), stream))
+		assert.Equal(t, 1, target.inserted)
+	})
+
+	t.Run("write failed", func(t *testing.T) {
+		target := &mockTarget{insertErrs: []error{nil, errors.New("mock")}}
+		r := NewReplayer(cfg, NewWriter(target, WriterConfig{}))
+		stream := newMockStream(
+			&msgstream.MsgPack{BeginTs: 0, EndTs: 200, Msgs: []msgstream.TsMsg{
+				newInsertMsg(100, 100, 1),
+				newInsertMsg(100, 150, 2),
+				newDeleteMsg(100, 160, 1),
+			}},
+		)
+		assert.ErrorContains(t, r.Run(context.Background(), stream), "mock")
+		// stopped at the failed insert
+		assert.Equal(t, 1, target.inserted)
+		assert.Equal(t, 0, target.deleted)
+	})
+
+	t.Run("ctx done", func(t *testing.T) {
+		r := NewReplayer(cfg, NewWriter(&mockTarget{}, WriterConfig{}))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		stream := &mockStream{ch: make(chan *msgstream.MsgPack)}
+		assert.ErrorIs(t, r.Run(ctx, stream), context.Canceled)
+	})
+
+	t.Run("insert batch rows", func(t *testing.T) {
+		target := &mockTarget{}
+		r := NewReplayer(cfg, NewWriter(target, WriterConfig{}))
+		r.SetInsertBatchRows(2)
+		stream := newMockStream(
+			&msgstream.MsgPack{BeginTs: 0, EndTs: 400, Msgs: []msgstream.TsMsg{
+				newInsertMsg(100, 100, 1, 2, 3, 4, 5),
+				newInsertMsg(100, 200, 6),
+			}},
+		)
+		assert.NoError(t, r.Run(context.Background(), stream))
+		assert.Equal(t, 4, target.inserted)
+	})
+
+	t.Run("partitions", func(t *testing.T) {
+		target := &mockTarget{}
+		r := NewReplayer(cfg, NewWriter(target, WriterConfig{}))
+		r.SetPartitions([]string{" p1 ", ""})
+		inPartition := func(msg msgstream.TsMsg, partition string) msgstream.TsMsg {
+			switch m := msg.(type) {
+			case *msgstream.InsertMsg:
+				m.PartitionName = partition
+			case *msgstream.DeleteMsg:
+				m.PartitionName = partition
+			}
+			return msg
+		}
+		stream := newMockStream(
+			&msgstream.MsgPack{BeginTs: 0, EndTs: 400, Msgs: []msgstream.TsMsg{
+				inPartition(newInsertMsg(100, 100, 1), "p1"),
+				inPartition(newInsertMsg(100, 110, 2), "p2"),
+				inPartition(newDeleteMsg(100, 200, 1), "p2"),
+				// a delete without partition applies to the whole collection
+				newDeleteMsg(100, 210, 1),
+			}},
+		)
+		assert.NoError(t, r.Run(context.Background(), stream))
+		assert.Equal(t, 1, target.inserted)
+		assert.Equal(t, 1, target.deleted)
+
+		assert.True(t, r.matchPartition("p1"))
+		assert.False(t, r.matchPartition("p2"))
+		r.SetPartitions(nil)
+		assert.True(t, r.matchPartition("p2"))
+	})
+
+	t.Run("conversion", func(t *testing.T) {
+		oldSchema := &schemapb.CollectionSchema{Fields: []*schemapb.FieldSchema{
+			{FieldID: 100, Name: "pk", DataType: schemapb.DataType_Int64, IsPrimaryKey: true},
+			{FieldID: 101, Name: "age", DataType: schemapb.DataType_Int32},
+			{FieldID: 102, Name: "dropped", DataType: schemapb.DataType_Bool},
+		}}
+		newSchema := &schemapb.CollectionSchema{Fields: []*schemapb.FieldSchema{
+			{FieldID: 100, Name: "pk", DataType: schemapb.DataType_Int64, IsPrimaryKey: true},
+			{FieldID: 101, Name: "age", DataType: schemapb.DataType_Int64},
+		}}
+		cfg := cfg
+		cfg.Conversion = typeutil.DiffSchema(oldSchema, newSchema).Plan
+		r := NewReplayer(cfg, NewWriter(&mockTarget{}, WriterConfig{}))
+
+		msg := newInsertMsg(100, 100, 1, 2)
+		msg.FieldsData = append(msg.FieldsData,
+			&schemapb.FieldData{Type: schemapb.DataType_Int32, FieldName: "age", Field: &schemapb.FieldData_Scalars{Scalars: &schemapb.ScalarField{
+				Data: &schemapb.ScalarField_IntData{IntData: &schemapb.IntArray{Data: []int32{10, 20}}},
+			}}},
+			&schemapb.FieldData{Type: schemapb.DataType_Bool, FieldName: "dropped", Field: &schemapb.FieldData_Scalars{Scalars: &schemapb.ScalarField{
+				Data: &schemapb.ScalarField_BoolData{BoolData: &schemapb.BoolArray{Data: []bool{true, false}}},
+			}}},
+		)
+		columns, err := r.insertColumns(msg, 0, 2)
+		assert.NoError(t, err)
+		assert.Len(t, columns, 2)
+		assert.Equal(t, "age", columns[1].Name())
+		assert.Equal(t, entity.FieldTypeInt64, columns[1].Type())
+
+		// a field not in the plan can not be converted
+		msg.FieldsData[0].FieldName = "unknown"
+		_, err = r.insertColumns(msg, 0, 2)
+		assert.Error(t, err)
+	})
+}
+
+func TestReplayerVectorTypes(t *testing.T) {
+	const dim = 2
+	sparseRows := [][]byte{
+		typeutil.CreateSparseFloatRow([]uint32{1, 10}, []float32{0.1, 0.2}),
+		typeutil.CreateSparseFloatRow([]uint32{5}, []float32{0.5}),
+		typeutil.CreateSparseFloatRow([]uint32{0, 3, 7}, []float32{0.3, 0.4, 0.6}),
+	}
+	// 3 rows of dim 2 in 2 bytes per element
+	halfRows := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	cases := []struct {
+		name     string
+		field    *schemapb.VectorField
+		dataType schemapb.DataType
+		colType  entity.FieldType
+		// rows returns the vectors of column
+		rows func(column entity.Column) interface{}
+		want interface{}
+	}{
+		{
+			name:     "float16",
+			dataType: schemapb.DataType_Float16Vector,
+			field:    &schemapb.VectorField{Dim: dim, Data: &schemapb.VectorField_Float16Vector{Float16Vector: halfRows}},
+			colType:  entity.FieldTypeFloat16Vector,
+			rows:     func(column entity.Column) interface{} { return column.(*entity.ColumnFloat16Vector).Data() },
+			want:     [][]byte{{1, 2, 3, 4}, {5, 6, 7, 8}},
+		},
+		{
+			name:     "bfloat16",
+			dataType: schemapb.DataType_BFloat16Vector,
+			field:    &schemapb.VectorField{Dim: dim, Data: &schemapb.VectorField_Bfloat16Vector{Bfloat16Vector: halfRows}},
+			colType:  entity.FieldTypeBFloat16Vector,
+			rows:     func(column entity.Column) interface{} { return column.(*entity.ColumnBFloat16Vector).Data() },
+			want:     [][]byte{{1, 2, 3, 4}, {5, 6, 7, 8}},
+		},
+		{
+			name:     "sparse",
+			dataType: schemapb.DataType_SparseFloatVector,
+			field: &schemapb.VectorField{Dim: 11, Data: &schemapb.VectorField_SparseFloatVector{
+				SparseFloatVector: &schemapb.SparseFloatArray{Dim: 11, Contents: sparseRows},
+			}},
+			colType: entity.FieldTypeSparseVector,
+			rows: func(column entity.Column) interface{} {
+				rows := make([]string, 0)
+				for _, v := range column.(*entity.ColumnSparseFloatVector).Data() {
+					rows = append(rows, fmt.Sprint(v.Serialize()))
+				}
+				return rows
+			},
+			want: []string{fmt.Sprint(sparseRows[0]), fmt.Sprint(sparseRows[1])},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			target := &columnTarget{}
+			r := NewReplayer(Config{CollectionID: 100, CollectionName: "test", StopTs: 1000}, NewWriter(target, WriterConfig{}))
+			r.SetInsertBatchRows(2)
+			msg := newInsertMsg(100, 100, 1, 2, 3)
+			msg.FieldsData = append(msg.FieldsData, &schemapb.FieldData{
+				Type:      c.dataType,
+				FieldName: "vec",
+				Field:     &schemapb.FieldData_Vectors{Vectors: c.field},
+			})
+			stream := newMockStream(&msgstream.MsgPack{BeginTs: 0, EndTs: 200, Msgs: []msgstream.TsMsg{msg}})
+			assert.NoError(t, r.Run(context.Background(), stream))
+
+			// split into the batches of rows [0, 2) and [2, 3)
+			require.Len(t, target.inserts, 2)
+			columns := target.inserts[0]
+			require.Len(t, columns, 2)
+			assert.Equal(t, "vec", columns[1].Name())
+			assert.Equal(t, c.colType, columns[1].Type())
+			assert.Equal(t, c.want, c.rows(columns[1]))
+			assert.Equal(t, 1, target.inserts[1][1].Len())
+		})
+	}
+}
+
+// columnTarget records the columns of every insert.
+type columnTarget struct {
+	inserts [][]entity.Column
+}
+
+func (t *columnTarget) Insert(ctx context.Context, collName string, partitionName string, columns ...entity.Column) (entity.Column, error) {
+	t.inserts = append(t.inserts, columns)
+	return nil, nil
+}
+
+func (t *columnTarget) DeleteByPks(ctx context.Context, collName string, partitionName string, ids entity.Column) error {
+	return nil
+}
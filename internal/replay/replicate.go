@@ -0,0 +1,402 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"context"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/msgpb"
+	"github.com/milvus-io/milvus-sdk-go/v2/client"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"github.com/xige-16/stream-read/pkg/common"
+	"github.com/xige-16/stream-read/pkg/log"
+	"github.com/xige-16/stream-read/pkg/metrics"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream"
+	"github.com/xige-16/stream-read/pkg/util/funcutil"
+	"github.com/xige-16/stream-read/pkg/util/paramtable"
+	"github.com/xige-16/stream-read/pkg/util/tsoutil"
+)
+
+// DDLTarget is the part of the target cluster DDL is mirrored to,
+// satisfied by the milvus go sdk client.
+type DDLTarget interface {
+	CreatePartition(ctx context.Context, collName string, partitionName string, opts ...client.CreatePartitionOption) error
+	DropPartition(ctx context.Context, collName string, partitionName string, opts ...client.DropPartitionOption) error
+	CreateIndex(ctx context.Context, collName string, fieldName string, idx entity.Index, async bool, opts ...client.IndexOption) error
+	DropIndex(ctx context.Context, collName string, fieldName string, opts ...client.IndexOption) error
+}
+
+// ReplicateDDLTypes are the DDL message types which could be mirrored.
+var ReplicateDDLTypes = []commonpb.MsgType{
+	commonpb.MsgType_CreatePartition,
+	commonpb.MsgType_DropPartition,
+	commonpb.MsgType_CreateIndex,
+	commonpb.MsgType_DropIndex,
+}
+
+// ReplicateConfig is the configuration of a Replicator.
+type ReplicateConfig struct {
+	// Config.StopTs is ignored, replication never stops by itself.
+	Config
+	SubName string
+	// DDLTypes are the DDL message types mirrored to the target, others are ignored.
+	DDLTypes []commonpb.MsgType
+	// CheckpointInterval is the min interval between two checkpoint saves.
+	CheckpointInterval time.Duration
+	// IdleTimeout reconnects the source if no pack is received within it, 0 means never.
+	IdleTimeout time.Duration
+	// ReconnectBackoff is the wait time before reconnecting the source.
+	ReconnectBackoff time.Duration
+	// SteadyLag is the lag under which replication is considered caught up.
+	SteadyLag time.Duration
+}
+
+// Replicator tails the source channels forever and mirrors the DML and
+// the selected DDL of one collection to the target cluster.
+type Replicator struct {
+	cfg      ReplicateConfig
+	replayer *Replayer
+	ddl      DDLTarget
+	store    CheckpointStore
+
+	// openStream subscribes and seeks the source to positions.
+	openStream func(ctx context.Context, positions []*msgpb.MsgPosition) (msgstream.MsgStream, error)
+
+	initial    []*msgpb.MsgPosition
+	checkpoint map[string]*msgpb.MsgPosition
+	// failed is the progress of the pack whose write failed, the messages applied
+	// before the failure are skipped when the pack is consumed again
+	failed   *packProgress
+	lastSave time.Time
+	paused   bool
+	state    string
+}
+
+// packProgress is the number of applied messages of a pack, and the number of
+// applied rows of the insert msg failed.
+type packProgress struct {
+	beginTs uint64
+	endTs   uint64
+	applied int
+	rows    int
+}
+
+// NewReplicator creates a Replicator, it starts from the positions saved in store,
+// or from positions if nothing is saved yet.
+func NewReplicator(cfg ReplicateConfig, factory msgstream.Factory, writer *Writer, ddl DDLTarget,
+	store CheckpointStore, positions []*msgpb.MsgPosition,
+) *Replicator {
+	return &Replicator{
+		cfg:      cfg,
+		replayer: NewReplayer(cfg.Config, writer),
+		ddl:      ddl,
+		store:    store,
+		openStream: func(ctx context.Context, positions []*msgpb.MsgPosition) (msgstream.MsgStream, error) {
+			return SeekStream(ctx, factory, cfg.SubName, positions)
+		},
+		initial:    positions,
+		checkpoint: make(map[string]*msgpb.MsgPosition),
+	}
+}
+
+// WatchSettings keeps the DML of r tuned by the replay settings in params, see WatchSettings.
+func (r *Replicator) WatchSettings(params *paramtable.ComponentParam) func() {
+	return WatchSettings(params, r.replayer)
+}
+
+// Run replicates until ctx is done, the source is reconnected on any failure.
+func (r *Replicator) Run(ctx context.Context) error {
+	saved, err := r.store.Load()
+	if err != nil {
+		return err
+	}
+	if saved != nil {
+		for _, pos := range saved.Positions {
+			r.checkpoint[pos.GetChannelName()] = pos
+		}
+		if saved.CollectionID != 0 {
+			r.replayer.cfg.CollectionID = saved.CollectionID
+		}
+		r.paused = saved.Paused
+		log.Info("resume from checkpoint", zap.Any("positions", saved.Positions),
+			zap.Bool("paused", saved.Paused), zap.Int64("collectionID", r.replayer.cfg.CollectionID))
+	}
+	if r.paused {
+		r.setState(metrics.ReplicatePausedLabel)
+	} else {
+		r.setState(metrics.ReplicateCatchUpLabel)
+	}
+
+	for {
+		err := r.runOnce(ctx)
+		if ctx.Err() != nil {
+			if err := r.saveCheckpoint(); err != nil {
+				log.Warn("save checkpoint failed", zap.Error(err))
+			}
+			return ctx.Err()
+		}
+		log.Warn("replication interrupted, reconnecting",
+			zap.Duration("backoff", r.cfg.ReconnectBackoff),
+			zap.Error(err))
+		metrics.ReplicateReconnectCounter.Inc()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(r.cfg.ReconnectBackoff):
+		}
+	}
+}
+
+func (r *Replicator) runOnce(ctx context.Context) error {
+	stream, err := r.openStream(ctx, r.positions())
+	if err != nil {
+		return err
+	}
+	defer stream.Close()
+
+	var idle <-chan time.Time
+	var timer *time.Timer
+	if r.cfg.IdleTimeout > 0 {
+		timer = time.NewTimer(r.cfg.IdleTimeout)
+		defer timer.Stop()
+		idle = timer.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-idle:
+			return errors.Newf("no message received in %s", r.cfg.IdleTimeout)
+		case pack, ok := <-stream.Chan():
+			if !ok {
+				if err := stream.Err(); err != nil {
+					return err
+				}
+				return errors.New("msgstream closed")
+			}
+			if timer != nil {
+				if !timer.Stop() {
+					<-timer.C
+				}
+				timer.Reset(r.cfg.IdleTimeout)
+			}
+			if err := r.handleMsgPack(ctx, pack); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// positions returns where the source should be seeked to, the checkpoint of a channel
+// takes precedence over its initial position.
+func (r *Replicator) positions() []*msgpb.MsgPosition {
+	positions := make([]*msgpb.MsgPosition, 0, len(r.initial))
+	for _, pos := range r.initial {
+		if cp, ok := r.checkpoint[pos.GetChannelName()]; ok {
+			positions = append(positions, cp)
+		} else {
+			positions = append(positions, pos)
+		}
+	}
+	return positions
+}
+
+// handleMsgPack applies the messages in pack and advances the checkpoint past it.
+// The checkpoint is kept if any write fails, so that pack is consumed again after reconnecting.
+func (r *Replicator) handleMsgPack(ctx context.Context, pack *msgstream.MsgPack) error {
+	r.replayer.preparePack(pack)
+	skip, skipRows := 0, 0
+	if r.failed != nil && r.failed.beginTs == pack.BeginTs && r.failed.endTs == pack.EndTs {
+		skip, skipRows = r.failed.applied, r.failed.rows
+	}
+	r.failed = nil
+	for i, msg := range pack.Msgs {
+		if i < skip {
+			continue
+		}
+		if i > skip {
+			skipRows = 0
+		}
+		if rows, err := r.handleMsg(ctx, msg, skipRows); err != nil {
+			r.failed = &packProgress{beginTs: pack.BeginTs, endTs: pack.EndTs, applied: i, rows: rows}
+			return errors.Wrapf(err, "replicate %s failed", msg.Type().String())
+		}
+	}
+
+	for _, pos := range pack.EndPositions {
+		r.checkpoint[pos.GetChannelName()] = pos
+	}
+	if time.Since(r.lastSave) >= r.cfg.CheckpointInterval {
+		if err := r.saveCheckpoint(); err != nil {
+			log.Warn("save checkpoint failed", zap.Error(err))
+		}
+	}
+
+	lag := time.Duration(tsoutil.SubByNow(pack.EndTs)) * time.Millisecond
+	for _, pos := range pack.EndPositions {
+		metrics.ReplicateLag.WithLabelValues(pos.GetChannelName()).Set(float64(lag.Milliseconds()))
+	}
+	if r.paused {
+		return nil
+	}
+	if lag <= r.cfg.SteadyLag {
+		r.setState(metrics.ReplicateSteadyLabel)
+	} else {
+		r.setState(metrics.ReplicateCatchUpLabel)
+	}
+	return nil
+}
+
+// handleMsg applies msg skipping the first skipRows rows of an insert, returns the
+// number of rows of an insert applied before it failed.
+func (r *Replicator) handleMsg(ctx context.Context, msg msgstream.TsMsg, skipRows int) (int, error) {
+	switch msg.Type() {
+	case commonpb.MsgType_DropCollection:
+		dropMsg := msg.(*msgstream.DropCollectionMsg)
+		if !r.paused && dropMsg.GetCollectionID() == r.replayer.cfg.CollectionID {
+			log.Info("collection dropped, replication paused",
+				zap.String("collection", r.cfg.CollectionName),
+				zap.Int64("collectionID", dropMsg.GetCollectionID()))
+			r.paused = true
+			r.setState(metrics.ReplicatePausedLabel)
+		}
+		return 0, nil
+	case commonpb.MsgType_CreateCollection:
+		createMsg := msg.(*msgstream.CreateCollectionMsg)
+		if r.paused && createMsg.GetCollectionName() == r.cfg.CollectionName {
+			log.Info("collection recreated, replication resumed",
+				zap.String("collection", r.cfg.CollectionName),
+				zap.Int64("collectionID", createMsg.GetCollectionID()))
+			r.paused = false
+			r.replayer.cfg.CollectionID = createMsg.GetCollectionID()
+			r.setState(metrics.ReplicateCatchUpLabel)
+		}
+		return 0, nil
+	}
+	if r.paused {
+		return 0, nil
+	}
+
+	applied, rows, err := r.replayer.applyDML(ctx, msg, skipRows)
+	if !applied && err == nil {
+		applied, err = r.applyDDL(ctx, msg)
+	}
+	if !applied {
+		return 0, nil
+	}
+	status := metrics.SuccessLabel
+	if err != nil {
+		status = metrics.FailLabel
+		log.Error("replicate msg failed", zap.String("type", msg.Type().String()), zap.Error(err))
+	}
+	metrics.ReplicateMsgCounter.WithLabelValues(msg.Type().String(), status).Inc()
+	return rows, err
+}
+
+// applyDDL mirrors msg to the target if its type is selected, returns whether msg is applied.
+func (r *Replicator) applyDDL(ctx context.Context, msg msgstream.TsMsg) (bool, error) {
+	if !r.ddlEnabled(msg.Type()) {
+		return false, nil
+	}
+	switch msg.Type() {
+	case commonpb.MsgType_CreatePartition:
+		m := msg.(*msgstream.CreatePartitionMsg)
+		if !r.replayer.match(m.GetCollectionID(), m.GetCollectionName()) {
+			return false, nil
+		}
+		log.Info("replicate create partition", zap.String("partition", m.GetPartitionName()))
+		return true, r.ddl.CreatePartition(ctx, m.GetCollectionName(), m.GetPartitionName())
+	case commonpb.MsgType_DropPartition:
+		m := msg.(*msgstream.DropPartitionMsg)
+		if !r.replayer.match(m.GetCollectionID(), m.GetCollectionName()) {
+			return false, nil
+		}
+		log.Info("replicate drop partition", zap.String("partition", m.GetPartitionName()))
+		return true, r.ddl.DropPartition(ctx, m.GetCollectionName(), m.GetPartitionName())
+	case commonpb.MsgType_CreateIndex:
+		m := msg.(*msgstream.CreateIndexMsg)
+		if m.GetCollectionName() != r.cfg.CollectionName {
+			return false, nil
+		}
+		log.Info("replicate create index", zap.String("field", m.GetFieldName()), zap.String("index", m.GetIndexName()))
+		idx := newIndexOf(m)
+		return true, r.ddl.CreateIndex(ctx, m.GetCollectionName(), m.GetFieldName(), idx, true, client.WithIndexName(m.GetIndexName()))
+	case commonpb.MsgType_DropIndex:
+		m := msg.(*msgstream.DropIndexMsg)
+		if m.GetCollectionName() != r.cfg.CollectionName {
+			return false, nil
+		}
+		log.Info("replicate drop index", zap.String("field", m.GetFieldName()), zap.String("index", m.GetIndexName()))
+		return true, r.ddl.DropIndex(ctx, m.GetCollectionName(), m.GetFieldName(), client.WithIndexName(m.GetIndexName()))
+	}
+	return false, nil
+}
+
+// newIndexOf returns the index created by m, its type is AUTOINDEX if not given,
+// as milvus creates it.
+func newIndexOf(m *msgstream.CreateIndexMsg) entity.Index {
+	params := funcutil.KeyValuePair2Map(m.GetExtraParams())
+	indexType := entity.AUTOINDEX
+	if t, ok := params[common.IndexTypeKey]; ok {
+		indexType = entity.IndexType(t)
+		delete(params, common.IndexTypeKey)
+	}
+	return entity.NewGenericIndex(m.GetIndexName(), indexType, params)
+}
+
+func (r *Replicator) ddlEnabled(msgType commonpb.MsgType) bool {
+	for _, t := range r.cfg.DDLTypes {
+		if t == msgType {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Replicator) saveCheckpoint() error {
+	if len(r.checkpoint) == 0 {
+		return nil
+	}
+	positions := make([]*msgpb.MsgPosition, 0, len(r.checkpoint))
+	for _, pos := range r.checkpoint {
+		positions = append(positions, pos)
+	}
+	checkpoint := &Checkpoint{Positions: positions, Paused: r.paused}
+	if r.replayer.cfg.CollectionID != r.cfg.CollectionID {
+		checkpoint.CollectionID = r.replayer.cfg.CollectionID
+	}
+	if err := r.store.Save(checkpoint); err != nil {
+		return err
+	}
+	r.lastSave = time.Now()
+	return nil
+}
+
+func (r *Replicator) setState(state string) {
+	if r.state == state {
+		return
+	}
+	log.Info("replication state changed", zap.String("from", r.state), zap.String("to", state))
+	r.state = state
+	metrics.SetReplicateState(state)
+}
@@ -0,0 +1,360 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/milvuspb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/msgpb"
+	"github.com/milvus-io/milvus-sdk-go/v2/client"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream"
+	"github.com/xige-16/stream-read/pkg/util/funcutil"
+)
+
+type mockDDLTarget struct {
+	partitions []string
+	indexes    []entity.Index
+}
+
+func (t *mockDDLTarget) CreatePartition(ctx context.Context, collName string, partitionName string, opts ...client.CreatePartitionOption) error {
+	t.partitions = append(t.partitions, partitionName)
+	return nil
+}
+
+func (t *mockDDLTarget) DropPartition(ctx context.Context, collName string, partitionName string, opts ...client.DropPartitionOption) error {
+	for i, p := range t.partitions {
+		if p == partitionName {
+			t.partitions = append(t.partitions[:i], t.partitions[i+1:]...)
+			return nil
+		}
+	}
+	return errors.New("partition not found")
+}
+
+func (t *mockDDLTarget) CreateIndex(ctx context.Context, collName string, fieldName string, idx entity.Index, async bool, opts ...client.IndexOption) error {
+	t.indexes = append(t.indexes, idx)
+	return nil
+}
+
+func (t *mockDDLTarget) DropIndex(ctx context.Context, collName string, fieldName string, opts ...client.IndexOption) error {
+	t.indexes = t.indexes[:0]
+	return nil
+}
+
+type memCheckpointStore struct {
+	checkpoint *Checkpoint
+}
+
+func (s *memCheckpointStore) Load() (*Checkpoint, error) {
+	return s.checkpoint, nil
+}
+
+func (s *memCheckpointStore) Save(checkpoint *Checkpoint) error {
+	s.checkpoint = checkpoint
+	return nil
+}
+
+func newPartitionMsg(msgType commonpb.MsgType, collectionID int64, ts uint64, partition string) msgstream.TsMsg {
+	if msgType == commonpb.MsgType_CreatePartition {
+		return &msgstream.CreatePartitionMsg{
+			BaseMsg: msgstream.BaseMsg{BeginTimestamp: ts, EndTimestamp: ts},
+			CreatePartitionRequest: msgpb.CreatePartitionRequest{
+				Base:           &commonpb.MsgBase{MsgType: msgType},
+				CollectionName: "test",
+				CollectionID:   collectionID,
+				PartitionName:  partition,
+			},
+		}
+	}
+	return &msgstream.DropPartitionMsg{
+		BaseMsg: msgstream.BaseMsg{BeginTimestamp: ts, EndTimestamp: ts},
+		DropPartitionRequest: msgpb.DropPartitionRequest{
+			Base:           &commonpb.MsgBase{MsgType: msgType},
+			CollectionName: "test",
+			CollectionID:   collectionID,
+			PartitionName:  partition,
+		},
+	}
+}
+
+func newTtPack(channel string, beginTs, endTs uint64, msgs ...msgstream.TsMsg) *msgstream.MsgPack {
+	return &msgstream.MsgPack{
+		BeginTs:      beginTs,
+		EndTs:        endTs,
+		Msgs:         msgs,
+		EndPositions: []*msgpb.MsgPosition{{ChannelName: channel, MsgID: []byte{byte(endTs)}, Timestamp: endTs}},
+	}
+}
+
+func TestReplicator(t *testing.T) {
+	cfg := ReplicateConfig{
+		Config: Config{
+			CollectionID:   100,
+			CollectionName: "test",
+		},
+		DDLTypes: []commonpb.MsgType{commonpb.MsgType_CreatePartition},
+	}
+	initial := []*msgpb.MsgPosition{{ChannelName: "dml_0", MsgID: []byte{0}}}
+
+	target := &mockTarget{}
+	ddl := &mockDDLTarget{}
+	store := &memCheckpointStore{}
+	r := NewReplicator(cfg, nil, NewWriter(target, WriterConfig{}), ddl, store, initial)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	streams := []*mockStream{
+		newMockStream(
+			newTtPack("dml_0", 0, 10,
+				newInsertMsg(100, 5, 1, 2),
+				newPartitionMsg(commonpb.MsgType_CreatePartition, 100, 6, "p1"),
+				// not selected
+				newPartitionMsg(commonpb.MsgType_DropPartition, 100, 7, "p1"),
+			),
+		),
+		newMockStream(
+			newTtPack("dml_0", 10, 20,
+				newDeleteMsg(100, 15, 1),
+				&msgstream.DropCollectionMsg{
+					BaseMsg: msgstream.BaseMsg{BeginTimestamp: 16, EndTimestamp: 16},
+					DropCollectionRequest: msgpb.DropCollectionRequest{
+						Base:         &commonpb.MsgBase{MsgType: commonpb.MsgType_DropCollection},
+						CollectionID: 100,
+					},
+				},
+				// paused
+				newInsertMsg(100, 17, 3),
+			),
+			newTtPack("dml_0", 20, 30,
+				&msgstream.CreateCollectionMsg{
+					BaseMsg: msgstream.BaseMsg{BeginTimestamp: 25, EndTimestamp: 25},
+					CreateCollectionRequest: msgpb.CreateCollectionRequest{
+						Base:           &commonpb.MsgBase{MsgType: commonpb.MsgType_CreateCollection},
+						CollectionName: "test",
+						CollectionID:   200,
+					},
+				},
+				newInsertMsg(100, 26, 4),
+				newInsertMsg(200, 27, 5),
+			),
+		),
+	}
+	var seeked [][]*msgpb.MsgPosition
+	r.openStream = func(ctx context.Context, positions []*msgpb.MsgPosition) (msgstream.MsgStream, error) {
+		seeked = append(seeked, positions)
+		if len(streams) == 0 {
+			cancel()
+			return nil, errors.New("mock error")
+		}
+		stream := streams[0]
+		streams = streams[1:]
+		return stream, nil
+	}
+
+	assert.ErrorIs(t, r.Run(ctx), context.Canceled)
+	assert.Equal(t, 2, target.inserted)
+	assert.Equal(t, 1, target.deleted)
+	assert.Equal(t, []string{"p1"}, ddl.partitions)
+
+	// reconnected from the checkpoint
+	assert.Len(t, seeked, 3)
+	assert.Equal(t, []byte{0}, seeked[0][0].GetMsgID())
+	assert.Equal(t, uint64(10), seeked[1][0].GetTimestamp())
+	assert.Equal(t, uint64(30), seeked[2][0].GetTimestamp())
+	assert.Len(t, store.checkpoint.Positions, 1)
+	assert.Equal(t, uint64(30), store.checkpoint.Positions[0].GetTimestamp())
+	assert.False(t, store.checkpoint.Paused)
+	assert.EqualValues(t, 200, store.checkpoint.CollectionID)
+
+	t.Run("resume from saved checkpoint", func(t *testing.T) {
+		r := NewReplicator(cfg, nil, NewWriter(&mockTarget{}, WriterConfig{}), ddl, store, initial)
+		ctx, cancel := context.WithCancel(context.Background())
+		r.openStream = func(ctx context.Context, positions []*msgpb.MsgPosition) (msgstream.MsgStream, error) {
+			assert.Equal(t, uint64(30), positions[0].GetTimestamp())
+			cancel()
+			return nil, errors.New("mock error")
+		}
+		assert.ErrorIs(t, r.Run(ctx), context.Canceled)
+		assert.EqualValues(t, 200, r.replayer.cfg.CollectionID)
+	})
+
+	t.Run("resume paused", func(t *testing.T) {
+		store := &memCheckpointStore{checkpoint: &Checkpoint{
+			Positions: []*msgpb.MsgPosition{{ChannelName: "dml_0", MsgID: []byte{20}, Timestamp: 20}},
+			Paused:    true,
+		}}
+		target := &mockTarget{}
+		r := NewReplicator(cfg, nil, NewWriter(target, WriterConfig{}), ddl, store, initial)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		streams := []*mockStream{newMockStream(newTtPack("dml_0", 20, 30, newInsertMsg(100, 26, 4)))}
+		r.openStream = func(ctx context.Context, positions []*msgpb.MsgPosition) (msgstream.MsgStream, error) {
+			if len(streams) == 0 {
+				cancel()
+				return nil, errors.New("mock error")
+			}
+			stream := streams[0]
+			streams = streams[1:]
+			return stream, nil
+		}
+		assert.ErrorIs(t, r.Run(ctx), context.Canceled)
+		// still paused after restarted
+		assert.Equal(t, 0, target.inserted)
+		assert.True(t, store.checkpoint.Paused)
+		assert.Equal(t, uint64(30), store.checkpoint.Positions[0].GetTimestamp())
+	})
+}
+
+func TestReplicatorFailedWrite(t *testing.T) {
+	cfg := ReplicateConfig{
+		Config: Config{
+			CollectionID:   100,
+			CollectionName: "test",
+		},
+	}
+	initial := []*msgpb.MsgPosition{{ChannelName: "dml_0", MsgID: []byte{0}}}
+
+	// the second insert fails once
+	target := &mockTarget{insertErrs: []error{nil, errors.New("mock")}}
+	store := &memCheckpointStore{}
+	r := NewReplicator(cfg, nil, NewWriter(target, WriterConfig{}), &mockDDLTarget{}, store, initial)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	newPack := func() *msgstream.MsgPack {
+		return newTtPack("dml_0", 0, 10, newInsertMsg(100, 5, 1), newInsertMsg(100, 6, 2))
+	}
+	streams := []*mockStream{newMockStream(newPack()), newMockStream(newPack())}
+	var seeked [][]*msgpb.MsgPosition
+	r.openStream = func(ctx context.Context, positions []*msgpb.MsgPosition) (msgstream.MsgStream, error) {
+		seeked = append(seeked, positions)
+		if len(streams) == 0 {
+			cancel()
+			return nil, errors.New("mock error")
+		}
+		stream := streams[0]
+		streams = streams[1:]
+		return stream, nil
+	}
+
+	assert.ErrorIs(t, r.Run(ctx), context.Canceled)
+	// the first insert is not applied again
+	assert.Equal(t, 2, target.inserted)
+
+	// the checkpoint is not advanced past the failed pack
+	assert.Len(t, seeked, 3)
+	assert.Equal(t, []byte{0}, seeked[1][0].GetMsgID())
+	assert.Equal(t, uint64(10), seeked[2][0].GetTimestamp())
+	assert.Equal(t, uint64(10), store.checkpoint.Positions[0].GetTimestamp())
+
+	t.Run("failed insert batch", func(t *testing.T) {
+		// the second batch fails once
+		target := &mockTarget{insertErrs: []error{nil, errors.New("mock")}}
+		r := NewReplicator(cfg, nil, NewWriter(target, WriterConfig{}), &mockDDLTarget{}, &memCheckpointStore{}, initial)
+		r.replayer.SetInsertBatchRows(2)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		newPack := func() *msgstream.MsgPack {
+			return newTtPack("dml_0", 0, 10, newInsertMsg(100, 5, 1, 2, 3, 4, 5))
+		}
+		streams := []*mockStream{newMockStream(newPack()), newMockStream(newPack())}
+		r.openStream = func(ctx context.Context, positions []*msgpb.MsgPosition) (msgstream.MsgStream, error) {
+			if len(streams) == 0 {
+				cancel()
+				return nil, errors.New("mock error")
+			}
+			stream := streams[0]
+			streams = streams[1:]
+			return stream, nil
+		}
+		assert.ErrorIs(t, r.Run(ctx), context.Canceled)
+		// the first batch is not inserted again, the rest rows are inserted in two batches
+		assert.Equal(t, 3, target.inserted)
+	})
+}
+
+func TestReplicatorCreateIndex(t *testing.T) {
+	ddl := &mockDDLTarget{}
+	r := NewReplicator(ReplicateConfig{
+		Config:   Config{CollectionID: 100, CollectionName: "test"},
+		DDLTypes: []commonpb.MsgType{commonpb.MsgType_CreateIndex},
+	}, nil, NewWriter(&mockTarget{}, WriterConfig{}), ddl, &memCheckpointStore{}, nil)
+	newCreateIndexMsg := func(indexName string, params map[string]string) msgstream.TsMsg {
+		return &msgstream.CreateIndexMsg{
+			CreateIndexRequest: milvuspb.CreateIndexRequest{
+				Base:           &commonpb.MsgBase{MsgType: commonpb.MsgType_CreateIndex},
+				CollectionName: "test",
+				FieldName:      "vec",
+				IndexName:      indexName,
+				ExtraParams:    funcutil.Map2KeyValuePair(params),
+			},
+		}
+	}
+
+	applied, err := r.applyDDL(context.Background(), newCreateIndexMsg("hnsw", map[string]string{
+		"index_type":  "HNSW",
+		"metric_type": "L2",
+		"params":      `{"M": 8}`,
+	}))
+	assert.True(t, applied)
+	assert.NoError(t, err)
+	applied, err = r.applyDDL(context.Background(), newCreateIndexMsg("auto", map[string]string{"metric_type": "L2"}))
+	assert.True(t, applied)
+	assert.NoError(t, err)
+
+	assert.Len(t, ddl.indexes, 2)
+	assert.Equal(t, "hnsw", ddl.indexes[0].Name())
+	assert.Equal(t, entity.HNSW, ddl.indexes[0].IndexType())
+	assert.Equal(t, map[string]string{"index_type": "HNSW", "metric_type": "L2", "params": `{"M": 8}`}, ddl.indexes[0].Params())
+	assert.Equal(t, entity.AUTOINDEX, ddl.indexes[1].IndexType())
+}
+
+func TestFileCheckpointStore(t *testing.T) {
+	store := NewFileCheckpointStore(filepath.Join(t.TempDir(), "checkpoint.json"))
+	checkpoint, err := store.Load()
+	assert.NoError(t, err)
+	assert.Nil(t, checkpoint)
+
+	err = store.Save(&Checkpoint{
+		Positions: []*msgpb.MsgPosition{
+			{ChannelName: "dml_1", MsgID: []byte{4, 5, 6}, Timestamp: 2000},
+			{ChannelName: "dml_0", MsgID: []byte{1, 2, 3}, Timestamp: 1000},
+		},
+		Paused:       true,
+		CollectionID: 200,
+	})
+	assert.NoError(t, err)
+
+	checkpoint, err = store.Load()
+	assert.NoError(t, err)
+	assert.True(t, checkpoint.Paused)
+	assert.EqualValues(t, 200, checkpoint.CollectionID)
+	positions := checkpoint.Positions
+	assert.Len(t, positions, 2)
+	assert.Equal(t, "dml_0", positions[0].GetChannelName())
+	assert.Equal(t, []byte{1, 2, 3}, positions[0].GetMsgID())
+	assert.Equal(t, uint64(1000), positions[0].GetTimestamp())
+	assert.Equal(t, "dml_1", positions[1].GetChannelName())
+}
@@ -0,0 +1,334 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cockroachdb/errors"
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/msgpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/xige-16/stream-read/pkg/log"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream"
+	"github.com/xige-16/stream-read/pkg/util/conc"
+	"github.com/xige-16/stream-read/pkg/util/typeutil"
+)
+
+// ReshardConfig is the configuration of a Resharder.
+type ReshardConfig struct {
+	// Config.PKFieldName is required, rows are routed by it.
+	Config
+	// TargetChannels are the pchannels the collection is resharded onto,
+	// the i-th channel holds the i-th shard.
+	TargetChannels []string
+}
+
+// Resharder consumes the source channels of a collection and produces its DML
+// onto another number of channels, routed by primary key the same way as the proxy.
+type Resharder struct {
+	cfg       ReshardConfig
+	producer  msgstream.MsgStream
+	vchannels []string
+	// ddl dedups the DDL broadcast to every source channel.
+	ddl typeutil.Set[string]
+	// pending are the DML produced asynchronously and not acked yet.
+	pending []*conc.Future[msgstream.MessageID]
+}
+
+// NewResharder creates a Resharder producing through producer,
+// which should be a producer of cfg.TargetChannels.
+func NewResharder(cfg ReshardConfig, producer msgstream.MsgStream) *Resharder {
+	vchannels := make([]string, 0, len(cfg.TargetChannels))
+	for i, pchannel := range cfg.TargetChannels {
+		vchannels = append(vchannels, fmt.Sprintf("%s_%dv%d", pchannel, cfg.CollectionID, i))
+	}
+	r := &Resharder{
+		cfg:       cfg,
+		producer:  producer,
+		vchannels: vchannels,
+		ddl:       typeutil.NewSet[string](),
+	}
+	producer.SetRepackFunc(r.repack)
+	return r
+}
+
+// Run consumes stream until the stop timestamp is reached, the collection is dropped
+// or ctx is done. A time tick of every consumed pack is produced to all the target
+// channels after its DML, so the target channels keep the hybrid timestamp order.
+func (r *Resharder) Run(ctx context.Context, stream msgstream.MsgStream) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case pack, ok := <-stream.Chan():
+			if !ok {
+				return stream.Err()
+			}
+			if pack.BeginTs >= r.cfg.StopTs {
+				log.Info("reshard done!")
+				return nil
+			}
+			dropped, err := r.handleMsgPack(pack)
+			if err != nil {
+				return err
+			}
+			if dropped {
+				log.Info("collection dropped, reshard done!")
+				return nil
+			}
+			if pack.EndTs >= r.cfg.StopTs {
+				log.Info("reshard done!")
+				return nil
+			}
+		}
+	}
+}
+
+// handleMsgPack produces the messages in pack, returns true if the collection is dropped.
+func (r *Resharder) handleMsgPack(pack *msgstream.MsgPack) (bool, error) {
+	sortByTs(pack.Msgs)
+	endTs := pack.EndTs
+	for _, msg := range pack.Msgs {
+		if msg.BeginTs() > r.cfg.StopTs {
+			continue
+		}
+		switch msg.Type() {
+		case commonpb.MsgType_Insert:
+			imsg := msg.(*msgstream.InsertMsg)
+			if !r.match(imsg.GetCollectionID(), imsg.GetCollectionName()) {
+				continue
+			}
+			if err := r.produceInsert(imsg); err != nil {
+				return false, err
+			}
+		case commonpb.MsgType_Delete:
+			dmsg := msg.(*msgstream.DeleteMsg)
+			if !r.match(dmsg.GetCollectionID(), dmsg.GetCollectionName()) {
+				continue
+			}
+			if err := r.produceDelete(dmsg); err != nil {
+				return false, err
+			}
+		case commonpb.MsgType_DropCollection:
+			dropMsg := msg.(*msgstream.DropCollectionMsg)
+			if dropMsg.GetCollectionID() != r.cfg.CollectionID {
+				continue
+			}
+			if err := r.broadcastDDL(msg); err != nil {
+				return false, err
+			}
+			return true, r.produceTimeTick(msg.EndTs())
+		case commonpb.MsgType_CreatePartition:
+			m := msg.(*msgstream.CreatePartitionMsg)
+			if m.GetCollectionID() != r.cfg.CollectionID {
+				continue
+			}
+			if err := r.broadcastDDL(msg); err != nil {
+				return false, err
+			}
+		case commonpb.MsgType_DropPartition:
+			m := msg.(*msgstream.DropPartitionMsg)
+			if m.GetCollectionID() != r.cfg.CollectionID {
+				continue
+			}
+			if err := r.broadcastDDL(msg); err != nil {
+				return false, err
+			}
+		}
+	}
+	if endTs > r.cfg.StopTs {
+		endTs = r.cfg.StopTs
+	}
+	return false, r.produceTimeTick(endTs)
+}
+
+func (r *Resharder) match(collectionID int64, collectionName string) bool {
+	return r.cfg.CollectionID == collectionID && r.cfg.CollectionName == collectionName
+}
+
+func (r *Resharder) produceInsert(imsg *msgstream.InsertMsg) error {
+	var pkField *schemapb.FieldData
+	for _, fd := range imsg.GetFieldsData() {
+		if fd.GetFieldName() == r.cfg.PKFieldName {
+			pkField = fd
+			break
+		}
+	}
+	if pkField == nil {
+		return errors.Newf("primary key field %s not found in insert msg", r.cfg.PKFieldName)
+	}
+	pks, err := fieldDataToIDs(pkField)
+	if err != nil {
+		return err
+	}
+	imsg.HashValues = typeutil.HashPK2Channels(pks, r.cfg.TargetChannels)
+	log.Debug("reshard insert msg", zap.Uint64("numRows", imsg.GetNumRows()), zap.Uint64("ts", imsg.BeginTs()))
+	return r.produceAsync(&msgstream.MsgPack{
+		BeginTs: imsg.BeginTs(),
+		EndTs:   imsg.EndTs(),
+		Msgs:    []msgstream.TsMsg{imsg},
+	})
+}
+
+// produceAsync produces the DML in pack without waiting for the acks,
+// they are awaited before the next time tick.
+func (r *Resharder) produceAsync(pack *msgstream.MsgPack) error {
+	futures, err := r.producer.ProduceAsync(pack)
+	for _, fs := range futures {
+		r.pending = append(r.pending, fs...)
+	}
+	return err
+}
+
+// produceDelete splits dmsg by the target shards of its pks,
+// DeleteRepackFunc expects every delete msg belongs to one shard.
+func (r *Resharder) produceDelete(dmsg *msgstream.DeleteMsg) error {
+	hashValues := typeutil.HashPK2Channels(dmsg.GetPrimaryKeys(), r.cfg.TargetChannels)
+	shards := make(map[uint32]*msgstream.DeleteMsg)
+	order := make([]uint32, 0)
+	for i, shard := range hashValues {
+		msg, ok := shards[shard]
+		if !ok {
+			msg = &msgstream.DeleteMsg{
+				BaseMsg: msgstream.BaseMsg{
+					Ctx:            dmsg.TraceCtx(),
+					BeginTimestamp: dmsg.BeginTimestamp,
+					EndTimestamp:   dmsg.EndTimestamp,
+				},
+				DeleteRequest: msgpb.DeleteRequest{
+					Base:           dmsg.GetBase(),
+					ShardName:      r.vchannels[shard],
+					DbName:         dmsg.GetDbName(),
+					CollectionName: dmsg.GetCollectionName(),
+					PartitionName:  dmsg.GetPartitionName(),
+					DbID:           dmsg.GetDbID(),
+					CollectionID:   dmsg.GetCollectionID(),
+					PartitionID:    dmsg.GetPartitionID(),
+					PrimaryKeys:    &schemapb.IDs{},
+				},
+			}
+			shards[shard] = msg
+			order = append(order, shard)
+		}
+		typeutil.AppendIDs(msg.PrimaryKeys, dmsg.GetPrimaryKeys(), i)
+		msg.Timestamps = append(msg.Timestamps, dmsg.GetTimestamps()[i])
+		msg.HashValues = append(msg.HashValues, shard)
+		msg.NumRows++
+	}
+
+	log.Debug("reshard delete msg", zap.Int64("numRows", dmsg.GetNumRows()), zap.Int("shards", len(shards)))
+	for _, shard := range order {
+		msg := shards[shard]
+		err := r.produceAsync(&msgstream.MsgPack{
+			BeginTs: msg.BeginTs(),
+			EndTs:   msg.EndTs(),
+			Msgs:    []msgstream.TsMsg{msg},
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// broadcastDDL produces msg to all the target channels once, though it is
+// consumed from every source channel.
+func (r *Resharder) broadcastDDL(msg msgstream.TsMsg) error {
+	key := fmt.Sprintf("%s-%d-%d", msg.Type().String(), msg.ID(), msg.BeginTs())
+	if r.ddl.Contain(key) {
+		return nil
+	}
+	r.ddl.Insert(key)
+	_, err := r.producer.Broadcast(&msgstream.MsgPack{
+		BeginTs: msg.BeginTs(),
+		EndTs:   msg.EndTs(),
+		Msgs:    []msgstream.TsMsg{msg},
+	})
+	return err
+}
+
+func (r *Resharder) produceTimeTick(ts uint64) error {
+	// the DML before the tick must be published
+	err := conc.AwaitAll(r.pending...)
+	r.pending = r.pending[:0]
+	if err != nil {
+		return err
+	}
+	// the DDL of earlier timestamps are never consumed again
+	r.ddl = typeutil.NewSet[string]()
+	_, err = r.producer.Broadcast(&msgstream.MsgPack{
+		BeginTs: ts,
+		EndTs:   ts,
+		Msgs: []msgstream.TsMsg{&msgstream.TimeTickMsg{
+			BaseMsg: msgstream.BaseMsg{
+				BeginTimestamp: ts,
+				EndTimestamp:   ts,
+			},
+			TimeTickMsg: msgpb.TimeTickMsg{
+				Base: &commonpb.MsgBase{
+					MsgType:   commonpb.MsgType_TimeTick,
+					Timestamp: ts,
+				},
+			},
+		}},
+	})
+	return err
+}
+
+// repack routes the insert rows and delete msgs by their hash values,
+// and sets the shard name of them to the target vchannels.
+func (r *Resharder) repack(tsMsgs []msgstream.TsMsg, hashKeys [][]int32) (map[int32]*msgstream.MsgPack, error) {
+	var result map[int32]*msgstream.MsgPack
+	var err error
+	switch tsMsgs[0].Type() {
+	case commonpb.MsgType_Insert:
+		result, err = msgstream.InsertRepackFunc(tsMsgs, hashKeys)
+	case commonpb.MsgType_Delete:
+		result, err = msgstream.DeleteRepackFunc(tsMsgs, hashKeys)
+	default:
+		result, err = msgstream.DefaultRepackFunc(tsMsgs, hashKeys)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for shard, pack := range result {
+		for _, msg := range pack.Msgs {
+			if imsg, ok := msg.(*msgstream.InsertMsg); ok {
+				imsg.ShardName = r.vchannels[shard]
+			}
+		}
+	}
+	return result, nil
+}
+
+func fieldDataToIDs(fd *schemapb.FieldData) (*schemapb.IDs, error) {
+	switch fd.GetType() {
+	case schemapb.DataType_Int64:
+		return &schemapb.IDs{IdField: &schemapb.IDs_IntId{IntId: &schemapb.LongArray{
+			Data: fd.GetScalars().GetLongData().GetData(),
+		}}}, nil
+	case schemapb.DataType_VarChar:
+		return &schemapb.IDs{IdField: &schemapb.IDs_StrId{StrId: &schemapb.StringArray{
+			Data: fd.GetScalars().GetStringData().GetData(),
+		}}}, nil
+	}
+	return nil, errors.Newf("unsupported primary key type %s", fd.GetType().String())
+}
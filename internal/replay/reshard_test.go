@@ -0,0 +1,219 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/msgpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream"
+	"github.com/xige-16/stream-read/pkg/util/conc"
+	"github.com/xige-16/stream-read/pkg/util/typeutil"
+)
+
+// mockProducer repacks produced msgs the same way as mqMsgStream.Produce,
+// and records the msgs of every channel.
+type mockProducer struct {
+	msgstream.MsgStream
+	channels   []string
+	repackFunc msgstream.RepackFunc
+	produced   map[string][]msgstream.TsMsg
+	sendErr    error
+}
+
+func newMockProducer(channels ...string) *mockProducer {
+	return &mockProducer{channels: channels, produced: make(map[string][]msgstream.TsMsg)}
+}
+
+func (p *mockProducer) SetRepackFunc(repackFunc msgstream.RepackFunc) {
+	p.repackFunc = repackFunc
+}
+
+func (p *mockProducer) Produce(pack *msgstream.MsgPack) error {
+	hashKeys := make([][]int32, len(pack.Msgs))
+	for i, msg := range pack.Msgs {
+		for _, v := range msg.HashKeys() {
+			hashKeys[i] = append(hashKeys[i], int32(v%uint32(len(p.channels))))
+		}
+	}
+	result, err := p.repackFunc(pack.Msgs, hashKeys)
+	if err != nil {
+		return err
+	}
+	for shard, pack := range result {
+		channel := p.channels[shard]
+		p.produced[channel] = append(p.produced[channel], pack.Msgs...)
+	}
+	return nil
+}
+
+func (p *mockProducer) ProduceAsync(pack *msgstream.MsgPack) (map[string][]*conc.Future[msgstream.MessageID], error) {
+	err := p.Produce(pack)
+	if err != nil {
+		return nil, err
+	}
+	future := conc.Go(func() (msgstream.MessageID, error) {
+		return nil, p.sendErr
+	})
+	return map[string][]*conc.Future[msgstream.MessageID]{p.channels[0]: {future}}, nil
+}
+
+func (p *mockProducer) Broadcast(pack *msgstream.MsgPack) (map[string][]msgstream.MessageID, error) {
+	for _, channel := range p.channels {
+		p.produced[channel] = append(p.produced[channel], pack.Msgs...)
+	}
+	return nil, nil
+}
+
+func newReshardInsertMsg(ts uint64, pks ...int64) *msgstream.InsertMsg {
+	msg := newInsertMsg(100, ts, pks...)
+	msg.RowIDs = pks
+	return msg
+}
+
+func TestResharder(t *testing.T) {
+	targets := []string{"dml_0", "dml_1", "dml_2"}
+	cfg := ReshardConfig{
+		Config: Config{
+			CollectionID:   100,
+			CollectionName: "test",
+			PKFieldName:    "pk",
+			StopTs:         300,
+		},
+		TargetChannels: targets,
+	}
+	producer := newMockProducer(targets...)
+	r := NewResharder(cfg, producer)
+
+	createPartition := func() msgstream.TsMsg {
+		return newPartitionMsg(commonpb.MsgType_CreatePartition, 100, 150, "p1")
+	}
+	stream := newMockStream(
+		// consumed from two source channels
+		&msgstream.MsgPack{BeginTs: 0, EndTs: 200, Msgs: []msgstream.TsMsg{
+			newReshardInsertMsg(100, 1, 2, 3, 4, 5, 6),
+			createPartition(),
+			createPartition(),
+			newDeleteMsg(100, 120, 1, 2, 3),
+			// other collection
+			newInsertMsg(101, 130, 7),
+		}},
+		&msgstream.MsgPack{BeginTs: 200, EndTs: 400, Msgs: []msgstream.TsMsg{
+			newReshardInsertMsg(250, 8),
+			// after the stop ts
+			newReshardInsertMsg(350, 9),
+		}},
+	)
+	assert.NoError(t, r.Run(context.Background(), stream))
+
+	inserted := make(map[int64]string)
+	deleted := make(map[int64]string)
+	for i, channel := range targets {
+		msgs := producer.produced[channel]
+		var lastTs uint64
+		ticks := make([]uint64, 0)
+		partitions := 0
+		for _, msg := range msgs {
+			// the hybrid timestamp order is kept
+			assert.GreaterOrEqual(t, msg.BeginTs(), lastTs)
+			lastTs = msg.BeginTs()
+			switch msg.Type() {
+			case commonpb.MsgType_Insert:
+				imsg := msg.(*msgstream.InsertMsg)
+				assert.Equal(t, fmt.Sprintf("%s_100v%d", channel, i), imsg.GetShardName())
+				for _, pk := range imsg.GetFieldsData()[0].GetScalars().GetLongData().GetData() {
+					inserted[pk] = channel
+				}
+			case commonpb.MsgType_Delete:
+				dmsg := msg.(*msgstream.DeleteMsg)
+				assert.Equal(t, fmt.Sprintf("%s_100v%d", channel, i), dmsg.GetShardName())
+				assert.Equal(t, int(dmsg.GetNumRows()), len(dmsg.GetTimestamps()))
+				for _, pk := range dmsg.GetPrimaryKeys().GetIntId().GetData() {
+					deleted[pk] = channel
+				}
+			case commonpb.MsgType_CreatePartition:
+				partitions++
+			case commonpb.MsgType_TimeTick:
+				ticks = append(ticks, msg.BeginTs())
+			}
+		}
+		assert.Equal(t, 1, partitions)
+		assert.Equal(t, []uint64{200, 300}, ticks)
+	}
+
+	assert.Len(t, inserted, 7)
+	assert.Len(t, deleted, 3)
+	for pk, channel := range inserted {
+		ids := &schemapb.IDs{IdField: &schemapb.IDs_IntId{IntId: &schemapb.LongArray{Data: []int64{pk}}}}
+		assert.Equal(t, targets[typeutil.HashPK2Channels(ids, targets)[0]], channel)
+		if ch, ok := deleted[pk]; ok {
+			assert.Equal(t, channel, ch)
+		}
+	}
+
+	t.Run("missing pk field", func(t *testing.T) {
+		cfg := cfg
+		cfg.PKFieldName = "id"
+		r := NewResharder(cfg, newMockProducer(targets...))
+		stream := newMockStream(&msgstream.MsgPack{BeginTs: 0, EndTs: 200, Msgs: []msgstream.TsMsg{
+			newReshardInsertMsg(100, 1),
+		}})
+		assert.Error(t, r.Run(context.Background(), stream))
+	})
+
+	t.Run("send failure", func(t *testing.T) {
+		producer := newMockProducer(targets...)
+		producer.sendErr = errors.New("mock error")
+		r := NewResharder(cfg, producer)
+		stream := newMockStream(&msgstream.MsgPack{BeginTs: 0, EndTs: 200, Msgs: []msgstream.TsMsg{
+			newReshardInsertMsg(100, 1),
+		}})
+		assert.Error(t, r.Run(context.Background(), stream))
+		// no time tick after the failed DML
+		for _, msg := range producer.produced[targets[0]] {
+			assert.NotEqual(t, commonpb.MsgType_TimeTick, msg.Type())
+		}
+	})
+
+	t.Run("drop collection", func(t *testing.T) {
+		producer := newMockProducer(targets...)
+		r := NewResharder(cfg, producer)
+		stream := newMockStream(&msgstream.MsgPack{BeginTs: 0, EndTs: 200, Msgs: []msgstream.TsMsg{
+			&msgstream.DropCollectionMsg{
+				BaseMsg: msgstream.BaseMsg{BeginTimestamp: 150, EndTimestamp: 150},
+				DropCollectionRequest: msgpb.DropCollectionRequest{
+					Base:         &commonpb.MsgBase{MsgType: commonpb.MsgType_DropCollection},
+					CollectionID: 100,
+				},
+			},
+			newReshardInsertMsg(160, 1),
+		}})
+		assert.NoError(t, r.Run(context.Background(), stream))
+		msgs := producer.produced[targets[0]]
+		assert.Len(t, msgs, 2)
+		assert.Equal(t, commonpb.MsgType_DropCollection, msgs[0].Type())
+		assert.Equal(t, commonpb.MsgType_TimeTick, msgs[1].Type())
+		assert.Equal(t, uint64(150), msgs[1].BeginTs())
+	})
+}
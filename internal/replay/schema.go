@@ -0,0 +1,83 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/golang/protobuf/jsonpb"
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"github.com/xige-16/stream-read/pkg/log"
+	"github.com/xige-16/stream-read/pkg/util/typeutil"
+)
+
+// SchemaTarget is the part of the target cluster describing the replayed collection,
+// satisfied by the milvus go sdk client.
+type SchemaTarget interface {
+	DescribeCollection(ctx context.Context, collName string) (*entity.Collection, error)
+}
+
+// LoadSchema reads the schema of the replayed collection from the json form of
+// schemapb.CollectionSchema, e.g.
+//
+//	{
+//	  "name": "test",
+//	  "fields": [
+//	    {"fieldID": 100, "name": "pk", "is_primary_key": true, "data_type": "Int64"},
+//	    {"fieldID": 101, "name": "vec", "data_type": "FloatVector", "type_params": [{"key": "dim", "value": "8"}]}
+//	  ]
+//	}
+func LoadSchema(path string) (*schemapb.CollectionSchema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	schema := &schemapb.CollectionSchema{}
+	if err := jsonpb.Unmarshal(bytes.NewReader(data), schema); err != nil {
+		return nil, fmt.Errorf("failed to parse schema, err %s", err.Error())
+	}
+	return schema, nil
+}
+
+// PlanConversion diffs the schema of the replayed collection with the one of collName in target,
+// and returns the plan converting the replayed inserts to the target, see Config.Conversion.
+// It returns an error if any change is breaking.
+func PlanConversion(ctx context.Context, target SchemaTarget, collName string, schema *schemapb.CollectionSchema) (*typeutil.ConversionPlan, error) {
+	coll, err := target.DescribeCollection(ctx, collName)
+	if err != nil {
+		return nil, err
+	}
+	diff := typeutil.DiffSchema(schema, coll.Schema.ProtoMessage())
+	for _, change := range diff.Changes {
+		log.Info("schema changed", zap.String("change", change.String()))
+	}
+	if breaking := diff.Breaking(); len(breaking) > 0 {
+		reasons := make([]string, 0, len(breaking))
+		for _, change := range breaking {
+			reasons = append(reasons, change.String())
+		}
+		return nil, fmt.Errorf("schema of collection %s is not compatible, %s", collName, strings.Join(reasons, "; "))
+	}
+	return diff.Plan, nil
+}
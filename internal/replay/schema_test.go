@@ -0,0 +1,92 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"github.com/xige-16/stream-read/pkg/util/typeutil"
+)
+
+const testSchema = `{
+  "name": "test",
+  "fields": [
+    {"fieldID": 100, "name": "pk", "is_primary_key": true, "data_type": "Int64"},
+    {"fieldID": 101, "name": "age", "data_type": "Int16"},
+    {"fieldID": 102, "name": "vec", "data_type": "FloatVector", "type_params": [{"key": "dim", "value": "8"}]}
+  ]
+}`
+
+type mockSchemaTarget struct {
+	schema *entity.Schema
+}
+
+func (t *mockSchemaTarget) DescribeCollection(ctx context.Context, collName string) (*entity.Collection, error) {
+	if t.schema == nil {
+		return nil, errors.New("collection not found")
+	}
+	return &entity.Collection{Name: collName, Schema: t.schema}, nil
+}
+
+func newTargetSchema(dim string) *entity.Schema {
+	return entity.NewSchema().WithName("test").
+		WithField(entity.NewField().WithName("pk").WithDataType(entity.FieldTypeInt64).WithIsPrimaryKey(true)).
+		WithField(entity.NewField().WithName("age").WithDataType(entity.FieldTypeInt64)).
+		WithField(entity.NewField().WithName("vec").WithDataType(entity.FieldTypeFloatVector).WithTypeParams(entity.TypeParamDim, dim))
+}
+
+func TestPlanConversion(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "schema.json")
+	require.NoError(t, os.WriteFile(path, []byte(testSchema), 0o600))
+	schema, err := LoadSchema(path)
+	require.NoError(t, err)
+	assert.Len(t, schema.GetFields(), 3)
+	assert.Equal(t, schemapb.DataType_Int16, schema.GetFields()[1].GetDataType())
+
+	t.Run("convertible", func(t *testing.T) {
+		plan, err := PlanConversion(context.Background(), &mockSchemaTarget{schema: newTargetSchema("8")}, "test", schema)
+		require.NoError(t, err)
+		assert.Equal(t, typeutil.ConversionKeep, plan.Fields["pk"].Action)
+		assert.Equal(t, typeutil.ConversionCast, plan.Fields["age"].Action)
+		assert.Equal(t, typeutil.ConversionKeep, plan.Fields["vec"].Action)
+	})
+
+	t.Run("breaking", func(t *testing.T) {
+		_, err := PlanConversion(context.Background(), &mockSchemaTarget{schema: newTargetSchema("16")}, "test", schema)
+		assert.ErrorContains(t, err, "dimension is changed from 8 to 16")
+	})
+
+	t.Run("describe failed", func(t *testing.T) {
+		_, err := PlanConversion(context.Background(), &mockSchemaTarget{}, "test", schema)
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid file", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte("{"), 0o600))
+		_, err := LoadSchema(path)
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,106 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/xige-16/stream-read/pkg/config"
+	"github.com/xige-16/stream-read/pkg/log"
+	"github.com/xige-16/stream-read/pkg/util/paramtable"
+)
+
+var settingsWatcherCounter atomic.Int64
+
+// WriterConfigFromParams returns the WriterConfig of the replay settings in params.
+func WriterConfigFromParams(params *paramtable.ComponentParam) WriterConfig {
+	return WriterConfig{
+		RowsPerSecond:  params.ReplayCfg.RateLimitRows.GetAsFloat(),
+		BytesPerSecond: params.ReplayCfg.RateLimitBytes.GetAsFloat(),
+		MaxBackoff:     params.ReplayCfg.RateLimitMaxBackoff.GetAsDuration(time.Second),
+	}
+}
+
+// settingsWatcher applies the refreshable replay settings to a Replayer and its Writer.
+type settingsWatcher struct {
+	params   *paramtable.ComponentParam
+	replayer *Replayer
+	handler  config.EventHandler
+}
+
+// WatchSettings applies the replay settings in params to r and its writer, and applies them
+// again whenever one of them is refreshed from the config sources, so a long replay is tuned
+// without a restart. The settings saved to params at runtime override the sources.
+// It returns a func to stop watching.
+func WatchSettings(params *paramtable.ComponentParam, r *Replayer) func() {
+	w := newSettingsWatcher(params, r)
+	w.apply()
+
+	keys := w.keys()
+	for _, key := range keys {
+		params.Watch(key, w.handler)
+	}
+	return func() {
+		for _, key := range keys {
+			params.Unwatch(key, w.handler)
+		}
+	}
+}
+
+func newSettingsWatcher(params *paramtable.ComponentParam, r *Replayer) *settingsWatcher {
+	w := &settingsWatcher{params: params, replayer: r}
+	w.handler = config.NewHandler("replay settings "+fmt.Sprint(settingsWatcherCounter.Inc()), func(event *config.Event) {
+		log.Info("replay setting refreshed", zap.String("key", event.Key), zap.String("value", event.Value))
+		// the value of the event may be shadowed by a higher priority source
+		w.apply()
+	})
+	return w
+}
+
+func (w *settingsWatcher) keys() []string {
+	cfg := &w.params.ReplayCfg
+	return []string{
+		cfg.RateLimitRows.Key,
+		cfg.RateLimitBytes.Key,
+		cfg.RateLimitMaxBackoff.Key,
+		cfg.InsertBatchRows.Key,
+		cfg.Partitions.Key,
+		w.params.LogCfg.Level.Key,
+	}
+}
+
+func (w *settingsWatcher) apply() {
+	cfg := &w.params.ReplayCfg
+	w.replayer.writer.SetConfig(WriterConfigFromParams(w.params))
+	w.replayer.SetInsertBatchRows(cfg.InsertBatchRows.GetAsInt())
+	w.replayer.SetPartitions(cfg.Partitions.GetAsStrings())
+
+	var level zapcore.Level
+	if err := level.UnmarshalText([]byte(w.params.LogCfg.Level.GetValue())); err != nil {
+		log.Warn("invalid log level", zap.String("level", w.params.LogCfg.Level.GetValue()), zap.Error(err))
+		return
+	}
+	if level != log.GetLevel() {
+		log.Info("log level updated", zap.Stringer("old", log.GetLevel()), zap.Stringer("new", level))
+		log.SetLevel(level)
+	}
+}
@@ -0,0 +1,81 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap/zapcore"
+
+	"github.com/xige-16/stream-read/pkg/config"
+	"github.com/xige-16/stream-read/pkg/log"
+	"github.com/xige-16/stream-read/pkg/util/paramtable"
+)
+
+func TestWatchSettings(t *testing.T) {
+	paramtable.Init()
+	params := paramtable.Get()
+	cfg := &params.ReplayCfg
+	keys := []string{
+		cfg.RateLimitRows.Key,
+		cfg.RateLimitBytes.Key,
+		cfg.RateLimitMaxBackoff.Key,
+		cfg.InsertBatchRows.Key,
+		cfg.Partitions.Key,
+		params.LogCfg.Level.Key,
+	}
+	orgLevel := log.GetLevel()
+	defer func() {
+		for _, key := range keys {
+			params.Reset(key)
+		}
+		log.SetLevel(orgLevel)
+	}()
+
+	params.Save(cfg.RateLimitRows.Key, "100")
+	params.Save(cfg.RateLimitMaxBackoff.Key, "5")
+	params.Save(cfg.InsertBatchRows.Key, "10")
+	params.Save(cfg.Partitions.Key, "p1,p2")
+	params.Save(params.LogCfg.Level.Key, "warn")
+
+	r := NewReplayer(Config{}, NewWriter(&mockTarget{}, WriterConfig{}))
+	unwatch := WatchSettings(params, r)
+	assert.Equal(t, WriterConfig{RowsPerSecond: 100, MaxBackoff: 5 * time.Second}, r.writer.Config())
+	assert.EqualValues(t, 10, r.insertBatchRows.Load())
+	assert.Equal(t, []string{"p1", "p2"}, *r.partitions.Load())
+	assert.Equal(t, zapcore.WarnLevel, log.GetLevel())
+
+	// saving does not dispatch events, refresh the way a source would
+	params.Save(cfg.RateLimitBytes.Key, "1000")
+	params.Save(cfg.Partitions.Key, "")
+	params.Save(params.LogCfg.Level.Key, "invalid")
+	w := newSettingsWatcher(params, r)
+	w.handler.OnEvent(&config.Event{
+		EventSource: "test",
+		EventType:   config.UpdateType,
+		Key:         cfg.RateLimitBytes.Key,
+		Value:       "1000",
+	})
+	assert.Equal(t, WriterConfig{RowsPerSecond: 100, BytesPerSecond: 1000, MaxBackoff: 5 * time.Second}, r.writer.Config())
+	assert.Empty(t, *r.partitions.Load())
+	// an invalid log level is ignored
+	assert.Equal(t, zapcore.WarnLevel, log.GetLevel())
+
+	unwatch()
+}
@@ -0,0 +1,260 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"go.uber.org/zap"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"github.com/xige-16/stream-read/pkg/log"
+	"github.com/xige-16/stream-read/pkg/util/merr"
+	"github.com/xige-16/stream-read/pkg/util/ratelimitutil"
+)
+
+const (
+	defaultInitialBackoff = 100 * time.Millisecond
+	defaultMaxBackoff     = 30 * time.Second
+
+	// when the target keeps rejecting writes, the rate is cut down to half,
+	// but never below minRateRatio of the configured rate.
+	rateDecreaseRatio = 0.5
+	minRateRatio      = 0.05
+	// every successful write recovers rateIncreaseRatio of the configured rate.
+	rateIncreaseRatio = 0.05
+)
+
+// Target is the subset of the milvus client used to apply replayed DML.
+type Target interface {
+	Insert(ctx context.Context, collName string, partitionName string, columns ...entity.Column) (entity.Column, error)
+	DeleteByPks(ctx context.Context, collName string, partitionName string, ids entity.Column) error
+}
+
+// WriterConfig configures the throughput of the Writer.
+type WriterConfig struct {
+	// RowsPerSecond limits the number of inserted and deleted rows per second, 0 means unlimited.
+	RowsPerSecond float64
+	// BytesPerSecond limits the size of written messages per second, 0 means unlimited.
+	BytesPerSecond float64
+	// MaxBackoff is the upper bound of the sleep between two retries on rate limit errors.
+	MaxBackoff time.Duration
+}
+
+// Writer applies DML to the target cluster.
+// Writes are throttled by token buckets on rows and bytes, and writes rejected by
+// the target with rate limit errors are retried with adaptive backoff instead of being dropped.
+type Writer struct {
+	target Target
+	mu     sync.Mutex
+	cfg    WriterConfig
+
+	rowLimiter  *ratelimitutil.Limiter
+	byteLimiter *ratelimitutil.Limiter
+
+	initialBackoff time.Duration
+	backoff        time.Duration
+}
+
+// NewWriter creates a Writer on top of target.
+func NewWriter(target Target, cfg WriterConfig) *Writer {
+	cfg = cfg.withDefaults()
+	return &Writer{
+		target:         target,
+		cfg:            cfg,
+		rowLimiter:     newLimiter(cfg.RowsPerSecond),
+		byteLimiter:    newLimiter(cfg.BytesPerSecond),
+		initialBackoff: defaultInitialBackoff,
+		backoff:        defaultInitialBackoff,
+	}
+}
+
+func (cfg WriterConfig) withDefaults() WriterConfig {
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = defaultMaxBackoff
+	}
+	return cfg
+}
+
+// SetConfig updates the configured rates and max backoff of w, it is safe to call
+// while w is writing. The rates lowered by the target rejections restart from the new ones.
+func (w *Writer) SetConfig(cfg WriterConfig) {
+	cfg = cfg.withDefaults()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if cfg == w.cfg {
+		return
+	}
+	log.Info("writer config updated", zap.Any("old", w.cfg), zap.Any("new", cfg))
+	w.cfg = cfg
+	setRate(w.rowLimiter, cfg.RowsPerSecond)
+	setRate(w.byteLimiter, cfg.BytesPerSecond)
+	if w.backoff > cfg.MaxBackoff {
+		w.backoff = cfg.MaxBackoff
+	}
+}
+
+// Config returns the current config of w.
+func (w *Writer) Config() WriterConfig {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.cfg
+}
+
+func setRate(limiter *ratelimitutil.Limiter, rate float64) {
+	if rate <= 0 {
+		limiter.SetLimit(ratelimitutil.Inf)
+		limiter.SetBurst(0)
+		return
+	}
+	limiter.SetBurst(rate)
+	limiter.SetLimit(ratelimitutil.Limit(rate))
+}
+
+func newLimiter(rate float64) *ratelimitutil.Limiter {
+	if rate <= 0 {
+		return ratelimitutil.NewLimiter(ratelimitutil.Inf, 0)
+	}
+	// allow one second of burst
+	return ratelimitutil.NewLimiter(ratelimitutil.Limit(rate), rate)
+}
+
+// Insert inserts columns into the target, numRows and size are used for throttling.
+func (w *Writer) Insert(ctx context.Context, collName, partitionName string, numRows int, size int, columns ...entity.Column) error {
+	return w.write(ctx, numRows, size, func() error {
+		_, err := w.target.Insert(ctx, collName, partitionName, columns...)
+		return err
+	})
+}
+
+// Delete deletes the entities of pks from the target, size is used for throttling.
+func (w *Writer) Delete(ctx context.Context, collName, partitionName string, size int, pks entity.Column) error {
+	return w.write(ctx, pks.Len(), size, func() error {
+		return w.target.DeleteByPks(ctx, collName, partitionName, pks)
+	})
+}
+
+func (w *Writer) write(ctx context.Context, numRows int, size int, fn func() error) error {
+	if err := w.rowLimiter.WaitN(ctx, numRows); err != nil {
+		return err
+	}
+	if err := w.byteLimiter.WaitN(ctx, size); err != nil {
+		return err
+	}
+
+	for {
+		err := fn()
+		if err == nil {
+			w.onSuccess()
+			return nil
+		}
+		if !IsRateLimitErr(err) {
+			return err
+		}
+
+		backoff := w.onRateLimited()
+		log.Ctx(ctx).Warn("target is rate limited, back off and retry",
+			zap.Duration("backoff", backoff),
+			zap.Float64("rowRate", float64(w.rowLimiter.Limit())),
+			zap.Float64("byteRate", float64(w.byteLimiter.Limit())),
+			zap.Error(err))
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), err.Error())
+		}
+	}
+}
+
+// onRateLimited decreases the rates multiplicatively, and returns the backoff
+// before the next retry.
+func (w *Writer) onRateLimited() time.Duration {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	decrease(w.rowLimiter, w.cfg.RowsPerSecond)
+	decrease(w.byteLimiter, w.cfg.BytesPerSecond)
+	backoff := w.backoff
+	w.backoff *= 2
+	if w.backoff > w.cfg.MaxBackoff {
+		w.backoff = w.cfg.MaxBackoff
+	}
+	return backoff
+}
+
+// onSuccess resets the backoff and increases the rates additively,
+// up to the configured ones.
+func (w *Writer) onSuccess() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.backoff = w.initialBackoff
+	increase(w.rowLimiter, w.cfg.RowsPerSecond)
+	increase(w.byteLimiter, w.cfg.BytesPerSecond)
+}
+
+func decrease(limiter *ratelimitutil.Limiter, configured float64) {
+	if configured <= 0 {
+		return
+	}
+	rate := float64(limiter.Limit()) * rateDecreaseRatio
+	if rate < configured*minRateRatio {
+		rate = configured * minRateRatio
+	}
+	limiter.SetLimit(ratelimitutil.Limit(rate))
+}
+
+func increase(limiter *ratelimitutil.Limiter, configured float64) {
+	if configured <= 0 {
+		return
+	}
+	rate := float64(limiter.Limit())
+	if rate >= configured {
+		return
+	}
+	rate += configured * rateIncreaseRatio
+	if rate > configured {
+		rate = configured
+	}
+	limiter.SetLimit(ratelimitutil.Limit(rate))
+}
+
+// IsRateLimitErr returns whether err is caused by the rate limiter of the target cluster.
+// The milvus sdk only keeps the reason of a failed status, so the reason is matched as well.
+func IsRateLimitErr(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, merr.ErrServiceRateLimit) || errors.Is(err, merr.ErrHTTPRateLimit) {
+		return true
+	}
+	reason := err.Error()
+	for _, pattern := range []string{
+		merr.ErrServiceRateLimit.Error(),
+		merr.ErrHTTPRateLimit.Error(),
+		commonpb.ErrorCode_RateLimit.String(),
+	} {
+		if strings.Contains(reason, pattern) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus-sdk-go/v2/entity"
+	"github.com/xige-16/stream-read/pkg/util/merr"
+	"github.com/xige-16/stream-read/pkg/util/ratelimitutil"
+)
+
+type mockTarget struct {
+	insertErrs []error
+	deleteErrs []error
+	inserted   int
+	deleted    int
+}
+
+func (t *mockTarget) Insert(ctx context.Context, collName string, partitionName string, columns ...entity.Column) (entity.Column, error) {
+	if len(t.insertErrs) > 0 {
+		err := t.insertErrs[0]
+		t.insertErrs = t.insertErrs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	t.inserted++
+	return nil, nil
+}
+
+func (t *mockTarget) DeleteByPks(ctx context.Context, collName string, partitionName string, ids entity.Column) error {
+	if len(t.deleteErrs) > 0 {
+		err := t.deleteErrs[0]
+		t.deleteErrs = t.deleteErrs[1:]
+		if err != nil {
+			return err
+		}
+	}
+	t.deleted += ids.Len()
+	return nil
+}
+
+func TestIsRateLimitErr(t *testing.T) {
+	assert.False(t, IsRateLimitErr(nil))
+	assert.False(t, IsRateLimitErr(errors.New("collection not found")))
+	assert.True(t, IsRateLimitErr(merr.WrapErrServiceRateLimit(100)))
+	assert.True(t, IsRateLimitErr(errors.New("rate limit exceeded[rate=100]")))
+	assert.True(t, IsRateLimitErr(errors.New("ErrorCode: RateLimit")))
+}
+
+func TestWriter(t *testing.T) {
+	pks := entity.NewColumnInt64("pk", []int64{1, 2, 3})
+
+	t.Run("retry on rate limit", func(t *testing.T) {
+		target := &mockTarget{
+			insertErrs: []error{
+				merr.WrapErrServiceRateLimit(10),
+				merr.WrapErrServiceRateLimit(10),
+			},
+		}
+		w := NewWriter(target, WriterConfig{RowsPerSecond: 1000, MaxBackoff: 10 * time.Millisecond})
+		w.initialBackoff = time.Millisecond
+		w.backoff = time.Millisecond
+		err := w.Insert(context.Background(), "coll", "", 3, 100, pks)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, target.inserted)
+		// rate is decreased twice and increased once
+		assert.Equal(t, ratelimitutil.Limit(300), w.rowLimiter.Limit())
+		assert.Equal(t, time.Millisecond, w.backoff)
+	})
+
+	t.Run("drop other errors", func(t *testing.T) {
+		target := &mockTarget{
+			deleteErrs: []error{errors.New("mock")},
+		}
+		w := NewWriter(target, WriterConfig{})
+		err := w.Delete(context.Background(), "coll", "", 100, pks)
+		assert.Error(t, err)
+		assert.Equal(t, 0, target.deleted)
+
+		err = w.Delete(context.Background(), "coll", "", 100, pks)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, target.deleted)
+	})
+
+	t.Run("ctx canceled while backing off", func(t *testing.T) {
+		target := &mockTarget{
+			insertErrs: []error{merr.WrapErrServiceRateLimit(10)},
+		}
+		w := NewWriter(target, WriterConfig{})
+		w.backoff = time.Hour
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		err := w.Insert(ctx, "coll", "", 3, 100, pks)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("throttle", func(t *testing.T) {
+		target := &mockTarget{}
+		w := NewWriter(target, WriterConfig{BytesPerSecond: 1000})
+		start := time.Now()
+		// the first write consumes the burst, the second one waits for 100 bytes
+		assert.NoError(t, w.Insert(context.Background(), "coll", "", 3, 1000, pks))
+		assert.NoError(t, w.Insert(context.Background(), "coll", "", 3, 100, pks))
+		assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+		assert.Equal(t, 2, target.inserted)
+	})
+
+	t.Run("set config", func(t *testing.T) {
+		w := NewWriter(&mockTarget{}, WriterConfig{RowsPerSecond: 10})
+		assert.Equal(t, defaultMaxBackoff, w.Config().MaxBackoff)
+
+		w.SetConfig(WriterConfig{BytesPerSecond: 1000, MaxBackoff: time.Second})
+		assert.Equal(t, WriterConfig{BytesPerSecond: 1000, MaxBackoff: time.Second}, w.Config())
+		// the rows limit is lifted
+		start := time.Now()
+		for i := 0; i < 5; i++ {
+			assert.NoError(t, w.Insert(context.Background(), "coll", "", 10, 1, pks))
+		}
+		assert.Less(t, time.Since(start), time.Second)
+
+		// the backoff is clamped by the new max backoff
+		w.backoff = time.Minute
+		w.SetConfig(WriterConfig{MaxBackoff: time.Second})
+		assert.Equal(t, time.Second, w.backoff)
+	})
+}
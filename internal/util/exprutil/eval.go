@@ -0,0 +1,720 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exprutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/xige-16/stream-read/internal/proto/planpb"
+	"github.com/xige-16/stream-read/pkg/util/funcutil"
+	"github.com/xige-16/stream-read/pkg/util/merr"
+)
+
+// Evaluate applies the predicate expr to the rows of fields, and returns whether each row matches.
+// The columns of expr are looked up in fields by field id.
+//
+// Values are compared the way the query nodes do: integers and floats are compared as numbers,
+// a JSON value is read by the nested path of its column, and an array element by its index.
+// A comparison on a missing JSON key or on values of different types never matches,
+// NotEqual is the negation of Equal.
+func Evaluate(expr *planpb.Expr, fields []*schemapb.FieldData) ([]bool, error) {
+	e, err := newEvaluator(fields)
+	if err != nil {
+		return nil, err
+	}
+	return e.eval(expr)
+}
+
+// Filter returns the indexes of the rows of fields matching expr.
+func Filter(expr *planpb.Expr, fields []*schemapb.FieldData) ([]int, error) {
+	mask, err := Evaluate(expr, fields)
+	if err != nil {
+		return nil, err
+	}
+	idxes := make([]int, 0, len(mask))
+	for i, matched := range mask {
+		if matched {
+			idxes = append(idxes, i)
+		}
+	}
+	return idxes, nil
+}
+
+type evaluator struct {
+	fields  map[int64]*schemapb.FieldData
+	numRows int
+	// the values of the fields read so far, the JSON rows are decoded once
+	columns map[int64][]any
+}
+
+func newEvaluator(fields []*schemapb.FieldData) (*evaluator, error) {
+	e := &evaluator{
+		fields:  make(map[int64]*schemapb.FieldData, len(fields)),
+		numRows: -1,
+		columns: make(map[int64][]any),
+	}
+	for _, field := range fields {
+		numRows, err := funcutil.GetNumRowOfFieldData(field)
+		if err != nil {
+			return nil, err
+		}
+		if e.numRows >= 0 && int(numRows) != e.numRows {
+			return nil, merr.WrapErrParameterInvalidMsg("field %d has %d rows, others have %d", field.GetFieldId(), numRows, e.numRows)
+		}
+		e.numRows = int(numRows)
+		e.fields[field.GetFieldId()] = field
+	}
+	if e.numRows < 0 {
+		e.numRows = 0
+	}
+	return e, nil
+}
+
+// eval evaluates a predicate to a row mask.
+func (e *evaluator) eval(expr *planpb.Expr) ([]bool, error) {
+	switch real := expr.GetExpr().(type) {
+	case *planpb.Expr_AlwaysTrueExpr:
+		return e.mask(func(int) bool { return true }), nil
+
+	case *planpb.Expr_UnaryExpr:
+		if real.UnaryExpr.GetOp() != planpb.UnaryExpr_Not {
+			return nil, merr.WrapErrParameterInvalidMsg("unsupported unary op %s", real.UnaryExpr.GetOp())
+		}
+		child, err := e.eval(real.UnaryExpr.GetChild())
+		if err != nil {
+			return nil, err
+		}
+		return e.mask(func(i int) bool { return !child[i] }), nil
+
+	case *planpb.Expr_BinaryExpr:
+		left, err := e.eval(real.BinaryExpr.GetLeft())
+		if err != nil {
+			return nil, err
+		}
+		right, err := e.eval(real.BinaryExpr.GetRight())
+		if err != nil {
+			return nil, err
+		}
+		switch real.BinaryExpr.GetOp() {
+		case planpb.BinaryExpr_LogicalAnd:
+			return e.mask(func(i int) bool { return left[i] && right[i] }), nil
+		case planpb.BinaryExpr_LogicalOr:
+			return e.mask(func(i int) bool { return left[i] || right[i] }), nil
+		default:
+			return nil, merr.WrapErrParameterInvalidMsg("unsupported binary op %s", real.BinaryExpr.GetOp())
+		}
+
+	case *planpb.Expr_UnaryRangeExpr:
+		return e.evalUnaryRange(real.UnaryRangeExpr)
+
+	case *planpb.Expr_BinaryRangeExpr:
+		return e.evalBinaryRange(real.BinaryRangeExpr)
+
+	case *planpb.Expr_TermExpr:
+		return e.evalTerm(real.TermExpr)
+
+	case *planpb.Expr_CompareExpr:
+		return e.evalCompare(real.CompareExpr)
+
+	case *planpb.Expr_BinaryArithOpEvalRangeExpr:
+		return e.evalArithRange(real.BinaryArithOpEvalRangeExpr)
+
+	case *planpb.Expr_ExistsExpr:
+		column, err := e.column(real.ExistsExpr.GetInfo())
+		if err != nil {
+			return nil, err
+		}
+		return e.mask(func(i int) bool { return column[i] != nil }), nil
+
+	case *planpb.Expr_JsonContainsExpr:
+		return e.evalContains(real.JsonContainsExpr)
+
+	case *planpb.Expr_ColumnExpr, *planpb.Expr_ValueExpr, *planpb.Expr_BinaryArithExpr:
+		values, err := e.values(expr)
+		if err != nil {
+			return nil, err
+		}
+		return e.mask(func(i int) bool {
+			matched, _ := values[i].(bool)
+			return matched
+		}), nil
+
+	default:
+		return nil, merr.WrapErrParameterInvalidMsg("unsupported expr %T", real)
+	}
+}
+
+// values evaluates a value expr to the value of each row.
+func (e *evaluator) values(expr *planpb.Expr) ([]any, error) {
+	switch real := expr.GetExpr().(type) {
+	case *planpb.Expr_ColumnExpr:
+		return e.column(real.ColumnExpr.GetInfo())
+
+	case *planpb.Expr_ValueExpr:
+		value := genericValue(real.ValueExpr.GetValue())
+		values := make([]any, e.numRows)
+		for i := range values {
+			values[i] = value
+		}
+		return values, nil
+
+	case *planpb.Expr_BinaryArithExpr:
+		left, err := e.values(real.BinaryArithExpr.GetLeft())
+		if err != nil {
+			return nil, err
+		}
+		right, err := e.values(real.BinaryArithExpr.GetRight())
+		if err != nil {
+			return nil, err
+		}
+		values := make([]any, e.numRows)
+		for i := range values {
+			values[i], _ = arith(real.BinaryArithExpr.GetOp(), left[i], right[i])
+		}
+		return values, nil
+
+	default:
+		mask, err := e.eval(expr)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]any, e.numRows)
+		for i := range values {
+			values[i] = mask[i]
+		}
+		return values, nil
+	}
+}
+
+func (e *evaluator) evalUnaryRange(expr *planpb.UnaryRangeExpr) ([]bool, error) {
+	column, err := e.column(expr.GetColumnInfo())
+	if err != nil {
+		return nil, err
+	}
+	value := literal(expr.GetValue(), expr.GetColumnInfo())
+	if expr.GetOp() == planpb.OpType_Match {
+		pattern, ok := value.(string)
+		if !ok {
+			return nil, merr.WrapErrParameterInvalidMsg("like pattern must be a string, got %v", expr.GetValue())
+		}
+		re, err := likeToRegexp(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return e.mask(func(i int) bool {
+			s, ok := column[i].(string)
+			return ok && re.MatchString(s)
+		}), nil
+	}
+	if !isComparisonOp(expr.GetOp()) {
+		return nil, merr.WrapErrParameterInvalidMsg("unsupported op %s of unary range expr", expr.GetOp())
+	}
+	return e.mask(func(i int) bool { return applyOp(expr.GetOp(), column[i], value) }), nil
+}
+
+func (e *evaluator) evalBinaryRange(expr *planpb.BinaryRangeExpr) ([]bool, error) {
+	column, err := e.column(expr.GetColumnInfo())
+	if err != nil {
+		return nil, err
+	}
+	lower := literal(expr.GetLowerValue(), expr.GetColumnInfo())
+	upper := literal(expr.GetUpperValue(), expr.GetColumnInfo())
+	lowerOp, upperOp := planpb.OpType_GreaterThan, planpb.OpType_LessThan
+	if expr.GetLowerInclusive() {
+		lowerOp = planpb.OpType_GreaterEqual
+	}
+	if expr.GetUpperInclusive() {
+		upperOp = planpb.OpType_LessEqual
+	}
+	return e.mask(func(i int) bool {
+		return applyOp(lowerOp, column[i], lower) && applyOp(upperOp, column[i], upper)
+	}), nil
+}
+
+func (e *evaluator) evalTerm(expr *planpb.TermExpr) ([]bool, error) {
+	column, err := e.column(expr.GetColumnInfo())
+	if err != nil {
+		return nil, err
+	}
+	terms := make([]any, 0, len(expr.GetValues()))
+	for _, value := range expr.GetValues() {
+		terms = append(terms, literal(value, expr.GetColumnInfo()))
+	}
+	return e.mask(func(i int) bool {
+		// is_in_field means the value of the column is an array holding any of the terms
+		if expr.GetIsInField() {
+			elements, ok := column[i].([]any)
+			return ok && containsAny(elements, terms)
+		}
+		return containsAny(terms, []any{column[i]})
+	}), nil
+}
+
+func (e *evaluator) evalCompare(expr *planpb.CompareExpr) ([]bool, error) {
+	if !isComparisonOp(expr.GetOp()) {
+		return nil, merr.WrapErrParameterInvalidMsg("unsupported op %s of compare expr", expr.GetOp())
+	}
+	left, err := e.column(expr.GetLeftColumnInfo())
+	if err != nil {
+		return nil, err
+	}
+	right, err := e.column(expr.GetRightColumnInfo())
+	if err != nil {
+		return nil, err
+	}
+	return e.mask(func(i int) bool { return applyOp(expr.GetOp(), left[i], right[i]) }), nil
+}
+
+func (e *evaluator) evalArithRange(expr *planpb.BinaryArithOpEvalRangeExpr) ([]bool, error) {
+	if !isComparisonOp(expr.GetOp()) {
+		return nil, merr.WrapErrParameterInvalidMsg("unsupported op %s of arith range expr", expr.GetOp())
+	}
+	column, err := e.column(expr.GetColumnInfo())
+	if err != nil {
+		return nil, err
+	}
+	operand := genericValue(expr.GetRightOperand())
+	value := genericValue(expr.GetValue())
+	return e.mask(func(i int) bool {
+		result, ok := arith(expr.GetArithOp(), column[i], operand)
+		return ok && applyOp(expr.GetOp(), result, value)
+	}), nil
+}
+
+func (e *evaluator) evalContains(expr *planpb.JSONContainsExpr) ([]bool, error) {
+	column, err := e.column(expr.GetColumnInfo())
+	if err != nil {
+		return nil, err
+	}
+	elements := make([]any, 0, len(expr.GetElements()))
+	for _, element := range expr.GetElements() {
+		elements = append(elements, genericValue(element))
+	}
+
+	var contains func(array []any) bool
+	switch expr.GetOp() {
+	case planpb.JSONContainsExpr_Contains, planpb.JSONContainsExpr_ContainsAny:
+		contains = func(array []any) bool { return containsAny(array, elements) }
+	case planpb.JSONContainsExpr_ContainsAll:
+		contains = func(array []any) bool {
+			for _, element := range elements {
+				if !containsAny(array, []any{element}) {
+					return false
+				}
+			}
+			return true
+		}
+	default:
+		return nil, merr.WrapErrParameterInvalidMsg("unsupported op %s of json contains expr", expr.GetOp())
+	}
+	return e.mask(func(i int) bool {
+		array, ok := column[i].([]any)
+		return ok && contains(array)
+	}), nil
+}
+
+func (e *evaluator) mask(fn func(i int) bool) []bool {
+	mask := make([]bool, e.numRows)
+	for i := range mask {
+		mask[i] = fn(i)
+	}
+	return mask
+}
+
+// column returns the value of each row read by info, nil if the nested path does not exist in the row.
+func (e *evaluator) column(info *planpb.ColumnInfo) ([]any, error) {
+	if info == nil {
+		return nil, merr.WrapErrParameterInvalidMsg("column info is missing")
+	}
+	values, ok := e.columns[info.GetFieldId()]
+	if !ok {
+		field, ok := e.fields[info.GetFieldId()]
+		if !ok {
+			return nil, merr.WrapErrFieldNotFound(info.GetFieldId())
+		}
+		var err error
+		values, err = scalarValues(field.GetScalars(), e.numRows)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read field %d", info.GetFieldId())
+		}
+		e.columns[info.GetFieldId()] = values
+	}
+	if len(info.GetNestedPath()) == 0 {
+		return values, nil
+	}
+
+	nested := make([]any, len(values))
+	for i, value := range values {
+		nested[i] = lookup(value, info.GetNestedPath())
+	}
+	return nested, nil
+}
+
+// scalarValues returns the values of sf as bool, int64, float64, string, []any
+// or the decoded JSON values.
+func scalarValues(sf *schemapb.ScalarField, numRows int) ([]any, error) {
+	var values []any
+	switch data := sf.GetData().(type) {
+	case *schemapb.ScalarField_BoolData:
+		values = toValues(data.BoolData.GetData(), func(v bool) any { return v })
+	case *schemapb.ScalarField_IntData:
+		values = toValues(data.IntData.GetData(), func(v int32) any { return int64(v) })
+	case *schemapb.ScalarField_LongData:
+		values = toValues(data.LongData.GetData(), func(v int64) any { return v })
+	case *schemapb.ScalarField_FloatData:
+		values = toValues(data.FloatData.GetData(), func(v float32) any { return float64(v) })
+	case *schemapb.ScalarField_DoubleData:
+		values = toValues(data.DoubleData.GetData(), func(v float64) any { return v })
+	case *schemapb.ScalarField_StringData:
+		values = toValues(data.StringData.GetData(), func(v string) any { return v })
+	case *schemapb.ScalarField_ArrayData:
+		values = make([]any, 0, len(data.ArrayData.GetData()))
+		for _, array := range data.ArrayData.GetData() {
+			elements, err := scalarValues(array, -1)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, elements)
+		}
+	case *schemapb.ScalarField_JsonData:
+		values = make([]any, 0, len(data.JsonData.GetData()))
+		for _, row := range data.JsonData.GetData() {
+			value, err := decodeJSON(row)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+	case nil:
+		// an empty array, or a vector field which can not be filtered on
+		if numRows <= 0 {
+			return []any{}, nil
+		}
+		return nil, merr.WrapErrParameterInvalidMsg("not a scalar field")
+	default:
+		return nil, merr.WrapErrParameterInvalidMsg("unsupported scalar field %T", data)
+	}
+	return values, nil
+}
+
+func toValues[T any](data []T, convert func(T) any) []any {
+	values := make([]any, len(data))
+	for i, v := range data {
+		values[i] = convert(v)
+	}
+	return values
+}
+
+// decodeJSON decodes a JSON row, the integral numbers are decoded as int64, the others as float64.
+func decodeJSON(data []byte) (any, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, errors.Wrapf(err, "invalid json %s", data)
+	}
+	return normalizeJSON(value), nil
+}
+
+func normalizeJSON(value any) any {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []any:
+		for i := range v {
+			v[i] = normalizeJSON(v[i])
+		}
+	case map[string]any:
+		for key := range v {
+			v[key] = normalizeJSON(v[key])
+		}
+	}
+	return value
+}
+
+// lookup reads value by path, the keys of JSON objects and the indexes of arrays.
+func lookup(value any, path []string) any {
+	for _, key := range path {
+		switch v := value.(type) {
+		case map[string]any:
+			value = v[key]
+		case []any:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(v) {
+				return nil
+			}
+			value = v[idx]
+		default:
+			return nil
+		}
+	}
+	return value
+}
+
+func genericValue(value *planpb.GenericValue) any {
+	switch v := value.GetVal().(type) {
+	case *planpb.GenericValue_BoolVal:
+		return v.BoolVal
+	case *planpb.GenericValue_Int64Val:
+		return v.Int64Val
+	case *planpb.GenericValue_FloatVal:
+		return v.FloatVal
+	case *planpb.GenericValue_StringVal:
+		return v.StringVal
+	case *planpb.GenericValue_ArrayVal:
+		return toValues(v.ArrayVal.GetArray(), genericValue)
+	default:
+		return nil
+	}
+}
+
+// literal returns the value compared with the column of info, a float compared with a float
+// column is rounded to float32 as the column is.
+func literal(value *planpb.GenericValue, info *planpb.ColumnInfo) any {
+	v := genericValue(value)
+	if f, ok := v.(float64); ok && len(info.GetNestedPath()) == 0 &&
+		(info.GetDataType() == schemapb.DataType_Float ||
+			info.GetDataType() == schemapb.DataType_Array && info.GetElementType() == schemapb.DataType_Float) {
+		return float64(float32(f))
+	}
+	return v
+}
+
+func isComparisonOp(op planpb.OpType) bool {
+	switch op {
+	case planpb.OpType_GreaterThan, planpb.OpType_GreaterEqual, planpb.OpType_LessThan, planpb.OpType_LessEqual,
+		planpb.OpType_Equal, planpb.OpType_NotEqual, planpb.OpType_PrefixMatch, planpb.OpType_PostfixMatch:
+		return true
+	default:
+		return false
+	}
+}
+
+func applyOp(op planpb.OpType, left, right any) bool {
+	switch op {
+	case planpb.OpType_Equal:
+		return equal(left, right)
+	case planpb.OpType_NotEqual:
+		return !equal(left, right)
+	case planpb.OpType_PrefixMatch, planpb.OpType_PostfixMatch:
+		s, ok1 := left.(string)
+		affix, ok2 := right.(string)
+		if !ok1 || !ok2 {
+			return false
+		}
+		if op == planpb.OpType_PrefixMatch {
+			return strings.HasPrefix(s, affix)
+		}
+		return strings.HasSuffix(s, affix)
+	}
+
+	c, ok := compare(left, right)
+	if !ok {
+		return false
+	}
+	switch op {
+	case planpb.OpType_GreaterThan:
+		return c > 0
+	case planpb.OpType_GreaterEqual:
+		return c >= 0
+	case planpb.OpType_LessThan:
+		return c < 0
+	case planpb.OpType_LessEqual:
+		return c <= 0
+	default:
+		return false
+	}
+}
+
+// compare orders two numbers, strings or bools, returns false if they are not comparable.
+func compare(left, right any) (int, bool) {
+	switch l := left.(type) {
+	case int64:
+		switch r := right.(type) {
+		case int64:
+			return compareOrdered(l, r), true
+		case float64:
+			return compareOrdered(float64(l), r), true
+		}
+	case float64:
+		switch r := right.(type) {
+		case int64:
+			return compareOrdered(l, float64(r)), true
+		case float64:
+			return compareOrdered(l, r), true
+		}
+	case string:
+		if r, ok := right.(string); ok {
+			return strings.Compare(l, r), true
+		}
+	case bool:
+		if r, ok := right.(bool); ok {
+			if l == r {
+				return 0, true
+			}
+			if r {
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	return 0, false
+}
+
+func compareOrdered[T int64 | float64](l, r T) int {
+	switch {
+	case l < r:
+		return -1
+	case l > r:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func equal(left, right any) bool {
+	if l, ok := left.([]any); ok {
+		r, ok := right.([]any)
+		if !ok || len(l) != len(r) {
+			return false
+		}
+		for i := range l {
+			if !equal(l[i], r[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	c, ok := compare(left, right)
+	return ok && c == 0
+}
+
+// containsAny returns whether any of values is an element of array.
+func containsAny(array []any, values []any) bool {
+	for _, element := range array {
+		for _, value := range values {
+			if equal(element, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// arith applies op to left and right, returns false if they are not numbers or op fails.
+func arith(op planpb.ArithOpType, left, right any) (any, bool) {
+	if op == planpb.ArithOpType_ArrayLength {
+		array, ok := left.([]any)
+		return int64(len(array)), ok
+	}
+
+	l, lok := left.(int64)
+	r, rok := right.(int64)
+	if lok && rok {
+		switch op {
+		case planpb.ArithOpType_Add:
+			return l + r, true
+		case planpb.ArithOpType_Sub:
+			return l - r, true
+		case planpb.ArithOpType_Mul:
+			return l * r, true
+		case planpb.ArithOpType_Div:
+			return l / r, r != 0
+		case planpb.ArithOpType_Mod:
+			if r == 0 {
+				return nil, false
+			}
+			return l % r, true
+		default:
+			return nil, false
+		}
+	}
+
+	lf, lok := toFloat(left)
+	rf, rok := toFloat(right)
+	if !lok || !rok {
+		return nil, false
+	}
+	switch op {
+	case planpb.ArithOpType_Add:
+		return lf + rf, true
+	case planpb.ArithOpType_Sub:
+		return lf - rf, true
+	case planpb.ArithOpType_Mul:
+		return lf * rf, true
+	case planpb.ArithOpType_Div:
+		return lf / rf, rf != 0
+	case planpb.ArithOpType_Mod:
+		return math.Mod(lf, rf), rf != 0
+	default:
+		return nil, false
+	}
+}
+
+func toFloat(value any) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// likeToRegexp translates a like pattern to a regexp, % matches any characters,
+// _ matches a single character and \ escapes them.
+func likeToRegexp(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	escaped := false
+	for _, c := range pattern {
+		switch {
+		case escaped:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '%':
+			b.WriteString("(?s:.*)")
+		case c == '_':
+			b.WriteString("(?s:.)")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if escaped {
+		return nil, merr.WrapErrParameterInvalidMsg("like pattern %q ends with an escape", pattern)
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,402 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exprutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/xige-16/stream-read/internal/proto/planpb"
+	"github.com/xige-16/stream-read/pkg/util/merr"
+)
+
+const (
+	pkField int64 = iota + 100
+	floatField
+	nameField
+	arrayField
+	jsonField
+	vectorField
+)
+
+func testFields() []*schemapb.FieldData {
+	return []*schemapb.FieldData{
+		{
+			Type:    schemapb.DataType_Int64,
+			FieldId: pkField,
+			Field: &schemapb.FieldData_Scalars{Scalars: &schemapb.ScalarField{
+				Data: &schemapb.ScalarField_LongData{LongData: &schemapb.LongArray{Data: []int64{1, 2, 3, 4}}},
+			}},
+		},
+		{
+			Type:    schemapb.DataType_Float,
+			FieldId: floatField,
+			Field: &schemapb.FieldData_Scalars{Scalars: &schemapb.ScalarField{
+				Data: &schemapb.ScalarField_FloatData{FloatData: &schemapb.FloatArray{Data: []float32{0.1, 2.5, 3, 4.5}}},
+			}},
+		},
+		{
+			Type:    schemapb.DataType_VarChar,
+			FieldId: nameField,
+			Field: &schemapb.FieldData_Scalars{Scalars: &schemapb.ScalarField{
+				Data: &schemapb.ScalarField_StringData{StringData: &schemapb.StringArray{Data: []string{"apple", "banana", "cherry", "a%b"}}},
+			}},
+		},
+		{
+			Type:    schemapb.DataType_Array,
+			FieldId: arrayField,
+			Field: &schemapb.FieldData_Scalars{Scalars: &schemapb.ScalarField{
+				Data: &schemapb.ScalarField_ArrayData{ArrayData: &schemapb.ArrayArray{
+					ElementType: schemapb.DataType_Int64,
+					Data: []*schemapb.ScalarField{
+						{Data: &schemapb.ScalarField_LongData{LongData: &schemapb.LongArray{Data: []int64{1, 2}}}},
+						{Data: &schemapb.ScalarField_LongData{LongData: &schemapb.LongArray{Data: []int64{3}}}},
+						{},
+						{Data: &schemapb.ScalarField_LongData{LongData: &schemapb.LongArray{Data: []int64{2, 4, 6}}}},
+					},
+				}},
+			}},
+		},
+		{
+			Type:    schemapb.DataType_JSON,
+			FieldId: jsonField,
+			Field: &schemapb.FieldData_Scalars{Scalars: &schemapb.ScalarField{
+				Data: &schemapb.ScalarField_JsonData{JsonData: &schemapb.JSONArray{Data: [][]byte{
+					[]byte(`{"a": 1, "b": {"c": "x"}, "tags": ["red", "blue"]}`),
+					[]byte(`{"a": 2.5, "b": {"c": "y"}, "tags": ["green"]}`),
+					[]byte(`{"b": 1}`),
+					[]byte(`{"a": "1", "tags": [[1, 2], 3]}`),
+				}}},
+			}},
+		},
+		{
+			Type:    schemapb.DataType_FloatVector,
+			FieldId: vectorField,
+			Field: &schemapb.FieldData_Vectors{Vectors: &schemapb.VectorField{
+				Dim:  1,
+				Data: &schemapb.VectorField_FloatVector{FloatVector: &schemapb.FloatArray{Data: []float32{1, 2, 3, 4}}},
+			}},
+		},
+	}
+}
+
+func column(fieldID int64, dataType schemapb.DataType, path ...string) *planpb.ColumnInfo {
+	return &planpb.ColumnInfo{FieldId: fieldID, DataType: dataType, NestedPath: path}
+}
+
+func int64Val(v int64) *planpb.GenericValue {
+	return &planpb.GenericValue{Val: &planpb.GenericValue_Int64Val{Int64Val: v}}
+}
+
+func floatVal(v float64) *planpb.GenericValue {
+	return &planpb.GenericValue{Val: &planpb.GenericValue_FloatVal{FloatVal: v}}
+}
+
+func stringVal(v string) *planpb.GenericValue {
+	return &planpb.GenericValue{Val: &planpb.GenericValue_StringVal{StringVal: v}}
+}
+
+func arrayVal(values ...*planpb.GenericValue) *planpb.GenericValue {
+	return &planpb.GenericValue{Val: &planpb.GenericValue_ArrayVal{ArrayVal: &planpb.Array{Array: values}}}
+}
+
+func unaryRange(info *planpb.ColumnInfo, op planpb.OpType, value *planpb.GenericValue) *planpb.Expr {
+	return &planpb.Expr{Expr: &planpb.Expr_UnaryRangeExpr{UnaryRangeExpr: &planpb.UnaryRangeExpr{
+		ColumnInfo: info,
+		Op:         op,
+		Value:      value,
+	}}}
+}
+
+func TestEvaluate(t *testing.T) {
+	pk := column(pkField, schemapb.DataType_Int64)
+	name := column(nameField, schemapb.DataType_VarChar)
+	array := &planpb.ColumnInfo{FieldId: arrayField, DataType: schemapb.DataType_Array, ElementType: schemapb.DataType_Int64}
+
+	cases := []struct {
+		name     string
+		expr     *planpb.Expr
+		expected []bool
+	}{
+		{
+			name:     "always true",
+			expr:     &planpb.Expr{Expr: &planpb.Expr_AlwaysTrueExpr{AlwaysTrueExpr: &planpb.AlwaysTrueExpr{}}},
+			expected: []bool{true, true, true, true},
+		},
+		{
+			name:     "int greater than",
+			expr:     unaryRange(pk, planpb.OpType_GreaterThan, int64Val(2)),
+			expected: []bool{false, false, true, true},
+		},
+		{
+			name:     "int compared with float",
+			expr:     unaryRange(pk, planpb.OpType_LessEqual, floatVal(2.5)),
+			expected: []bool{true, true, false, false},
+		},
+		{
+			name:     "float equal is rounded to float32",
+			expr:     unaryRange(column(floatField, schemapb.DataType_Float), planpb.OpType_Equal, floatVal(0.1)),
+			expected: []bool{true, false, false, false},
+		},
+		{
+			name:     "string not equal",
+			expr:     unaryRange(name, planpb.OpType_NotEqual, stringVal("banana")),
+			expected: []bool{true, false, true, true},
+		},
+		{
+			name:     "prefix match",
+			expr:     unaryRange(name, planpb.OpType_PrefixMatch, stringVal("ch")),
+			expected: []bool{false, false, true, false},
+		},
+		{
+			name:     "postfix match",
+			expr:     unaryRange(name, planpb.OpType_PostfixMatch, stringVal("na")),
+			expected: []bool{false, true, false, false},
+		},
+		{
+			name:     "like",
+			expr:     unaryRange(name, planpb.OpType_Match, stringVal("_a%a")),
+			expected: []bool{false, true, false, false},
+		},
+		{
+			name:     "like escaped",
+			expr:     unaryRange(name, planpb.OpType_Match, stringVal(`a\%%`)),
+			expected: []bool{false, false, false, true},
+		},
+		{
+			name: "binary range",
+			expr: &planpb.Expr{Expr: &planpb.Expr_BinaryRangeExpr{BinaryRangeExpr: &planpb.BinaryRangeExpr{
+				ColumnInfo:     pk,
+				LowerInclusive: true,
+				LowerValue:     int64Val(2),
+				UpperValue:     int64Val(4),
+			}}},
+			expected: []bool{false, true, true, false},
+		},
+		{
+			name: "term",
+			expr: &planpb.Expr{Expr: &planpb.Expr_TermExpr{TermExpr: &planpb.TermExpr{
+				ColumnInfo: pk,
+				Values:     []*planpb.GenericValue{int64Val(1), int64Val(4), int64Val(5)},
+			}}},
+			expected: []bool{true, false, false, true},
+		},
+		{
+			name: "term in field",
+			expr: &planpb.Expr{Expr: &planpb.Expr_TermExpr{TermExpr: &planpb.TermExpr{
+				ColumnInfo: array,
+				Values:     []*planpb.GenericValue{int64Val(2)},
+				IsInField:  true,
+			}}},
+			expected: []bool{true, false, false, true},
+		},
+		{
+			name: "not and or",
+			expr: &planpb.Expr{Expr: &planpb.Expr_BinaryExpr{BinaryExpr: &planpb.BinaryExpr{
+				Op: planpb.BinaryExpr_LogicalOr,
+				Left: &planpb.Expr{Expr: &planpb.Expr_UnaryExpr{UnaryExpr: &planpb.UnaryExpr{
+					Op:    planpb.UnaryExpr_Not,
+					Child: unaryRange(pk, planpb.OpType_GreaterThan, int64Val(1)),
+				}}},
+				Right: &planpb.Expr{Expr: &planpb.Expr_BinaryExpr{BinaryExpr: &planpb.BinaryExpr{
+					Op:    planpb.BinaryExpr_LogicalAnd,
+					Left:  unaryRange(pk, planpb.OpType_GreaterThan, int64Val(2)),
+					Right: unaryRange(name, planpb.OpType_Equal, stringVal("cherry")),
+				}}},
+			}}},
+			expected: []bool{true, false, true, false},
+		},
+		{
+			name: "compare columns",
+			expr: &planpb.Expr{Expr: &planpb.Expr_CompareExpr{CompareExpr: &planpb.CompareExpr{
+				LeftColumnInfo:  column(floatField, schemapb.DataType_Float),
+				RightColumnInfo: pk,
+				Op:              planpb.OpType_GreaterEqual,
+			}}},
+			expected: []bool{false, true, true, true},
+		},
+		{
+			name: "arith",
+			expr: &planpb.Expr{Expr: &planpb.Expr_BinaryArithOpEvalRangeExpr{BinaryArithOpEvalRangeExpr: &planpb.BinaryArithOpEvalRangeExpr{
+				ColumnInfo:   pk,
+				ArithOp:      planpb.ArithOpType_Mod,
+				RightOperand: int64Val(2),
+				Op:           planpb.OpType_Equal,
+				Value:        int64Val(0),
+			}}},
+			expected: []bool{false, true, false, true},
+		},
+		{
+			name: "mod by zero",
+			expr: &planpb.Expr{Expr: &planpb.Expr_BinaryArithOpEvalRangeExpr{BinaryArithOpEvalRangeExpr: &planpb.BinaryArithOpEvalRangeExpr{
+				ColumnInfo:   pk,
+				ArithOp:      planpb.ArithOpType_Mod,
+				RightOperand: int64Val(0),
+				Op:           planpb.OpType_NotEqual,
+				Value:        int64Val(0),
+			}}},
+			expected: []bool{false, false, false, false},
+		},
+		{
+			name: "array length",
+			expr: &planpb.Expr{Expr: &planpb.Expr_BinaryArithOpEvalRangeExpr{BinaryArithOpEvalRangeExpr: &planpb.BinaryArithOpEvalRangeExpr{
+				ColumnInfo: array,
+				ArithOp:    planpb.ArithOpType_ArrayLength,
+				Op:         planpb.OpType_GreaterEqual,
+				Value:      int64Val(2),
+			}}},
+			expected: []bool{true, false, false, true},
+		},
+		{
+			name: "array element",
+			expr: unaryRange(&planpb.ColumnInfo{
+				FieldId:     arrayField,
+				DataType:    schemapb.DataType_Array,
+				ElementType: schemapb.DataType_Int64,
+				NestedPath:  []string{"1"},
+			}, planpb.OpType_Equal, int64Val(2)),
+			expected: []bool{true, false, false, false},
+		},
+		{
+			name:     "array equal",
+			expr:     unaryRange(array, planpb.OpType_Equal, arrayVal(int64Val(1), int64Val(2))),
+			expected: []bool{true, false, false, false},
+		},
+		{
+			name:     "json number",
+			expr:     unaryRange(column(jsonField, schemapb.DataType_JSON, "a"), planpb.OpType_GreaterEqual, int64Val(1)),
+			expected: []bool{true, true, false, false},
+		},
+		{
+			name:     "json missing key not equal",
+			expr:     unaryRange(column(jsonField, schemapb.DataType_JSON, "a"), planpb.OpType_NotEqual, int64Val(1)),
+			expected: []bool{false, true, true, true},
+		},
+		{
+			name:     "json nested path",
+			expr:     unaryRange(column(jsonField, schemapb.DataType_JSON, "b", "c"), planpb.OpType_Equal, stringVal("y")),
+			expected: []bool{false, true, false, false},
+		},
+		{
+			name:     "json array index",
+			expr:     unaryRange(column(jsonField, schemapb.DataType_JSON, "tags", "0"), planpb.OpType_Equal, stringVal("green")),
+			expected: []bool{false, true, false, false},
+		},
+		{
+			name: "exists",
+			expr: &planpb.Expr{Expr: &planpb.Expr_ExistsExpr{ExistsExpr: &planpb.ExistsExpr{
+				Info: column(jsonField, schemapb.DataType_JSON, "b", "c"),
+			}}},
+			expected: []bool{true, true, false, false},
+		},
+		{
+			name: "json contains",
+			expr: &planpb.Expr{Expr: &planpb.Expr_JsonContainsExpr{JsonContainsExpr: &planpb.JSONContainsExpr{
+				ColumnInfo: column(jsonField, schemapb.DataType_JSON, "tags"),
+				Elements:   []*planpb.GenericValue{stringVal("blue")},
+				Op:         planpb.JSONContainsExpr_Contains,
+			}}},
+			expected: []bool{true, false, false, false},
+		},
+		{
+			name: "json contains array",
+			expr: &planpb.Expr{Expr: &planpb.Expr_JsonContainsExpr{JsonContainsExpr: &planpb.JSONContainsExpr{
+				ColumnInfo: column(jsonField, schemapb.DataType_JSON, "tags"),
+				Elements:   []*planpb.GenericValue{arrayVal(int64Val(1), int64Val(2))},
+				Op:         planpb.JSONContainsExpr_Contains,
+			}}},
+			expected: []bool{false, false, false, true},
+		},
+		{
+			name: "array contains all",
+			expr: &planpb.Expr{Expr: &planpb.Expr_JsonContainsExpr{JsonContainsExpr: &planpb.JSONContainsExpr{
+				ColumnInfo: array,
+				Elements:   []*planpb.GenericValue{int64Val(2), int64Val(4)},
+				Op:         planpb.JSONContainsExpr_ContainsAll,
+			}}},
+			expected: []bool{false, false, false, true},
+		},
+		{
+			name: "array contains any",
+			expr: &planpb.Expr{Expr: &planpb.Expr_JsonContainsExpr{JsonContainsExpr: &planpb.JSONContainsExpr{
+				ColumnInfo: array,
+				Elements:   []*planpb.GenericValue{int64Val(3), int64Val(4)},
+				Op:         planpb.JSONContainsExpr_ContainsAny,
+			}}},
+			expected: []bool{false, true, false, true},
+		},
+		{
+			name:     "value expr",
+			expr:     &planpb.Expr{Expr: &planpb.Expr_ValueExpr{ValueExpr: &planpb.ValueExpr{Value: &planpb.GenericValue{Val: &planpb.GenericValue_BoolVal{BoolVal: true}}}}},
+			expected: []bool{true, true, true, true},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			mask, err := Evaluate(c.expr, testFields())
+			assert.NoError(t, err)
+			assert.Equal(t, c.expected, mask)
+		})
+	}
+
+	t.Run("filter", func(t *testing.T) {
+		idxes, err := Filter(unaryRange(pk, planpb.OpType_GreaterThan, int64Val(2)), testFields())
+		assert.NoError(t, err)
+		assert.Equal(t, []int{2, 3}, idxes)
+	})
+}
+
+func TestEvaluate_Error(t *testing.T) {
+	t.Run("field not found", func(t *testing.T) {
+		_, err := Evaluate(unaryRange(column(999, schemapb.DataType_Int64), planpb.OpType_Equal, int64Val(1)), testFields())
+		assert.ErrorIs(t, err, merr.ErrFieldNotFound)
+	})
+
+	t.Run("vector field", func(t *testing.T) {
+		_, err := Evaluate(unaryRange(column(vectorField, schemapb.DataType_FloatVector), planpb.OpType_Equal, int64Val(1)), testFields())
+		assert.ErrorIs(t, err, merr.ErrParameterInvalid)
+	})
+
+	t.Run("row count mismatch", func(t *testing.T) {
+		fields := testFields()
+		fields[0].GetScalars().GetLongData().Data = []int64{1}
+		_, err := Evaluate(&planpb.Expr{Expr: &planpb.Expr_AlwaysTrueExpr{AlwaysTrueExpr: &planpb.AlwaysTrueExpr{}}}, fields)
+		assert.ErrorIs(t, err, merr.ErrParameterInvalid)
+	})
+
+	t.Run("unsupported op", func(t *testing.T) {
+		_, err := Evaluate(unaryRange(column(pkField, schemapb.DataType_Int64), planpb.OpType_In, int64Val(1)), testFields())
+		assert.ErrorIs(t, err, merr.ErrParameterInvalid)
+	})
+
+	t.Run("invalid like", func(t *testing.T) {
+		_, err := Evaluate(unaryRange(column(nameField, schemapb.DataType_VarChar), planpb.OpType_Match, stringVal(`a\`)), testFields())
+		assert.ErrorIs(t, err, merr.ErrParameterInvalid)
+	})
+
+	t.Run("invalid json", func(t *testing.T) {
+		fields := testFields()
+		fields[4].GetScalars().GetJsonData().Data[0] = []byte("{")
+		_, err := Evaluate(&planpb.Expr{Expr: &planpb.Expr_ExistsExpr{ExistsExpr: &planpb.ExistsExpr{
+			Info: column(jsonField, schemapb.DataType_JSON, "a"),
+		}}}, fields)
+		assert.Error(t, err)
+	})
+}
@@ -40,6 +40,9 @@ func Init(opts ...Option) (*Manager, error) {
 		s := NewFileSource(o.FileInfo)
 		sourceManager.AddSource(s)
 	}
+	for _, di := range o.DirInfos {
+		sourceManager.AddSource(NewDirSource(di))
+	}
 	if o.EnvKeyFormatter != nil {
 		sourceManager.AddSource(NewEnvSource(o.EnvKeyFormatter))
 	}
@@ -55,7 +58,8 @@ func Init(opts ...Option) (*Manager, error) {
 
 var formattedKeys = typeutil.NewConcurrentMap[string, string]()
 
-func formatKey(key string) string {
+// FormatKey returns the key the sources store key by, which ignores the case, dots, slashes and underscores.
+func FormatKey(key string) string {
 	cached, ok := formattedKeys.Get(key)
 	if ok {
 		return cached
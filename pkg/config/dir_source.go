@@ -0,0 +1,154 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+	"golang.org/x/exp/maps"
+)
+
+// DirSource reads configs from a directory holding one file per key, the file name is the key
+// and the file content is the value, as kubernetes mounts ConfigMaps and Secrets.
+type DirSource struct {
+	sync.RWMutex
+	dir      string
+	priority int
+	secret   bool
+	configs  map[string]string
+
+	configRefresher *refresher
+}
+
+func NewDirSource(dirInfo *DirInfo) *DirSource {
+	ds := &DirSource{
+		dir:      dirInfo.Dir,
+		priority: dirInfo.Priority,
+		secret:   dirInfo.Secret,
+		configs:  make(map[string]string),
+	}
+	if ds.priority == 0 {
+		ds.priority = DirPriority
+	}
+	ds.configRefresher = newRefresher(dirInfo.RefreshInterval, ds.loadFromDir)
+	return ds
+}
+
+// GetConfigurationByKey implements ConfigSource
+func (ds *DirSource) GetConfigurationByKey(key string) (string, error) {
+	ds.RLock()
+	v, ok := ds.configs[key]
+	ds.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("key not found: %s", key)
+	}
+	return v, nil
+}
+
+// GetConfigurations implements ConfigSource
+func (ds *DirSource) GetConfigurations() (map[string]string, error) {
+	configMap := make(map[string]string)
+
+	err := ds.loadFromDir()
+	if err != nil {
+		return nil, err
+	}
+
+	ds.configRefresher.start(ds.GetSourceName())
+
+	ds.RLock()
+	maps.Copy(configMap, ds.configs)
+	ds.RUnlock()
+	return configMap, nil
+}
+
+// GetPriority implements ConfigSource
+func (ds *DirSource) GetPriority() int {
+	return ds.priority
+}
+
+// GetSourceName implements ConfigSource, the name is unique per directory
+func (ds *DirSource) GetSourceName() string {
+	return "DirSource:" + ds.dir
+}
+
+// IsSecret implements secretSource, all the keys of a secret directory are secret.
+func (ds *DirSource) IsSecret(key string) bool {
+	return ds.secret
+}
+
+func (ds *DirSource) Close() {
+	ds.configRefresher.stop()
+}
+
+func (ds *DirSource) SetEventHandler(eh EventHandler) {
+	ds.Lock()
+	defer ds.Unlock()
+	ds.configRefresher.eh = eh
+}
+
+func (ds *DirSource) UpdateOptions(opts Options) {
+}
+
+func (ds *DirSource) loadFromDir() error {
+	newConfig := make(map[string]string)
+
+	entries, err := os.ReadDir(ds.dir)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "Read config dir failed: "+ds.dir)
+	}
+	for _, entry := range entries {
+		// skip the hidden entries, like the ..data symlink kubernetes updates atomically
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(ds.dir, entry.Name())
+		// the keys are usually symlinks, stat follows them
+		info, err := os.Stat(path)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return errors.Wrap(err, "Read config failed: "+path)
+		}
+		value := strings.TrimRight(string(content), "\r\n")
+		// lower cased as the keys of yaml files
+		newConfig[strings.ToLower(entry.Name())] = value
+		newConfig[FormatKey(entry.Name())] = value
+	}
+
+	ds.RLock()
+	source := make(map[string]string)
+	maps.Copy(source, ds.configs)
+	ds.RUnlock()
+
+	err = ds.configRefresher.fireEvents(ds.GetSourceName(), source, newConfig)
+	if err != nil {
+		return err
+	}
+
+	ds.Lock()
+	defer ds.Unlock()
+	ds.configs = newConfig
+	return nil
+}
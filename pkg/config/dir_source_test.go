@@ -0,0 +1,125 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/exp/maps"
+)
+
+// mountDir writes configs the way kubernetes mounts a ConfigMap,
+// the keys are symlinks to the files of a hidden data dir.
+func mountDir(t *testing.T, dir string, configs map[string]string) {
+	data := path.Join(dir, "..data")
+	os.RemoveAll(data)
+	assert.NoError(t, os.Mkdir(data, 0o700))
+	for key, value := range configs {
+		assert.NoError(t, os.WriteFile(path.Join(data, key), []byte(value), 0o600))
+		os.Remove(path.Join(dir, key))
+		assert.NoError(t, os.Symlink(path.Join(data, key), path.Join(dir, key)))
+	}
+}
+
+func TestLoadFromDirSource(t *testing.T) {
+	t.Run("dir not exist", func(t *testing.T) {
+		ds := NewDirSource(&DirInfo{Dir: "dir_not_exist"})
+		assert.NoError(t, ds.loadFromDir())
+		assert.Zero(t, len(ds.configs))
+		assert.Equal(t, DirPriority, ds.GetPriority())
+	})
+
+	t.Run("mounted", func(t *testing.T) {
+		dir := t.TempDir()
+		mountDir(t, dir, map[string]string{"a.b": "1\n", "c_d": "2"})
+		assert.NoError(t, os.Mkdir(path.Join(dir, "sub"), 0o700))
+
+		ds := NewDirSource(&DirInfo{Dir: dir, Priority: HighPriority})
+		ret, err := ds.GetConfigurations()
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, maps.Keys(ret), []string{"a.b", "ab", "c_d", "cd"})
+		v, err := ds.GetConfigurationByKey("a.b")
+		assert.NoError(t, err)
+		assert.Equal(t, "1", v)
+		_, err = ds.GetConfigurationByKey("sub")
+		assert.Error(t, err)
+		assert.Equal(t, HighPriority, ds.GetPriority())
+		assert.Equal(t, "DirSource:"+dir, ds.GetSourceName())
+		assert.False(t, ds.IsSecret("ab"))
+	})
+}
+
+func TestDirSourceManager(t *testing.T) {
+	configDir := t.TempDir()
+	secretDir := t.TempDir()
+	mountDir(t, configDir, map[string]string{"a.b": "1", "c.d": "2"})
+	mountDir(t, secretDir, map[string]string{"a.b": "secret", "e.f": "pass"})
+
+	mgr, _ := Init(
+		WithDirSource(&DirInfo{Dir: configDir, RefreshInterval: 100 * time.Millisecond}),
+		WithDirSource(&DirInfo{Dir: secretDir, Priority: HighPriority, Secret: true}),
+	)
+	defer mgr.Close()
+
+	v, err := mgr.GetConfig("a.b")
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", v)
+	v, err = mgr.GetConfig("c.d")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", v)
+
+	assert.True(t, mgr.IsSecret("a.b"))
+	assert.True(t, mgr.IsSecret("e.f"))
+	assert.False(t, mgr.IsSecret("c.d"))
+	masked := mgr.GetMaskedConfigs()
+	assert.Equal(t, MaskedValue, masked["ab"])
+	assert.Equal(t, MaskedValue, masked["ef"])
+	assert.Equal(t, "2", masked["cd"])
+	// the overlays of a secret key are masked as well
+	mgr.SetConfig("e.f", "other")
+	assert.Equal(t, MaskedValue, mgr.GetMaskedConfigs()["ef"])
+	assert.Equal(t, "other", mgr.GetConfigs()["ef"])
+
+	assert.Equal(t, MaskedValue, mgr.maskEvent(&Event{EventSource: "DirSource:" + secretDir, Key: "ab", Value: "secret"}).Value)
+	assert.Equal(t, "1", mgr.maskEvent(&Event{EventSource: "DirSource:" + configDir, Key: "ab", Value: "1"}).Value)
+
+	t.Run("refresh", func(t *testing.T) {
+		handled := make(chan *Event, 10)
+		mgr.Dispatcher.Register("cd", NewHandler("test", func(e *Event) {
+			handled <- e
+		}))
+		mountDir(t, configDir, map[string]string{"c.d": "3"})
+		select {
+		case e := <-handled:
+			assert.Equal(t, "3", e.Value)
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "config is not refreshed")
+		}
+		v, err := mgr.GetConfig("c.d")
+		assert.NoError(t, err)
+		assert.Equal(t, "3", v)
+		// a.b removed from the config dir is still held by the secret dir
+		assert.Eventually(t, func() bool {
+			_, err := mgr.GetConfig("a.b")
+			return err == nil
+		}, 5*time.Second, 50*time.Millisecond)
+	})
+}
@@ -149,7 +149,7 @@ func (es *EtcdSource) refreshConfigurations() error {
 		key := string(kv.Key)
 		key = strings.TrimPrefix(key, prefix+"/")
 		newConfig[key] = string(kv.Value)
-		newConfig[formatKey(key)] = string(kv.Value)
+		newConfig[FormatKey(key)] = string(kv.Value)
 		log.Debug("got config from etcd", zap.String("key", string(kv.Key)), zap.String("value", string(kv.Value)))
 	}
 	es.Lock()
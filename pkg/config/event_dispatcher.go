@@ -36,14 +36,14 @@ func NewEventDispatcher() *EventDispatcher {
 func (ed *EventDispatcher) Get(key string) []EventHandler {
 	ed.mut.RLock()
 	defer ed.mut.RUnlock()
-	return ed.registry[formatKey(key)]
+	return ed.registry[FormatKey(key)]
 }
 
 func (ed *EventDispatcher) Dispatch(event *Event) {
 	ed.mut.RLock()
 	defer ed.mut.RUnlock()
 	var hs []EventHandler
-	realKey := formatKey(event.Key)
+	realKey := FormatKey(event.Key)
 	hs, ok := ed.registry[realKey]
 	if !ok {
 		for _, v := range ed.keyPrefix {
@@ -63,7 +63,7 @@ func (ed *EventDispatcher) Dispatch(event *Event) {
 func (ed *EventDispatcher) Register(key string, handler EventHandler) {
 	ed.mut.Lock()
 	defer ed.mut.Unlock()
-	key = formatKey(key)
+	key = FormatKey(key)
 	v, ok := ed.registry[key]
 	if ok {
 		ed.registry[key] = append(v, handler)
@@ -76,7 +76,7 @@ func (ed *EventDispatcher) Register(key string, handler EventHandler) {
 func (ed *EventDispatcher) RegisterForKeyPrefix(keyPrefix string, handler EventHandler) {
 	ed.mut.Lock()
 	defer ed.mut.Unlock()
-	keyPrefix = formatKey(keyPrefix)
+	keyPrefix = FormatKey(keyPrefix)
 	v, ok := ed.registry[keyPrefix]
 	if ok {
 		ed.registry[keyPrefix] = append(v, handler)
@@ -89,7 +89,7 @@ func (ed *EventDispatcher) RegisterForKeyPrefix(keyPrefix string, handler EventH
 func (ed *EventDispatcher) Unregister(key string, handler EventHandler) {
 	ed.mut.Lock()
 	defer ed.mut.Unlock()
-	key = formatKey(key)
+	key = FormatKey(key)
 	v, ok := ed.registry[key]
 	if !ok {
 		return
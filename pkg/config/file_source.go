@@ -149,7 +149,7 @@ func (fs *FileSource) loadFromFile() error {
 				}
 			}
 			newConfig[key] = str
-			newConfig[formatKey(key)] = str
+			newConfig[FormatKey(key)] = str
 		}
 	}
 
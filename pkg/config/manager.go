@@ -28,8 +28,17 @@ import (
 
 const (
 	TombValue = "TOMB_VAULE"
+	// MaskedValue replaces the secret values in logs
+	MaskedValue = "******"
+	// RuntimeSource is the source of the configs set at runtime
+	RuntimeSource = "Runtime"
 )
 
+// secretSource is implemented by the sources holding sensitive values, like DirSource of a Secret.
+type secretSource interface {
+	IsSecret(key string) bool
+}
+
 type Filter func(key string) (string, bool)
 
 func WithSubstr(substring string) Filter {
@@ -97,7 +106,7 @@ func NewManager() *Manager {
 }
 
 func (m *Manager) GetConfig(key string) (string, error) {
-	realKey := formatKey(key)
+	realKey := FormatKey(key)
 	v, ok := m.overlays.Get(realKey)
 	if ok {
 		if v == TombValue {
@@ -112,6 +121,28 @@ func (m *Manager) GetConfig(key string) (string, error) {
 	return m.getConfigValueBySource(realKey, sourceName)
 }
 
+// GetConfigWithSource returns the value of key and the name of the source it comes from,
+// RuntimeSource if it is set at runtime.
+func (m *Manager) GetConfigWithSource(key string) (string, string, error) {
+	realKey := FormatKey(key)
+	v, ok := m.overlays.Get(realKey)
+	if ok {
+		if v == TombValue {
+			return "", "", fmt.Errorf("key not found %s", key)
+		}
+		return v, RuntimeSource, nil
+	}
+	sourceName, ok := m.keySourceMap.Get(realKey)
+	if !ok {
+		return "", "", fmt.Errorf("key not found: %s", key)
+	}
+	v, err := m.getConfigValueBySource(realKey, sourceName)
+	if err != nil {
+		return "", "", err
+	}
+	return v, sourceName, nil
+}
+
 // GetConfigs returns all the key values
 func (m *Manager) GetConfigs() map[string]string {
 	config := make(map[string]string)
@@ -134,6 +165,49 @@ func (m *Manager) GetConfigs() map[string]string {
 	return config
 }
 
+// GetMaskedConfigs returns all the key values as GetConfigs does, with the secret values masked,
+// it should be used whenever the configs are logged or exposed.
+func (m *Manager) GetMaskedConfigs() map[string]string {
+	config := m.GetConfigs()
+	for key := range config {
+		if m.IsSecret(key) {
+			config[key] = MaskedValue
+		}
+	}
+	return config
+}
+
+// IsSecret returns whether key is held by a secret source, the value is considered secret
+// even if it is overridden by another source.
+func (m *Manager) IsSecret(key string) bool {
+	realKey := FormatKey(key)
+	secret := false
+	m.sources.Range(func(_ string, source Source) bool {
+		if m.isSecretIn(source, realKey) {
+			_, err := source.GetConfigurationByKey(realKey)
+			secret = err == nil
+		}
+		return !secret
+	})
+	return secret
+}
+
+func (m *Manager) isSecretIn(source Source, key string) bool {
+	s, ok := source.(secretSource)
+	return ok && s.IsSecret(key)
+}
+
+// maskEvent returns the event to be logged, the value of an event from a secret source is masked.
+func (m *Manager) maskEvent(e *Event) *Event {
+	source, ok := m.sources.Get(e.EventSource)
+	if !ok || !m.isSecretIn(source, e.Key) {
+		return e
+	}
+	masked := *e
+	masked.Value = MaskedValue
+	return &masked
+}
+
 func (m *Manager) GetBy(filters ...Filter) map[string]string {
 	matchedConfig := make(map[string]string)
 
@@ -190,7 +264,7 @@ func (m *Manager) AddSource(source Source) error {
 // Update config at runtime, which can be called by others
 // The most used scenario is UT
 func (m *Manager) SetConfig(key, value string) {
-	m.overlays.Insert(formatKey(key), value)
+	m.overlays.Insert(FormatKey(key), value)
 }
 
 func (m *Manager) SetMapConfig(key, value string) {
@@ -199,17 +273,17 @@ func (m *Manager) SetMapConfig(key, value string) {
 
 // Delete config at runtime, which has the highest priority to override all other sources
 func (m *Manager) DeleteConfig(key string) {
-	m.overlays.Insert(formatKey(key), TombValue)
+	m.overlays.Insert(FormatKey(key), TombValue)
 }
 
 // Remove the config which set at runtime, use config from sources
 func (m *Manager) ResetConfig(key string) {
-	m.overlays.Remove(formatKey(key))
+	m.overlays.Remove(FormatKey(key))
 }
 
 // Ignore any of update events, which means the config cannot auto refresh anymore
 func (m *Manager) ForbidUpdate(key string) {
-	m.forbiddenKeys.Insert(formatKey(key))
+	m.forbiddenKeys.Insert(FormatKey(key))
 }
 
 func (m *Manager) UpdateSourceOptions(opts ...Option) {
@@ -312,20 +386,20 @@ func (m *Manager) updateEvent(e *Event) error {
 		}
 	}
 
-	log.Info("receive update event", zap.Any("event", e))
+	log.Info("receive update event", zap.Any("event", m.maskEvent(e)))
 	e.HasUpdated = true
 	return nil
 }
 
 // OnEvent Triggers actions when an event is generated
 func (m *Manager) OnEvent(event *Event) {
-	if m.forbiddenKeys.Contain(formatKey(event.Key)) {
+	if m.forbiddenKeys.Contain(FormatKey(event.Key)) {
 		log.Info("ignore event for forbidden key", zap.String("key", event.Key))
 		return
 	}
 	err := m.updateEvent(event)
 	if err != nil {
-		log.Warn("failed in updating event with error", zap.Error(err), zap.Any("event", event))
+		log.Warn("failed in updating event with error", zap.Error(err), zap.Any("event", m.maskEvent(event)))
 		return
 	}
 
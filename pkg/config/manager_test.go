@@ -25,6 +25,7 @@ import (
 
 	"github.com/cockroachdb/errors"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.etcd.io/etcd/server/v3/embed"
 	"go.etcd.io/etcd/server/v3/etcdserver/api/v3client"
 	"golang.org/x/sync/errgroup"
@@ -35,7 +36,7 @@ func TestAllConfigFromManager(t *testing.T) {
 	all := mgr.GetConfigs()
 	assert.Equal(t, 0, len(all))
 
-	mgr, _ = Init(WithEnvSource(formatKey))
+	mgr, _ = Init(WithEnvSource(FormatKey))
 	all = mgr.GetConfigs()
 	assert.Less(t, 0, len(all))
 }
@@ -61,9 +62,9 @@ func TestConfigChangeEvent(t *testing.T) {
 
 func TestAllDupliateSource(t *testing.T) {
 	mgr, _ := Init()
-	err := mgr.AddSource(NewEnvSource(formatKey))
+	err := mgr.AddSource(NewEnvSource(FormatKey))
 	assert.NoError(t, err)
-	err = mgr.AddSource(NewEnvSource(formatKey))
+	err = mgr.AddSource(NewEnvSource(FormatKey))
 	assert.Error(t, err)
 
 	err = mgr.AddSource(ErrSource{})
@@ -94,7 +95,7 @@ func TestBasic(t *testing.T) {
 	assert.Error(t, err)
 
 	// test forbid config
-	envSource := NewEnvSource(formatKey)
+	envSource := NewEnvSource(FormatKey)
 	err = mgr.AddSource(envSource)
 	assert.NoError(t, err)
 
@@ -125,27 +126,29 @@ func TestBasic(t *testing.T) {
 }
 
 func TestOnEvent(t *testing.T) {
-	cfg, _ := embed.ConfigFromFile("../../configs/advanced/etcd.yaml")
-	cfg.Dir = "/tmp/milvus/test"
+	cfg := embed.NewConfig()
+	cfg.Dir = t.TempDir()
 	e, err := embed.StartEtcd(cfg)
-	assert.NoError(t, err)
+	require.NoError(t, err)
 	defer e.Close()
-	defer os.RemoveAll(cfg.Dir)
 
 	client := v3client.New(e.Server)
 
 	dir, _ := os.MkdirTemp("", "milvus")
 	yamlFile := path.Join(dir, "milvus.yaml")
-	mgr, _ := Init(WithEnvSource(formatKey),
+	mgr, _ := Init(WithEnvSource(FormatKey),
 		WithFilesSource(&FileInfo{
 			Files:           []string{yamlFile},
 			RefreshInterval: 10 * time.Millisecond,
-		}),
-		WithEtcdSource(&EtcdInfo{
-			Endpoints:       []string{cfg.ACUrls[0].Host},
-			KeyPrefix:       "test",
-			RefreshInterval: 10 * time.Millisecond,
 		}))
+	// Init does not add the etcd source, it is added by the paramtable
+	s, err := NewEtcdSource(&EtcdInfo{
+		Endpoints:       []string{e.Clients[0].Addr().String()},
+		KeyPrefix:       "test",
+		RefreshInterval: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	require.NoError(t, mgr.AddSource(s))
 
 	os.WriteFile(yamlFile, []byte("a.b: aaa"), 0o600)
 	time.Sleep(time.Second)
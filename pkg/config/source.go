@@ -21,6 +21,9 @@ const (
 	HighPriority   = 1
 	NormalPriority = HighPriority + 10
 	LowPriority    = NormalPriority + 10
+	// DirPriority is the default priority of directory sources,
+	// higher than yaml files and lower than env.
+	DirPriority = NormalPriority + 1
 )
 
 type Source interface {
@@ -54,9 +57,20 @@ type FileInfo struct {
 	RefreshInterval time.Duration
 }
 
+// DirInfo has attribute for directory source, like a mounted kubernetes ConfigMap or Secret
+type DirInfo struct {
+	Dir string
+	// Priority of the source, DirPriority if 0
+	Priority int
+	// Secret masks the values of the source when configs are logged
+	Secret          bool
+	RefreshInterval time.Duration
+}
+
 // Options hold options
 type Options struct {
 	FileInfo        *FileInfo
+	DirInfos        []*DirInfo
 	EtcdInfo        *EtcdInfo
 	EnvKeyFormatter func(string) string
 }
@@ -71,6 +85,13 @@ func WithFilesSource(fi *FileInfo) Option {
 	}
 }
 
+// WithDirSource adds a source reading one key per file from a directory, could be used multiple times
+func WithDirSource(di *DirInfo) Option {
+	return func(options *Options) {
+		options.DirInfos = append(options.DirInfos, di)
+	}
+}
+
 // WithEtcdSource accept the information for initiating a remote source
 func WithEtcdSource(ri *EtcdInfo) Option {
 	return func(options *Options) {
@@ -26,6 +26,8 @@ const (
 	CreateConsumerLabel = "create_consumer"
 
 	msgStreamOpType = "message_op_type"
+	stallPolicyName = "stall_policy"
+	discardReason   = "reason"
 )
 
 var (
@@ -56,6 +58,62 @@ var (
 			Name:      "op_count",
 			Help:      "count of stream message operation",
 		}, []string{msgStreamOpType, statusLabelName})
+
+	MsgStreamTickWait = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: milvusNamespace,
+			Subsystem: "msgstream",
+			Name:      "tick_wait_seconds",
+			Help:      "how long the time tick msgstream has been waiting for the next time tick of the channel",
+		}, []string{channelNameLabelName})
+
+	MsgStreamTickStallCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: milvusNamespace,
+			Subsystem: "msgstream",
+			Name:      "tick_stall_count",
+			Help:      "count of the channels found stalled by the time tick watchdog",
+		}, []string{channelNameLabelName, stallPolicyName})
+
+	MsgStreamReceivedMsgCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: milvusNamespace,
+			Subsystem: "msgstream",
+			Name:      "received_msg_count",
+			Help:      "count of the messages received by msgstream",
+		}, []string{channelNameLabelName, msgTypeLabelName})
+
+	MsgStreamReceivedBytesCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: milvusNamespace,
+			Subsystem: "msgstream",
+			Name:      "received_bytes",
+			Help:      "bytes of the message payloads received by msgstream",
+		}, []string{channelNameLabelName, msgTypeLabelName})
+
+	MsgStreamDiscardedMsgCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: milvusNamespace,
+			Subsystem: "msgstream",
+			Name:      "discarded_msg_count",
+			Help:      "count of the messages received but not delivered by msgstream, for being skipped, bad or duplicate",
+		}, []string{channelNameLabelName, discardReason})
+
+	MsgStreamBufferedMsgs = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: milvusNamespace,
+			Subsystem: "msgstream",
+			Name:      "buffered_msg_num",
+			Help:      "number of the messages of the channel buffered by the time tick msgstream until the next time tick",
+		}, []string{channelNameLabelName})
+
+	MsgStreamReceiveBufLen = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: milvusNamespace,
+			Subsystem: "msgstream",
+			Name:      "receive_buf_len",
+			Help:      "number of the msg packs in the receive buffer of the msgstream consuming the channel",
+		}, []string{channelNameLabelName})
 )
 
 // RegisterMsgStreamMetrics registers msg stream metrics
@@ -63,4 +121,11 @@ func RegisterMsgStreamMetrics(registry *prometheus.Registry) {
 	registry.MustRegister(NumConsumers)
 	registry.MustRegister(MsgStreamRequestLatency)
 	registry.MustRegister(MsgStreamOpCounter)
+	registry.MustRegister(MsgStreamTickWait)
+	registry.MustRegister(MsgStreamTickStallCounter)
+	registry.MustRegister(MsgStreamReceivedMsgCounter)
+	registry.MustRegister(MsgStreamReceivedBytesCounter)
+	registry.MustRegister(MsgStreamDiscardedMsgCounter)
+	registry.MustRegister(MsgStreamBufferedMsgs)
+	registry.MustRegister(MsgStreamReceiveBufLen)
 }
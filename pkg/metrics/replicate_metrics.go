@@ -0,0 +1,82 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+const (
+	replicateSubsystem = "replicate"
+
+	ReplicateCatchUpLabel = "catch_up"
+	ReplicateSteadyLabel  = "steady"
+	ReplicatePausedLabel  = "paused"
+
+	replicateStateLabelName = "state"
+)
+
+var (
+	ReplicateLag = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: milvusNamespace,
+			Subsystem: replicateSubsystem,
+			Name:      "lag",
+			Help:      "lag in milliseconds between now and the latest replicated time tick",
+		}, []string{channelNameLabelName})
+
+	ReplicateMsgCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: milvusNamespace,
+			Subsystem: replicateSubsystem,
+			Name:      "msg_count",
+			Help:      "count of messages applied to the target cluster",
+		}, []string{msgTypeLabelName, statusLabelName})
+
+	ReplicateState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: milvusNamespace,
+			Subsystem: replicateSubsystem,
+			Name:      "state",
+			Help:      "current state of the replication, 1 for the active state",
+		}, []string{replicateStateLabelName})
+
+	ReplicateReconnectCounter = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Namespace: milvusNamespace,
+			Subsystem: replicateSubsystem,
+			Name:      "reconnect_count",
+			Help:      "count of reconnecting to the source message queue",
+		})
+)
+
+// RegisterReplicateMetrics registers replicate metrics
+func RegisterReplicateMetrics(registry *prometheus.Registry) {
+	registry.MustRegister(ReplicateLag)
+	registry.MustRegister(ReplicateMsgCounter)
+	registry.MustRegister(ReplicateState)
+	registry.MustRegister(ReplicateReconnectCounter)
+}
+
+// SetReplicateState marks state as the only active replicate state.
+func SetReplicateState(state string) {
+	for _, s := range []string{ReplicateCatchUpLabel, ReplicateSteadyLabel, ReplicatePausedLabel} {
+		if s == state {
+			ReplicateState.WithLabelValues(s).Set(1)
+		} else {
+			ReplicateState.WithLabelValues(s).Set(0)
+		}
+	}
+}
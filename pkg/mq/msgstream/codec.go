@@ -0,0 +1,181 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgstream
+
+import (
+	"bytes"
+	"io"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+	"github.com/klauspost/compress/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4"
+)
+
+// CodecPropertyKey is the message property of the codec name of the payload,
+// a payload without it is the raw marshaled msg.
+const CodecPropertyKey = "payload_codec"
+
+const (
+	CodecNone   = "none"
+	CodecZstd   = "zstd"
+	CodecSnappy = "snappy"
+	CodecLz4    = "lz4"
+)
+
+// PayloadCodec encodes the marshaled msgs before they are sent to mq.
+type PayloadCodec interface {
+	Name() string
+	Encode(payload []byte) ([]byte, error)
+	Decode(payload []byte) ([]byte, error)
+}
+
+var (
+	codecMu sync.RWMutex
+	codecs  = map[string]PayloadCodec{
+		CodecNone:   noneCodec{},
+		CodecZstd:   newZstdCodec(),
+		CodecSnappy: snappyCodec{},
+		CodecLz4:    lz4Codec{},
+	}
+)
+
+// RegisterPayloadCodec registers codec by its name, replaces the codec of the same name.
+func RegisterPayloadCodec(codec PayloadCodec) {
+	codecMu.Lock()
+	defer codecMu.Unlock()
+	codecs[codec.Name()] = codec
+}
+
+// GetPayloadCodec returns the codec of name, empty name is CodecNone.
+func GetPayloadCodec(name string) (PayloadCodec, error) {
+	if len(name) == 0 {
+		name = CodecNone
+	}
+	codecMu.RLock()
+	defer codecMu.RUnlock()
+	codec, ok := codecs[name]
+	if !ok {
+		return nil, errors.Newf("unknown payload codec %s", name)
+	}
+	return codec, nil
+}
+
+// EncodePayload encodes payload by codec, and records the codec in properties.
+func EncodePayload(codec PayloadCodec, payload []byte, properties map[string]string) ([]byte, error) {
+	if codec == nil || codec.Name() == CodecNone {
+		return payload, nil
+	}
+	encoded, err := codec.Encode(payload)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to encode payload by %s", codec.Name())
+	}
+	properties[CodecPropertyKey] = codec.Name()
+	return encoded, nil
+}
+
+// DecodePayload decodes payload by the codec recorded in properties.
+func DecodePayload(payload []byte, properties map[string]string) ([]byte, error) {
+	name, ok := properties[CodecPropertyKey]
+	if !ok {
+		return payload, nil
+	}
+	codec, err := GetPayloadCodec(name)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := codec.Decode(payload)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to decode payload by %s", name)
+	}
+	return decoded, nil
+}
+
+type noneCodec struct{}
+
+func (noneCodec) Name() string {
+	return CodecNone
+}
+
+func (noneCodec) Encode(payload []byte) ([]byte, error) {
+	return payload, nil
+}
+
+func (noneCodec) Decode(payload []byte) ([]byte, error) {
+	return payload, nil
+}
+
+// zstdCodec shares one encoder and decoder, EncodeAll and DecodeAll are safe for concurrent use.
+type zstdCodec struct {
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+func newZstdCodec() *zstdCodec {
+	encoder, _ := zstd.NewWriter(nil)
+	decoder, _ := zstd.NewReader(nil)
+	return &zstdCodec{encoder: encoder, decoder: decoder}
+}
+
+func (c *zstdCodec) Name() string {
+	return CodecZstd
+}
+
+func (c *zstdCodec) Encode(payload []byte) ([]byte, error) {
+	return c.encoder.EncodeAll(payload, nil), nil
+}
+
+func (c *zstdCodec) Decode(payload []byte) ([]byte, error) {
+	return c.decoder.DecodeAll(payload, nil)
+}
+
+type snappyCodec struct{}
+
+func (snappyCodec) Name() string {
+	return CodecSnappy
+}
+
+func (snappyCodec) Encode(payload []byte) ([]byte, error) {
+	return snappy.Encode(nil, payload), nil
+}
+
+func (snappyCodec) Decode(payload []byte) ([]byte, error) {
+	return snappy.Decode(nil, payload)
+}
+
+type lz4Codec struct{}
+
+func (lz4Codec) Name() string {
+	return CodecLz4
+}
+
+func (lz4Codec) Encode(payload []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	w := lz4.NewWriter(buf)
+	if _, err := w.Write(payload); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (lz4Codec) Decode(payload []byte) ([]byte, error) {
+	return io.ReadAll(lz4.NewReader(bytes.NewReader(payload)))
+}
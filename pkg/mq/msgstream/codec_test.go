@@ -0,0 +1,124 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgstream
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper/memory"
+	"github.com/xige-16/stream-read/pkg/util/paramtable"
+)
+
+func TestPayloadCodec(t *testing.T) {
+	payload := bytes.Repeat([]byte("stream-read payload "), 1024)
+	for _, name := range []string{CodecNone, CodecZstd, CodecSnappy, CodecLz4} {
+		t.Run(name, func(t *testing.T) {
+			codec, err := GetPayloadCodec(name)
+			require.NoError(t, err)
+			assert.Equal(t, name, codec.Name())
+
+			properties := make(map[string]string)
+			encoded, err := EncodePayload(codec, payload, properties)
+			assert.NoError(t, err)
+			if name == CodecNone {
+				assert.NotContains(t, properties, CodecPropertyKey)
+			} else {
+				assert.Equal(t, name, properties[CodecPropertyKey])
+				assert.Less(t, len(encoded), len(payload))
+			}
+
+			decoded, err := DecodePayload(encoded, properties)
+			assert.NoError(t, err)
+			assert.Equal(t, payload, decoded)
+		})
+	}
+
+	t.Run("default", func(t *testing.T) {
+		codec, err := GetPayloadCodec("")
+		assert.NoError(t, err)
+		assert.Equal(t, CodecNone, codec.Name())
+		encoded, err := EncodePayload(nil, payload, map[string]string{})
+		assert.NoError(t, err)
+		assert.Equal(t, payload, encoded)
+	})
+
+	t.Run("unknown codec", func(t *testing.T) {
+		_, err := GetPayloadCodec("gzip")
+		assert.Error(t, err)
+		_, err = DecodePayload(payload, map[string]string{CodecPropertyKey: "gzip"})
+		assert.Error(t, err)
+	})
+
+	t.Run("corrupted payload", func(t *testing.T) {
+		for _, name := range []string{CodecZstd, CodecSnappy, CodecLz4} {
+			_, err := DecodePayload(payload, map[string]string{CodecPropertyKey: name})
+			assert.Error(t, err, name)
+		}
+	})
+}
+
+func TestMqMsgStream_PayloadCodec(t *testing.T) {
+	paramtable.Init()
+	params := paramtable.Get()
+	ctx := context.Background()
+	mq := memory.NewClient()
+	defer mq.Close()
+	factory := &ProtoUDFactory{}
+	channels := []string{"dml_0"}
+
+	params.Save(params.MQCfg.PayloadCodec.Key, "unknown")
+	_, err := NewMqMsgStream(ctx, 16, 16, mq.NewClient(), factory.NewUnmarshalDispatcher())
+	assert.Error(t, err)
+
+	params.Save(params.MQCfg.PayloadCodec.Key, CodecZstd)
+	defer params.Reset(params.MQCfg.PayloadCodec.Key)
+	producer, err := NewMqMsgStream(ctx, 16, 16, mq.NewClient(), factory.NewUnmarshalDispatcher())
+	require.NoError(t, err)
+	defer producer.Close()
+	producer.AsProducer(channels)
+
+	// the consumer decodes whatever its own codec is
+	params.Reset(params.MQCfg.PayloadCodec.Key)
+	consumer, err := NewMqMsgStream(ctx, 16, 16, mq.NewClient(), factory.NewUnmarshalDispatcher())
+	require.NoError(t, err)
+	defer consumer.Close()
+	require.NoError(t, consumer.AsConsumer(ctx, channels, "sub", mqwrapper.SubscriptionPositionEarliest))
+
+	msg, _ := newRepackInsertMsg(16, 1)
+	msg.HashValues = make([]uint32, 16)
+	require.NoError(t, producer.Produce(&MsgPack{Msgs: []TsMsg{msg}}))
+
+	select {
+	case pack := <-consumer.Chan():
+		require.Len(t, pack.Msgs, 1)
+		assert.Equal(t, commonpb.MsgType_Insert, pack.Msgs[0].Type())
+		imsg := pack.Msgs[0].(*InsertMsg)
+		assert.Equal(t, msg.GetRowIDs(), imsg.GetRowIDs())
+		assert.Equal(t, msg.GetFieldsData()[1].GetVectors().GetFloatVector().GetData(),
+			imsg.GetFieldsData()[1].GetVectors().GetFloatVector().GetData())
+	case <-time.After(5 * time.Second):
+		t.Fatal("no msg pack received")
+	}
+}
@@ -0,0 +1,114 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgstream
+
+import (
+	"path/filepath"
+
+	"github.com/cockroachdb/errors"
+	"go.uber.org/zap"
+
+	"github.com/xige-16/stream-read/pkg/log"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper"
+	"github.com/xige-16/stream-read/pkg/util/merr"
+	"github.com/xige-16/stream-read/pkg/util/paramtable"
+)
+
+// The policies of an undecodable message, see mq.badMsgPolicy.
+const (
+	BadMsgPolicySkip       = "skip"
+	BadMsgPolicyFail       = "fail"
+	BadMsgPolicyQuarantine = "quarantine"
+)
+
+// QuarantineFunc receives an undecodable message and the error decoding it under the
+// quarantine policy, the stream fails if it returns an error.
+type QuarantineFunc func(msg mqwrapper.Message, err error) error
+
+// BadMessageError is the error a stream fails with on an undecodable message,
+// it is merr.ErrMqBadMessage.
+type BadMessageError struct {
+	Channel string
+	MsgID   MessageID
+	err     error
+}
+
+func newBadMessageError(msg mqwrapper.Message, cause error) *BadMessageError {
+	channel := filepath.Base(msg.Topic())
+	return &BadMessageError{
+		Channel: channel,
+		MsgID:   msg.ID(),
+		err:     merr.WrapErrMqBadMessage(channel, msg.ID().Serialize(), cause),
+	}
+}
+
+func (e *BadMessageError) Error() string {
+	return e.err.Error()
+}
+
+func (e *BadMessageError) Unwrap() error {
+	return e.err
+}
+
+func (ms *mqMsgStream) SetBadMsgPolicy(policy string, quarantine QuarantineFunc) error {
+	switch policy {
+	case BadMsgPolicySkip, BadMsgPolicyFail:
+	case BadMsgPolicyQuarantine:
+		if quarantine == nil {
+			return merr.WrapErrParameterInvalidMsg("quarantine policy without a quarantine callback")
+		}
+	default:
+		return merr.WrapErrParameterInvalid("[skip, fail, quarantine]", policy, "unknown bad message policy")
+	}
+	ms.badMsgPolicy = policy
+	ms.quarantine = quarantine
+	return nil
+}
+
+func (ms *mqMsgStream) getBadMsgPolicy() string {
+	if len(ms.badMsgPolicy) > 0 {
+		return ms.badMsgPolicy
+	}
+	return paramtable.Get().MQCfg.BadMsgPolicy.GetValue()
+}
+
+// handleBadMsg handles msg failed to decode with cause by the bad message policy,
+// returns the error to stop consuming with if the policy does not go on.
+func (ms *mqMsgStream) handleBadMsg(consumer mqwrapper.Consumer, msg mqwrapper.Message, cause error) error {
+	ms.recordDiscarded(filepath.Base(msg.Topic()), discardBad)
+	logger := log.With(zap.String("channel", filepath.Base(msg.Topic())),
+		zap.Binary("msgID", msg.ID().Serialize()), zap.Error(cause))
+	switch policy := ms.getBadMsgPolicy(); policy {
+	case BadMsgPolicyFail:
+		logger.Warn("stop consuming for bad message")
+		return newBadMessageError(msg, cause)
+	case BadMsgPolicyQuarantine:
+		if ms.quarantine == nil {
+			logger.Warn("stop consuming for bad message, no quarantine callback")
+			return newBadMessageError(msg, cause)
+		}
+		if err := ms.quarantine(msg, cause); err != nil {
+			logger.Warn("failed to quarantine bad message", zap.NamedError("quarantineErr", err))
+			return newBadMessageError(msg, errors.Wrapf(err, "failed to quarantine, %s", cause.Error()))
+		}
+		logger.Warn("bad message quarantined")
+	default:
+		logger.Warn("skip bad message")
+	}
+	ms.ackReceived(consumer, msg, nil)
+	return nil
+}
@@ -30,6 +30,8 @@ import (
 
 	"github.com/xige-16/stream-read/pkg/log"
 	"github.com/xige-16/stream-read/pkg/metrics"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper/archive"
 	pulsarmqwrapper "github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper/pulsar"
 	"github.com/xige-16/stream-read/pkg/util/paramtable"
 	"github.com/xige-16/stream-read/pkg/util/retry"
@@ -73,6 +75,19 @@ func NewPmsFactory(serviceParam *paramtable.ServiceParam) *PmsFactory {
 	return f
 }
 
+// NewArchiveFactory creates a factory of the msgstreams consuming the channel archives under dir
+// instead of the broker.
+func NewArchiveFactory(dir string, serviceParam *paramtable.ServiceParam) *CommonFactory {
+	return &CommonFactory{
+		Newer: func(context.Context) (mqwrapper.Client, error) {
+			return archive.NewClient(dir)
+		},
+		DispatcherFactory: ProtoUDFactory{},
+		ReceiveBufSize:    serviceParam.MQCfg.ReceiveBufSize.GetAsInt64(),
+		MQBufSize:         serviceParam.MQCfg.MQBufSize.GetAsInt64(),
+	}
+}
+
 // NewMsgStream is used to generate a new Msgstream object
 func (f *PmsFactory) NewMsgStream(ctx context.Context) (MsgStream, error) {
 	var timeout time.Duration = f.RequestTimeout
@@ -140,6 +155,11 @@ func (f *PmsFactory) getAuthentication() (pulsar.Authentication, error) {
 	return auth, nil
 }
 
+// NewSubscriptionAdmin creates a SubscriptionAdmin on the tenant and namespace of the factory.
+func (f *PmsFactory) NewSubscriptionAdmin() (*pulsarmqwrapper.SubscriptionAdmin, error) {
+	return pulsarmqwrapper.NewSubscriptionAdmin(f.PulsarTenant, f.PulsarNameSpace, f.PulsarWebAddress, f.PulsarAuthPlugin, f.PulsarAuthParams)
+}
+
 func (f *PmsFactory) NewMsgStreamDisposer(ctx context.Context) func([]string, string) error {
 	return func(channels []string, subname string) error {
 		// try to delete the old subscription
@@ -35,7 +35,9 @@ import (
 	"github.com/milvus-io/milvus-proto/go-api/v2/msgpb"
 	"github.com/xige-16/stream-read/pkg/config"
 	"github.com/xige-16/stream-read/pkg/log"
+	"github.com/xige-16/stream-read/pkg/metrics"
 	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper"
+	"github.com/xige-16/stream-read/pkg/util/conc"
 	"github.com/xige-16/stream-read/pkg/util/merr"
 	"github.com/xige-16/stream-read/pkg/util/paramtable"
 	"github.com/xige-16/stream-read/pkg/util/retry"
@@ -68,6 +70,25 @@ type mqMsgStream struct {
 	onceChan      sync.Once
 	enableProduce atomic.Value
 	configEvent   config.EventHandler
+	manualAck     bool
+	subType       mqwrapper.SubscriptionType
+	codec         PayloadCodec
+	// err is the error the stream failed with
+	err          atomic.Pointer[error]
+	closeBufOnce sync.Once
+	statsMu      sync.Mutex
+	chanStats    map[string]*channelStats
+	// badMsgPolicy overrides mq.badMsgPolicy if not empty
+	badMsgPolicy string
+	quarantine   QuarantineFunc
+}
+
+// msgAck is a received message to be acked in manual ack mode.
+type msgAck struct {
+	consumer mqwrapper.Consumer
+	msg      mqwrapper.Message
+	// data is false for the time ticks, which are never redelivered
+	data bool
 }
 
 // NewMqMsgStream is used to generate a new mqMsgStream object
@@ -77,6 +98,10 @@ func NewMqMsgStream(ctx context.Context,
 	client mqwrapper.Client,
 	unmarshal UnmarshalDispatcher,
 ) (*mqMsgStream, error) {
+	codec, err := GetPayloadCodec(paramtable.Get().MQCfg.PayloadCodec.GetValue())
+	if err != nil {
+		return nil, err
+	}
 	streamCtx, streamCancel := context.WithCancel(ctx)
 	producers := make(map[string]mqwrapper.Producer)
 	consumers := make(map[string]mqwrapper.Consumer)
@@ -100,6 +125,8 @@ func NewMqMsgStream(ctx context.Context,
 		consumerLock: &sync.Mutex{},
 		closeRWMutex: &sync.RWMutex{},
 		closed:       0,
+		codec:        codec,
+		chanStats:    make(map[string]*channelStats),
 	}
 	ctxLog := log.Ctx(ctx)
 	stream.enableProduce.Store(paramtable.Get().CommonCfg.TTMsgEnabled.GetAsBool())
@@ -118,6 +145,17 @@ func NewMqMsgStream(ctx context.Context,
 	return stream, nil
 }
 
+func (ms *mqMsgStream) producerOptions(channel string) mqwrapper.ProducerOptions {
+	mqCfg := &paramtable.Get().MQCfg
+	return mqwrapper.ProducerOptions{
+		Topic:                   channel,
+		EnableCompression:       true,
+		EnableBatching:          mqCfg.EnableProduceBatching.GetAsBool(),
+		BatchingMaxMessages:     mqCfg.ProduceBatchingMaxMessages.GetAsUint(),
+		BatchingMaxPublishDelay: mqCfg.ProduceBatchingMaxPublishDelay.GetAsDuration(time.Millisecond),
+	}
+}
+
 // AsProducer create producer to send message to channels
 func (ms *mqMsgStream) AsProducer(channels []string) {
 	for _, channel := range channels {
@@ -127,7 +165,7 @@ func (ms *mqMsgStream) AsProducer(channels []string) {
 		}
 
 		fn := func() error {
-			pp, err := ms.client.CreateProducer(mqwrapper.ProducerOptions{Topic: channel, EnableCompression: true})
+			pp, err := ms.client.CreateProducer(ms.producerOptions(channel))
 			if err != nil {
 				return err
 			}
@@ -179,6 +217,8 @@ func (ms *mqMsgStream) AsConsumer(ctx context.Context, channels []string, subNam
 				SubscriptionName:            subName,
 				SubscriptionInitialPosition: position,
 				BufSize:                     ms.bufSize,
+				SubscriptionType:            ms.subType,
+				KeepSubscription:            ms.manualAck,
 			})
 			if err != nil {
 				return err
@@ -191,6 +231,7 @@ func (ms *mqMsgStream) AsConsumer(ctx context.Context, channels []string, subNam
 			defer ms.consumerLock.Unlock()
 			ms.consumers[channel] = pc
 			ms.consumerChannels = append(ms.consumerChannels, channel)
+			ms.registerChannelStats(channel)
 			return nil
 		}
 
@@ -208,6 +249,42 @@ func (ms *mqMsgStream) AsConsumer(ctx context.Context, channels []string, subNam
 	return nil
 }
 
+func (ms *mqMsgStream) SetManualAck(enable bool) {
+	ms.manualAck = enable
+}
+
+func (ms *mqMsgStream) SetSubscriptionType(subType mqwrapper.SubscriptionType) {
+	ms.subType = subType
+}
+
+func (ms *mqMsgStream) AckPack(msgPack *MsgPack) {
+	for _, a := range msgPack.acks {
+		a.consumer.Ack(a.msg)
+	}
+	msgPack.acks = nil
+}
+
+func (ms *mqMsgStream) Nack(msgPack *MsgPack) {
+	for _, a := range msgPack.acks {
+		if a.data {
+			a.consumer.Nack(a.msg)
+		} else {
+			a.consumer.Ack(a.msg)
+		}
+	}
+	msgPack.acks = nil
+}
+
+// ackReceived acks msg at once if not in manual ack mode, or it is never handled.
+// Returns the ack of msg in manual ack mode.
+func (ms *mqMsgStream) ackReceived(consumer mqwrapper.Consumer, msg mqwrapper.Message, tsMsg TsMsg) *msgAck {
+	if !ms.manualAck || tsMsg == nil {
+		consumer.Ack(msg)
+		return nil
+	}
+	return &msgAck{consumer: consumer, msg: msg, data: tsMsg.Type() != commonpb.MsgType_TimeTick}
+}
+
 func (ms *mqMsgStream) SetRepackFunc(repackFunc RepackFunc) {
 	ms.repackFunc = repackFunc
 }
@@ -234,10 +311,36 @@ func (ms *mqMsgStream) Close() {
 	}
 
 	ms.client.Close()
-	close(ms.receiveBuf)
+	ms.closeReceiveBuf()
 	paramtable.Get().Unwatch(paramtable.Get().CommonCfg.TTMsgEnabled.Key, ms.configEvent)
 }
 
+func (ms *mqMsgStream) closeReceiveBuf() {
+	ms.closeBufOnce.Do(func() {
+		close(ms.receiveBuf)
+	})
+}
+
+// fail stops consuming with err, the receivers see Chan closed and get err by Err.
+// It must be called by the goroutine sending to receiveBuf.
+func (ms *mqMsgStream) fail(err error) {
+	ms.abort(err)
+	ms.closeReceiveBuf()
+}
+
+// abort stops consuming with err, the goroutine sending to receiveBuf closes it once exited.
+func (ms *mqMsgStream) abort(err error) {
+	ms.err.CompareAndSwap(nil, &err)
+	ms.streamCancel()
+}
+
+func (ms *mqMsgStream) Err() error {
+	if err := ms.err.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
 func (ms *mqMsgStream) ComputeProduceChannelIndexes(tsMsgs []TsMsg) [][]int32 {
 	if len(tsMsgs) <= 0 {
 		return nil
@@ -271,17 +374,19 @@ func (ms *mqMsgStream) isEnabledProduce() bool {
 	return ms.enableProduce.Load().(bool)
 }
 
-func (ms *mqMsgStream) Produce(msgPack *MsgPack) error {
+// repack checks whether msgPack could be produced, and repacks it by the producer channel indexes.
+// A nil result without error means there is nothing to produce.
+func (ms *mqMsgStream) repack(msgPack *MsgPack) (map[int32]*MsgPack, error) {
 	if !ms.isEnabledProduce() {
 		log.Warn("can't produce the msg in the backup instance", zap.Stack("stack"))
-		return merr.ErrDenyProduceMsg
+		return nil, merr.ErrDenyProduceMsg
 	}
 	if msgPack == nil || len(msgPack.Msgs) <= 0 {
 		log.Debug("Warning: Receive empty msgPack")
-		return nil
+		return nil, nil
 	}
 	if len(ms.producers) <= 0 {
-		return errors.New("nil producer in msg stream")
+		return nil, errors.New("nil producer in msg stream")
 	}
 	tsMsgs := msgPack.Msgs
 	reBucketValues := ms.ComputeProduceChannelIndexes(msgPack.Msgs)
@@ -300,6 +405,47 @@ func (ms *mqMsgStream) Produce(msgPack *MsgPack) error {
 			result, err = DefaultRepackFunc(tsMsgs, reBucketValues)
 		}
 	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// toProducerMessage marshals and encodes tsMsg into the message sent to mq.
+func (ms *mqMsgStream) toProducerMessage(spanCtx context.Context, tsMsg TsMsg) (*mqwrapper.ProducerMessage, error) {
+	mb, err := tsMsg.Marshal(tsMsg)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := convertToByteArray(mb)
+	if err != nil {
+		return nil, err
+	}
+
+	msg := &mqwrapper.ProducerMessage{Properties: map[string]string{}, Key: messageKey(tsMsg)}
+	msg.Payload, err = EncodePayload(ms.codec, m, msg.Properties)
+	if err != nil {
+		return nil, err
+	}
+	InjectCtx(spanCtx, msg.Properties)
+	return msg, nil
+}
+
+// messageKey returns the key of the message sent for tsMsg, the shard of a dml msg,
+// so a KeyShared subscription consumes the dml of each shard in order.
+func messageKey(tsMsg TsMsg) string {
+	switch msg := tsMsg.(type) {
+	case *InsertMsg:
+		return msg.GetShardName()
+	case *DeleteMsg:
+		return msg.GetShardName()
+	}
+	return ""
+}
+
+func (ms *mqMsgStream) Produce(msgPack *MsgPack) error {
+	result, err := ms.repack(msgPack)
 	if err != nil {
 		return err
 	}
@@ -309,19 +455,11 @@ func (ms *mqMsgStream) Produce(msgPack *MsgPack) error {
 			spanCtx, sp := MsgSpanFromCtx(v.Msgs[i].TraceCtx(), v.Msgs[i])
 			defer sp.End()
 
-			mb, err := v.Msgs[i].Marshal(v.Msgs[i])
+			msg, err := ms.toProducerMessage(spanCtx, v.Msgs[i])
 			if err != nil {
 				return err
 			}
 
-			m, err := convertToByteArray(mb)
-			if err != nil {
-				return err
-			}
-
-			msg := &mqwrapper.ProducerMessage{Payload: m, Properties: map[string]string{}}
-			InjectCtx(spanCtx, msg.Properties)
-
 			ms.producerLock.RLock()
 			if _, err := ms.producers[channel].Send(spanCtx, msg); err != nil {
 				ms.producerLock.RUnlock()
@@ -334,6 +472,36 @@ func (ms *mqMsgStream) Produce(msgPack *MsgPack) error {
 	return nil
 }
 
+// ProduceAsync sends the msgs of msgPack without waiting for the acks, returns the futures of
+// the message ids by channel, in the order the msgs are sent to the channel. The msgs are sent
+// in order within a channel, as each producer publishes messages in the order of calls.
+// A marshal failure stops sending, the msgs sent before are still published.
+func (ms *mqMsgStream) ProduceAsync(msgPack *MsgPack) (map[string][]*conc.Future[MessageID], error) {
+	result, err := ms.repack(msgPack)
+	if err != nil {
+		return nil, err
+	}
+	futures := make(map[string][]*conc.Future[MessageID], len(result))
+	for k, v := range result {
+		channel := ms.producerChannels[k]
+		for i := 0; i < len(v.Msgs); i++ {
+			spanCtx, sp := MsgSpanFromCtx(v.Msgs[i].TraceCtx(), v.Msgs[i])
+			msg, err := ms.toProducerMessage(spanCtx, v.Msgs[i])
+			if err != nil {
+				sp.End()
+				return futures, err
+			}
+
+			ms.producerLock.RLock()
+			future := ms.producers[channel].SendAsync(spanCtx, msg)
+			ms.producerLock.RUnlock()
+			sp.End()
+			futures[channel] = append(futures[channel], future)
+		}
+	}
+	return futures, nil
+}
+
 // BroadcastMark broadcast msg pack to all producers and returns corresponding msg id
 // the returned message id serves as marking
 func (ms *mqMsgStream) Broadcast(msgPack *MsgPack) (map[string][]MessageID, error) {
@@ -352,19 +520,12 @@ func (ms *mqMsgStream) Broadcast(msgPack *MsgPack) (map[string][]MessageID, erro
 	for _, v := range msgPack.Msgs {
 		spanCtx, sp := MsgSpanFromCtx(v.TraceCtx(), v)
 
-		mb, err := v.Marshal(v)
+		msg, err := ms.toProducerMessage(spanCtx, v)
 		if err != nil {
+			sp.End()
 			return ids, err
 		}
 
-		m, err := convertToByteArray(mb)
-		if err != nil {
-			return ids, err
-		}
-
-		msg := &mqwrapper.ProducerMessage{Payload: m, Properties: map[string]string{}}
-		InjectCtx(spanCtx, msg.Properties)
-
 		ms.producerLock.Lock()
 		for channel, producer := range ms.producers {
 			id, err := producer.Send(spanCtx, msg)
@@ -383,18 +544,25 @@ func (ms *mqMsgStream) Broadcast(msgPack *MsgPack) (map[string][]MessageID, erro
 }
 
 func (ms *mqMsgStream) getTsMsgFromConsumerMsg(msg mqwrapper.Message) (TsMsg, error) {
+	if corrupt, ok := msg.(mqwrapper.CorruptMessage); ok && corrupt.ReadErr() != nil {
+		return nil, corrupt.ReadErr()
+	}
 	header := commonpb.MsgHeader{}
 	if msg.Payload() == nil {
 		return nil, fmt.Errorf("failed to unmarshal message header, payload is empty")
 	}
-	err := proto.Unmarshal(msg.Payload(), &header)
+	payload, err := DecodePayload(msg.Payload(), msg.Properties())
+	if err != nil {
+		return nil, err
+	}
+	err = proto.Unmarshal(payload, &header)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal message header, err %s", err.Error())
 	}
 	if header.Base == nil {
 		return nil, fmt.Errorf("failed to unmarshal message, header is uncomplete")
 	}
-	tsMsg, err := ms.unmarshal.Unmarshal(msg.Payload(), header.Base.MsgType)
+	tsMsg, err := ms.unmarshal.Unmarshal(payload, header.Base.MsgType)
 	if err != nil {
 		return nil, fmt.Errorf("failed to unmarshal tsMsg, err %s", err.Error())
 	}
@@ -422,18 +590,18 @@ func (ms *mqMsgStream) receiveMsg(consumer mqwrapper.Consumer) {
 			if !ok {
 				return
 			}
-			consumer.Ack(msg)
-			if msg.Payload() == nil {
-				log.Warn("MqMsgStream get msg whose payload is nil")
-				continue
-			}
 			// not need to check the preCreatedTopic is empty, related issue: https://github.com/milvus-io/milvus/issues/27295
 			// if the message not belong to the topic, will skip it
 			tsMsg, err := ms.getTsMsgFromConsumerMsg(msg)
 			if err != nil {
-				log.Warn("Failed to getTsMsgFromConsumerMsg", zap.Error(err))
+				if err := ms.handleBadMsg(consumer, msg, err); err != nil {
+					ms.abort(err)
+					return
+				}
 				continue
 			}
+			ms.recordReceived(msg, tsMsg)
+			ack := ms.ackReceived(consumer, msg, tsMsg)
 			pos := tsMsg.Position()
 			tsMsg.SetPosition(&MsgPosition{
 				ChannelName: pos.ChannelName,
@@ -452,8 +620,12 @@ func (ms *mqMsgStream) receiveMsg(consumer mqwrapper.Consumer) {
 				BeginTs:        tsMsg.BeginTs(),
 				EndTs:          tsMsg.EndTs(),
 			}
+			if ack != nil {
+				msgPack.acks = []msgAck{*ack}
+			}
 			select {
 			case ms.receiveBuf <- &msgPack:
+				ms.recordReceiveBuf(pos.ChannelName)
 			case <-ms.ctx.Done():
 				return
 			}
@@ -463,9 +635,22 @@ func (ms *mqMsgStream) receiveMsg(consumer mqwrapper.Consumer) {
 
 func (ms *mqMsgStream) Chan() <-chan *MsgPack {
 	ms.onceChan.Do(func() {
+		wg := &sync.WaitGroup{}
 		for _, c := range ms.consumers {
-			go ms.receiveMsg(c)
+			wg.Add(1)
+			go func(c mqwrapper.Consumer) {
+				defer wg.Done()
+				ms.receiveMsg(c)
+			}(c)
 		}
+		consumerNum := len(ms.consumers)
+		go func() {
+			wg.Wait()
+			// closes receiveBuf if a receiver aborts the stream or all the channels end
+			if ms.Err() != nil || consumerNum > 0 {
+				ms.closeReceiveBuf()
+			}
+		}()
 	})
 
 	return ms.receiveBuf
@@ -518,6 +703,13 @@ type MqTtMsgStream struct {
 	chanWaitGroup      *sync.WaitGroup
 	lastTimeStamp      Timestamp
 	syncConsumer       chan int
+	// chanMsgAcks are the acks of the msgs in chanMsgBuf in manual ack mode
+	chanMsgAcks map[TsMsg]*msgAck
+	// chanTickWatch are the time tick watchdogs of the channels
+	chanTickWatch map[mqwrapper.Consumer]*tickWatch
+	// ended is set once a consumer reaches the end of its channel, the stream is closed
+	// after sending the last complete pack
+	ended atomic.Bool
 }
 
 // NewMqTtMsgStream is used to generate a new MqTtMsgStream object
@@ -547,6 +739,8 @@ func NewMqTtMsgStream(ctx context.Context,
 		chanTtMsgTimeMutex: &sync.RWMutex{},
 		chanWaitGroup:      &sync.WaitGroup{},
 		syncConsumer:       syncConsumer,
+		chanMsgAcks:        make(map[TsMsg]*msgAck),
+		chanTickWatch:      make(map[mqwrapper.Consumer]*tickWatch),
 	}, nil
 }
 
@@ -563,6 +757,10 @@ func (ms *MqTtMsgStream) addConsumer(consumer mqwrapper.Consumer, channel string
 		Timestamp:   ms.lastTimeStamp,
 	}
 	ms.chanStopChan[consumer] = make(chan bool)
+	ms.chanTtMsgTimeMutex.Lock()
+	ms.chanTickWatch[consumer] = &tickWatch{channel: channel}
+	ms.chanTtMsgTimeMutex.Unlock()
+	ms.registerChannelStats(channel)
 	ms.chanTtMsgTime[consumer] = 0
 }
 
@@ -578,6 +776,8 @@ func (ms *MqTtMsgStream) AsConsumer(ctx context.Context, channels []string, subN
 				SubscriptionName:            subName,
 				SubscriptionInitialPosition: position,
 				BufSize:                     ms.bufSize,
+				SubscriptionType:            ms.subType,
+				KeepSubscription:            ms.manualAck,
 			})
 			if err != nil {
 				return err
@@ -660,6 +860,7 @@ func (ms *MqTtMsgStream) bufMsgPackToChannel() {
 			return
 		default:
 			timeTickBuf := make([]TsMsg, 0)
+			acks := make([]msgAck, 0)
 			// startMsgPosition := make([]*msgpb.MsgPosition, 0)
 			// endMsgPositions := make([]*msgpb.MsgPosition, 0)
 			startPositions := make(map[string]*msgpb.MsgPosition)
@@ -673,10 +874,19 @@ func (ms *MqTtMsgStream) bufMsgPackToChannel() {
 				for _, consumer := range ms.consumers {
 					if !chanTtMsgSync[consumer] {
 						ms.chanWaitGroup.Add(1)
+						ms.startWaiting(consumer)
 						go ms.consumeToTtMsg(consumer)
 					}
 				}
-				ms.chanWaitGroup.Wait()
+				if !ms.waitTimeTicks() {
+					ms.consumerLock.Unlock()
+					return
+				}
+				// no more time tick could be reached by all the channels
+				if ms.ended.Load() {
+					ms.consumerLock.Unlock()
+					break
+				}
 
 				// block here until all channels reach same timetick
 				currTs, ok := ms.allChanReachSameTtMsg(chanTtMsgSync)
@@ -701,16 +911,19 @@ func (ms *MqTtMsgStream) bufMsgPackToChannel() {
 					for _, v := range msgs {
 						if v.Type() == commonpb.MsgType_TimeTick {
 							timeTickMsg = v
+							acks = ms.takeAck(acks, v)
 							continue
 						}
 						if v.EndTs() <= currTs {
 							size += uint64(v.Size())
 							timeTickBuf = append(timeTickBuf, v)
+							acks = ms.takeAck(acks, v)
 						} else {
 							tempBuffer = append(tempBuffer, v)
 						}
 					}
 					ms.chanMsgBuf[consumer] = tempBuffer
+					metrics.MsgStreamBufferedMsgs.WithLabelValues(channelName).Set(float64(len(tempBuffer)))
 
 					//	startMsgPosition = append(startMsgPosition, proto.Clone(ms.chanMsgPos[consumer]).(*msgpb.MsgPosition))
 					var newPos *msgpb.MsgPosition
@@ -744,6 +957,7 @@ func (ms *MqTtMsgStream) bufMsgPackToChannel() {
 			for _, msg := range timeTickBuf {
 				if isDMLMsg(msg) && idset.Contain(msg.ID()) {
 					log.Warn("mqTtMsgStream, found duplicated msg", zap.Int64("msgID", msg.ID()))
+					ms.recordDiscarded(msg.Position().GetChannelName(), discardDuplicate)
 					continue
 				}
 				idset.Insert(msg.ID())
@@ -758,15 +972,23 @@ func (ms *MqTtMsgStream) bufMsgPackToChannel() {
 					Msgs:           uniqueMsgs,
 					StartPositions: lo.MapToSlice(startPositions, func(_ string, pos *msgpb.MsgPosition) *msgpb.MsgPosition { return pos }),
 					EndPositions:   lo.MapToSlice(endPositions, func(_ string, pos *msgpb.MsgPosition) *msgpb.MsgPosition { return pos }),
+					// the duplicated msgs are acked with the pack
+					acks: acks,
 				}
 
 				select {
 				case ms.receiveBuf <- &msgPack:
+					ms.recordReceiveBuf(lo.Keys(endPositions)...)
 				case <-ms.ctx.Done():
 					return
 				}
 				ms.lastTimeStamp = endTs
 			}
+			if ms.ended.Load() {
+				log.Info("msgstream reached the end of channels", zap.Uint64("lastTimeStamp", ms.lastTimeStamp))
+				ms.closeReceiveBuf()
+				return
+			}
 		}
 	}
 }
@@ -782,30 +1004,37 @@ func (ms *MqTtMsgStream) consumeToTtMsg(consumer mqwrapper.Consumer) {
 			return
 		case msg, ok := <-consumer.Chan():
 			if !ok {
-				log.Debug("consumer closed!")
+				// a finite channel, such as an archive, is closed at its end
+				if ms.ctx.Err() == nil {
+					log.Info("consumer reached the end of channel", zap.String("subscription", consumer.Subscription()))
+					ms.ended.Store(true)
+				}
 				return
 			}
-			consumer.Ack(msg)
-
-			if msg.Payload() == nil {
-				log.Warn("MqTtMsgStream get msg whose payload is nil")
-				continue
-			}
 			// not need to check the preCreatedTopic is empty, related issue: https://github.com/milvus-io/milvus/issues/27295
 			// if the message not belong to the topic, will skip it
 			tsMsg, err := ms.getTsMsgFromConsumerMsg(msg)
 			if err != nil {
-				log.Warn("Failed to getTsMsgFromConsumerMsg", zap.Error(err))
+				if err := ms.handleBadMsg(consumer, msg, err); err != nil {
+					ms.abort(err)
+					return
+				}
 				continue
 			}
+			ms.recordReceived(msg, tsMsg)
 
 			ms.chanMsgBufMutex.Lock()
 			ms.chanMsgBuf[consumer] = append(ms.chanMsgBuf[consumer], tsMsg)
+			if ack := ms.ackReceived(consumer, msg, tsMsg); ack != nil {
+				ms.chanMsgAcks[tsMsg] = ack
+			}
+			metrics.MsgStreamBufferedMsgs.WithLabelValues(tsMsg.Position().ChannelName).Set(float64(len(ms.chanMsgBuf[consumer])))
 			ms.chanMsgBufMutex.Unlock()
 
 			if tsMsg.Type() == commonpb.MsgType_TimeTick {
 				ms.chanTtMsgTimeMutex.Lock()
 				ms.chanTtMsgTime[consumer] = tsMsg.(*TimeTickMsg).Base.Timestamp
+				ms.tickReceived(consumer, ms.chanTtMsgTime[consumer])
 				ms.chanTtMsgTimeMutex.Unlock()
 				return
 			}
@@ -813,6 +1042,15 @@ func (ms *MqTtMsgStream) consumeToTtMsg(consumer mqwrapper.Consumer) {
 	}
 }
 
+// takeAck appends the ack of msg to acks in manual ack mode, must be called with chanMsgBufMutex held.
+func (ms *MqTtMsgStream) takeAck(acks []msgAck, msg TsMsg) []msgAck {
+	if ack, ok := ms.chanMsgAcks[msg]; ok {
+		delete(ms.chanMsgAcks, msg)
+		return append(acks, *ack)
+	}
+	return acks
+}
+
 // return true only when all channels reach same timetick
 func (ms *MqTtMsgStream) allChanReachSameTtMsg(chanTtMsgSync map[mqwrapper.Consumer]bool) (Timestamp, bool) {
 	tsMap := make(map[Timestamp]int)
@@ -912,18 +1150,16 @@ func (ms *MqTtMsgStream) Seek(ctx context.Context, msgPositions []*msgpb.MsgPosi
 				if !ok {
 					return fmt.Errorf("consumer closed")
 				}
-				consumer.Ack(msg)
-
-				headerMsg := commonpb.MsgHeader{}
-				err := proto.Unmarshal(msg.Payload(), &headerMsg)
-				if err != nil {
-					return fmt.Errorf("failed to unmarshal message header, err %s", err.Error())
-				}
-				tsMsg, err := ms.unmarshal.Unmarshal(msg.Payload(), headerMsg.Base.MsgType)
+				tsMsg, err := ms.getTsMsgFromConsumerMsg(msg)
 				if err != nil {
-					return fmt.Errorf("failed to unmarshal tsMsg, err %s", err.Error())
+					if err := ms.handleBadMsg(consumer, msg, err); err != nil {
+						return err
+					}
+					continue
 				}
+				ms.recordReceived(msg, tsMsg)
 				if tsMsg.Type() == commonpb.MsgType_TimeTick && tsMsg.BeginTs() >= mp.Timestamp {
+					consumer.Ack(msg)
 					runLoop = false
 				} else if tsMsg.BeginTs() > mp.Timestamp {
 					ctx, _ := ExtractCtx(tsMsg, msg.Properties())
@@ -934,7 +1170,14 @@ func (ms *MqTtMsgStream) Seek(ctx context.Context, msgPositions []*msgpb.MsgPosi
 						MsgID:       msg.ID().Serialize(),
 					})
 					ms.chanMsgBuf[consumer] = append(ms.chanMsgBuf[consumer], tsMsg)
+					if ack := ms.ackReceived(consumer, msg, tsMsg); ack != nil {
+						ms.chanMsgBufMutex.Lock()
+						ms.chanMsgAcks[tsMsg] = ack
+						ms.chanMsgBufMutex.Unlock()
+					}
 				} else {
+					consumer.Ack(msg)
+					ms.recordDiscarded(filepath.Base(msg.Topic()), discardSkipped)
 					log.Info("skip msg",
 						zap.Int64("source", tsMsg.SourceID()),
 						zap.String("type", tsMsg.Type().String()),
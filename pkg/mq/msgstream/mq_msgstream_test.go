@@ -0,0 +1,763 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgstream
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/msgpb"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper/archive"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper/memory"
+	"github.com/xige-16/stream-read/pkg/util/conc"
+	"github.com/xige-16/stream-read/pkg/util/merr"
+	"github.com/xige-16/stream-read/pkg/util/paramtable"
+	"github.com/xige-16/stream-read/pkg/util/tsoutil"
+)
+
+// optionsClient records the options of the producers created by a memory mq client.
+type optionsClient struct {
+	*memory.Client
+	options map[string]mqwrapper.ProducerOptions
+}
+
+func (c *optionsClient) CreateProducer(options mqwrapper.ProducerOptions) (mqwrapper.Producer, error) {
+	c.options[options.Topic] = options
+	return c.Client.CreateProducer(options)
+}
+
+func TestMqMsgStream_ProduceAsync(t *testing.T) {
+	paramtable.Init()
+	ctx := context.Background()
+	mq := memory.NewClient()
+	defer mq.Close()
+	client := &optionsClient{Client: mq.NewClient(), options: make(map[string]mqwrapper.ProducerOptions)}
+	factory := &ProtoUDFactory{}
+	stream, err := NewMqMsgStream(ctx, 16, 16, client, factory.NewUnmarshalDispatcher())
+	assert.NoError(t, err)
+	channels := []string{"dml_0", "dml_1"}
+	stream.AsProducer(channels)
+	defer stream.Close()
+	assert.False(t, client.options["dml_0"].EnableBatching)
+
+	t.Run("ordered within channel", func(t *testing.T) {
+		pack := &MsgPack{}
+		for i := 0; i < 10; i++ {
+			msg, keys := newRepackInsertMsg(4, len(channels))
+			msg.HashValues = make([]uint32, len(keys))
+			for j, key := range keys {
+				msg.HashValues[j] = uint32(key)
+			}
+			msg.Base.Timestamp = uint64(100 + i)
+			pack.Msgs = append(pack.Msgs, msg)
+		}
+		futures, err := stream.ProduceAsync(pack)
+		assert.NoError(t, err)
+		assert.Len(t, futures, 2)
+		for _, channel := range channels {
+			assert.Len(t, futures[channel], 10)
+			ids := make([]int64, 0)
+			for _, f := range futures[channel] {
+				id, err := f.Await()
+				assert.NoError(t, err)
+				offset, err := memory.DeserializeMemoryMsgID(id.Serialize())
+				assert.NoError(t, err)
+				ids = append(ids, offset)
+			}
+			assert.IsIncreasing(t, ids)
+
+			consumer, err := mq.Subscribe(mqwrapper.ConsumerOptions{
+				Topic:                       channel,
+				SubscriptionName:            "sub",
+				SubscriptionInitialPosition: mqwrapper.SubscriptionPositionEarliest,
+				SubscriptionType:            mqwrapper.Exclusive,
+				BufSize:                     16,
+			})
+			require.NoError(t, err)
+			var lastTs uint64
+			for i := 0; i < 10; i++ {
+				var payload []byte
+				select {
+				case msg := <-consumer.Chan():
+					payload = msg.Payload()
+				case <-time.After(5 * time.Second):
+					t.Fatal("no msg received")
+				}
+				msg, err := (&InsertMsg{}).Unmarshal(payload)
+				assert.NoError(t, err)
+				imsg := msg.(*InsertMsg)
+				assert.EqualValues(t, 2, imsg.GetNumRows())
+				assert.Greater(t, imsg.GetBase().GetTimestamp(), lastTs)
+				lastTs = imsg.GetBase().GetTimestamp()
+			}
+			assert.Empty(t, consumer.Chan())
+			consumer.Close()
+		}
+	})
+
+	t.Run("empty pack", func(t *testing.T) {
+		futures, err := stream.ProduceAsync(&MsgPack{})
+		assert.NoError(t, err)
+		assert.Empty(t, futures)
+	})
+
+	t.Run("produce disabled", func(t *testing.T) {
+		stream.EnableProduce(false)
+		defer stream.EnableProduce(true)
+		msg, _ := newRepackInsertMsg(1, 1)
+		msg.HashValues = []uint32{0}
+		_, err := stream.ProduceAsync(&MsgPack{Msgs: []TsMsg{msg}})
+		assert.ErrorIs(t, err, merr.ErrDenyProduceMsg)
+	})
+
+	// the sends fail once the client is closed, so it runs last
+	t.Run("send failure", func(t *testing.T) {
+		client.Close()
+		msg, _ := newRepackInsertMsg(1, 1)
+		msg.HashValues = []uint32{1}
+		futures, err := stream.ProduceAsync(&MsgPack{Msgs: []TsMsg{msg}})
+		assert.NoError(t, err)
+		_, err = futures["dml_1"][0].Await()
+		assert.Error(t, err)
+		assert.Error(t, conc.AwaitAll(futures["dml_1"]...))
+	})
+}
+
+func newTimeTickPack(ts uint64) *MsgPack {
+	return &MsgPack{
+		BeginTs: ts,
+		EndTs:   ts,
+		Msgs: []TsMsg{&TimeTickMsg{
+			BaseMsg: BaseMsg{BeginTimestamp: ts, EndTimestamp: ts, HashValues: []uint32{0}},
+			TimeTickMsg: msgpb.TimeTickMsg{
+				Base: &commonpb.MsgBase{MsgType: commonpb.MsgType_TimeTick, Timestamp: ts},
+			},
+		}},
+	}
+}
+
+func receivePack(t *testing.T, stream MsgStream) *MsgPack {
+	t.Helper()
+	select {
+	case pack := <-stream.Chan():
+		require.NotNil(t, pack)
+		return pack
+	case <-time.After(5 * time.Second):
+		t.Fatal("no msg pack received")
+	}
+	return nil
+}
+
+func TestMqTtMsgStream_ManualAck(t *testing.T) {
+	paramtable.Init()
+	ctx := context.Background()
+	mq := memory.NewClient()
+	defer mq.Close()
+	factory := &ProtoUDFactory{}
+	channels := []string{"dml_0"}
+
+	producer, err := NewMqMsgStream(ctx, 16, 16, mq.NewClient(), factory.NewUnmarshalDispatcher())
+	require.NoError(t, err)
+	defer producer.Close()
+	producer.AsProducer(channels)
+	var logical int64
+	produceTick := func() uint64 {
+		// the ticks must increase even in the same millisecond
+		logical++
+		ts := tsoutil.ComposeTSByTime(time.Now(), logical)
+		_, err := producer.Broadcast(newTimeTickPack(ts))
+		require.NoError(t, err)
+		return ts
+	}
+
+	newConsumer := func(position mqwrapper.SubscriptionInitialPosition) MsgStream {
+		stream, err := NewMqTtMsgStream(ctx, 16, 16, mq.NewClient(), factory.NewUnmarshalDispatcher())
+		require.NoError(t, err)
+		stream.SetManualAck(true)
+		require.NoError(t, stream.AsConsumer(ctx, channels, "sub", position))
+		return stream
+	}
+
+	insertMsg, _ := newRepackInsertMsg(2, 1)
+	insertMsg.HashValues = []uint32{0, 0}
+	insertTs := tsoutil.ComposeTSByTime(time.Now(), 0)
+	insertMsg.Timestamps = []uint64{insertTs, insertTs}
+	require.NoError(t, producer.Produce(&MsgPack{Msgs: []TsMsg{insertMsg}}))
+	produceTick()
+
+	// not acked before closed
+	stream := newConsumer(mqwrapper.SubscriptionPositionEarliest)
+	pack := receivePack(t, stream)
+	assert.Len(t, pack.Msgs, 1)
+	stream.Close()
+
+	// redelivered to the next consumer of the subscription
+	stream = newConsumer(mqwrapper.SubscriptionPositionLatest)
+	pack = receivePack(t, stream)
+	assert.Len(t, pack.Msgs, 1)
+	assert.Equal(t, commonpb.MsgType_Insert, pack.Msgs[0].Type())
+
+	// redelivered by nack
+	stream.Nack(pack)
+	produceTick()
+	pack = receivePack(t, stream)
+	assert.Len(t, pack.Msgs, 1)
+	stream.AckPack(pack)
+	stream.Close()
+
+	// the cursor moves after acked
+	stream = newConsumer(mqwrapper.SubscriptionPositionLatest)
+	defer stream.Close()
+	lastTick := produceTick()
+	pack = receivePack(t, stream)
+	assert.Empty(t, pack.Msgs)
+	assert.Equal(t, lastTick, pack.EndTs)
+	stream.AckPack(pack)
+}
+
+func TestMqMsgStream_KeyShared(t *testing.T) {
+	paramtable.Init()
+	ctx := context.Background()
+	mq := memory.NewClient()
+	defer mq.Close()
+	factory := &ProtoUDFactory{}
+	channels := []string{"dml_0"}
+
+	consumers := make([]MsgStream, 2)
+	for i := range consumers {
+		stream, err := NewMqMsgStream(ctx, 16, 16, mq.NewClient(), factory.NewUnmarshalDispatcher())
+		require.NoError(t, err)
+		defer stream.Close()
+		stream.SetSubscriptionType(mqwrapper.KeyShared)
+		require.NoError(t, stream.AsConsumer(ctx, channels, "sub", mqwrapper.SubscriptionPositionEarliest))
+		consumers[i] = stream
+	}
+
+	producer, err := NewMqMsgStream(ctx, 16, 16, mq.NewClient(), factory.NewUnmarshalDispatcher())
+	require.NoError(t, err)
+	defer producer.Close()
+	producer.AsProducer(channels)
+	for i := 0; i < 20; i++ {
+		insertMsg, _ := newRepackInsertMsg(1, 1)
+		insertMsg.HashValues = []uint32{0}
+		insertMsg.ShardName = fmt.Sprintf("shard_%d", i%4)
+		insertMsg.RowIDs = []int64{int64(i)}
+		require.NoError(t, producer.Produce(&MsgPack{Msgs: []TsMsg{insertMsg}}))
+	}
+
+	owners := make(map[string]int)
+	total := 0
+	for i, stream := range consumers {
+		lastRowIDs := make(map[string]int64)
+	loop:
+		for {
+			select {
+			case pack := <-stream.Chan():
+				for _, msg := range pack.Msgs {
+					total++
+					insertMsg := msg.(*InsertMsg)
+					shard := insertMsg.GetShardName()
+					// all the msgs of one shard go to one consumer
+					if owner, ok := owners[shard]; ok {
+						assert.Equal(t, i, owner)
+					}
+					owners[shard] = i
+					// in order within a shard
+					if last, ok := lastRowIDs[shard]; ok {
+						assert.Greater(t, insertMsg.GetRowIDs()[0], last)
+					}
+					lastRowIDs[shard] = insertMsg.GetRowIDs()[0]
+				}
+			case <-time.After(200 * time.Millisecond):
+				break loop
+			}
+		}
+	}
+	assert.Equal(t, 20, total)
+	assert.Len(t, owners, 4)
+}
+
+func TestMqTtMsgStream_SeekArchive(t *testing.T) {
+	paramtable.Init()
+	ctx := context.Background()
+	mq := memory.NewClient()
+	defer mq.Close()
+	factory := &ProtoUDFactory{}
+	channels := []string{"dml_0"}
+
+	producer, err := NewMqMsgStream(ctx, 16, 16, mq.NewClient(), factory.NewUnmarshalDispatcher())
+	require.NoError(t, err)
+	defer producer.Close()
+	producer.AsProducer(channels)
+	now := time.Now()
+	for i := int64(0); i < 2; i++ {
+		insertMsg, _ := newRepackInsertMsg(2, 1)
+		insertMsg.HashValues = []uint32{0, 0}
+		insertTs := tsoutil.ComposeTSByTime(now, 2*i)
+		insertMsg.Timestamps = []uint64{insertTs, insertTs}
+		require.NoError(t, producer.Produce(&MsgPack{Msgs: []TsMsg{insertMsg}}))
+		_, err := producer.Broadcast(newTimeTickPack(tsoutil.ComposeTSByTime(now, 2*i+1)))
+		require.NoError(t, err)
+	}
+
+	// archive the raw messages consumed in manual ack mode
+	dir := t.TempDir()
+	writer, err := archive.NewWriter(dir, archive.WriterConfig{})
+	require.NoError(t, err)
+	stream, err := NewMqMsgStream(ctx, 16, 16, mq.NewClient(), factory.NewUnmarshalDispatcher())
+	require.NoError(t, err)
+	stream.SetManualAck(true)
+	require.NoError(t, stream.AsConsumer(ctx, channels, "archive", mqwrapper.SubscriptionPositionEarliest))
+	for i := 0; i < 4; i++ {
+		pack := receivePack(t, stream)
+		raws := pack.RawMessages()
+		require.Len(t, raws, 1)
+		require.NoError(t, writer.Write(pack.Msgs[0].Position().GetChannelName(), pack.BeginTs, raws[0]))
+		stream.AckPack(pack)
+		assert.Empty(t, pack.RawMessages())
+	}
+	stream.Close()
+	require.NoError(t, writer.Close())
+
+	// the position recorded against the broker seeks the archive the same
+	live, err := NewMqTtMsgStream(ctx, 16, 16, mq.NewClient(), factory.NewUnmarshalDispatcher())
+	require.NoError(t, err)
+	require.NoError(t, live.AsConsumer(ctx, channels, "live", mqwrapper.SubscriptionPositionEarliest))
+	position := receivePack(t, live).EndPositions[0]
+	live.Close()
+
+	archiveClient, err := archive.NewClient(dir)
+	require.NoError(t, err)
+	for name, client := range map[string]mqwrapper.Client{"broker": mq.NewClient(), "archive": archiveClient} {
+		t.Run(name, func(t *testing.T) {
+			stream, err := NewMqTtMsgStream(ctx, 16, 16, client, factory.NewUnmarshalDispatcher())
+			require.NoError(t, err)
+			defer stream.Close()
+			require.NoError(t, stream.AsConsumer(ctx, channels, "seek", mqwrapper.SubscriptionPositionUnknown))
+			require.NoError(t, stream.Seek(ctx, []*msgpb.MsgPosition{position}))
+			pack := receivePack(t, stream)
+			require.Len(t, pack.Msgs, 1)
+			assert.Equal(t, tsoutil.ComposeTSByTime(now, 2), pack.Msgs[0].BeginTs())
+			assert.Equal(t, tsoutil.ComposeTSByTime(now, 3), pack.EndTs)
+		})
+	}
+
+	t.Run("end of archive", func(t *testing.T) {
+		stream, err := NewMqTtMsgStream(ctx, 16, 16, archiveClient, factory.NewUnmarshalDispatcher())
+		require.NoError(t, err)
+		defer stream.Close()
+		require.NoError(t, stream.AsConsumer(ctx, channels, "end", mqwrapper.SubscriptionPositionEarliest))
+		var packs []*MsgPack
+		for {
+			select {
+			case pack, ok := <-stream.Chan():
+				if ok {
+					packs = append(packs, pack)
+					continue
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("msgstream not closed at the end of the archive")
+			}
+			break
+		}
+		assert.NoError(t, stream.Err())
+		require.Len(t, packs, 2)
+		for i, pack := range packs {
+			require.Len(t, pack.Msgs, 1)
+			assert.Equal(t, tsoutil.ComposeTSByTime(now, int64(2*i)), pack.Msgs[0].BeginTs())
+			assert.Equal(t, tsoutil.ComposeTSByTime(now, int64(2*i+1)), pack.EndTs)
+		}
+	})
+
+	t.Run("truncated archive", func(t *testing.T) {
+		// the last time tick is cut
+		logs, err := filepath.Glob(filepath.Join(dir, "dml_0", "*.log"))
+		require.NoError(t, err)
+		require.Len(t, logs, 1)
+		info, err := os.Stat(logs[0])
+		require.NoError(t, err)
+		require.NoError(t, os.Truncate(logs[0], info.Size()-4))
+		archiveClient, err := archive.NewClient(dir)
+		require.NoError(t, err)
+
+		consume := func(t *testing.T, policy string) (MsgStream, []*MsgPack) {
+			stream, err := NewMqTtMsgStream(ctx, 16, 16, archiveClient, factory.NewUnmarshalDispatcher())
+			require.NoError(t, err)
+			t.Cleanup(stream.Close)
+			require.NoError(t, stream.SetBadMsgPolicy(policy, nil))
+			require.NoError(t, stream.AsConsumer(ctx, channels, "truncated", mqwrapper.SubscriptionPositionEarliest))
+			var packs []*MsgPack
+			for {
+				select {
+				case pack, ok := <-stream.Chan():
+					if ok {
+						packs = append(packs, pack)
+						continue
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatal("msgstream not closed at the end of the archive")
+				}
+				return stream, packs
+			}
+		}
+
+		stream, packs := consume(t, BadMsgPolicyFail)
+		assert.Len(t, packs, 1)
+		assert.ErrorIs(t, stream.Err(), merr.ErrMqBadMessage)
+
+		stream, packs = consume(t, BadMsgPolicySkip)
+		assert.Len(t, packs, 1)
+		assert.NoError(t, stream.Err())
+		assert.EqualValues(t, 1, stream.Stats().Channels["dml_0"].BadMsgs)
+	})
+}
+
+func TestMqTtMsgStream_TickStall(t *testing.T) {
+	paramtable.Init()
+	params := paramtable.Get()
+	params.Save(params.MQCfg.TickStallTimeout.Key, "0.1")
+	defer params.Reset(params.MQCfg.TickStallTimeout.Key)
+	ctx := context.Background()
+	factory := &ProtoUDFactory{}
+
+	// setup returns a stream of channels, and tick produces a time tick of ts to the channels
+	setup := func(t *testing.T, policy string, channels ...string) (*MqTtMsgStream, func(ts uint64, channels ...string)) {
+		params.Save(params.MQCfg.TickStallPolicy.Key, policy)
+		t.Cleanup(func() { params.Reset(params.MQCfg.TickStallPolicy.Key) })
+		mq := memory.NewClient()
+		t.Cleanup(mq.Close)
+		producers := make(map[string]MsgStream)
+		for _, channel := range channels {
+			producer, err := NewMqMsgStream(ctx, 16, 16, mq.NewClient(), factory.NewUnmarshalDispatcher())
+			require.NoError(t, err)
+			producer.AsProducer([]string{channel})
+			producers[channel] = producer
+			t.Cleanup(producer.Close)
+		}
+		tick := func(ts uint64, channels ...string) {
+			for _, channel := range channels {
+				_, err := producers[channel].Broadcast(newTimeTickPack(ts))
+				require.NoError(t, err)
+			}
+		}
+		stream, err := NewMqTtMsgStream(ctx, 16, 16, mq.NewClient(), factory.NewUnmarshalDispatcher())
+		require.NoError(t, err)
+		require.NoError(t, stream.AsConsumer(ctx, channels, "sub", mqwrapper.SubscriptionPositionEarliest))
+		t.Cleanup(stream.Close)
+		return stream, tick
+	}
+	now := time.Now()
+	ts1 := tsoutil.ComposeTSByTime(now, 1)
+	ts2 := tsoutil.ComposeTSByTime(now.Add(time.Second), 0)
+	ts3 := tsoutil.ComposeTSByTime(now.Add(2*time.Second), 0)
+
+	t.Run("wait", func(t *testing.T) {
+		stream, tick := setup(t, StallPolicyWait, "dml_0", "dml_1")
+		tick(ts1, "dml_0", "dml_1")
+		assert.Equal(t, ts1, receivePack(t, stream).EndTs)
+
+		tick(ts2, "dml_0")
+		assert.Eventually(t, func() bool {
+			return stream.Stats().Channels["dml_1"].Stalled
+		}, 5*time.Second, 10*time.Millisecond)
+		stats := stream.Stats().Channels
+		assert.False(t, stats["dml_0"].Stalled)
+		assert.Equal(t, ts2, stats["dml_0"].LastTimeTick)
+		assert.Equal(t, time.Second, stats["dml_1"].TickLag)
+		assert.Greater(t, stats["dml_1"].Waited, 100*time.Millisecond)
+
+		// resumed once the channel catches up
+		tick(ts2, "dml_1")
+		assert.Equal(t, ts2, receivePack(t, stream).EndTs)
+		stats = stream.Stats().Channels
+		assert.False(t, stats["dml_1"].Stalled)
+		assert.Equal(t, ts2, stats["dml_1"].LastTimeTick)
+		assert.NoError(t, stream.Err())
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		stream, tick := setup(t, StallPolicyFail, "dml_0", "dml_1")
+		tick(ts1, "dml_0", "dml_1")
+		assert.Equal(t, ts1, receivePack(t, stream).EndTs)
+
+		tick(ts2, "dml_0")
+		select {
+		case _, ok := <-stream.Chan():
+			assert.False(t, ok)
+		case <-time.After(5 * time.Second):
+			t.Fatal("stream not failed")
+		}
+		assert.ErrorIs(t, stream.Err(), merr.ErrMqChannelStalled)
+	})
+
+	t.Run("drop", func(t *testing.T) {
+		stream, tick := setup(t, StallPolicyDrop, "dml_0", "dml_1")
+		tick(ts1, "dml_0", "dml_1")
+		assert.Equal(t, ts1, receivePack(t, stream).EndTs)
+
+		// go on with the other channel
+		tick(ts2, "dml_0")
+		assert.Equal(t, ts2, receivePack(t, stream).EndTs)
+		tick(ts3, "dml_0")
+		assert.Equal(t, ts3, receivePack(t, stream).EndTs)
+		stats := stream.Stats().Channels
+		assert.True(t, stats["dml_1"].Dropped)
+		assert.False(t, stats["dml_0"].Dropped)
+		assert.NoError(t, stream.Err())
+	})
+
+	t.Run("drop the last channel", func(t *testing.T) {
+		stream, _ := setup(t, StallPolicyDrop, "dml_0")
+		select {
+		case _, ok := <-stream.Chan():
+			assert.False(t, ok)
+		case <-time.After(5 * time.Second):
+			t.Fatal("stream not failed")
+		}
+		assert.ErrorIs(t, stream.Err(), merr.ErrMqChannelStalled)
+	})
+}
+
+func TestMqTtMsgStream_Stats(t *testing.T) {
+	paramtable.Init()
+	ctx := context.Background()
+	mq := memory.NewClient()
+	defer mq.Close()
+	factory := &ProtoUDFactory{}
+	channels := []string{"dml_0"}
+
+	producer, err := NewMqMsgStream(ctx, 16, 16, mq.NewClient(), factory.NewUnmarshalDispatcher())
+	require.NoError(t, err)
+	defer producer.Close()
+	producer.AsProducer(channels)
+
+	// the insert is sent twice, and a bad message follows the time tick
+	msg, _ := newRepackInsertMsg(4, 1)
+	msg.HashValues = []uint32{0}
+	msg.Base.MsgID = 1
+	for i := 0; i < 2; i++ {
+		_, err = producer.Broadcast(&MsgPack{Msgs: []TsMsg{msg}})
+		require.NoError(t, err)
+	}
+	_, err = producer.Broadcast(newTimeTickPack(200))
+	require.NoError(t, err)
+	rawProducer, err := mq.NewClient().CreateProducer(mqwrapper.ProducerOptions{Topic: "dml_0"})
+	require.NoError(t, err)
+	defer rawProducer.Close()
+	_, err = rawProducer.Send(ctx, &mqwrapper.ProducerMessage{Payload: []byte("bad payload")})
+	require.NoError(t, err)
+
+	stream, err := NewMqTtMsgStream(ctx, 16, 16, mq.NewClient(), factory.NewUnmarshalDispatcher())
+	require.NoError(t, err)
+	defer stream.Close()
+	require.NoError(t, stream.AsConsumer(ctx, channels, "sub", mqwrapper.SubscriptionPositionEarliest))
+	// starts consuming
+	packs := stream.Chan()
+
+	assert.Eventually(t, func() bool {
+		stats := stream.Stats()
+		return stats.ReceiveBufLen == 1 && stats.Channels["dml_0"].BadMsgs == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	stats := stream.Stats()
+	assert.Equal(t, 16, stats.ReceiveBufCap)
+	s := stats.Channels["dml_0"]
+	assert.EqualValues(t, 2, s.ReceivedMsgs[commonpb.MsgType_Insert])
+	assert.EqualValues(t, 1, s.ReceivedMsgs[commonpb.MsgType_TimeTick])
+	assert.Greater(t, s.ReceivedBytes[commonpb.MsgType_Insert], s.ReceivedBytes[commonpb.MsgType_TimeTick])
+	assert.EqualValues(t, 1, s.DuplicateMsgs)
+	assert.EqualValues(t, 0, s.SkippedMsgs)
+	assert.EqualValues(t, 200, s.LastTimeTick)
+	assert.Equal(t, 0, s.BufferedMsgs)
+	require.NotNil(t, s.LastMsgID)
+	offset, err := memory.DeserializeMemoryMsgID(s.LastMsgID.Serialize())
+	assert.NoError(t, err)
+	// the bad message is not counted as received
+	assert.EqualValues(t, 2, offset)
+
+	pack := <-packs
+	require.Len(t, pack.Msgs, 1)
+	assert.Equal(t, 0, stream.Stats().ReceiveBufLen)
+
+	t.Run("seek", func(t *testing.T) {
+		seeker, err := NewMqTtMsgStream(ctx, 16, 16, mq.NewClient(), factory.NewUnmarshalDispatcher())
+		require.NoError(t, err)
+		defer seeker.Close()
+		require.NoError(t, seeker.AsConsumer(ctx, channels, "seek", mqwrapper.SubscriptionPositionUnknown))
+		err = seeker.Seek(ctx, []*MsgPosition{{
+			ChannelName: "dml_0",
+			MsgID:       pack.Msgs[0].Position().GetMsgID(),
+			Timestamp:   pack.EndTs,
+		}})
+		require.NoError(t, err)
+		s := seeker.Stats().Channels["dml_0"]
+		assert.EqualValues(t, 2, s.SkippedMsgs)
+		assert.EqualValues(t, 2, s.ReceivedMsgs[commonpb.MsgType_Insert])
+	})
+}
+
+func TestMqMsgStream_BadMsgPolicy(t *testing.T) {
+	paramtable.Init()
+	ctx := context.Background()
+	factory := &ProtoUDFactory{}
+	channels := []string{"dml_0"}
+	now := time.Now()
+	ts1 := tsoutil.ComposeTSByTime(now, 0)
+	ts2 := tsoutil.ComposeTSByTime(now.Add(time.Second), 0)
+
+	// setup produces a time tick, a bad message and another time tick
+	setup := func(t *testing.T) *memory.Client {
+		mq := memory.NewClient()
+		t.Cleanup(mq.Close)
+		producer, err := NewMqMsgStream(ctx, 16, 16, mq.NewClient(), factory.NewUnmarshalDispatcher())
+		require.NoError(t, err)
+		defer producer.Close()
+		producer.AsProducer(channels)
+		rawProducer, err := mq.NewClient().CreateProducer(mqwrapper.ProducerOptions{Topic: "dml_0"})
+		require.NoError(t, err)
+		defer rawProducer.Close()
+
+		_, err = producer.Broadcast(newTimeTickPack(ts1))
+		require.NoError(t, err)
+		_, err = rawProducer.Send(ctx, &mqwrapper.ProducerMessage{Payload: []byte("bad payload")})
+		require.NoError(t, err)
+		_, err = producer.Broadcast(newTimeTickPack(ts2))
+		require.NoError(t, err)
+		return mq
+	}
+	newStream := func(t *testing.T, mq *memory.Client, policy string, quarantine QuarantineFunc) MsgStream {
+		stream, err := NewMqTtMsgStream(ctx, 16, 16, mq.NewClient(), factory.NewUnmarshalDispatcher())
+		require.NoError(t, err)
+		t.Cleanup(stream.Close)
+		require.NoError(t, stream.SetBadMsgPolicy(policy, quarantine))
+		return stream
+	}
+	// assertFailed waits for Chan closed, the packs before the bad message may be received first
+	assertFailed := func(t *testing.T, stream MsgStream) {
+		timeout := time.After(5 * time.Second)
+	loop:
+		for {
+			select {
+			case _, ok := <-stream.Chan():
+				if !ok {
+					break loop
+				}
+			case <-timeout:
+				t.Fatal("stream not failed")
+			}
+		}
+		err := stream.Err()
+		assert.ErrorIs(t, err, merr.ErrMqBadMessage)
+		badMsgErr := &BadMessageError{}
+		require.ErrorAs(t, err, &badMsgErr)
+		assert.Equal(t, "dml_0", badMsgErr.Channel)
+		offset, err := memory.DeserializeMemoryMsgID(badMsgErr.MsgID.Serialize())
+		assert.NoError(t, err)
+		assert.EqualValues(t, 1, offset)
+	}
+
+	t.Run("invalid policy", func(t *testing.T) {
+		stream, err := NewMqMsgStream(ctx, 16, 16, memory.NewClient(), factory.NewUnmarshalDispatcher())
+		require.NoError(t, err)
+		defer stream.Close()
+		assert.Error(t, stream.SetBadMsgPolicy("unknown", nil))
+		assert.Error(t, stream.SetBadMsgPolicy(BadMsgPolicyQuarantine, nil))
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		mq := setup(t)
+		stream, err := NewMqMsgStream(ctx, 16, 16, mq.NewClient(), factory.NewUnmarshalDispatcher())
+		require.NoError(t, err)
+		defer stream.Close()
+		require.NoError(t, stream.SetBadMsgPolicy(BadMsgPolicySkip, nil))
+		require.NoError(t, stream.AsConsumer(ctx, channels, "sub", mqwrapper.SubscriptionPositionEarliest))
+		assert.Equal(t, ts1, receivePack(t, stream).EndTs)
+		assert.Equal(t, ts2, receivePack(t, stream).EndTs)
+		assert.EqualValues(t, 1, stream.Stats().Channels["dml_0"].BadMsgs)
+		assert.NoError(t, stream.Err())
+	})
+
+	t.Run("fail", func(t *testing.T) {
+		mq := setup(t)
+		stream, err := NewMqMsgStream(ctx, 16, 16, mq.NewClient(), factory.NewUnmarshalDispatcher())
+		require.NoError(t, err)
+		defer stream.Close()
+		require.NoError(t, stream.SetBadMsgPolicy(BadMsgPolicyFail, nil))
+		require.NoError(t, stream.AsConsumer(ctx, channels, "sub", mqwrapper.SubscriptionPositionEarliest))
+		assert.Equal(t, ts1, receivePack(t, stream).EndTs)
+		assertFailed(t, stream)
+	})
+
+	t.Run("tt fail", func(t *testing.T) {
+		stream := newStream(t, setup(t), BadMsgPolicyFail, nil)
+		require.NoError(t, stream.AsConsumer(ctx, channels, "sub", mqwrapper.SubscriptionPositionEarliest))
+		assertFailed(t, stream)
+	})
+
+	t.Run("quarantine", func(t *testing.T) {
+		var quarantined []mqwrapper.Message
+		stream := newStream(t, setup(t), BadMsgPolicyQuarantine, func(msg mqwrapper.Message, err error) error {
+			assert.Error(t, err)
+			quarantined = append(quarantined, msg)
+			return nil
+		})
+		require.NoError(t, stream.AsConsumer(ctx, channels, "sub", mqwrapper.SubscriptionPositionEarliest))
+		assert.Equal(t, ts1, receivePack(t, stream).EndTs)
+		assert.Equal(t, ts2, receivePack(t, stream).EndTs)
+		require.Len(t, quarantined, 1)
+		assert.Equal(t, "bad payload", string(quarantined[0].Payload()))
+	})
+
+	t.Run("quarantine failed", func(t *testing.T) {
+		stream := newStream(t, setup(t), BadMsgPolicyQuarantine, func(msg mqwrapper.Message, err error) error {
+			return errors.New("mock error")
+		})
+		require.NoError(t, stream.AsConsumer(ctx, channels, "sub", mqwrapper.SubscriptionPositionEarliest))
+		assertFailed(t, stream)
+	})
+
+	t.Run("seek", func(t *testing.T) {
+		mq := setup(t)
+		seek := func(stream MsgStream, subName string) error {
+			require.NoError(t, stream.AsConsumer(ctx, channels, subName, mqwrapper.SubscriptionPositionUnknown))
+			return stream.Seek(ctx, []*MsgPosition{{
+				ChannelName: "dml_0",
+				MsgID:       memory.SerializeMemoryMsgID(0),
+				Timestamp:   ts2,
+			}})
+		}
+
+		stream := newStream(t, mq, BadMsgPolicyFail, nil)
+		err := seek(stream, "seek_fail")
+		assert.ErrorIs(t, err, merr.ErrMqBadMessage)
+
+		stream = newStream(t, mq, BadMsgPolicySkip, nil)
+		require.NoError(t, seek(stream, "seek_skip"))
+		s := stream.Stats().Channels["dml_0"]
+		assert.EqualValues(t, 1, s.BadMsgs)
+		assert.EqualValues(t, 1, s.SkippedMsgs)
+	})
+}
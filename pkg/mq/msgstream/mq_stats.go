@@ -0,0 +1,188 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgstream
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/xige-16/stream-read/pkg/metrics"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper"
+)
+
+// The reasons of the messages received but not delivered.
+const (
+	discardSkipped   = "skipped"
+	discardBad       = "bad"
+	discardDuplicate = "duplicate"
+)
+
+// StreamStats are the states of a msgstream consuming channels.
+type StreamStats struct {
+	// ReceiveBufLen is the number of the packs not taken from Chan yet, ReceiveBufCap at most
+	ReceiveBufLen int
+	ReceiveBufCap int
+	Channels      map[string]ChannelStats
+}
+
+// ChannelStats are the states of a channel consumed by a msgstream.
+type ChannelStats struct {
+	// ReceivedMsgs and ReceivedBytes are the messages and the bytes of their payloads received by MsgType
+	ReceivedMsgs  map[commonpb.MsgType]int64
+	ReceivedBytes map[commonpb.MsgType]int64
+	// LastMsgID is the id of the last message received, nil if none
+	LastMsgID MessageID
+	// SkippedMsgs are before the seek position, BadMsgs fail to unmarshal,
+	// and DuplicateMsgs are the DML received again within a time tick
+	SkippedMsgs   int64
+	BadMsgs       int64
+	DuplicateMsgs int64
+
+	// the following are only of the time tick msgstreams
+
+	// BufferedMsgs is the number of the messages buffered until the next time tick
+	BufferedMsgs int
+	// LastTimeTick is the last time tick received from the channel
+	LastTimeTick Timestamp
+	// LastTickTime is when the last time tick is received
+	LastTickTime time.Time
+	// TickLag is how far the last time tick is behind the latest one of all the channels
+	TickLag time.Duration
+	// Waited is how long the stream has been waiting for the next time tick of the channel,
+	// zero if the stream is not blocked by it
+	Waited time.Duration
+	// Stalled is true if Waited exceeds mq.tickStallTimeout
+	Stalled bool
+	// Dropped is true if the channel is dropped for stalling
+	Dropped bool
+}
+
+// channelStats are the counters of a channel, guarded by statsMu.
+type channelStats struct {
+	receivedMsgs  map[commonpb.MsgType]int64
+	receivedBytes map[commonpb.MsgType]int64
+	lastMsgID     MessageID
+	skipped       int64
+	bad           int64
+	duplicate     int64
+}
+
+// getChannelStats returns the counters of channel, must be called with statsMu held.
+func (ms *mqMsgStream) getChannelStats(channel string) *channelStats {
+	s, ok := ms.chanStats[channel]
+	if !ok {
+		s = &channelStats{
+			receivedMsgs:  make(map[commonpb.MsgType]int64),
+			receivedBytes: make(map[commonpb.MsgType]int64),
+		}
+		ms.chanStats[channel] = s
+	}
+	return s
+}
+
+func (ms *mqMsgStream) registerChannelStats(channel string) {
+	ms.statsMu.Lock()
+	defer ms.statsMu.Unlock()
+	ms.getChannelStats(channel)
+}
+
+// recordReceived counts msg unmarshaled into tsMsg.
+func (ms *mqMsgStream) recordReceived(msg mqwrapper.Message, tsMsg TsMsg) {
+	channel := filepath.Base(msg.Topic())
+	msgType := tsMsg.Type()
+	size := len(msg.Payload())
+	metrics.MsgStreamReceivedMsgCounter.WithLabelValues(channel, msgType.String()).Inc()
+	metrics.MsgStreamReceivedBytesCounter.WithLabelValues(channel, msgType.String()).Add(float64(size))
+
+	ms.statsMu.Lock()
+	defer ms.statsMu.Unlock()
+	s := ms.getChannelStats(channel)
+	s.receivedMsgs[msgType]++
+	s.receivedBytes[msgType] += int64(size)
+	s.lastMsgID = msg.ID()
+}
+
+// recordDiscarded counts a message of channel received but not delivered for reason.
+func (ms *mqMsgStream) recordDiscarded(channel string, reason string) {
+	metrics.MsgStreamDiscardedMsgCounter.WithLabelValues(channel, reason).Inc()
+
+	ms.statsMu.Lock()
+	defer ms.statsMu.Unlock()
+	s := ms.getChannelStats(channel)
+	switch reason {
+	case discardSkipped:
+		s.skipped++
+	case discardBad:
+		s.bad++
+	case discardDuplicate:
+		s.duplicate++
+	}
+}
+
+// recordReceiveBuf sets the fill level of receiveBuf to the gauges of channels.
+func (ms *mqMsgStream) recordReceiveBuf(channels ...string) {
+	for _, channel := range channels {
+		metrics.MsgStreamReceiveBufLen.WithLabelValues(channel).Set(float64(len(ms.receiveBuf)))
+	}
+}
+
+// Stats returns the receive buffer fill level and the counters of the consumed channels.
+func (ms *mqMsgStream) Stats() *StreamStats {
+	stats := &StreamStats{
+		ReceiveBufLen: len(ms.receiveBuf),
+		ReceiveBufCap: cap(ms.receiveBuf),
+		Channels:      make(map[string]ChannelStats),
+	}
+	ms.statsMu.Lock()
+	defer ms.statsMu.Unlock()
+	for channel, s := range ms.chanStats {
+		cs := ChannelStats{
+			ReceivedMsgs:  make(map[commonpb.MsgType]int64, len(s.receivedMsgs)),
+			ReceivedBytes: make(map[commonpb.MsgType]int64, len(s.receivedBytes)),
+			LastMsgID:     s.lastMsgID,
+			SkippedMsgs:   s.skipped,
+			BadMsgs:       s.bad,
+			DuplicateMsgs: s.duplicate,
+		}
+		for msgType, n := range s.receivedMsgs {
+			cs.ReceivedMsgs[msgType] = n
+		}
+		for msgType, n := range s.receivedBytes {
+			cs.ReceivedBytes[msgType] = n
+		}
+		stats.Channels[channel] = cs
+	}
+	return stats
+}
+
+// Stats returns the states of the consumed channels, including the time ticks and the
+// dropped channels.
+func (ms *MqTtMsgStream) Stats() *StreamStats {
+	stats := ms.mqMsgStream.Stats()
+	ms.chanTtMsgTimeMutex.RLock()
+	ms.chanMsgBufMutex.Lock()
+	for consumer, w := range ms.chanTickWatch {
+		s := stats.Channels[w.channel]
+		s.BufferedMsgs = len(ms.chanMsgBuf[consumer])
+		stats.Channels[w.channel] = s
+	}
+	ms.chanMsgBufMutex.Unlock()
+	ms.chanTtMsgTimeMutex.RUnlock()
+	ms.tickStats(stats)
+	return stats
+}
@@ -0,0 +1,233 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgstream
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/xige-16/stream-read/pkg/log"
+	"github.com/xige-16/stream-read/pkg/metrics"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper"
+	"github.com/xige-16/stream-read/pkg/util/merr"
+	"github.com/xige-16/stream-read/pkg/util/paramtable"
+	"github.com/xige-16/stream-read/pkg/util/tsoutil"
+)
+
+// The policies of a stalled channel, see mq.tickStallPolicy.
+const (
+	StallPolicyWait = "wait"
+	StallPolicyFail = "fail"
+	StallPolicyDrop = "drop"
+)
+
+const (
+	minTickCheckInterval = 10 * time.Millisecond
+	maxTickCheckInterval = time.Second
+)
+
+// tickWatch is the time tick watchdog state of a channel, guarded by chanTtMsgTimeMutex.
+type tickWatch struct {
+	channel      string
+	lastTimeTick Timestamp
+	lastTickTime time.Time
+	// waitSince is when the stream starts waiting for the next time tick, zero if not waiting
+	waitSince time.Time
+	stalled   bool
+	dropped   bool
+}
+
+func (ms *MqTtMsgStream) startWaiting(consumer mqwrapper.Consumer) {
+	ms.chanTtMsgTimeMutex.Lock()
+	defer ms.chanTtMsgTimeMutex.Unlock()
+	if w := ms.chanTickWatch[consumer]; w != nil && w.waitSince.IsZero() {
+		w.waitSince = time.Now()
+	}
+}
+
+// tickReceived records a time tick of consumer, must be called with chanTtMsgTimeMutex held.
+func (ms *MqTtMsgStream) tickReceived(consumer mqwrapper.Consumer, ts Timestamp) {
+	w := ms.chanTickWatch[consumer]
+	if w == nil {
+		return
+	}
+	if w.stalled {
+		log.Info("channel time tick resumed", zap.String("channel", w.channel), zap.Duration("waited", time.Since(w.waitSince)))
+	}
+	w.lastTimeTick = ts
+	w.lastTickTime = time.Now()
+	w.waitSince = time.Time{}
+	w.stalled = false
+	metrics.MsgStreamTickWait.WithLabelValues(w.channel).Set(0)
+}
+
+// waitTimeTicks waits for the consumers started by bufMsgPackToChannel to reach their next
+// time ticks, and handles the stalled channels by mq.tickStallPolicy meanwhile. It returns
+// false if the stream fails, must be called with consumerLock held.
+func (ms *MqTtMsgStream) waitTimeTicks() bool {
+	done := make(chan struct{})
+	go func() {
+		ms.chanWaitGroup.Wait()
+		close(done)
+	}()
+
+	for {
+		timeout := paramtable.Get().MQCfg.TickStallTimeout.GetAsDuration(time.Second)
+		interval := timeout / 4
+		if interval < minTickCheckInterval {
+			interval = minTickCheckInterval
+		} else if interval > maxTickCheckInterval {
+			interval = maxTickCheckInterval
+		}
+		select {
+		case <-done:
+			if ms.Err() != nil {
+				// a consumer aborted the stream
+				ms.closeReceiveBuf()
+				return false
+			}
+			ms.removeDroppedChannels()
+			return true
+		case <-time.After(interval):
+			if timeout > 0 && !ms.checkStalled(timeout) {
+				<-done
+				return false
+			}
+		}
+	}
+}
+
+// checkStalled handles the channels waited for longer than timeout, returns false if the stream fails.
+func (ms *MqTtMsgStream) checkStalled(timeout time.Duration) bool {
+	policy := paramtable.Get().MQCfg.TickStallPolicy.GetValue()
+	ms.chanTtMsgTimeMutex.Lock()
+	defer ms.chanTtMsgTimeMutex.Unlock()
+	for consumer, w := range ms.chanTickWatch {
+		if w.dropped || w.waitSince.IsZero() {
+			continue
+		}
+		waited := time.Since(w.waitSince)
+		metrics.MsgStreamTickWait.WithLabelValues(w.channel).Set(waited.Seconds())
+		if waited <= timeout || w.stalled {
+			continue
+		}
+
+		w.stalled = true
+		metrics.MsgStreamTickStallCounter.WithLabelValues(w.channel, policy).Inc()
+		log.Warn("channel time tick stalled", zap.String("channel", w.channel), zap.Duration("waited", waited),
+			zap.Time("lastTickTime", w.lastTickTime), zap.Uint64("lastTimeTick", w.lastTimeTick),
+			zap.Duration("tickLag", ms.tickLag(w)), zap.String("policy", policy))
+		switch policy {
+		case StallPolicyFail:
+			ms.fail(merr.WrapErrMqChannelStalled(w.channel, waited))
+			return false
+		case StallPolicyDrop:
+			if ms.activeChannelNum() <= 1 {
+				ms.fail(merr.WrapErrMqChannelStalled(w.channel, waited, "the last channel can not be dropped"))
+				return false
+			}
+			ms.dropChannel(consumer, w)
+		}
+	}
+	return true
+}
+
+// activeChannelNum returns the number of channels not dropped, must be called with chanTtMsgTimeMutex held.
+func (ms *MqTtMsgStream) activeChannelNum() int {
+	num := 0
+	for _, w := range ms.chanTickWatch {
+		if !w.dropped {
+			num++
+		}
+	}
+	return num
+}
+
+// dropChannel stops consuming a stalled channel, must be called with chanTtMsgTimeMutex held.
+func (ms *MqTtMsgStream) dropChannel(consumer mqwrapper.Consumer, w *tickWatch) {
+	w.dropped = true
+	w.waitSince = time.Time{}
+	metrics.MsgStreamTickWait.WithLabelValues(w.channel).Set(0)
+	close(ms.chanStopChan[consumer])
+}
+
+// removeDroppedChannels removes the dropped channels after their consumeToTtMsg exit, the
+// messages buffered are discarded, must be called with consumerLock held.
+func (ms *MqTtMsgStream) removeDroppedChannels() {
+	ms.chanTtMsgTimeMutex.Lock()
+	defer ms.chanTtMsgTimeMutex.Unlock()
+	for consumer, w := range ms.chanTickWatch {
+		if !w.dropped || ms.consumers[w.channel] != consumer {
+			continue
+		}
+		delete(ms.consumers, w.channel)
+		for i, channel := range ms.consumerChannels {
+			if channel == w.channel {
+				ms.consumerChannels = append(ms.consumerChannels[:i], ms.consumerChannels[i+1:]...)
+				break
+			}
+		}
+		delete(ms.chanTtMsgTime, consumer)
+
+		ms.chanMsgBufMutex.Lock()
+		for _, msg := range ms.chanMsgBuf[consumer] {
+			delete(ms.chanMsgAcks, msg)
+		}
+		delete(ms.chanMsgBuf, consumer)
+		delete(ms.chanMsgPos, consumer)
+		ms.chanMsgBufMutex.Unlock()
+		// the messages not acked are redelivered to the next consumer in manual ack mode
+		consumer.Close()
+		log.Warn("stalled channel dropped", zap.String("channel", w.channel))
+	}
+}
+
+// tickLag returns how far the time tick of w is behind the latest one,
+// must be called with chanTtMsgTimeMutex held.
+func (ms *MqTtMsgStream) tickLag(w *tickWatch) time.Duration {
+	var maxTs Timestamp
+	for _, other := range ms.chanTickWatch {
+		if other.lastTimeTick > maxTs {
+			maxTs = other.lastTimeTick
+		}
+	}
+	if w.lastTimeTick == 0 {
+		return 0
+	}
+	return tsoutil.PhysicalTime(maxTs).Sub(tsoutil.PhysicalTime(w.lastTimeTick))
+}
+
+// tickStats fills the time tick states of the channels in stats.
+func (ms *MqTtMsgStream) tickStats(stats *StreamStats) {
+	timeout := paramtable.Get().MQCfg.TickStallTimeout.GetAsDuration(time.Second)
+	ms.chanTtMsgTimeMutex.RLock()
+	defer ms.chanTtMsgTimeMutex.RUnlock()
+	for _, w := range ms.chanTickWatch {
+		s := stats.Channels[w.channel]
+		s.LastTimeTick = w.lastTimeTick
+		s.LastTickTime = w.lastTickTime
+		s.TickLag = ms.tickLag(w)
+		s.Stalled = w.stalled
+		s.Dropped = w.dropped
+		if !w.waitSince.IsZero() && !w.dropped {
+			s.Waited = time.Since(w.waitSince)
+			s.Stalled = s.Stalled || (timeout > 0 && s.Waited > timeout)
+		}
+		stats.Channels[w.channel] = s
+	}
+}
@@ -0,0 +1,161 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper"
+	"github.com/xige-16/stream-read/pkg/util/retry"
+)
+
+// Check Client implements Client interface
+var _ mqwrapper.Client = (*Client)(nil)
+
+// Client serves the channel archives written by Writer as a read-only message queue,
+// the messages keep the ids they have in the broker, so the positions recorded against
+// the broker seek the archive. The archive of a channel is served as it is when the
+// channel is first subscribed by the client. A record failed to read, e.g. of a truncated
+// segment, is received as a mqwrapper.CorruptMessage with the id of its index entry.
+type Client struct {
+	dir      string
+	mu       sync.Mutex
+	channels map[string]*channel
+}
+
+// NewClient creates a client of the archives under dir.
+func NewClient(dir string) (*Client, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, errors.Newf("archive %s is not a directory", dir)
+	}
+	return &Client{
+		dir:      dir,
+		channels: make(map[string]*channel),
+	}, nil
+}
+
+// channel is the loaded archive of a channel.
+type channel struct {
+	dir     string
+	entries []indexEntry
+	// seqs are the sequences of the message ids
+	seqs map[string]int64
+}
+
+func (c *Client) getChannel(topic string) (*channel, error) {
+	name := filepath.Base(topic)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ch, ok := c.channels[name]; ok {
+		return ch, nil
+	}
+	dir := filepath.Join(c.dir, name)
+	entries, err := readChannelIndex(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, errors.Newf("no archive of channel %s in %s", name, c.dir)
+	}
+	ch := &channel{
+		dir:     dir,
+		entries: entries,
+		seqs:    make(map[string]int64, len(entries)),
+	}
+	for i, entry := range entries {
+		ch.seqs[string(entry.id)] = int64(i)
+	}
+	c.channels[name] = ch
+	return ch, nil
+}
+
+// MsgIDByTimestamp returns the id of the first message of topic whose timestamp is not less than ts.
+func (c *Client) MsgIDByTimestamp(topic string, ts uint64) (mqwrapper.MessageID, error) {
+	ch, err := c.getChannel(topic)
+	if err != nil {
+		return nil, err
+	}
+	for i, entry := range ch.entries {
+		if entry.ts >= ts {
+			return &archiveID{id: entry.id, seq: int64(i), ch: ch}, nil
+		}
+	}
+	return nil, errors.Newf("no message of channel %s after ts %d in the archive", topic, ts)
+}
+
+// CreateProducer always fails, the archives are written by Writer.
+func (c *Client) CreateProducer(options mqwrapper.ProducerOptions) (mqwrapper.Producer, error) {
+	return nil, errors.Newf("archive is read-only, can not produce to %s", options.Topic)
+}
+
+// Subscribe creates a consumer of the archive of options.Topic. The archive keeps no
+// subscription, every consumer receives all the messages from its initial position,
+// and Ack or Nack takes no effect.
+func (c *Client) Subscribe(options mqwrapper.ConsumerOptions) (mqwrapper.Consumer, error) {
+	ch, err := c.getChannel(options.Topic)
+	if err != nil {
+		return nil, retry.Unrecoverable(err)
+	}
+	bufSize := options.BufSize
+	if bufSize <= 0 {
+		bufSize = 1
+	}
+	consumer := &Consumer{
+		ch:      ch,
+		topic:   options.Topic,
+		subName: options.SubscriptionName,
+		msgChan: make(chan mqwrapper.Message, bufSize),
+		closeCh: make(chan struct{}),
+	}
+	if options.SubscriptionInitialPosition == mqwrapper.SubscriptionPositionLatest {
+		consumer.cursor = int64(len(ch.entries))
+	}
+	return consumer, nil
+}
+
+// EarliestMessageID returns the id of the first message of an archive.
+func (c *Client) EarliestMessageID() mqwrapper.MessageID {
+	return &archiveID{seq: 0}
+}
+
+// StringToMsgID always fails, the message ids of the broker have no string form in the archive.
+func (c *Client) StringToMsgID(id string) (mqwrapper.MessageID, error) {
+	return nil, errors.Newf("archive can not parse message id %s", id)
+}
+
+// BytesToMsgID returns the message id of the broker, it is resolved by the consumer it seeks.
+func (c *Client) BytesToMsgID(id []byte) (mqwrapper.MessageID, error) {
+	if len(id) == 0 {
+		return nil, errors.New("empty message id")
+	}
+	return &archiveID{id: id, seq: -1}, nil
+}
+
+// Close releases the loaded archives.
+func (c *Client) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.channels = make(map[string]*channel)
+}
@@ -0,0 +1,290 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper/memory"
+	"github.com/xige-16/stream-read/pkg/util/tsoutil"
+)
+
+const waitTimeout = 5 * time.Second
+
+// brokerMessages returns n messages produced to and consumed from an in-memory broker.
+func brokerMessages(t *testing.T, topic string, n int) []mqwrapper.Message {
+	client := memory.NewClient()
+	t.Cleanup(client.Close)
+	producer, err := client.CreateProducer(mqwrapper.ProducerOptions{Topic: topic})
+	require.NoError(t, err)
+	defer producer.Close()
+	for i := 0; i < n; i++ {
+		_, err := producer.Send(context.Background(), &mqwrapper.ProducerMessage{
+			Payload:    []byte(strconv.Itoa(i)),
+			Properties: map[string]string{"index": strconv.Itoa(i)},
+			Key:        "key-" + strconv.Itoa(i),
+		})
+		require.NoError(t, err)
+	}
+	consumer, err := client.Subscribe(mqwrapper.ConsumerOptions{
+		Topic:                       topic,
+		SubscriptionName:            "sub",
+		SubscriptionInitialPosition: mqwrapper.SubscriptionPositionEarliest,
+		BufSize:                     int64(n),
+	})
+	require.NoError(t, err)
+	defer consumer.Close()
+	return receive(t, consumer, n)
+}
+
+func receive(t *testing.T, consumer mqwrapper.Consumer, n int) []mqwrapper.Message {
+	t.Helper()
+	msgs := make([]mqwrapper.Message, 0, n)
+	for len(msgs) < n {
+		select {
+		case msg := <-consumer.Chan():
+			msgs = append(msgs, msg)
+		case <-time.After(waitTimeout):
+			t.Fatalf("received %d messages, expect %d", len(msgs), n)
+		}
+	}
+	return msgs
+}
+
+func payloads(msgs []mqwrapper.Message) []string {
+	result := make([]string, 0, len(msgs))
+	for _, msg := range msgs {
+		result = append(result, string(msg.Payload()))
+	}
+	return result
+}
+
+func subscribe(t *testing.T, client *Client, topic string, position mqwrapper.SubscriptionInitialPosition) mqwrapper.Consumer {
+	consumer, err := client.Subscribe(mqwrapper.ConsumerOptions{
+		Topic:                       topic,
+		SubscriptionName:            "sub",
+		SubscriptionInitialPosition: position,
+		BufSize:                     16,
+	})
+	require.NoError(t, err)
+	t.Cleanup(consumer.Close)
+	return consumer
+}
+
+func TestWriterClient(t *testing.T) {
+	dir := t.TempDir()
+	msgs := brokerMessages(t, "dml_0", 10)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tsOf := func(i int) uint64 {
+		// a bucket of every 4 messages
+		return tsoutil.ComposeTSByTime(start.Add(time.Duration(i)*15*time.Minute), 0)
+	}
+
+	writer, err := NewWriter(dir, WriterConfig{})
+	require.NoError(t, err)
+	id, err := writer.LastMessageID("dml_0")
+	assert.NoError(t, err)
+	assert.Nil(t, id)
+	for i, msg := range msgs[:6] {
+		require.NoError(t, writer.Write("dml_0", tsOf(i), msg))
+	}
+	require.NoError(t, writer.Close())
+
+	// appended in a new segment after reopened
+	writer, err = NewWriter(dir, WriterConfig{MaxSegmentSize: 1})
+	require.NoError(t, err)
+	id, err = writer.LastMessageID("dml_0")
+	assert.NoError(t, err)
+	assert.Equal(t, msgs[5].ID().Serialize(), id)
+	for i, msg := range msgs[6:] {
+		require.NoError(t, writer.Write("dml_0", tsOf(i+6), msg))
+	}
+	require.NoError(t, writer.Sync())
+	defer writer.Close()
+
+	segments, err := listSegments(dir + "/dml_0")
+	assert.NoError(t, err)
+	// 0-3 and 4-5 by bucket, 6-9 one per segment by size
+	assert.Len(t, segments, 6)
+	assert.Equal(t, segments[0].bucket, tsoutil.PhysicalTime(tsOf(0)).UnixMilli())
+	assert.Equal(t, segments[1].bucket, tsoutil.PhysicalTime(tsOf(4)).UnixMilli())
+
+	client, err := NewClient(dir)
+	require.NoError(t, err)
+	defer client.Close()
+
+	t.Run("consume", func(t *testing.T) {
+		consumer := subscribe(t, client, "dml_0", mqwrapper.SubscriptionPositionEarliest)
+		received := receive(t, consumer, 10)
+		for i, msg := range received {
+			assert.Equal(t, msgs[i].ID().Serialize(), msg.ID().Serialize())
+			assert.Equal(t, msgs[i].Payload(), msg.Payload())
+			assert.Equal(t, msgs[i].Properties(), msg.Properties())
+			assert.Equal(t, msgs[i].Key(), msg.Key())
+			assert.Equal(t, "dml_0", msg.Topic())
+		}
+		assert.True(t, received[0].ID().AtEarliestPosition())
+		lessOrEqual, err := received[3].ID().LessOrEqualThan(msgs[4].ID().Serialize())
+		assert.NoError(t, err)
+		assert.True(t, lessOrEqual)
+		lessOrEqual, err = received[5].ID().LessOrEqualThan(msgs[4].ID().Serialize())
+		assert.NoError(t, err)
+		assert.False(t, lessOrEqual)
+
+		latest, err := consumer.GetLatestMsgID()
+		assert.NoError(t, err)
+		equal, err := latest.Equal(msgs[9].ID().Serialize())
+		assert.NoError(t, err)
+		assert.True(t, equal)
+
+		assert.Error(t, consumer.Seek(client.EarliestMessageID(), true))
+	})
+
+	t.Run("seek", func(t *testing.T) {
+		// the message ids of the broker seek the archive
+		id, err := client.BytesToMsgID(msgs[4].ID().Serialize())
+		require.NoError(t, err)
+		consumer := subscribe(t, client, "dml_0", mqwrapper.SubscriptionPositionUnknown)
+		assert.NoError(t, consumer.Seek(id, true))
+		assert.Equal(t, []string{"4", "5", "6"}, payloads(receive(t, consumer, 3)))
+
+		consumer = subscribe(t, client, "dml_0", mqwrapper.SubscriptionPositionUnknown)
+		assert.NoError(t, consumer.Seek(id, false))
+		assert.Equal(t, []string{"5"}, payloads(receive(t, consumer, 1)))
+
+		unknown, err := client.BytesToMsgID(memory.SerializeMemoryMsgID(100))
+		require.NoError(t, err)
+		assert.Error(t, consumer.Seek(unknown, false))
+		_, err = client.BytesToMsgID(nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("timestamp", func(t *testing.T) {
+		id, err := client.MsgIDByTimestamp("dml_0", tsOf(7)-1)
+		require.NoError(t, err)
+		assert.Equal(t, msgs[7].ID().Serialize(), id.Serialize())
+		_, err = client.MsgIDByTimestamp("dml_0", tsOf(10))
+		assert.Error(t, err)
+	})
+
+	t.Run("latest", func(t *testing.T) {
+		consumer := subscribe(t, client, "dml_0", mqwrapper.SubscriptionPositionLatest)
+		select {
+		case _, ok := <-consumer.Chan():
+			assert.False(t, ok, "unexpected message")
+		case <-time.After(5 * time.Second):
+			t.Fatal("consumer not closed at the end of the archive")
+		}
+	})
+
+	t.Run("read only", func(t *testing.T) {
+		_, err := client.CreateProducer(mqwrapper.ProducerOptions{Topic: "dml_0"})
+		assert.Error(t, err)
+		_, err = client.Subscribe(mqwrapper.ConsumerOptions{Topic: "dml_1"})
+		assert.Error(t, err)
+		_, err = client.StringToMsgID("0")
+		assert.Error(t, err)
+		_, err = NewClient(dir + "/none")
+		assert.Error(t, err)
+	})
+}
+
+func TestClient_Corruption(t *testing.T) {
+	dir := t.TempDir()
+	msgs := brokerMessages(t, "dml_0", 3)
+	writer, err := NewWriter(dir, WriterConfig{})
+	require.NoError(t, err)
+	ts := tsoutil.ComposeTSByTime(time.Now(), 0)
+	for _, msg := range msgs {
+		require.NoError(t, writer.Write("dml_0", ts, msg))
+	}
+	require.NoError(t, writer.Close())
+	segments, err := listSegments(dir + "/dml_0")
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+	s := segments[0]
+
+	// a torn index entry is ignored
+	index, err := os.OpenFile(s.indexPath(dir+"/dml_0"), os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = index.Write([]byte{1, 2, 3})
+	require.NoError(t, err)
+	require.NoError(t, index.Close())
+
+	// a corrupted record is received with its read error
+	entries, err := readIndex(dir+"/dml_0", s)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	logFile, err := os.OpenFile(s.logPath(dir+"/dml_0"), os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = logFile.WriteAt([]byte{0xff}, entries[1].offset+recordHeaderSize+1)
+	require.NoError(t, err)
+	require.NoError(t, logFile.Close())
+
+	client, err := NewClient(dir)
+	require.NoError(t, err)
+	defer client.Close()
+	consumer := subscribe(t, client, "dml_0", mqwrapper.SubscriptionPositionEarliest)
+	received := receive(t, consumer, 3)
+	assert.Equal(t, []string{"0", "", "2"}, payloads(received))
+	assert.NoError(t, received[0].(mqwrapper.CorruptMessage).ReadErr())
+	assert.Error(t, received[1].(mqwrapper.CorruptMessage).ReadErr())
+	assert.Equal(t, msgs[1].ID().Serialize(), received[1].ID().Serialize())
+	assert.NoError(t, received[2].(mqwrapper.CorruptMessage).ReadErr())
+}
+
+func TestClient_TruncatedSegment(t *testing.T) {
+	dir := t.TempDir()
+	msgs := brokerMessages(t, "dml_0", 3)
+	writer, err := NewWriter(dir, WriterConfig{})
+	require.NoError(t, err)
+	ts := tsoutil.ComposeTSByTime(time.Now(), 0)
+	for _, msg := range msgs {
+		require.NoError(t, writer.Write("dml_0", ts, msg))
+	}
+	require.NoError(t, writer.Close())
+	segments, err := listSegments(dir + "/dml_0")
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+	entries, err := readIndex(dir+"/dml_0", segments[0])
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	// the segment is cut in the middle of the second record
+	require.NoError(t, os.Truncate(segments[0].logPath(dir+"/dml_0"), entries[1].offset+recordHeaderSize))
+
+	client, err := NewClient(dir)
+	require.NoError(t, err)
+	defer client.Close()
+	consumer := subscribe(t, client, "dml_0", mqwrapper.SubscriptionPositionEarliest)
+	received := receive(t, consumer, 3)
+	assert.Equal(t, "0", string(received[0].Payload()))
+	assert.NoError(t, received[0].(mqwrapper.CorruptMessage).ReadErr())
+	for i, msg := range received[1:] {
+		assert.Error(t, msg.(mqwrapper.CorruptMessage).ReadErr())
+		assert.Equal(t, msgs[i+1].ID().Serialize(), msg.ID().Serialize())
+	}
+	_, ok := <-consumer.Chan()
+	assert.False(t, ok)
+}
@@ -0,0 +1,185 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper"
+)
+
+// Check Consumer implements Consumer interface
+var _ mqwrapper.Consumer = (*Consumer)(nil)
+
+// Consumer reads the archive of a channel in order.
+type Consumer struct {
+	ch      *channel
+	topic   string
+	subName string
+	msgChan chan mqwrapper.Message
+
+	mu sync.Mutex
+	// cursor is the sequence of the first message to read
+	cursor  int64
+	started bool
+
+	closeCh   chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// Subscription returns the subscription name of the consumer.
+func (ac *Consumer) Subscription() string {
+	return ac.subName
+}
+
+// Chan starts reading the archive from the cursor, it is closed at the end of the archive.
+func (ac *Consumer) Chan() <-chan mqwrapper.Message {
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	if !ac.started {
+		ac.started = true
+		ac.wg.Add(1)
+		go ac.read(ac.cursor)
+	}
+	return ac.msgChan
+}
+
+// Seek moves the cursor to id, which must be archived, it fails once Chan is called.
+func (ac *Consumer) Seek(id mqwrapper.MessageID, inclusive bool) error {
+	var seq int64
+	if !id.AtEarliestPosition() {
+		var ok bool
+		seq, ok = ac.ch.seqs[string(id.Serialize())]
+		if !ok {
+			return errors.Newf("message id %v not found in the archive of %s", id.Serialize(), ac.topic)
+		}
+	}
+	if !inclusive {
+		seq++
+	}
+	ac.mu.Lock()
+	defer ac.mu.Unlock()
+	if ac.started {
+		return errors.New("archive consumer can not seek after consuming")
+	}
+	ac.cursor = seq
+	return nil
+}
+
+// read sends the messages from seq until the end of the archive or the consumer is closed,
+// msgChan is closed once read exits to report the end of the archive.
+func (ac *Consumer) read(seq int64) {
+	defer ac.wg.Done()
+	defer close(ac.msgChan)
+	var (
+		current *segment
+		f       *os.File
+		r       *bufio.Reader
+		// pos is the offset of r in f, -1 if unknown
+		pos int64
+	)
+	defer func() {
+		if f != nil {
+			f.Close()
+		}
+	}()
+
+	for ; seq < int64(len(ac.ch.entries)); seq++ {
+		entry := ac.ch.entries[seq]
+		msg := &archiveMessage{
+			topic: ac.topic,
+			rec:   &record{},
+			id:    &archiveID{id: entry.id, seq: seq, ch: ac.ch},
+		}
+		if current == nil || *current != entry.segment {
+			if f != nil {
+				f.Close()
+			}
+			var err error
+			f, err = os.Open(entry.segment.logPath(ac.ch.dir))
+			if err != nil {
+				f, current = nil, nil
+				msg.err = errors.Wrapf(err, "failed to open archive segment %s", entry.segment.name())
+			} else {
+				current = &entry.segment
+				r = bufio.NewReader(f)
+				pos = 0
+			}
+		}
+		if msg.err == nil && pos != entry.offset {
+			if _, err := f.Seek(entry.offset, io.SeekStart); err != nil {
+				pos = -1
+				msg.err = errors.Wrapf(err, "failed to seek archive segment %s", entry.segment.name())
+			} else {
+				r.Reset(f)
+				pos = entry.offset
+			}
+		}
+		if msg.err == nil {
+			rec, n, err := readRecord(r)
+			if err == nil && !bytes.Equal(rec.id, entry.id) {
+				err = errors.New("archive record does not match the index")
+			}
+			if err != nil {
+				pos = -1
+				msg.err = errors.Wrapf(err, "bad archive record of segment %s at offset %d", entry.segment.name(), entry.offset)
+			} else {
+				pos += n
+				msg.rec = rec
+			}
+		}
+
+		// a message failed to read is sent as well, the consumer decides to skip it or not
+		select {
+		case ac.msgChan <- msg:
+		case <-ac.closeCh:
+			return
+		}
+	}
+}
+
+// Ack takes no effect, the archive keeps no subscription.
+func (ac *Consumer) Ack(msg mqwrapper.Message) {}
+
+// Nack takes no effect, the archive keeps no subscription.
+func (ac *Consumer) Nack(msg mqwrapper.Message) {}
+
+// Close stops reading the archive.
+func (ac *Consumer) Close() {
+	ac.closeOnce.Do(func() {
+		close(ac.closeCh)
+	})
+	ac.wg.Wait()
+}
+
+// GetLatestMsgID returns the id of the last message of the archive.
+func (ac *Consumer) GetLatestMsgID() (mqwrapper.MessageID, error) {
+	seq := int64(len(ac.ch.entries) - 1)
+	return &archiveID{id: ac.ch.entries[seq].id, seq: seq, ch: ac.ch}, nil
+}
+
+// CheckTopicValid always succeeds, the archive is never produced to.
+func (ac *Consumer) CheckTopicValid(channel string) error {
+	return nil
+}
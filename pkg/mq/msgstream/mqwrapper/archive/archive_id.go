@@ -0,0 +1,61 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"bytes"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper"
+)
+
+// Check archiveID implements MessageID interface
+var _ mqwrapper.MessageID = (*archiveID)(nil)
+
+// archiveID is the message id in the broker the message is archived from, so that
+// the positions recorded against the broker and the archive are interchangeable.
+type archiveID struct {
+	// id is nil for the earliest position
+	id []byte
+	// seq is the sequence of the message in the archive of ch, -1 if not resolved
+	seq int64
+	ch  *channel
+}
+
+func (id *archiveID) Serialize() []byte {
+	return id.id
+}
+
+func (id *archiveID) AtEarliestPosition() bool {
+	return id.seq == 0
+}
+
+func (id *archiveID) LessOrEqualThan(msgID []byte) (bool, error) {
+	if id.ch == nil {
+		return false, errors.New("archive message id is not resolved by a consumer")
+	}
+	other, ok := id.ch.seqs[string(msgID)]
+	if !ok {
+		return false, errors.Newf("message id %v not found in the archive", msgID)
+	}
+	return id.seq <= other, nil
+}
+
+func (id *archiveID) Equal(msgID []byte) (bool, error) {
+	return bytes.Equal(id.id, msgID), nil
+}
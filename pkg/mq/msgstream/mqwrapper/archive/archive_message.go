@@ -0,0 +1,56 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper"
+)
+
+// Check archiveMessage implements CorruptMessage interface
+var _ mqwrapper.CorruptMessage = (*archiveMessage)(nil)
+
+type archiveMessage struct {
+	topic string
+	rec   *record
+	id    *archiveID
+	// err is the error reading the record, rec is empty if set
+	err error
+}
+
+func (m *archiveMessage) Topic() string {
+	return m.topic
+}
+
+func (m *archiveMessage) Properties() map[string]string {
+	return m.rec.properties
+}
+
+func (m *archiveMessage) Payload() []byte {
+	return m.rec.payload
+}
+
+func (m *archiveMessage) Key() string {
+	return m.rec.key
+}
+
+func (m *archiveMessage) ID() mqwrapper.MessageID {
+	return m.id
+}
+
+func (m *archiveMessage) ReadErr() error {
+	return m.err
+}
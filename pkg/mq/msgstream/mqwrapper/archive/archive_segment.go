@@ -0,0 +1,260 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper"
+)
+
+// The archive of a channel is a directory of segment files named by their sequence
+// and the start of their time bucket, <seq>_<bucket>.log holds the records and
+// <seq>_<bucket>.idx holds an entry of (ts, offset, message id) per record.
+const (
+	logSuffix   = ".log"
+	indexSuffix = ".idx"
+
+	// recordHeaderSize is the body length and the crc32 of the body.
+	recordHeaderSize = 8
+	// maxRecordSize guards against reading a corrupted length.
+	maxRecordSize = 256 << 20
+)
+
+type segment struct {
+	seq    int64
+	bucket int64
+}
+
+func (s segment) name() string {
+	return fmt.Sprintf("%08d_%013d", s.seq, s.bucket)
+}
+
+func (s segment) logPath(dir string) string {
+	return filepath.Join(dir, s.name()+logSuffix)
+}
+
+func (s segment) indexPath(dir string) string {
+	return filepath.Join(dir, s.name()+indexSuffix)
+}
+
+// listSegments returns the segments of the channel archive in dir ordered by sequence.
+func listSegments(dir string) ([]segment, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	segments := make([]segment, 0)
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), indexSuffix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		var s segment
+		if _, err := fmt.Sscanf(name, "%d_%d", &s.seq, &s.bucket); err != nil {
+			continue
+		}
+		segments = append(segments, s)
+	}
+	sort.Slice(segments, func(i, j int) bool {
+		return segments[i].seq < segments[j].seq
+	})
+	return segments, nil
+}
+
+// record is a message kept in the archive.
+type record struct {
+	id         []byte
+	key        string
+	payload    []byte
+	properties map[string]string
+}
+
+func appendBytes(buf []byte, b []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+// encodeRecord returns the header and the body of msg.
+func encodeRecord(msg mqwrapper.Message) []byte {
+	buf := make([]byte, recordHeaderSize)
+	buf = appendBytes(buf, msg.ID().Serialize())
+	buf = appendBytes(buf, []byte(msg.Key()))
+	buf = appendBytes(buf, msg.Payload())
+	properties := msg.Properties()
+	keys := make([]string, 0, len(properties))
+	for k := range properties {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	buf = binary.AppendUvarint(buf, uint64(len(keys)))
+	for _, k := range keys {
+		buf = appendBytes(buf, []byte(k))
+		buf = appendBytes(buf, []byte(properties[k]))
+	}
+	body := buf[recordHeaderSize:]
+	binary.BigEndian.PutUint32(buf, uint32(len(body)))
+	binary.BigEndian.PutUint32(buf[4:], crc32.ChecksumIEEE(body))
+	return buf
+}
+
+type bodyReader struct {
+	body []byte
+	err  error
+}
+
+func (r *bodyReader) bytes() []byte {
+	if r.err != nil {
+		return nil
+	}
+	n, size := binary.Uvarint(r.body)
+	if size <= 0 || uint64(len(r.body)-size) < n {
+		r.err = errors.New("archive record is truncated")
+		return nil
+	}
+	b := r.body[size : size+int(n)]
+	r.body = r.body[size+int(n):]
+	return b
+}
+
+func (r *bodyReader) count() int {
+	if r.err != nil {
+		return 0
+	}
+	n, size := binary.Uvarint(r.body)
+	if size <= 0 || n > uint64(len(r.body)) {
+		r.err = errors.New("archive record is truncated")
+		return 0
+	}
+	r.body = r.body[size:]
+	return int(n)
+}
+
+// readRecord reads the next record from r, returns the record and its size.
+func readRecord(r io.Reader) (*record, int64, error) {
+	header := make([]byte, recordHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, 0, err
+	}
+	size := binary.BigEndian.Uint32(header)
+	if size > maxRecordSize {
+		return nil, 0, errors.Newf("archive record size %d exceeds the limit", size)
+	}
+	body := make([]byte, size)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return nil, 0, err
+	}
+	if crc32.ChecksumIEEE(body) != binary.BigEndian.Uint32(header[4:]) {
+		return nil, 0, errors.New("archive record checksum mismatch")
+	}
+
+	br := &bodyReader{body: body}
+	rec := &record{
+		id:      br.bytes(),
+		key:     string(br.bytes()),
+		payload: br.bytes(),
+	}
+	n := br.count()
+	rec.properties = make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		k := string(br.bytes())
+		rec.properties[k] = string(br.bytes())
+	}
+	if br.err != nil {
+		return nil, 0, br.err
+	}
+	return rec, recordHeaderSize + int64(size), nil
+}
+
+// indexEntry locates a record by its offset in the log of its segment.
+type indexEntry struct {
+	ts      uint64
+	segment segment
+	offset  int64
+	id      []byte
+}
+
+func encodeIndexEntry(ts uint64, offset int64, id []byte) []byte {
+	buf := binary.BigEndian.AppendUint64(nil, ts)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(offset))
+	return appendBytes(buf, id)
+}
+
+// readIndex reads the index entries of s, a torn entry at the tail left by
+// a crash is ignored, so is its record.
+func readIndex(dir string, s segment) ([]indexEntry, error) {
+	f, err := os.Open(s.indexPath(dir))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+	entries := make([]indexEntry, 0)
+	header := make([]byte, 16)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return entries, nil
+			}
+			return nil, err
+		}
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return entries, nil
+		}
+		id := make([]byte, n)
+		if _, err := io.ReadFull(r, id); err != nil {
+			return entries, nil
+		}
+		entries = append(entries, indexEntry{
+			ts:      binary.BigEndian.Uint64(header),
+			segment: s,
+			offset:  int64(binary.BigEndian.Uint64(header[8:])),
+			id:      id,
+		})
+	}
+}
+
+// readChannelIndex reads the index entries of all the segments in dir.
+func readChannelIndex(dir string) ([]indexEntry, error) {
+	segments, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]indexEntry, 0)
+	for _, s := range segments {
+		segmentEntries, err := readIndex(dir, s)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, segmentEntries...)
+	}
+	return entries, nil
+}
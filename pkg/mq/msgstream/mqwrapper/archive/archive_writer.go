@@ -0,0 +1,220 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cockroachdb/errors"
+	"go.uber.org/zap"
+
+	"github.com/xige-16/stream-read/pkg/log"
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper"
+	"github.com/xige-16/stream-read/pkg/util/tsoutil"
+)
+
+const (
+	DefaultBucketDuration = time.Hour
+	DefaultMaxSegmentSize = 256 << 20
+)
+
+// WriterConfig decides how the archive of a channel is split into segment files.
+type WriterConfig struct {
+	// BucketDuration is the time span of the messages in one segment file
+	BucketDuration time.Duration
+	// MaxSegmentSize rolls a new segment file of the same bucket once the current one is larger
+	MaxSegmentSize int64
+}
+
+// Writer appends the messages of channels to their archives under a directory.
+// Writer is not safe for concurrent use by multiple goroutines.
+type Writer struct {
+	dir      string
+	cfg      WriterConfig
+	channels map[string]*channelWriter
+}
+
+// NewWriter creates a writer of the archives under dir, the existing archives are appended.
+func NewWriter(dir string, cfg WriterConfig) (*Writer, error) {
+	if cfg.BucketDuration <= 0 {
+		cfg.BucketDuration = DefaultBucketDuration
+	}
+	if cfg.MaxSegmentSize <= 0 {
+		cfg.MaxSegmentSize = DefaultMaxSegmentSize
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &Writer{
+		dir:      dir,
+		cfg:      cfg,
+		channels: make(map[string]*channelWriter),
+	}, nil
+}
+
+// LastMessageID returns the id of the last message archived of channel, nil if none.
+func (w *Writer) LastMessageID(channel string) ([]byte, error) {
+	entries, err := readChannelIndex(filepath.Join(w.dir, channel))
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	return entries[len(entries)-1].id, nil
+}
+
+// Write appends msg of hybrid timestamp ts to the archive of channel, it is durable after Sync.
+func (w *Writer) Write(channel string, ts uint64, msg mqwrapper.Message) error {
+	cw, ok := w.channels[channel]
+	if !ok {
+		dir := filepath.Join(w.dir, channel)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+		segments, err := listSegments(dir)
+		if err != nil {
+			return err
+		}
+		cw = &channelWriter{dir: dir, cfg: w.cfg}
+		if len(segments) > 0 {
+			// never append to a segment which may have a torn tail
+			cw.nextSeq = segments[len(segments)-1].seq + 1
+		}
+		w.channels[channel] = cw
+	}
+	return cw.write(ts, msg)
+}
+
+// Sync flushes the archives to disk.
+func (w *Writer) Sync() error {
+	for _, cw := range w.channels {
+		if err := cw.sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close syncs and closes the archives.
+func (w *Writer) Close() error {
+	var errs error
+	for channel, cw := range w.channels {
+		if err := cw.close(); err != nil {
+			errs = errors.CombineErrors(errs, err)
+		}
+		delete(w.channels, channel)
+	}
+	return errs
+}
+
+type channelWriter struct {
+	dir     string
+	cfg     WriterConfig
+	nextSeq int64
+
+	current  *segment
+	log      *os.File
+	logBuf   *bufio.Writer
+	index    *os.File
+	indexBuf *bufio.Writer
+	size     int64
+}
+
+func (cw *channelWriter) bucketOf(ts uint64) int64 {
+	physical := tsoutil.PhysicalTime(ts).UnixMilli()
+	return physical - physical%cw.cfg.BucketDuration.Milliseconds()
+}
+
+func (cw *channelWriter) write(ts uint64, msg mqwrapper.Message) error {
+	// the messages of an older bucket arriving late stay in the current segment
+	bucket := cw.bucketOf(ts)
+	if cw.current == nil || bucket > cw.current.bucket || cw.size >= cw.cfg.MaxSegmentSize {
+		if err := cw.roll(bucket); err != nil {
+			return err
+		}
+	}
+
+	rec := encodeRecord(msg)
+	if _, err := cw.logBuf.Write(rec); err != nil {
+		return err
+	}
+	if _, err := cw.indexBuf.Write(encodeIndexEntry(ts, cw.size, msg.ID().Serialize())); err != nil {
+		return err
+	}
+	cw.size += int64(len(rec))
+	return nil
+}
+
+// roll closes the current segment and opens a new one of bucket.
+func (cw *channelWriter) roll(bucket int64) error {
+	if err := cw.close(); err != nil {
+		return err
+	}
+	if cw.current != nil && cw.current.bucket > bucket {
+		bucket = cw.current.bucket
+	}
+	s := segment{seq: cw.nextSeq, bucket: bucket}
+	logFile, err := os.OpenFile(s.logPath(cw.dir), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	indexFile, err := os.OpenFile(s.indexPath(cw.dir), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		logFile.Close()
+		return err
+	}
+	log.Info("archive segment created", zap.String("dir", cw.dir), zap.String("segment", s.name()))
+	cw.nextSeq++
+	cw.current = &s
+	cw.log, cw.logBuf = logFile, bufio.NewWriter(logFile)
+	cw.index, cw.indexBuf = indexFile, bufio.NewWriter(indexFile)
+	cw.size = 0
+	return nil
+}
+
+// sync flushes the log before the index, a record indexed but torn by a crash
+// fails its checksum and is dropped by the consumers.
+func (cw *channelWriter) sync() error {
+	if cw.log == nil {
+		return nil
+	}
+	if err := cw.logBuf.Flush(); err != nil {
+		return err
+	}
+	if err := cw.log.Sync(); err != nil {
+		return err
+	}
+	if err := cw.indexBuf.Flush(); err != nil {
+		return err
+	}
+	return cw.index.Sync()
+}
+
+func (cw *channelWriter) close() error {
+	if cw.log == nil {
+		return nil
+	}
+	err := cw.sync()
+	err = errors.CombineErrors(err, cw.log.Close())
+	err = errors.CombineErrors(err, cw.index.Close())
+	cw.log, cw.logBuf, cw.index, cw.indexBuf = nil, nil, nil, nil
+	return err
+}
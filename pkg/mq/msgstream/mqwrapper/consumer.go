@@ -30,6 +30,41 @@ const (
 	SubscriptionPositionUnknown
 )
 
+// SubscriptionType is the type of a subscription, which decides how the messages
+// are dispatched among the consumers of the subscription
+type SubscriptionType int
+
+const (
+	// Exclusive allows only one consumer of the subscription, it is the default type
+	Exclusive SubscriptionType = iota
+
+	// Failover dispatches all the messages to one active consumer,
+	// the next consumer takes over when the active one is closed
+	Failover
+
+	// Shared dispatches the messages round robin among the consumers,
+	// there is no ordering guarantee across messages
+	Shared
+
+	// KeyShared dispatches the messages of the same key to the same consumer,
+	// the ordering is only guaranteed among the messages of one key
+	KeyShared
+)
+
+func (t SubscriptionType) String() string {
+	switch t {
+	case Exclusive:
+		return "Exclusive"
+	case Failover:
+		return "Failover"
+	case Shared:
+		return "Shared"
+	case KeyShared:
+		return "KeyShared"
+	}
+	return "Unknown"
+}
+
 const DefaultPartitionIdx = 0
 
 // UniqueID is the type of message id
@@ -47,8 +82,16 @@ type ConsumerOptions struct {
 	// Default is `Latest`
 	SubscriptionInitialPosition
 
+	// SubscriptionType decides how the messages are dispatched among the consumers
+	// of the subscription, default is `Exclusive`
+	SubscriptionType SubscriptionType
+
 	// Set receive channel size
 	BufSize int64
+
+	// KeepSubscription keeps the subscription and its cursor when the consumer is closed,
+	// by default the subscription is removed
+	KeepSubscription bool
 }
 
 // Consumer is the interface that provides operations of a consumer
@@ -65,6 +108,9 @@ type Consumer interface {
 	// Ack make sure that msg is received
 	Ack(Message)
 
+	// Nack make sure that msg is redelivered later
+	Nack(Message)
+
 	// Close consumer
 	Close()
 
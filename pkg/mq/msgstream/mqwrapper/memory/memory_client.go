@@ -0,0 +1,204 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper"
+	"github.com/xige-16/stream-read/pkg/util/retry"
+)
+
+// Check Client implements Client interface
+var _ mqwrapper.Client = (*Client)(nil)
+
+// broker keeps the topics of the clients created from each other.
+type broker struct {
+	mu      sync.Mutex
+	topics  map[string]*topic
+	closeCh chan struct{}
+	once    sync.Once
+}
+
+// close stops dispatching messages, the messages are dropped.
+func (b *broker) close() {
+	b.once.Do(func() {
+		close(b.closeCh)
+	})
+}
+
+// Client is an in-process message queue keeping the topics in memory, it runs
+// the msgstream without a message queue in tests. The subscriptions dispatch
+// messages among their consumers the same way as pulsar.
+type Client struct {
+	*broker
+	closed atomic.Bool
+	// ownBroker closes the broker with the client
+	ownBroker bool
+}
+
+// NewClient creates an empty in-process message queue, it is dropped when the client is closed.
+func NewClient() *Client {
+	return &Client{
+		broker: &broker{
+			topics:  make(map[string]*topic),
+			closeCh: make(chan struct{}),
+		},
+		ownBroker: true,
+	}
+}
+
+// NewClient creates another client of the message queue of c, closing it keeps the queue,
+// so the streams closing their clients share the topics and subscriptions.
+func (c *Client) NewClient() *Client {
+	return &Client{broker: c.broker}
+}
+
+type topic struct {
+	name string
+	msgs []*memoryMessage
+	subs map[string]*subscription
+	// notify is closed and renewed when a message is published or a consumer changes.
+	notify chan struct{}
+}
+
+func (t *topic) signal() {
+	close(t.notify)
+	t.notify = make(chan struct{})
+}
+
+// getTopic returns the topic of name, creates it if not exist, must be called with b.mu held.
+func (b *broker) getTopic(name string) *topic {
+	t, ok := b.topics[name]
+	if !ok {
+		t = &topic{
+			name:   name,
+			subs:   make(map[string]*subscription),
+			notify: make(chan struct{}),
+		}
+		b.topics[name] = t
+	}
+	return t
+}
+
+func (c *Client) publish(topicName string, message *mqwrapper.ProducerMessage) (mqwrapper.MessageID, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed.Load() {
+		return nil, errors.New("memory mq client is closed")
+	}
+	select {
+	case <-c.closeCh:
+		return nil, errors.New("memory mq broker is closed")
+	default:
+	}
+	t := c.getTopic(topicName)
+	properties := make(map[string]string, len(message.Properties))
+	for k, v := range message.Properties {
+		properties[k] = v
+	}
+	msg := &memoryMessage{
+		topic:      topicName,
+		offset:     int64(len(t.msgs)),
+		payload:    message.Payload,
+		properties: properties,
+		key:        message.Key,
+	}
+	t.msgs = append(t.msgs, msg)
+	t.signal()
+	return memoryID(msg.offset), nil
+}
+
+// CreateProducer creates a producer of options.Topic.
+func (c *Client) CreateProducer(options mqwrapper.ProducerOptions) (mqwrapper.Producer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.getTopic(options.Topic)
+	return &memoryProducer{client: c, topic: options.Topic}, nil
+}
+
+// Subscribe creates a consumer of options.SubscriptionName, the subscription is created
+// at the initial position if not exist. Like pulsar, an exclusive subscription accepts only
+// one consumer, and a subscription with consumers can not be subscribed with another type.
+func (c *Client) Subscribe(options mqwrapper.ConsumerOptions) (mqwrapper.Consumer, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := c.getTopic(options.Topic)
+	sub, ok := t.subs[options.SubscriptionName]
+	if !ok {
+		sub = newSubscription(c.broker, t, options)
+		t.subs[options.SubscriptionName] = sub
+		go sub.dispatch()
+	}
+	if len(sub.consumers) > 0 {
+		if sub.subType == mqwrapper.Exclusive {
+			return nil, retry.Unrecoverable(errors.Newf("ConsumerBusy: exclusive subscription %s already has a consumer", sub.name))
+		}
+		if sub.subType != options.SubscriptionType {
+			return nil, errors.Newf("subscription %s is %s, can not be subscribed as %s", sub.name, sub.subType, options.SubscriptionType)
+		}
+	}
+	sub.subType = options.SubscriptionType
+
+	bufSize := options.BufSize
+	if bufSize <= 0 {
+		bufSize = 1
+	}
+	consumer := &Consumer{
+		sub:              sub,
+		msgChan:          make(chan mqwrapper.Message, bufSize),
+		keepSubscription: options.KeepSubscription,
+	}
+	sub.consumers = append(sub.consumers, consumer)
+	t.signal()
+	return consumer, nil
+}
+
+// EarliestMessageID returns the id of the first message of a topic.
+func (c *Client) EarliestMessageID() mqwrapper.MessageID {
+	return memoryID(0)
+}
+
+// StringToMsgID parses the offset in id.
+func (c *Client) StringToMsgID(id string) (mqwrapper.MessageID, error) {
+	offset, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return memoryID(offset), nil
+}
+
+// BytesToMsgID deserializes a message id.
+func (c *Client) BytesToMsgID(id []byte) (mqwrapper.MessageID, error) {
+	offset, err := DeserializeMemoryMsgID(id)
+	if err != nil {
+		return nil, err
+	}
+	return memoryID(offset), nil
+}
+
+// Close closes the client, and the broker if it is created with the client.
+func (c *Client) Close() {
+	c.closed.Store(true)
+	if c.ownBroker {
+		c.broker.close()
+	}
+}
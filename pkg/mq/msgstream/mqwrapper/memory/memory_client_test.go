@@ -0,0 +1,314 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper"
+)
+
+const waitTimeout = 5 * time.Second
+
+func produce(t *testing.T, client *Client, topic string, keys ...string) {
+	producer, err := client.CreateProducer(mqwrapper.ProducerOptions{Topic: topic})
+	require.NoError(t, err)
+	defer producer.Close()
+	for i, key := range keys {
+		_, err := producer.Send(context.Background(), &mqwrapper.ProducerMessage{
+			Payload:    []byte(strconv.Itoa(i)),
+			Properties: map[string]string{"key": key},
+			Key:        key,
+		})
+		require.NoError(t, err)
+	}
+}
+
+func subscribe(t *testing.T, client *Client, topic, subName string, subType mqwrapper.SubscriptionType) mqwrapper.Consumer {
+	consumer, err := client.Subscribe(mqwrapper.ConsumerOptions{
+		Topic:                       topic,
+		SubscriptionName:            subName,
+		SubscriptionInitialPosition: mqwrapper.SubscriptionPositionEarliest,
+		SubscriptionType:            subType,
+		BufSize:                     1024,
+	})
+	require.NoError(t, err)
+	return consumer
+}
+
+// receive returns n messages received by consumer.
+func receive(t *testing.T, consumer mqwrapper.Consumer, n int) []mqwrapper.Message {
+	msgs := make([]mqwrapper.Message, 0, n)
+	for len(msgs) < n {
+		select {
+		case msg := <-consumer.Chan():
+			msgs = append(msgs, msg)
+		case <-time.After(waitTimeout):
+			t.Fatalf("received %d messages, expect %d", len(msgs), n)
+		}
+	}
+	return msgs
+}
+
+func offsets(msgs []mqwrapper.Message) []int {
+	result := make([]int, 0, len(msgs))
+	for _, msg := range msgs {
+		offset, _ := DeserializeMemoryMsgID(msg.ID().Serialize())
+		result = append(result, int(offset))
+	}
+	return result
+}
+
+func TestClient_ProduceConsume(t *testing.T) {
+	client := NewClient()
+	defer client.Close()
+	produce(t, client, "topic", "a", "b", "c")
+
+	consumer := subscribe(t, client, "topic", "sub", mqwrapper.Exclusive)
+	defer consumer.Close()
+	msgs := receive(t, consumer, 3)
+	assert.Equal(t, []int{0, 1, 2}, offsets(msgs))
+	assert.Equal(t, "1", string(msgs[1].Payload()))
+	assert.Equal(t, "b", msgs[1].Key())
+	assert.Equal(t, "b", msgs[1].Properties()["key"])
+	assert.Equal(t, "topic", msgs[1].Topic())
+	assert.Equal(t, "sub", consumer.Subscription())
+
+	latest, err := consumer.GetLatestMsgID()
+	assert.NoError(t, err)
+	equal, err := latest.Equal(msgs[2].ID().Serialize())
+	assert.NoError(t, err)
+	assert.True(t, equal)
+	assert.Error(t, consumer.CheckTopicValid("topic"))
+	assert.NoError(t, consumer.CheckTopicValid("other"))
+
+	// latest subscription only receives the new messages
+	latestConsumer, err := client.Subscribe(mqwrapper.ConsumerOptions{
+		Topic:                       "topic2",
+		SubscriptionName:            "latest",
+		SubscriptionInitialPosition: mqwrapper.SubscriptionPositionLatest,
+		BufSize:                     16,
+	})
+	assert.NoError(t, err)
+	defer latestConsumer.Close()
+	produce(t, client, "topic2", "d")
+	msg := receive(t, latestConsumer, 1)[0]
+	assert.Equal(t, "d", msg.Key())
+
+	t.Run("seek", func(t *testing.T) {
+		assert.NoError(t, consumer.Seek(msgs[1].ID(), false))
+		assert.Equal(t, []int{2}, offsets(receive(t, consumer, 1)))
+		assert.NoError(t, consumer.Seek(msgs[1].ID(), true))
+		assert.Equal(t, []int{1, 2}, offsets(receive(t, consumer, 2)))
+	})
+
+	t.Run("message id", func(t *testing.T) {
+		id, err := client.StringToMsgID("2")
+		assert.NoError(t, err)
+		lessOrEqual, err := msgs[1].ID().LessOrEqualThan(id.Serialize())
+		assert.NoError(t, err)
+		assert.True(t, lessOrEqual)
+		_, err = client.StringToMsgID("x")
+		assert.Error(t, err)
+
+		id, err = client.BytesToMsgID(msgs[2].ID().Serialize())
+		assert.NoError(t, err)
+		equal, err := id.Equal(msgs[2].ID().Serialize())
+		assert.NoError(t, err)
+		assert.True(t, equal)
+		_, err = client.BytesToMsgID([]byte{1})
+		assert.Error(t, err)
+		assert.True(t, client.EarliestMessageID().AtEarliestPosition())
+	})
+}
+
+func TestClient_Exclusive(t *testing.T) {
+	client := NewClient()
+	defer client.Close()
+	consumer := subscribe(t, client, "topic", "sub", mqwrapper.Exclusive)
+	_, err := client.Subscribe(mqwrapper.ConsumerOptions{Topic: "topic", SubscriptionName: "sub"})
+	assert.Error(t, err)
+
+	// another subscription is not affected
+	other := subscribe(t, client, "topic", "other", mqwrapper.Exclusive)
+	other.Close()
+
+	// resubscribe after closed
+	consumer.Close()
+	consumer = subscribe(t, client, "topic", "sub", mqwrapper.Shared)
+	defer consumer.Close()
+	_, err = client.Subscribe(mqwrapper.ConsumerOptions{Topic: "topic", SubscriptionName: "sub", SubscriptionType: mqwrapper.KeyShared})
+	assert.Error(t, err)
+}
+
+func TestClient_Failover(t *testing.T) {
+	client := NewClient()
+	defer client.Close()
+	active := subscribe(t, client, "topic", "sub", mqwrapper.Failover)
+	standby := subscribe(t, client, "topic", "sub", mqwrapper.Failover)
+	defer standby.Close()
+	produce(t, client, "topic", "a", "b", "c", "d")
+
+	msgs := receive(t, active, 4)
+	assert.Equal(t, []int{0, 1, 2, 3}, offsets(msgs))
+	active.Ack(msgs[0])
+	active.Ack(msgs[1])
+	assert.Empty(t, standby.Chan())
+
+	// the messages not acked are redelivered to the standby in order
+	active.Close()
+	assert.Equal(t, []int{2, 3}, offsets(receive(t, standby, 2)))
+}
+
+func TestClient_Shared(t *testing.T) {
+	client := NewClient()
+	defer client.Close()
+	consumers := make([]mqwrapper.Consumer, 3)
+	for i := range consumers {
+		consumers[i] = subscribe(t, client, "topic", "sub", mqwrapper.Shared)
+		defer consumers[i].Close()
+	}
+	keys := make([]string, 30)
+	produce(t, client, "topic", keys...)
+
+	received := make(map[int]bool)
+	for _, consumer := range consumers {
+		// dispatched round robin
+		for _, offset := range offsets(receive(t, consumer, 10)) {
+			received[offset] = true
+		}
+	}
+	assert.Len(t, received, 30)
+}
+
+func TestClient_KeyShared(t *testing.T) {
+	client := NewClient()
+	defer client.Close()
+	consumers := make([]mqwrapper.Consumer, 3)
+	for i := range consumers {
+		consumers[i] = subscribe(t, client, "topic", "sub", mqwrapper.KeyShared)
+		defer consumers[i].Close()
+	}
+	keys := make([]string, 0)
+	for i := 0; i < 100; i++ {
+		keys = append(keys, fmt.Sprintf("key-%d", i%10))
+	}
+	produce(t, client, "topic", keys...)
+
+	owners := make(map[string]int)
+	total := 0
+	for i, consumer := range consumers {
+		lastOffsets := make(map[string]int)
+	loop:
+		for {
+			select {
+			case msg := <-consumer.Chan():
+				total++
+				owner, ok := owners[msg.Key()]
+				if ok {
+					// all the messages of one key go to one consumer
+					assert.Equal(t, i, owner)
+				}
+				owners[msg.Key()] = i
+				// in order within a key
+				offset := offsets([]mqwrapper.Message{msg})[0]
+				if last, ok := lastOffsets[msg.Key()]; ok {
+					assert.Greater(t, offset, last)
+				}
+				lastOffsets[msg.Key()] = offset
+			case <-time.After(100 * time.Millisecond):
+				break loop
+			}
+		}
+	}
+	assert.Equal(t, 100, total)
+	assert.Len(t, owners, 10)
+}
+
+func TestClient_Close(t *testing.T) {
+	client := NewClient()
+	producer, err := client.CreateProducer(mqwrapper.ProducerOptions{Topic: "topic"})
+	require.NoError(t, err)
+	future := producer.SendAsync(context.Background(), &mqwrapper.ProducerMessage{Payload: []byte("0")})
+	_, err = future.Await()
+	assert.NoError(t, err)
+
+	client.Close()
+	client.Close()
+	_, err = producer.Send(context.Background(), &mqwrapper.ProducerMessage{Payload: []byte("1")})
+	assert.Error(t, err)
+}
+
+func TestClient_Nack(t *testing.T) {
+	client := NewClient()
+	defer client.Close()
+	produce(t, client, "topic", "a", "b", "c")
+	consumer := subscribe(t, client, "topic", "sub", mqwrapper.Exclusive)
+	defer consumer.Close()
+	msgs := receive(t, consumer, 3)
+	consumer.Ack(msgs[0])
+	consumer.Nack(msgs[1])
+	assert.Equal(t, []int{1}, offsets(receive(t, consumer, 1)))
+	// nack an acked message is ignored
+	consumer.Nack(msgs[0])
+	assert.Empty(t, consumer.Chan())
+}
+
+func TestClient_KeepSubscription(t *testing.T) {
+	client := NewClient()
+	defer client.Close()
+	produce(t, client, "topic", "a", "b", "c")
+
+	subscribeKeep := func(client *Client, keep bool) mqwrapper.Consumer {
+		consumer, err := client.Subscribe(mqwrapper.ConsumerOptions{
+			Topic:                       "topic",
+			SubscriptionName:            "sub",
+			SubscriptionInitialPosition: mqwrapper.SubscriptionPositionEarliest,
+			SubscriptionType:            mqwrapper.Exclusive,
+			BufSize:                     1024,
+			KeepSubscription:            keep,
+		})
+		require.NoError(t, err)
+		return consumer
+	}
+
+	// closing the client of the same queue keeps the subscription cursor
+	other := client.NewClient()
+	consumer := subscribeKeep(other, true)
+	msgs := receive(t, consumer, 2)
+	consumer.Ack(msgs[0])
+	consumer.Close()
+	other.Close()
+
+	other = client.NewClient()
+	defer other.Close()
+	consumer = subscribeKeep(other, false)
+	assert.Equal(t, []int{1, 2}, offsets(receive(t, consumer, 2)))
+	// the last consumer unsubscribes
+	consumer.Close()
+
+	consumer = subscribeKeep(other, false)
+	defer consumer.Close()
+	assert.Equal(t, []int{0, 1, 2}, offsets(receive(t, consumer, 3)))
+}
@@ -0,0 +1,261 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"hash/fnv"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper"
+)
+
+// Check Consumer implements Consumer interface
+var _ mqwrapper.Consumer = (*Consumer)(nil)
+
+// dispatchRetryInterval is the interval of retrying a consumer whose channel is full.
+const dispatchRetryInterval = time.Millisecond
+
+type subscription struct {
+	broker    *broker
+	topic     *topic
+	name      string
+	subType   mqwrapper.SubscriptionType
+	consumers []*Consumer
+	// cursor is the offset of the next message never dispatched.
+	cursor int64
+	// redeliver are the messages dispatched but not acked by a closed consumer, sorted by offset.
+	redeliver []*memoryMessage
+	// unacked are the consumers of the messages dispatched but not acked.
+	unacked map[int64]*Consumer
+	// next is the round robin index of shared subscriptions.
+	next int
+	// dropped is set when the subscription is removed from its topic.
+	dropped bool
+}
+
+func newSubscription(b *broker, t *topic, options mqwrapper.ConsumerOptions) *subscription {
+	sub := &subscription{
+		broker:  b,
+		topic:   t,
+		name:    options.SubscriptionName,
+		subType: options.SubscriptionType,
+		unacked: make(map[int64]*Consumer),
+	}
+	if options.SubscriptionInitialPosition != mqwrapper.SubscriptionPositionEarliest {
+		sub.cursor = int64(len(t.msgs))
+	}
+	return sub
+}
+
+// peek returns the next message to dispatch, must be called with broker.mu held.
+func (s *subscription) peek() *memoryMessage {
+	if len(s.redeliver) > 0 {
+		return s.redeliver[0]
+	}
+	if s.cursor < int64(len(s.topic.msgs)) {
+		return s.topic.msgs[s.cursor]
+	}
+	return nil
+}
+
+func (s *subscription) pop() {
+	if len(s.redeliver) > 0 {
+		s.redeliver = s.redeliver[1:]
+		return
+	}
+	s.cursor++
+}
+
+// pick returns the consumer msg is dispatched to, must be called with broker.mu held.
+func (s *subscription) pick(msg *memoryMessage) *Consumer {
+	switch s.subType {
+	case mqwrapper.Shared:
+		s.next = (s.next + 1) % len(s.consumers)
+		return s.consumers[s.next]
+	case mqwrapper.KeyShared:
+		h := fnv.New32a()
+		h.Write([]byte(msg.key))
+		return s.consumers[h.Sum32()%uint32(len(s.consumers))]
+	default:
+		// the earliest consumer is the active one of a failover subscription
+		return s.consumers[0]
+	}
+}
+
+// requeue redelivers the messages of offsets.
+func (s *subscription) requeue(offsets []int64) {
+	for _, offset := range offsets {
+		delete(s.unacked, offset)
+		s.redeliver = append(s.redeliver, s.topic.msgs[offset])
+	}
+	sort.Slice(s.redeliver, func(i, j int) bool {
+		return s.redeliver[i].offset < s.redeliver[j].offset
+	})
+}
+
+// dispatch sends the messages to the consumers until the broker is closed or the subscription is dropped.
+func (s *subscription) dispatch() {
+	c := s.broker
+	c.mu.Lock()
+	for {
+		if s.dropped {
+			c.mu.Unlock()
+			return
+		}
+		var wait <-chan time.Time
+		msg := s.peek()
+		if msg != nil && len(s.consumers) > 0 {
+			consumer := s.pick(msg)
+			select {
+			case consumer.msgChan <- msg:
+				s.pop()
+				s.unacked[msg.offset] = consumer
+				continue
+			default:
+				wait = time.After(dispatchRetryInterval)
+			}
+		}
+		notify := s.topic.notify
+		c.mu.Unlock()
+		select {
+		case <-notify:
+		case <-wait:
+		case <-c.closeCh:
+			return
+		}
+		c.mu.Lock()
+	}
+}
+
+// Consumer is a consumer of an in-process subscription.
+type Consumer struct {
+	sub     *subscription
+	msgChan chan mqwrapper.Message
+	// keepSubscription closes the consumer without unsubscribing
+	keepSubscription bool
+	closeOnce        sync.Once
+}
+
+// Subscription returns the subscription name of the consumer.
+func (mc *Consumer) Subscription() string {
+	return mc.sub.name
+}
+
+// Chan returns the channel of the messages dispatched to the consumer.
+func (mc *Consumer) Chan() <-chan mqwrapper.Message {
+	return mc.msgChan
+}
+
+// Seek moves the subscription cursor to id, the messages not acked are dropped.
+func (mc *Consumer) Seek(id mqwrapper.MessageID, inclusive bool) error {
+	offset, err := DeserializeMemoryMsgID(id.Serialize())
+	if err != nil {
+		return err
+	}
+	if !inclusive {
+		offset++
+	}
+	c := mc.sub.broker
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	mc.sub.cursor = offset
+	mc.sub.redeliver = nil
+	mc.sub.unacked = make(map[int64]*Consumer)
+	mc.sub.topic.signal()
+	return nil
+}
+
+// Ack acknowledges msg, it is never redelivered.
+func (mc *Consumer) Ack(msg mqwrapper.Message) {
+	offset, err := DeserializeMemoryMsgID(msg.ID().Serialize())
+	if err != nil {
+		return
+	}
+	c := mc.sub.broker
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(mc.sub.unacked, offset)
+}
+
+// Nack redelivers msg at once.
+func (mc *Consumer) Nack(msg mqwrapper.Message) {
+	offset, err := DeserializeMemoryMsgID(msg.ID().Serialize())
+	if err != nil {
+		return
+	}
+	c := mc.sub.broker
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if mc.sub.unacked[offset] != mc {
+		return
+	}
+	mc.sub.requeue([]int64{offset})
+	mc.sub.topic.signal()
+}
+
+// Close removes the consumer from the subscription, the messages dispatched
+// to it but not acked are redelivered to the other consumers. Like pulsar, the
+// last consumer unsubscribes unless it keeps the subscription.
+func (mc *Consumer) Close() {
+	mc.closeOnce.Do(func() {
+		c := mc.sub.broker
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		sub := mc.sub
+		for i, consumer := range sub.consumers {
+			if consumer == mc {
+				sub.consumers = append(sub.consumers[:i], sub.consumers[i+1:]...)
+				break
+			}
+		}
+		offsets := make([]int64, 0)
+		for offset, consumer := range sub.unacked {
+			if consumer == mc {
+				offsets = append(offsets, offset)
+			}
+		}
+		sub.requeue(offsets)
+		if !mc.keepSubscription && len(sub.consumers) == 0 {
+			delete(sub.topic.subs, sub.name)
+			sub.dropped = true
+		}
+		sub.topic.signal()
+	})
+}
+
+// GetLatestMsgID returns the id of the last message of the topic.
+func (mc *Consumer) GetLatestMsgID() (mqwrapper.MessageID, error) {
+	c := mc.sub.broker
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return memoryID(len(mc.sub.topic.msgs) - 1), nil
+}
+
+// CheckTopicValid returns an error if channel is not empty.
+func (mc *Consumer) CheckTopicValid(channel string) error {
+	c := mc.sub.broker
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t, ok := c.topics[channel]; ok && len(t.msgs) > 0 {
+		return errors.Newf("topic %s is not empty", channel)
+	}
+	return nil
+}
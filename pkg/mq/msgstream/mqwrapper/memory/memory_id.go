@@ -0,0 +1,68 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"encoding/binary"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper"
+)
+
+// Check memoryID implements MessageID interface
+var _ mqwrapper.MessageID = memoryID(0)
+
+// memoryID is the offset of a message in its topic.
+type memoryID int64
+
+func (id memoryID) Serialize() []byte {
+	return SerializeMemoryMsgID(int64(id))
+}
+
+func (id memoryID) AtEarliestPosition() bool {
+	return id <= 0
+}
+
+func (id memoryID) LessOrEqualThan(msgID []byte) (bool, error) {
+	other, err := DeserializeMemoryMsgID(msgID)
+	if err != nil {
+		return false, err
+	}
+	return int64(id) <= other, nil
+}
+
+func (id memoryID) Equal(msgID []byte) (bool, error) {
+	other, err := DeserializeMemoryMsgID(msgID)
+	if err != nil {
+		return false, err
+	}
+	return int64(id) == other, nil
+}
+
+// SerializeMemoryMsgID returns the serialized message id of offset.
+func SerializeMemoryMsgID(offset int64) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(offset))
+}
+
+// DeserializeMemoryMsgID returns the offset of a serialized message id.
+func DeserializeMemoryMsgID(msgID []byte) (int64, error) {
+	if len(msgID) != 8 {
+		return 0, errors.Newf("invalid memory message id length %d", len(msgID))
+	}
+	return int64(binary.BigEndian.Uint64(msgID)), nil
+}
@@ -0,0 +1,52 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper"
+)
+
+// Check memoryMessage implements ConsumerMessage
+var _ mqwrapper.Message = (*memoryMessage)(nil)
+
+type memoryMessage struct {
+	topic      string
+	offset     int64
+	payload    []byte
+	properties map[string]string
+	key        string
+}
+
+func (m *memoryMessage) Topic() string {
+	return m.topic
+}
+
+func (m *memoryMessage) Properties() map[string]string {
+	return m.properties
+}
+
+func (m *memoryMessage) Payload() []byte {
+	return m.payload
+}
+
+func (m *memoryMessage) Key() string {
+	return m.key
+}
+
+func (m *memoryMessage) ID() mqwrapper.MessageID {
+	return memoryID(m.offset)
+}
@@ -0,0 +1,46 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory
+
+import (
+	"context"
+
+	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper"
+	"github.com/xige-16/stream-read/pkg/util/conc"
+)
+
+// implementation assertion
+var _ mqwrapper.Producer = (*memoryProducer)(nil)
+
+type memoryProducer struct {
+	client *Client
+	topic  string
+}
+
+func (mp *memoryProducer) Send(ctx context.Context, message *mqwrapper.ProducerMessage) (mqwrapper.MessageID, error) {
+	return mp.client.publish(mp.topic, message)
+}
+
+// SendAsync publishes the message at once, so the order of calls is kept.
+func (mp *memoryProducer) SendAsync(ctx context.Context, message *mqwrapper.ProducerMessage) *conc.Future[mqwrapper.MessageID] {
+	promise := conc.NewPromise[mqwrapper.MessageID]()
+	promise.Complete(mp.client.publish(mp.topic, message))
+	return promise.Future()
+}
+
+func (mp *memoryProducer) Close() {
+}
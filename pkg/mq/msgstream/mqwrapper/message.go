@@ -31,4 +31,16 @@ type Message interface {
 	// ID get the unique message ID associated with this message.
 	// The message id can be used to univocally refer to a message without having the keep the entire payload in memory.
 	ID() MessageID
+
+	// Key get the key of the message, empty if the message was produced without a key
+	Key() string
+}
+
+// CorruptMessage is implemented by the messages an mq failed to read, such as a truncated
+// record of an archive, which are handled by the consumers as the undecodable ones.
+type CorruptMessage interface {
+	Message
+
+	// ReadErr returns the error reading the message, nil if the message is intact
+	ReadErr() error
 }
@@ -16,7 +16,12 @@
 
 package mqwrapper
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/xige-16/stream-read/pkg/util/conc"
+)
 
 // ProducerOptions contains the options of a producer
 type ProducerOptions struct {
@@ -26,6 +31,14 @@ type ProducerOptions struct {
 	// Enable compression
 	// For Pulsar, this enables ZSTD compression with default compression level
 	EnableCompression bool
+
+	// EnableBatching groups the messages sent asynchronously into batches,
+	// disabled by default.
+	EnableBatching bool
+	// BatchingMaxMessages is the max number of messages in one batch, 0 means the mq default
+	BatchingMaxMessages uint
+	// BatchingMaxPublishDelay is the max delay of sending a batch, 0 means the mq default
+	BatchingMaxPublishDelay time.Duration
 }
 
 // ProducerMessage contains the messages of a producer
@@ -35,6 +48,9 @@ type ProducerMessage struct {
 	// Properties are application defined key/value pairs that will be attached to the message.
 	// Return the properties attached to the message.
 	Properties map[string]string
+	// Key routes the message to a consumer of a KeyShared subscription,
+	// the messages of the same key are consumed in order by one consumer.
+	Key string
 }
 
 // Producer is the interface that provides operations of producer
@@ -45,5 +61,9 @@ type Producer interface {
 	// publish a message
 	Send(ctx context.Context, message *ProducerMessage) (MessageID, error)
 
+	// publish a message without waiting for the ack, the messages sent
+	// by one producer are published in the order of calls
+	SendAsync(ctx context.Context, message *ProducerMessage) *conc.Future[MessageID]
+
 	Close()
 }
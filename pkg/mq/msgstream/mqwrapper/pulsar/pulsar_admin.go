@@ -0,0 +1,164 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pulsar
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	pulsarctl "github.com/streamnative/pulsarctl/pkg/pulsar"
+	"github.com/streamnative/pulsarctl/pkg/pulsar/utils"
+	"go.uber.org/zap"
+
+	"github.com/xige-16/stream-read/pkg/log"
+)
+
+// SubscriptionInfo describes a subscription of a topic.
+type SubscriptionInfo struct {
+	Name      string
+	Type      string
+	Backlog   int64
+	Consumers int
+}
+
+// SubscriptionAdmin manages the subscriptions of the topics in one tenant and namespace.
+type SubscriptionAdmin struct {
+	admin     pulsarctl.Client
+	tenant    string
+	namespace string
+}
+
+// NewSubscriptionAdmin creates a SubscriptionAdmin through the pulsar web service.
+func NewSubscriptionAdmin(tenant, namespace, address, authPlugin, authParams string) (*SubscriptionAdmin, error) {
+	admin, err := NewAdminClient(address, authPlugin, authParams)
+	if err != nil {
+		return nil, err
+	}
+	return &SubscriptionAdmin{
+		admin:     admin,
+		tenant:    tenant,
+		namespace: namespace,
+	}, nil
+}
+
+func (sa *SubscriptionAdmin) topicName(topic string) (utils.TopicName, error) {
+	fullTopicName, err := GetFullTopicName(sa.tenant, sa.namespace, topic)
+	if err != nil {
+		return utils.TopicName{}, err
+	}
+	topicName, err := utils.GetTopicName(fullTopicName)
+	if err != nil {
+		return utils.TopicName{}, err
+	}
+	return *topicName, nil
+}
+
+// ListSubscriptions returns the subscriptions of topic with their backlog, sorted by name.
+func (sa *SubscriptionAdmin) ListSubscriptions(topic string) ([]SubscriptionInfo, error) {
+	topicName, err := sa.topicName(topic)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := sa.admin.Topics().GetStats(topicName)
+	if err != nil {
+		return nil, err
+	}
+	subs := make([]SubscriptionInfo, 0, len(stats.Subscriptions))
+	for name, sub := range stats.Subscriptions {
+		subs = append(subs, SubscriptionInfo{
+			Name:      name,
+			Type:      sub.SubType,
+			Backlog:   sub.MsgBacklog,
+			Consumers: len(sub.Consumers),
+		})
+	}
+	sort.Slice(subs, func(i, j int) bool {
+		return subs[i].Name < subs[j].Name
+	})
+	return subs, nil
+}
+
+// DeleteSubscription deletes subName of topic, a subscription not found is ignored.
+// Force deletes the subscription even if it has active consumers.
+func (sa *SubscriptionAdmin) DeleteSubscription(topic, subName string, force bool) error {
+	topicName, err := sa.topicName(topic)
+	if err != nil {
+		return err
+	}
+	err = sa.admin.Subscriptions().Delete(topicName, subName, force)
+	if err != nil && strings.Contains(err.Error(), "Subscription not found") {
+		return nil
+	}
+	return err
+}
+
+// DeleteStaleSubscriptions deletes the subscriptions of topic named with prefix which
+// have no consumers, returns the names deleted.
+func (sa *SubscriptionAdmin) DeleteStaleSubscriptions(topic, prefix string) ([]string, error) {
+	subs, err := sa.ListSubscriptions(topic)
+	if err != nil {
+		return nil, err
+	}
+	deleted := make([]string, 0)
+	for _, sub := range subs {
+		if !strings.HasPrefix(sub.Name, prefix) || sub.Consumers > 0 {
+			continue
+		}
+		if err := sa.DeleteSubscription(topic, sub.Name, false); err != nil {
+			log.Warn("failed to delete stale subscription",
+				zap.String("topic", topic),
+				zap.String("subscription", sub.Name),
+				zap.Error(err))
+			return deleted, err
+		}
+		deleted = append(deleted, sub.Name)
+	}
+	return deleted, nil
+}
+
+// ResetCursorToMessageID resets the cursor of subName to the serialized message id.
+func (sa *SubscriptionAdmin) ResetCursorToMessageID(topic, subName string, msgID []byte) error {
+	topicName, err := sa.topicName(topic)
+	if err != nil {
+		return err
+	}
+	pID, err := DeserializePulsarMsgID(msgID)
+	if err != nil {
+		return err
+	}
+	return sa.admin.Subscriptions().ResetCursorToMessageID(topicName, subName, toAdminMessageID(pID))
+}
+
+// ResetCursorToTimestamp resets the cursor of subName to the first message published after t.
+func (sa *SubscriptionAdmin) ResetCursorToTimestamp(topic, subName string, t time.Time) error {
+	topicName, err := sa.topicName(topic)
+	if err != nil {
+		return err
+	}
+	return sa.admin.Subscriptions().ResetCursorToTimestamp(topicName, subName, t.UnixMilli())
+}
+
+func toAdminMessageID(id pulsar.MessageID) utils.MessageID {
+	return utils.MessageID{
+		LedgerID:         id.LedgerID(),
+		EntryID:          id.EntryID(),
+		PartitionedIndex: int(id.PartitionIdx()),
+		BatchIndex:       int(id.BatchIdx()),
+	}
+}
@@ -0,0 +1,48 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pulsar
+
+import (
+	"testing"
+
+	"github.com/apache/pulsar-client-go/pulsar"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscriptionAdmin_TopicName(t *testing.T) {
+	sa := &SubscriptionAdmin{tenant: "public", namespace: "default"}
+	topicName, err := sa.topicName("topic")
+	assert.NoError(t, err)
+	assert.Equal(t, "persistent://public/default/topic", topicName.String())
+
+	sa = &SubscriptionAdmin{tenant: "public"}
+	_, err = sa.topicName("topic")
+	assert.Error(t, err)
+}
+
+func TestSubscriptionAdmin_MessageID(t *testing.T) {
+	id := pulsar.LatestMessageID()
+	adminID := toAdminMessageID(id)
+	assert.Equal(t, id.LedgerID(), adminID.LedgerID)
+	assert.Equal(t, id.EntryID(), adminID.EntryID)
+	assert.Equal(t, int(id.PartitionIdx()), adminID.PartitionedIndex)
+	assert.Equal(t, int(id.BatchIdx()), adminID.BatchIndex)
+
+	sa := &SubscriptionAdmin{tenant: "public", namespace: "default"}
+	err := sa.ResetCursorToMessageID("topic", "sub", []byte("invalid"))
+	assert.Error(t, err)
+}
@@ -81,10 +81,17 @@ func (pc *pulsarClient) CreateProducer(options mqwrapper.ProducerOptions) (mqwra
 		opts.CompressionType = pulsar.ZSTD
 		opts.CompressionLevel = pulsar.Faster
 	}
-	// disable automatic batching
-	opts.DisableBatching = true
-	// change the batching max publish delay higher to avoid extra cpu consumption
-	opts.BatchingMaxPublishDelay = 1 * time.Minute
+	if options.EnableBatching {
+		opts.BatchingMaxMessages = options.BatchingMaxMessages
+		opts.BatchingMaxPublishDelay = options.BatchingMaxPublishDelay
+		// a batch must hold the messages of one key to be dispatched to key shared consumers
+		opts.BatcherBuilderType = pulsar.KeyBasedBatchBuilder
+	} else {
+		// disable automatic batching
+		opts.DisableBatching = true
+		// change the batching max publish delay higher to avoid extra cpu consumption
+		opts.BatchingMaxPublishDelay = 1 * time.Minute
+	}
 
 	pp, err := pc.client.CreateProducer(opts)
 	if err != nil {
@@ -116,7 +123,7 @@ func (pc *pulsarClient) Subscribe(options mqwrapper.ConsumerOptions) (mqwrapper.
 	consumer, err := pc.client.Subscribe(pulsar.ConsumerOptions{
 		Topic:                       fullTopicName,
 		SubscriptionName:            options.SubscriptionName,
-		Type:                        pulsar.Exclusive,
+		Type:                        toPulsarSubscriptionType(options.SubscriptionType),
 		SubscriptionInitialPosition: pulsar.SubscriptionInitialPosition(options.SubscriptionInitialPosition),
 		MessageChannel:              receiveChannel,
 	})
@@ -128,7 +135,7 @@ func (pc *pulsarClient) Subscribe(options mqwrapper.ConsumerOptions) (mqwrapper.
 		return nil, err
 	}
 
-	pConsumer := &Consumer{c: consumer, closeCh: make(chan struct{})}
+	pConsumer := &Consumer{c: consumer, closeCh: make(chan struct{}), keepSubscription: options.KeepSubscription}
 	// prevent seek to earliest patch applied when using latest position options
 	if options.SubscriptionInitialPosition == mqwrapper.SubscriptionPositionLatest {
 		pConsumer.AtLatest = true
@@ -140,6 +147,19 @@ func (pc *pulsarClient) Subscribe(options mqwrapper.ConsumerOptions) (mqwrapper.
 	return pConsumer, nil
 }
 
+func toPulsarSubscriptionType(subType mqwrapper.SubscriptionType) pulsar.SubscriptionType {
+	switch subType {
+	case mqwrapper.Failover:
+		return pulsar.Failover
+	case mqwrapper.Shared:
+		return pulsar.Shared
+	case mqwrapper.KeyShared:
+		return pulsar.KeyShared
+	default:
+		return pulsar.Exclusive
+	}
+}
+
 func GetFullTopicName(tenant string, namespace string, topic string) (string, error) {
 	if len(tenant) == 0 || len(namespace) == 0 || len(topic) == 0 {
 		log.Error("build full topic name failed",
@@ -789,3 +789,11 @@ func TestPulsarClient_GetFullTopicName(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "tenant/namespace/topic", fullTopicName)
 }
+
+func TestPulsarClient_SubscriptionType(t *testing.T) {
+	assert.Equal(t, pulsar.Exclusive, toPulsarSubscriptionType(mqwrapper.Exclusive))
+	assert.Equal(t, pulsar.Failover, toPulsarSubscriptionType(mqwrapper.Failover))
+	assert.Equal(t, pulsar.Shared, toPulsarSubscriptionType(mqwrapper.Shared))
+	assert.Equal(t, pulsar.KeyShared, toPulsarSubscriptionType(mqwrapper.KeyShared))
+	assert.Equal(t, pulsar.Exclusive, toPulsarSubscriptionType(mqwrapper.SubscriptionType(-1)))
+}
@@ -41,6 +41,8 @@ type Consumer struct {
 	once       sync.Once
 	skip       bool
 	closeOnce  sync.Once
+	// keepSubscription closes the consumer without unsubscribing
+	keepSubscription bool
 }
 
 // Subscription get a subscription for the consumer
@@ -114,9 +116,20 @@ func (pc *Consumer) Ack(message mqwrapper.Message) {
 	pc.c.Ack(pm.msg)
 }
 
+// Nack the consumption of a single message, it is redelivered after the redelivery delay
+func (pc *Consumer) Nack(message mqwrapper.Message) {
+	pm := message.(*pulsarMessage)
+	pc.c.Nack(pm.msg)
+}
+
 // Close the consumer and stop the broker to push more messages
 func (pc *Consumer) Close() {
 	pc.closeOnce.Do(func() {
+		if pc.keepSubscription {
+			pc.c.Close()
+			close(pc.closeCh)
+			return
+		}
 		// Unsubscribe for the consumer
 		fn := func() error {
 			err := pc.c.Unsubscribe()
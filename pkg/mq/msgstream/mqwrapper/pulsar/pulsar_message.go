@@ -41,6 +41,10 @@ func (pm *pulsarMessage) Payload() []byte {
 	return pm.msg.Payload()
 }
 
+func (pm *pulsarMessage) Key() string {
+	return pm.msg.Key()
+}
+
 func (pm *pulsarMessage) ID() mqwrapper.MessageID {
 	id := pm.msg.ID()
 	pid := &pulsarID{messageID: id}
@@ -23,6 +23,7 @@ import (
 
 	"github.com/xige-16/stream-read/pkg/metrics"
 	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper"
+	"github.com/xige-16/stream-read/pkg/util/conc"
 	"github.com/xige-16/stream-read/pkg/util/timerecord"
 )
 
@@ -42,7 +43,7 @@ func (pp *pulsarProducer) Send(ctx context.Context, message *mqwrapper.ProducerM
 	start := timerecord.NewTimeRecorder("send msg to stream")
 	metrics.MsgStreamOpCounter.WithLabelValues(metrics.SendMsgLabel, metrics.TotalLabel).Inc()
 
-	ppm := &pulsar.ProducerMessage{Payload: message.Payload, Properties: message.Properties}
+	ppm := &pulsar.ProducerMessage{Payload: message.Payload, Properties: message.Properties, Key: message.Key}
 	pmID, err := pp.p.Send(ctx, ppm)
 	if err != nil {
 		metrics.MsgStreamOpCounter.WithLabelValues(metrics.SendMsgLabel, metrics.FailLabel).Inc()
@@ -54,6 +55,25 @@ func (pp *pulsarProducer) Send(ctx context.Context, message *mqwrapper.ProducerM
 	return &pulsarID{messageID: pmID}, nil
 }
 
+func (pp *pulsarProducer) SendAsync(ctx context.Context, message *mqwrapper.ProducerMessage) *conc.Future[mqwrapper.MessageID] {
+	start := timerecord.NewTimeRecorder("send msg to stream async")
+	metrics.MsgStreamOpCounter.WithLabelValues(metrics.SendMsgLabel, metrics.TotalLabel).Inc()
+
+	promise := conc.NewPromise[mqwrapper.MessageID]()
+	ppm := &pulsar.ProducerMessage{Payload: message.Payload, Properties: message.Properties, Key: message.Key}
+	pp.p.SendAsync(ctx, ppm, func(pmID pulsar.MessageID, _ *pulsar.ProducerMessage, err error) {
+		if err != nil {
+			metrics.MsgStreamOpCounter.WithLabelValues(metrics.SendMsgLabel, metrics.FailLabel).Inc()
+			promise.Complete(nil, err)
+			return
+		}
+		metrics.MsgStreamRequestLatency.WithLabelValues(metrics.SendMsgLabel).Observe(float64(start.ElapseSpan().Milliseconds()))
+		metrics.MsgStreamOpCounter.WithLabelValues(metrics.SendMsgLabel, metrics.SuccessLabel).Inc()
+		promise.Complete(&pulsarID{messageID: pmID}, nil)
+	})
+	return promise.Future()
+}
+
 func (pp *pulsarProducer) Close() {
 	pp.p.Close()
 }
@@ -302,6 +302,73 @@ func (it *InsertMsg) IndexMsg(index int) *InsertMsg {
 	}
 }
 
+// SelectRequest returns the request holding the rows of indexes, the fields data
+// are gathered column by column into one columnar request.
+func (it *InsertMsg) SelectRequest(indexes []int) msgpb.InsertRequest {
+	timestamps := make([]uint64, 0, len(indexes))
+	rowIDs := make([]int64, 0, len(indexes))
+	for _, index := range indexes {
+		timestamps = append(timestamps, it.Timestamps[index])
+		rowIDs = append(rowIDs, it.RowIDs[index])
+	}
+	request := msgpb.InsertRequest{
+		Base: commonpbutil.NewMsgBase(
+			commonpbutil.WithMsgType(commonpb.MsgType_Insert),
+			commonpbutil.WithMsgID(it.Base.MsgID),
+			commonpbutil.WithTimeStamp(it.Base.Timestamp),
+			commonpbutil.WithSourceID(it.Base.SourceID),
+		),
+		DbID:           it.DbID,
+		CollectionID:   it.CollectionID,
+		PartitionID:    it.PartitionID,
+		CollectionName: it.CollectionName,
+		PartitionName:  it.PartitionName,
+		SegmentID:      it.SegmentID,
+		ShardName:      it.ShardName,
+		Timestamps:     timestamps,
+		RowIDs:         rowIDs,
+	}
+
+	if it.IsRowBased() {
+		request.RowData = make([]*commonpb.Blob, 0, len(indexes))
+		for _, index := range indexes {
+			request.RowData = append(request.RowData, it.RowData[index])
+		}
+		request.Version = msgpb.InsertDataVersion_RowBased
+		return request
+	}
+
+	fieldsData := typeutil.PrepareResultFieldData(it.GetFieldsData(), int64(len(indexes)))
+	for _, index := range indexes {
+		typeutil.AppendFieldData(fieldsData, it.GetFieldsData(), int64(index))
+	}
+	request.FieldsData = fieldsData
+	request.NumRows = uint64(len(indexes))
+	request.Version = msgpb.InsertDataVersion_ColumnBased
+	return request
+}
+
+// SelectMsg returns the msg holding the rows of indexes, see SelectRequest.
+func (it *InsertMsg) SelectMsg(indexes []int) *InsertMsg {
+	var hashValues []uint32
+	if len(it.HashValues) == len(it.Timestamps) {
+		hashValues = make([]uint32, 0, len(indexes))
+		for _, index := range indexes {
+			hashValues = append(hashValues, it.HashValues[index])
+		}
+	}
+	return &InsertMsg{
+		BaseMsg: BaseMsg{
+			Ctx:            it.TraceCtx(),
+			BeginTimestamp: it.BeginTimestamp,
+			EndTimestamp:   it.EndTimestamp,
+			HashValues:     hashValues,
+			MsgPosition:    it.MsgPosition,
+		},
+		InsertRequest: it.SelectRequest(indexes),
+	}
+}
+
 func (it *InsertMsg) Size() int {
 	return proto.Size(&it.InsertRequest)
 }
@@ -409,6 +476,58 @@ func (dt *DeleteMsg) Size() int {
 	return proto.Size(&dt.DeleteRequest)
 }
 
+// SelectMsg returns the msg deleting the rows of indexes.
+func (dt *DeleteMsg) SelectMsg(indexes []int) *DeleteMsg {
+	var hashValues []uint32
+	if len(dt.HashValues) == len(dt.Timestamps) {
+		hashValues = make([]uint32, 0, len(indexes))
+	}
+	var int64PrimaryKeys []int64
+	if len(dt.Int64PrimaryKeys) == len(dt.Timestamps) {
+		int64PrimaryKeys = make([]int64, 0, len(indexes))
+	}
+	timestamps := make([]uint64, 0, len(indexes))
+	primaryKeys := &schemapb.IDs{}
+	for _, index := range indexes {
+		if hashValues != nil {
+			hashValues = append(hashValues, dt.HashValues[index])
+		}
+		if int64PrimaryKeys != nil {
+			int64PrimaryKeys = append(int64PrimaryKeys, dt.Int64PrimaryKeys[index])
+		}
+		timestamps = append(timestamps, dt.Timestamps[index])
+		typeutil.AppendIDs(primaryKeys, dt.GetPrimaryKeys(), index)
+	}
+	return &DeleteMsg{
+		BaseMsg: BaseMsg{
+			Ctx:            dt.TraceCtx(),
+			BeginTimestamp: dt.BeginTimestamp,
+			EndTimestamp:   dt.EndTimestamp,
+			HashValues:     hashValues,
+			MsgPosition:    dt.MsgPosition,
+		},
+		DeleteRequest: msgpb.DeleteRequest{
+			Base: commonpbutil.NewMsgBase(
+				commonpbutil.WithMsgType(commonpb.MsgType_Delete),
+				commonpbutil.WithMsgID(dt.Base.GetMsgID()),
+				commonpbutil.WithTimeStamp(dt.Base.GetTimestamp()),
+				commonpbutil.WithSourceID(dt.Base.GetSourceID()),
+			),
+			ShardName:        dt.ShardName,
+			DbName:           dt.DbName,
+			CollectionName:   dt.CollectionName,
+			PartitionName:    dt.PartitionName,
+			DbID:             dt.DbID,
+			CollectionID:     dt.CollectionID,
+			PartitionID:      dt.PartitionID,
+			Int64PrimaryKeys: int64PrimaryKeys,
+			Timestamps:       timestamps,
+			NumRows:          int64(len(indexes)),
+			PrimaryKeys:      primaryKeys,
+		},
+	}
+}
+
 // ///////////////////////////////////////Upsert//////////////////////////////////////////
 type UpsertMsg struct {
 	InsertMsg *InsertMsg
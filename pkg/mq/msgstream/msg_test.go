@@ -269,6 +269,69 @@ func TestInsertMsg_IndexMsg(t *testing.T) {
 	assert.Equal(t, int64(1), indexMsg.FieldsData[0].Field.(*schemapb.FieldData_Scalars).Scalars.Data.(*schemapb.ScalarField_LongData).LongData.Data[0])
 }
 
+func TestInsertMsg_SelectMsg(t *testing.T) {
+	msg := &InsertMsg{
+		BaseMsg: BaseMsg{
+			BeginTimestamp: 1,
+			EndTimestamp:   2,
+			HashValues:     []uint32{0, 1, 0},
+		},
+		InsertRequest: msgpb.InsertRequest{
+			Base: &commonpb.MsgBase{
+				MsgType:   commonpb.MsgType_Insert,
+				MsgID:     3,
+				Timestamp: 4,
+				SourceID:  5,
+			},
+			CollectionID: 7,
+			ShardName:    "test",
+			Timestamps:   []uint64{10, 11, 12},
+			RowIDs:       []int64{20, 21, 22},
+			RowData:      []*commonpb.Blob{{Value: []byte{1}}, {Value: []byte{2}}, {Value: []byte{3}}},
+			Version:      msgpb.InsertDataVersion_RowBased,
+		},
+	}
+	selectMsg := msg.SelectMsg([]int{0, 2})
+	assert.Equal(t, []uint64{10, 12}, selectMsg.GetTimestamps())
+	assert.Equal(t, []int64{20, 22}, selectMsg.GetRowIDs())
+	assert.Equal(t, []uint32{0, 0}, selectMsg.HashKeys())
+	assert.Equal(t, []byte{3}, selectMsg.GetRowData()[1].Value)
+	assert.Equal(t, int64(3), selectMsg.GetBase().GetMsgID())
+	assert.Equal(t, uint64(1), selectMsg.BeginTs())
+
+	msg.Version = msgpb.InsertDataVersion_ColumnBased
+	msg.RowData = nil
+	msg.NumRows = 3
+	msg.FieldsData = []*schemapb.FieldData{
+		{
+			Type:      schemapb.DataType_Int64,
+			FieldName: "pk",
+			Field: &schemapb.FieldData_Scalars{
+				Scalars: &schemapb.ScalarField{
+					Data: &schemapb.ScalarField_LongData{LongData: &schemapb.LongArray{Data: []int64{1, 2, 3}}},
+				},
+			},
+		},
+		{
+			Type:      schemapb.DataType_FloatVector,
+			FieldName: "vec",
+			Field: &schemapb.FieldData_Vectors{
+				Vectors: &schemapb.VectorField{
+					Dim:  2,
+					Data: &schemapb.VectorField_FloatVector{FloatVector: &schemapb.FloatArray{Data: []float32{1, 1, 2, 2, 3, 3}}},
+				},
+			},
+		},
+	}
+	selectMsg = msg.SelectMsg([]int{1, 2})
+	assert.NoError(t, selectMsg.CheckAligned())
+	assert.Equal(t, uint64(2), selectMsg.GetNumRows())
+	assert.Equal(t, []uint32{1, 0}, selectMsg.HashKeys())
+	assert.Equal(t, []int64{2, 3}, selectMsg.GetFieldsData()[0].GetScalars().GetLongData().GetData())
+	assert.Equal(t, []float32{2, 2, 3, 3}, selectMsg.GetFieldsData()[1].GetVectors().GetFloatVector().GetData())
+	assert.Equal(t, int64(2), selectMsg.GetFieldsData()[1].GetVectors().GetDim())
+}
+
 func TestDeleteMsg(t *testing.T) {
 	deleteMsg := &DeleteMsg{
 		BaseMsg: generateBaseMsg(),
@@ -311,6 +374,39 @@ func TestDeleteMsg(t *testing.T) {
 	assert.Equal(t, int64(3), deleteMsg2.SourceID())
 }
 
+func TestDeleteMsg_SelectMsg(t *testing.T) {
+	msg := &DeleteMsg{
+		BaseMsg: BaseMsg{
+			BeginTimestamp: 1,
+			EndTimestamp:   2,
+			HashValues:     []uint32{0, 1, 0},
+		},
+		DeleteRequest: msgpb.DeleteRequest{
+			Base: &commonpb.MsgBase{
+				MsgType:   commonpb.MsgType_Delete,
+				MsgID:     3,
+				Timestamp: 4,
+				SourceID:  5,
+			},
+			CollectionID:     7,
+			ShardName:        "test",
+			Timestamps:       []uint64{10, 11, 12},
+			Int64PrimaryKeys: []int64{20, 21, 22},
+			PrimaryKeys:      &schemapb.IDs{IdField: &schemapb.IDs_IntId{IntId: &schemapb.LongArray{Data: []int64{20, 21, 22}}}},
+			NumRows:          3,
+		},
+	}
+	selectMsg := msg.SelectMsg([]int{0, 2})
+	assert.NoError(t, selectMsg.CheckAligned())
+	assert.Equal(t, []uint64{10, 12}, selectMsg.GetTimestamps())
+	assert.Equal(t, []int64{20, 22}, selectMsg.GetInt64PrimaryKeys())
+	assert.Equal(t, []int64{20, 22}, selectMsg.GetPrimaryKeys().GetIntId().GetData())
+	assert.Equal(t, []uint32{0, 0}, selectMsg.HashKeys())
+	assert.Equal(t, int64(3), selectMsg.GetBase().GetMsgID())
+	assert.Equal(t, int64(7), selectMsg.GetCollectionID())
+	assert.Equal(t, uint64(1), selectMsg.BeginTs())
+}
+
 func TestDeleteMsg_Unmarshal_IllegalParameter(t *testing.T) {
 	deleteMsg := &DeleteMsg{}
 	tsMsg, err := deleteMsg.Unmarshal(10)
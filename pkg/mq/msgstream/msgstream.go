@@ -21,6 +21,7 @@ import (
 
 	"github.com/milvus-io/milvus-proto/go-api/v2/msgpb"
 	"github.com/xige-16/stream-read/pkg/mq/msgstream/mqwrapper"
+	"github.com/xige-16/stream-read/pkg/util/conc"
 	"github.com/xige-16/stream-read/pkg/util/typeutil"
 )
 
@@ -46,6 +47,19 @@ type MsgPack struct {
 	Msgs           []TsMsg
 	StartPositions []*MsgPosition
 	EndPositions   []*MsgPosition
+
+	// acks are the received messages acked by AckPack in manual ack mode
+	acks []msgAck
+}
+
+// RawMessages returns the mq messages the pack is assembled from, including the time ticks
+// and the duplicated ones. They are only kept in manual ack mode until the pack is acked.
+func (p *MsgPack) RawMessages() []mqwrapper.Message {
+	msgs := make([]mqwrapper.Message, 0, len(p.acks))
+	for _, a := range p.acks {
+		msgs = append(msgs, a.msg)
+	}
+	return msgs
 }
 
 // RepackFunc is a function type which used to repack message after hash by primary key
@@ -57,6 +71,7 @@ type MsgStream interface {
 
 	AsProducer(channels []string)
 	Produce(*MsgPack) error
+	ProduceAsync(*MsgPack) (map[string][]*conc.Future[MessageID], error)
 	SetRepackFunc(repackFunc RepackFunc)
 	GetProduceChannels() []string
 	Broadcast(*MsgPack) (map[string][]MessageID, error)
@@ -64,11 +79,34 @@ type MsgStream interface {
 	AsConsumer(ctx context.Context, channels []string, subName string, position mqwrapper.SubscriptionInitialPosition) error
 	Chan() <-chan *MsgPack
 	Seek(ctx context.Context, offset []*MsgPosition) error
+	// Err returns the error the stream stopped consuming with, Chan is closed once it fails.
+	Err() error
+	// Stats returns the states of the stream and its consumed channels.
+	Stats() *StreamStats
 
 	GetLatestMsgID(channel string) (MessageID, error)
 	CheckTopicValid(channel string) error
 
 	EnableProduce(can bool)
+
+	// SetManualAck switches the manual ack mode, which must be set before AsConsumer.
+	// In manual ack mode the received messages are acked by AckPack after they are handled,
+	// and the subscriptions are kept on Close, so the subscription cursors only move after
+	// the messages are handled. Subscribe with SubscriptionPositionLatest to resume from them.
+	SetManualAck(enable bool)
+	// SetSubscriptionType sets the type of the subscriptions, which must be set before AsConsumer,
+	// default is mqwrapper.Exclusive. The produced dml messages are keyed by shard, so with
+	// mqwrapper.KeyShared the messages are only consumed in order within a shard, and a consumer
+	// of mqwrapper.Shared or mqwrapper.KeyShared receives only a part of the time ticks.
+	SetSubscriptionType(subType mqwrapper.SubscriptionType)
+	// AckPack acks the messages of a received msgPack, no-op if not in manual ack mode.
+	AckPack(*MsgPack)
+	// Nack redelivers the messages of a received msgPack later, no-op if not in manual ack mode.
+	Nack(*MsgPack)
+	// SetBadMsgPolicy overrides mq.badMsgPolicy of the stream, which must be set before consuming.
+	// The quarantine callback is required by BadMsgPolicyQuarantine. The policy applies to the
+	// messages skipped by Seek too, and the stream fails with a *BadMessageError.
+	SetBadMsgPolicy(policy string, quarantine QuarantineFunc) error
 }
 
 type Factory interface {
@@ -20,6 +20,7 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -38,6 +39,26 @@ func UnsubscribeChannels(ctx context.Context, factory Factory, subName string, c
 	}
 }
 
+// UniqueSubName returns a subscription name made unique by the current time and a random suffix,
+// so concurrent runs never conflict on an exclusive subscription.
+func UniqueSubName(prefix string) string {
+	return fmt.Sprintf("%s-%d-%d", prefix, time.Now().UnixNano(), rand.Intn(10000))
+}
+
+// UniqueSubscription returns a unique subscription name with prefix for one run,
+// the returned cleanup removes the subscription from channels and should be deferred.
+func UniqueSubscription(ctx context.Context, factory Factory, prefix string, channels []string) (string, func()) {
+	subName := UniqueSubName(prefix)
+	cleanup := func() {
+		log.Info("clean up subscription", zap.String("subname", subName), zap.Strings("channels", channels))
+		err := factory.NewMsgStreamDisposer(ctx)(channels, subName)
+		if err != nil {
+			log.Warn("failed to clean up subscription", zap.String("subname", subName), zap.Strings("channels", channels), zap.Error(err))
+		}
+	}
+	return subName, cleanup
+}
+
 func GetChannelLatestMsgID(ctx context.Context, factory Factory, channelName string) ([]byte, error) {
 	dmlStream, err := factory.NewMsgStream(ctx)
 	if err != nil {
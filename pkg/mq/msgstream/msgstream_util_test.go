@@ -0,0 +1,57 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgstream
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+type mockDisposerFactory struct {
+	Factory
+	disposed map[string][]string
+	err      error
+}
+
+func (f *mockDisposerFactory) NewMsgStreamDisposer(ctx context.Context) func([]string, string) error {
+	return func(channels []string, subName string) error {
+		f.disposed[subName] = channels
+		return f.err
+	}
+}
+
+func TestUniqueSubscription(t *testing.T) {
+	assert.True(t, strings.HasPrefix(UniqueSubName("recovery-milvus"), "recovery-milvus-"))
+	assert.NotEqual(t, UniqueSubName("recovery-milvus"), UniqueSubName("recovery-milvus"))
+
+	factory := &mockDisposerFactory{disposed: make(map[string][]string)}
+	subName, cleanup := UniqueSubscription(context.Background(), factory, "recovery-milvus", []string{"dml_0", "dml_1"})
+	assert.True(t, strings.HasPrefix(subName, "recovery-milvus-"))
+	assert.Empty(t, factory.disposed)
+	cleanup()
+	assert.Equal(t, []string{"dml_0", "dml_1"}, factory.disposed[subName])
+
+	// failure is only logged
+	factory.err = errors.New("mock error")
+	_, cleanup = UniqueSubscription(context.Background(), factory, "recovery-milvus", []string{"dml_0"})
+	cleanup()
+	assert.Len(t, factory.disposed, 2)
+}
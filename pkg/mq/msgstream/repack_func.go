@@ -42,14 +42,23 @@ func InsertRepackFunc(tsMsgs []TsMsg, hashKeys [][]int32) (map[int32]*MsgPack, e
 		if insertRequest.NRows() != uint64(keysLen) {
 			return nil, errors.New("the length of hashValue, timestamps, rowIDs, RowData are not equal")
 		}
+		// gather the rows of every bucket into one msg, in the order buckets first appear
+		buckets := make(map[int32][]int)
+		order := make([]int32, 0)
 		for index, key := range keys {
+			if _, ok := buckets[key]; !ok {
+				order = append(order, key)
+			}
+			buckets[key] = append(buckets[key], index)
+		}
+		for _, key := range order {
 			_, ok := result[key]
 			if !ok {
 				msgPack := MsgPack{}
 				result[key] = &msgPack
 			}
 
-			insertMsg := insertRequest.IndexMsg(index)
+			insertMsg := insertRequest.SelectMsg(buckets[key])
 			result[key].Msgs = append(result[key].Msgs, insertMsg)
 		}
 	}
@@ -0,0 +1,138 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package msgstream
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/msgpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+)
+
+const repackDim = 128
+
+func newRepackInsertMsg(numRows int, numShards int) (*InsertMsg, []int32) {
+	pks := make([]int64, numRows)
+	timestamps := make([]uint64, numRows)
+	vectors := make([]float32, numRows*repackDim)
+	keys := make([]int32, numRows)
+	for i := 0; i < numRows; i++ {
+		pks[i] = int64(i)
+		timestamps[i] = 100
+		vectors[i*repackDim] = float32(i)
+		keys[i] = int32(i % numShards)
+	}
+	msg := &InsertMsg{
+		BaseMsg: BaseMsg{BeginTimestamp: 100, EndTimestamp: 100},
+		InsertRequest: msgpb.InsertRequest{
+			Base:         &commonpb.MsgBase{MsgType: commonpb.MsgType_Insert, Timestamp: 100},
+			CollectionID: 1,
+			Timestamps:   timestamps,
+			RowIDs:       pks,
+			NumRows:      uint64(numRows),
+			Version:      msgpb.InsertDataVersion_ColumnBased,
+			FieldsData: []*schemapb.FieldData{
+				{
+					Type:      schemapb.DataType_Int64,
+					FieldName: "pk",
+					Field: &schemapb.FieldData_Scalars{Scalars: &schemapb.ScalarField{
+						Data: &schemapb.ScalarField_LongData{LongData: &schemapb.LongArray{Data: pks}},
+					}},
+				},
+				{
+					Type:      schemapb.DataType_FloatVector,
+					FieldName: "vec",
+					Field: &schemapb.FieldData_Vectors{Vectors: &schemapb.VectorField{
+						Dim:  repackDim,
+						Data: &schemapb.VectorField_FloatVector{FloatVector: &schemapb.FloatArray{Data: vectors}},
+					}},
+				},
+			},
+		},
+	}
+	return msg, keys
+}
+
+func TestInsertRepackFunc(t *testing.T) {
+	msg, keys := newRepackInsertMsg(10, 3)
+	result, err := InsertRepackFunc([]TsMsg{msg}, [][]int32{keys})
+	assert.NoError(t, err)
+	assert.Len(t, result, 3)
+	for key, pack := range result {
+		// one columnar msg per bucket
+		assert.Len(t, pack.Msgs, 1)
+		insertMsg := pack.Msgs[0].(*InsertMsg)
+		assert.NoError(t, insertMsg.CheckAligned())
+		for i, pk := range insertMsg.GetFieldsData()[0].GetScalars().GetLongData().GetData() {
+			assert.Equal(t, key, int32(pk%3))
+			assert.Equal(t, float32(pk), insertMsg.GetFieldsData()[1].GetVectors().GetFloatVector().GetData()[i*repackDim])
+		}
+	}
+	assert.Equal(t, uint64(4), result[0].Msgs[0].(*InsertMsg).GetNumRows())
+	assert.Equal(t, uint64(3), result[2].Msgs[0].(*InsertMsg).GetNumRows())
+
+	_, err = InsertRepackFunc([]TsMsg{msg}, [][]int32{keys[:5]})
+	assert.Error(t, err)
+	_, err = InsertRepackFunc([]TsMsg{&DeleteMsg{DeleteRequest: msgpb.DeleteRequest{
+		Base: &commonpb.MsgBase{MsgType: commonpb.MsgType_Delete},
+	}}}, [][]int32{keys})
+	assert.Error(t, err)
+}
+
+// indexRepack repacks msg with one single-row msg per row, as InsertRepackFunc did before.
+func indexRepack(msg *InsertMsg, keys []int32) map[int32]*MsgPack {
+	result := make(map[int32]*MsgPack)
+	for index, key := range keys {
+		if _, ok := result[key]; !ok {
+			result[key] = &MsgPack{}
+		}
+		result[key].Msgs = append(result[key].Msgs, msg.IndexMsg(index))
+	}
+	return result
+}
+
+func benchmarkRepack(b *testing.B, numRows int, repack func(*InsertMsg, []int32) map[int32]*MsgPack) {
+	msg, keys := newRepackInsertMsg(numRows, 4)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		result := repack(msg, keys)
+		for _, pack := range result {
+			for _, m := range pack.Msgs {
+				if _, err := m.Marshal(m); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkInsertRepackFunc_Columnar(b *testing.B) {
+	benchmarkRepack(b, 10000, func(msg *InsertMsg, keys []int32) map[int32]*MsgPack {
+		result, err := InsertRepackFunc([]TsMsg{msg}, [][]int32{keys})
+		if err != nil {
+			b.Fatal(err)
+		}
+		return result
+	})
+}
+
+func BenchmarkInsertRepackFunc_PerRow(b *testing.B) {
+	benchmarkRepack(b, 10000, indexRepack)
+}
@@ -16,6 +16,8 @@
 
 package conc
 
+import "sync"
+
 type future interface {
 	wait()
 	OK() bool
@@ -90,6 +92,32 @@ func Go[T any](fn func() (T, error)) *Future[T] {
 	return future
 }
 
+// Promise is the writable side of a Future,
+// use it if the async task is completed by a callback instead of a goroutine.
+type Promise[T any] struct {
+	future *Future[T]
+	once   sync.Once
+}
+
+// NewPromise creates a Promise with an uncompleted Future.
+func NewPromise[T any]() *Promise[T] {
+	return &Promise[T]{future: newFuture[T]()}
+}
+
+// Return the future completed by this promise.
+func (promise *Promise[T]) Future() *Future[T] {
+	return promise.future
+}
+
+// Complete sets the result of the future and wakes up its waiters,
+// only the first call takes effect.
+func (promise *Promise[T]) Complete(value T, err error) {
+	promise.once.Do(func() {
+		promise.future.value, promise.future.err = value, err
+		close(promise.future.ch)
+	})
+}
+
 // Await for multiple futures,
 // Return nil if no future returns error,
 // or return the first error in these futures.
@@ -46,6 +46,33 @@ func (s *FutureSuite) TestFuture() {
 	s.Equal(10, resultFuture.Value())
 }
 
+func (s *FutureSuite) TestPromise() {
+	promise := NewPromise[int]()
+	future := promise.Future()
+	select {
+	case <-future.Inner():
+		s.Fail("future completed before the promise")
+	default:
+	}
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		promise.Complete(10, nil)
+	}()
+	s.True(future.OK())
+	s.Equal(10, future.Value())
+
+	// only the first completion takes effect
+	promise.Complete(20, errors.New("mock error"))
+	value, err := future.Await()
+	s.NoError(err)
+	s.Equal(10, value)
+
+	errPromise := NewPromise[int]()
+	errPromise.Complete(0, errors.New("mock error"))
+	s.Error(AwaitAll(future, errPromise.Future()))
+}
+
 func TestFuture(t *testing.T) {
 	suite.Run(t, new(FutureSuite))
 }
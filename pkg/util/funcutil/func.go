@@ -146,7 +146,7 @@ func CheckCtxValid(ctx context.Context) bool {
 func GetVecFieldIDs(schema *schemapb.CollectionSchema) []int64 {
 	var vecFieldIDs []int64
 	for _, field := range schema.Fields {
-		if field.DataType == schemapb.DataType_BinaryVector || field.DataType == schemapb.DataType_FloatVector {
+		if typeutil.IsVectorType(field.DataType) {
 			vecFieldIDs = append(vecFieldIDs, field.FieldID)
 		}
 	}
@@ -260,6 +260,28 @@ func GetNumRowsOfBinaryVectorField(bDatas []byte, dim int64) (uint64, error) {
 	return uint64((8 * int64(l)) / dim), nil
 }
 
+// GetNumRowsOfFloat16VectorField returns the num of rows of the float16 or bfloat16 vectors
+// of dim in bytes, every element takes 2 bytes.
+func GetNumRowsOfFloat16VectorField(f16Datas []byte, dim int64) (uint64, error) {
+	if dim <= 0 {
+		return 0, fmt.Errorf("dim(%d) should be greater than 0", dim)
+	}
+	l := len(f16Datas)
+	if int64(l)%(dim*2) != 0 {
+		return 0, fmt.Errorf("the length(%d) of float16 data should divide the dim(%d) * 2", l, dim)
+	}
+	return uint64(int64(l) / dim / 2), nil
+}
+
+// GetNumRowsOfSparseFloatVectorField returns the num of rows of the sparse float vectors,
+// which are checked to be well formed.
+func GetNumRowsOfSparseFloatVectorField(sparseDatas *schemapb.SparseFloatArray) (uint64, error) {
+	if err := typeutil.ValidateSparseFloatRows(sparseDatas.GetContents()...); err != nil {
+		return 0, err
+	}
+	return uint64(len(sparseDatas.GetContents())), nil
+}
+
 // GetNumRowOfFieldDataWithSchema returns num of rows with schema specification.
 func GetNumRowOfFieldDataWithSchema(fieldData *schemapb.FieldData, helper *typeutil.SchemaHelper) (uint64, error) {
 	var fieldNumRows uint64
@@ -297,6 +319,23 @@ func GetNumRowOfFieldDataWithSchema(fieldData *schemapb.FieldData, helper *typeu
 		if err != nil {
 			return 0, err
 		}
+	case schemapb.DataType_Float16Vector:
+		dim := fieldData.GetVectors().GetDim()
+		fieldNumRows, err = GetNumRowsOfFloat16VectorField(fieldData.GetVectors().GetFloat16Vector(), dim)
+		if err != nil {
+			return 0, err
+		}
+	case schemapb.DataType_BFloat16Vector:
+		dim := fieldData.GetVectors().GetDim()
+		fieldNumRows, err = GetNumRowsOfFloat16VectorField(fieldData.GetVectors().GetBfloat16Vector(), dim)
+		if err != nil {
+			return 0, err
+		}
+	case schemapb.DataType_SparseFloatVector:
+		fieldNumRows, err = GetNumRowsOfSparseFloatVectorField(fieldData.GetVectors().GetSparseFloatVector())
+		if err != nil {
+			return 0, err
+		}
 	default:
 		return 0, fmt.Errorf("%s is not supported now", fieldSchema.GetDataType())
 	}
@@ -346,6 +385,23 @@ func GetNumRowOfFieldData(fieldData *schemapb.FieldData) (uint64, error) {
 			if err != nil {
 				return 0, err
 			}
+		case *schemapb.VectorField_Float16Vector:
+			dim := vectorField.GetDim()
+			fieldNumRows, err = GetNumRowsOfFloat16VectorField(vectorField.GetFloat16Vector(), dim)
+			if err != nil {
+				return 0, err
+			}
+		case *schemapb.VectorField_Bfloat16Vector:
+			dim := vectorField.GetDim()
+			fieldNumRows, err = GetNumRowsOfFloat16VectorField(vectorField.GetBfloat16Vector(), dim)
+			if err != nil {
+				return 0, err
+			}
+		case *schemapb.VectorField_SparseFloatVector:
+			fieldNumRows, err = GetNumRowsOfSparseFloatVectorField(vectorField.GetSparseFloatVector())
+			if err != nil {
+				return 0, err
+			}
 		default:
 			return 0, fmt.Errorf("%s is not supported now", vectorFieldType)
 		}
@@ -251,6 +251,84 @@ func TestGetNumRowsOfFloatVectorField(t *testing.T) {
 	}
 }
 
+func TestGetNumRowsOfFloat16VectorField(t *testing.T) {
+	cases := []struct {
+		name     string
+		f16Datas []byte
+		dim      int64
+		want     uint64
+		errIsNil bool
+	}{
+		{"negative dim", []byte{}, -1, 0, false},
+		{"zero dim", []byte{}, 0, 0, false},
+		{"odd length", make([]byte, 3), 1, 0, false},
+		{"length not divided by dim", make([]byte, 2*3), 2, 0, false},
+		{"empty", []byte{}, 128, 0, true},
+		{"one row", make([]byte, 2*2), 2, 1, true},
+		{"two rows", make([]byte, 2*2*2), 2, 2, true},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := GetNumRowsOfFloat16VectorField(test.f16Datas, test.dim)
+			if test.errIsNil {
+				assert.NoError(t, err)
+				assert.Equal(t, test.want, got)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestGetNumRowOfFieldData_Vectors(t *testing.T) {
+	cases := []struct {
+		name     string
+		vectors  *schemapb.VectorField
+		want     uint64
+		errIsNil bool
+	}{
+		{"float_vector", &schemapb.VectorField{Dim: 2, Data: &schemapb.VectorField_FloatVector{FloatVector: &schemapb.FloatArray{Data: make([]float32, 6)}}}, 3, true},
+		{"binary_vector", &schemapb.VectorField{Dim: 8, Data: &schemapb.VectorField_BinaryVector{BinaryVector: make([]byte, 3)}}, 3, true},
+		{"float16_vector", &schemapb.VectorField{Dim: 2, Data: &schemapb.VectorField_Float16Vector{Float16Vector: make([]byte, 12)}}, 3, true},
+		{"float16_vector bad dim", &schemapb.VectorField{Dim: 4, Data: &schemapb.VectorField_Float16Vector{Float16Vector: make([]byte, 12)}}, 0, false},
+		{"bfloat16_vector", &schemapb.VectorField{Dim: 2, Data: &schemapb.VectorField_Bfloat16Vector{Bfloat16Vector: make([]byte, 12)}}, 3, true},
+		{"bfloat16_vector bad dim", &schemapb.VectorField{Dim: 0, Data: &schemapb.VectorField_Bfloat16Vector{Bfloat16Vector: make([]byte, 12)}}, 0, false},
+		{"sparse_float_vector", &schemapb.VectorField{Data: &schemapb.VectorField_SparseFloatVector{SparseFloatVector: &schemapb.SparseFloatArray{
+			Contents: [][]byte{typeutil.CreateSparseFloatRow([]uint32{0}, []float32{1}), typeutil.CreateSparseFloatRow([]uint32{3, 5}, []float32{1, 2})},
+		}}}, 2, true},
+		{"sparse_float_vector bad row", &schemapb.VectorField{Data: &schemapb.VectorField_SparseFloatVector{SparseFloatVector: &schemapb.SparseFloatArray{
+			Contents: [][]byte{typeutil.CreateSparseFloatRow([]uint32{3, 3}, []float32{1, 2})},
+		}}}, 0, false},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := GetNumRowOfFieldData(&schemapb.FieldData{Field: &schemapb.FieldData_Vectors{Vectors: test.vectors}})
+			if test.errIsNil {
+				assert.NoError(t, err)
+				assert.Equal(t, test.want, got)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestGetVecFieldIDs(t *testing.T) {
+	schema := &schemapb.CollectionSchema{
+		Fields: []*schemapb.FieldSchema{
+			{FieldID: 100, DataType: schemapb.DataType_Int64},
+			{FieldID: 101, DataType: schemapb.DataType_FloatVector},
+			{FieldID: 102, DataType: schemapb.DataType_BinaryVector},
+			{FieldID: 103, DataType: schemapb.DataType_Float16Vector},
+			{FieldID: 104, DataType: schemapb.DataType_BFloat16Vector},
+			{FieldID: 105, DataType: schemapb.DataType_SparseFloatVector},
+		},
+	}
+	assert.Equal(t, []int64{101, 102, 103, 104, 105}, GetVecFieldIDs(schema))
+}
+
 func TestGetNumRowsOfBinaryVectorField(t *testing.T) {
 	cases := []struct {
 		bDatas   []byte
@@ -464,6 +542,9 @@ func (s *NumRowsWithSchemaSuite) SetupSuite() {
 			{FieldID: 109, Name: "json", DataType: schemapb.DataType_JSON},
 			{FieldID: 110, Name: "float_vector", DataType: schemapb.DataType_FloatVector, TypeParams: []*commonpb.KeyValuePair{{Key: "dim", Value: "8"}}},
 			{FieldID: 111, Name: "binary_vector", DataType: schemapb.DataType_BinaryVector, TypeParams: []*commonpb.KeyValuePair{{Key: "dim", Value: "8"}}},
+			{FieldID: 112, Name: "float16_vector", DataType: schemapb.DataType_Float16Vector, TypeParams: []*commonpb.KeyValuePair{{Key: "dim", Value: "8"}}},
+			{FieldID: 113, Name: "bfloat16_vector", DataType: schemapb.DataType_BFloat16Vector, TypeParams: []*commonpb.KeyValuePair{{Key: "dim", Value: "8"}}},
+			{FieldID: 114, Name: "sparse_float_vector", DataType: schemapb.DataType_SparseFloatVector},
 			{FieldID: 999, Name: "unknown", DataType: schemapb.DataType_None},
 		},
 	}
@@ -606,6 +687,51 @@ func (s *NumRowsWithSchemaSuite) TestNormalCases() {
 			},
 			expect: 8,
 		},
+		{
+			tag: "float16_vector",
+			input: &schemapb.FieldData{
+				FieldName: "float16_vector",
+				Field: &schemapb.FieldData_Vectors{
+					Vectors: &schemapb.VectorField{
+						Dim:  8,
+						Data: &schemapb.VectorField_Float16Vector{Float16Vector: make([]byte, 3*8*2)},
+					},
+				},
+			},
+			expect: 3,
+		},
+		{
+			tag: "bfloat16_vector",
+			input: &schemapb.FieldData{
+				FieldName: "bfloat16_vector",
+				Field: &schemapb.FieldData_Vectors{
+					Vectors: &schemapb.VectorField{
+						Dim:  8,
+						Data: &schemapb.VectorField_Bfloat16Vector{Bfloat16Vector: make([]byte, 3*8*2)},
+					},
+				},
+			},
+			expect: 3,
+		},
+		{
+			tag: "sparse_float_vector",
+			input: &schemapb.FieldData{
+				FieldName: "sparse_float_vector",
+				Field: &schemapb.FieldData_Vectors{
+					Vectors: &schemapb.VectorField{
+						Dim: 11,
+						Data: &schemapb.VectorField_SparseFloatVector{SparseFloatVector: &schemapb.SparseFloatArray{
+							Dim: 11,
+							Contents: [][]byte{
+								typeutil.CreateSparseFloatRow([]uint32{1, 10}, []float32{1, 2}),
+								typeutil.CreateSparseFloatRow(nil, nil),
+							},
+						}},
+					},
+				},
+			},
+			expect: 2,
+		},
 	}
 	for _, tc := range cases {
 		s.Run(tc.tag, func() {
@@ -660,6 +786,43 @@ func (s *NumRowsWithSchemaSuite) TestErrorCases() {
 					},
 				},
 			},
+			{
+				tag: "float16_vector",
+				input: &schemapb.FieldData{
+					FieldName: "float16_vector",
+					Field: &schemapb.FieldData_Vectors{
+						Vectors: &schemapb.VectorField{
+							Dim:  3,
+							Data: &schemapb.VectorField_Float16Vector{Float16Vector: make([]byte, 8*2)},
+						},
+					},
+				},
+			},
+			{
+				tag: "bfloat16_vector",
+				input: &schemapb.FieldData{
+					FieldName: "bfloat16_vector",
+					Field: &schemapb.FieldData_Vectors{
+						Vectors: &schemapb.VectorField{
+							Dim:  3,
+							Data: &schemapb.VectorField_Bfloat16Vector{Bfloat16Vector: make([]byte, 8*2)},
+						},
+					},
+				},
+			},
+			{
+				tag: "sparse_float_vector",
+				input: &schemapb.FieldData{
+					FieldName: "sparse_float_vector",
+					Field: &schemapb.FieldData_Vectors{
+						Vectors: &schemapb.VectorField{
+							Data: &schemapb.VectorField_SparseFloatVector{SparseFloatVector: &schemapb.SparseFloatArray{
+								Contents: [][]byte{{1, 2, 3}},
+							}},
+						},
+					},
+				},
+			},
 		}
 
 		for _, tc := range cases {
@@ -52,6 +52,41 @@ func fieldDataToPlaceholderValue(fieldData *schemapb.FieldData) (*commonpb.Place
 			Values: flattenedByteVectorsToByteVectors(x.BinaryVector, int(vectors.Dim)),
 		}
 		return placeholderValue, nil
+	case schemapb.DataType_Float16Vector:
+		vectors := fieldData.GetVectors()
+		x, ok := vectors.GetData().(*schemapb.VectorField_Float16Vector)
+		if !ok {
+			return nil, errors.New("vector data is not schemapb.VectorField_Float16Vector")
+		}
+		placeholderValue := &commonpb.PlaceholderValue{
+			Tag:    "$0",
+			Type:   commonpb.PlaceholderType_Float16Vector,
+			Values: flattenedByteVectorsToByteVectors(x.Float16Vector, int(vectors.Dim)*2),
+		}
+		return placeholderValue, nil
+	case schemapb.DataType_BFloat16Vector:
+		vectors := fieldData.GetVectors()
+		x, ok := vectors.GetData().(*schemapb.VectorField_Bfloat16Vector)
+		if !ok {
+			return nil, errors.New("vector data is not schemapb.VectorField_Bfloat16Vector")
+		}
+		placeholderValue := &commonpb.PlaceholderValue{
+			Tag:    "$0",
+			Type:   commonpb.PlaceholderType_BFloat16Vector,
+			Values: flattenedByteVectorsToByteVectors(x.Bfloat16Vector, int(vectors.Dim)*2),
+		}
+		return placeholderValue, nil
+	case schemapb.DataType_SparseFloatVector:
+		vectors, ok := fieldData.GetVectors().GetData().(*schemapb.VectorField_SparseFloatVector)
+		if !ok {
+			return nil, errors.New("vector data is not schemapb.VectorField_SparseFloatVector")
+		}
+		placeholderValue := &commonpb.PlaceholderValue{
+			Tag:    "$0",
+			Type:   commonpb.PlaceholderType_SparseFloatVector,
+			Values: vectors.SparseFloatVector.GetContents(),
+		}
+		return placeholderValue, nil
 	default:
 		return nil, errors.New("field is not a vector field")
 	}
@@ -3,7 +3,13 @@ package funcutil
 import (
 	"testing"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/xige-16/stream-read/pkg/util/typeutil"
 )
 
 func Test_flattenedByteVectorsToByteVectors(t *testing.T) {
@@ -18,3 +24,77 @@ func Test_flattenedByteVectorsToByteVectors(t *testing.T) {
 
 	assert.Equal(t, expected, actual)
 }
+
+func TestFieldDataToPlaceholderGroupBytes(t *testing.T) {
+	sparseRows := [][]byte{
+		typeutil.CreateSparseFloatRow([]uint32{1, 3}, []float32{0.5, 1}),
+		typeutil.CreateSparseFloatRow([]uint32{2}, []float32{2}),
+	}
+	cases := []struct {
+		name            string
+		fieldData       *schemapb.FieldData
+		placeholderType commonpb.PlaceholderType
+		values          [][]byte
+	}{
+		{
+			name: "float_vector",
+			fieldData: &schemapb.FieldData{Type: schemapb.DataType_FloatVector, Field: &schemapb.FieldData_Vectors{Vectors: &schemapb.VectorField{
+				Dim: 1, Data: &schemapb.VectorField_FloatVector{FloatVector: &schemapb.FloatArray{Data: []float32{1, 2}}},
+			}}},
+			placeholderType: commonpb.PlaceholderType_FloatVector,
+			values:          [][]byte{{0, 0, 0x80, 0x3f}, {0, 0, 0, 0x40}},
+		},
+		{
+			name: "binary_vector",
+			fieldData: &schemapb.FieldData{Type: schemapb.DataType_BinaryVector, Field: &schemapb.FieldData_Vectors{Vectors: &schemapb.VectorField{
+				Dim: 2, Data: &schemapb.VectorField_BinaryVector{BinaryVector: []byte{1, 2, 3, 4}},
+			}}},
+			placeholderType: commonpb.PlaceholderType_BinaryVector,
+			values:          [][]byte{{1, 2}, {3, 4}},
+		},
+		{
+			name: "float16_vector",
+			fieldData: &schemapb.FieldData{Type: schemapb.DataType_Float16Vector, Field: &schemapb.FieldData_Vectors{Vectors: &schemapb.VectorField{
+				Dim: 2, Data: &schemapb.VectorField_Float16Vector{Float16Vector: []byte{1, 2, 3, 4, 5, 6, 7, 8}},
+			}}},
+			placeholderType: commonpb.PlaceholderType_Float16Vector,
+			values:          [][]byte{{1, 2, 3, 4}, {5, 6, 7, 8}},
+		},
+		{
+			name: "bfloat16_vector",
+			fieldData: &schemapb.FieldData{Type: schemapb.DataType_BFloat16Vector, Field: &schemapb.FieldData_Vectors{Vectors: &schemapb.VectorField{
+				Dim: 1, Data: &schemapb.VectorField_Bfloat16Vector{Bfloat16Vector: []byte{1, 2, 3, 4}},
+			}}},
+			placeholderType: commonpb.PlaceholderType_BFloat16Vector,
+			values:          [][]byte{{1, 2}, {3, 4}},
+		},
+		{
+			name: "sparse_float_vector",
+			fieldData: &schemapb.FieldData{Type: schemapb.DataType_SparseFloatVector, Field: &schemapb.FieldData_Vectors{Vectors: &schemapb.VectorField{
+				Dim: 4, Data: &schemapb.VectorField_SparseFloatVector{SparseFloatVector: &schemapb.SparseFloatArray{Dim: 4, Contents: sparseRows}},
+			}}},
+			placeholderType: commonpb.PlaceholderType_SparseFloatVector,
+			values:          sparseRows,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			bytes, err := FieldDataToPlaceholderGroupBytes(c.fieldData)
+			require.NoError(t, err)
+			group := &commonpb.PlaceholderGroup{}
+			require.NoError(t, proto.Unmarshal(bytes, group))
+			require.Len(t, group.GetPlaceholders(), 1)
+			assert.Equal(t, c.placeholderType, group.GetPlaceholders()[0].GetType())
+			assert.Equal(t, c.values, group.GetPlaceholders()[0].GetValues())
+
+			// mismatched data
+			_, err = FieldDataToPlaceholderGroupBytes(&schemapb.FieldData{Type: c.fieldData.GetType(), Field: &schemapb.FieldData_Vectors{
+				Vectors: &schemapb.VectorField{},
+			}})
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := FieldDataToPlaceholderGroupBytes(&schemapb.FieldData{Type: schemapb.DataType_Int64})
+	assert.Error(t, err)
+}
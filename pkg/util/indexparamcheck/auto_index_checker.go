@@ -0,0 +1,33 @@
+package indexparamcheck
+
+import (
+	"fmt"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/xige-16/stream-read/pkg/util/typeutil"
+)
+
+// autoIndexChecker checks if an AUTOINDEX can be built, the index and its parameters are
+// picked by the server, so only the data type and the metric type are checked.
+type autoIndexChecker struct {
+	baseChecker
+}
+
+func (c autoIndexChecker) CheckTrain(params map[string]string) error {
+	return nil
+}
+
+func (c autoIndexChecker) StaticCheck(params map[string]string) error {
+	return nil
+}
+
+func (c autoIndexChecker) CheckValidDataType(dType schemapb.DataType) error {
+	if typeutil.IsJSONType(dType) {
+		return fmt.Errorf("AUTOINDEX are not supported on %s field", dType.String())
+	}
+	return nil
+}
+
+func newAutoIndexChecker() IndexChecker {
+	return &autoIndexChecker{}
+}
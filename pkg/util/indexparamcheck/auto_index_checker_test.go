@@ -0,0 +1,36 @@
+package indexparamcheck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+)
+
+func Test_autoIndexChecker_CheckTrain(t *testing.T) {
+	c := newAutoIndexChecker()
+	assert.NoError(t, c.CheckTrain(nil))
+	assert.NoError(t, c.StaticCheck(map[string]string{}))
+}
+
+func Test_autoIndexChecker_CheckValidDataType(t *testing.T) {
+	assertValidDataTypes(t, newAutoIndexChecker(),
+		schemapb.DataType_None,
+		schemapb.DataType_Bool,
+		schemapb.DataType_Int8,
+		schemapb.DataType_Int16,
+		schemapb.DataType_Int32,
+		schemapb.DataType_Int64,
+		schemapb.DataType_Float,
+		schemapb.DataType_Double,
+		schemapb.DataType_String,
+		schemapb.DataType_VarChar,
+		schemapb.DataType_Array,
+		schemapb.DataType_BinaryVector,
+		schemapb.DataType_FloatVector,
+		schemapb.DataType_Float16Vector,
+		schemapb.DataType_BFloat16Vector,
+		schemapb.DataType_SparseFloatVector,
+	)
+}
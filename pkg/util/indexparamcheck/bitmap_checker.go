@@ -0,0 +1,39 @@
+package indexparamcheck
+
+import (
+	"fmt"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/xige-16/stream-read/pkg/util/typeutil"
+)
+
+// bitmapChecker checks if a BITMAP index can be built.
+type bitmapChecker struct {
+	scalarIndexChecker
+}
+
+func (c bitmapChecker) StaticCheck(params map[string]string) error {
+	_, exist := params[BitmapCardinalityLimit]
+	if exist && !CheckIntByRange(params, BitmapCardinalityLimit, BitmapMinCardinalityLimit, BitmapMaxCardinalityLimit) {
+		return errOutOfRange(BitmapCardinalityLimit, BitmapMinCardinalityLimit, BitmapMaxCardinalityLimit)
+	}
+	return nil
+}
+
+func (c bitmapChecker) CheckTrain(params map[string]string) error {
+	return c.StaticCheck(params)
+}
+
+// CheckValidDataType allows the bool, integer and varchar fields, and the arrays whose
+// element type is unknown here.
+func (c bitmapChecker) CheckValidDataType(dType schemapb.DataType) error {
+	if !typeutil.IsBoolType(dType) && !typeutil.IsIntegerType(dType) && !typeutil.IsStringType(dType) &&
+		!typeutil.IsArrayType(dType) {
+		return fmt.Errorf("BITMAP are not supported on %s field", dType.String())
+	}
+	return nil
+}
+
+func newBitmapChecker() IndexChecker {
+	return &bitmapChecker{}
+}
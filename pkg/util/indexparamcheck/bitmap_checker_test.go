@@ -0,0 +1,44 @@
+package indexparamcheck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+)
+
+func Test_bitmapChecker_CheckTrain(t *testing.T) {
+	c := newBitmapChecker()
+	cases := []struct {
+		params   map[string]string
+		errIsNil bool
+	}{
+		{map[string]string{}, true},
+		{map[string]string{BitmapCardinalityLimit: "1"}, true},
+		{map[string]string{BitmapCardinalityLimit: "1000"}, true},
+		{map[string]string{BitmapCardinalityLimit: "0"}, false},
+		{map[string]string{BitmapCardinalityLimit: "1001"}, false},
+		{map[string]string{BitmapCardinalityLimit: "a"}, false},
+	}
+	for _, test := range cases {
+		if test.errIsNil {
+			assert.NoError(t, c.CheckTrain(test.params))
+		} else {
+			assert.Error(t, c.CheckTrain(test.params))
+		}
+	}
+}
+
+func Test_bitmapChecker_CheckValidDataType(t *testing.T) {
+	assertValidDataTypes(t, newBitmapChecker(),
+		schemapb.DataType_Bool,
+		schemapb.DataType_Int8,
+		schemapb.DataType_Int16,
+		schemapb.DataType_Int32,
+		schemapb.DataType_Int64,
+		schemapb.DataType_String,
+		schemapb.DataType_VarChar,
+		schemapb.DataType_Array,
+	)
+}
@@ -0,0 +1,57 @@
+package indexparamcheck
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+)
+
+// cagraChecker checks if a GPU_CAGRA index can be built.
+type cagraChecker struct {
+	floatVectorBaseChecker
+}
+
+func (c cagraChecker) StaticCheck(params map[string]string) error {
+	interDegree, interDegreeExist := params[CagraInterDegree]
+	if interDegreeExist && !CheckIntByRange(params, CagraInterDegree, CagraMinDegree, CagraMaxDegree) {
+		return errOutOfRange(CagraInterDegree, CagraMinDegree, CagraMaxDegree)
+	}
+	graphDegree, graphDegreeExist := params[CagraGraphDegree]
+	if graphDegreeExist && !CheckIntByRange(params, CagraGraphDegree, CagraMinDegree, CagraMaxDegree) {
+		return errOutOfRange(CagraGraphDegree, CagraMinDegree, CagraMaxDegree)
+	}
+	if interDegreeExist && graphDegreeExist {
+		inter, _ := strconv.Atoi(interDegree)
+		graph, _ := strconv.Atoi(graphDegree)
+		if graph > inter {
+			return fmt.Errorf("%s should not be larger than %s, %s: %d, %s: %d",
+				CagraGraphDegree, CagraInterDegree, CagraGraphDegree, graph, CagraInterDegree, inter)
+		}
+	}
+	if _, ok := params[CagraBuildAlgo]; ok && !CheckStrByValues(params, CagraBuildAlgo, CagraBuildAlgoTypes) {
+		return fmt.Errorf("%s not supported, supported: %v", CagraBuildAlgo, CagraBuildAlgoTypes)
+	}
+	if !CheckStrByValues(params, Metric, RaftMetrics) {
+		return fmt.Errorf("metric type not found or not supported, supported: %v", RaftMetrics)
+	}
+	return nil
+}
+
+func (c cagraChecker) CheckTrain(params map[string]string) error {
+	if err := c.baseChecker.CheckTrain(params); err != nil {
+		return err
+	}
+	return c.StaticCheck(params)
+}
+
+func (c cagraChecker) CheckValidDataType(dType schemapb.DataType) error {
+	if dType != schemapb.DataType_FloatVector {
+		return fmt.Errorf("float vector are only supported")
+	}
+	return nil
+}
+
+func newCagraChecker() IndexChecker {
+	return &cagraChecker{}
+}
@@ -0,0 +1,68 @@
+package indexparamcheck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/xige-16/stream-read/pkg/util/metric"
+)
+
+func Test_cagraChecker_CheckTrain(t *testing.T) {
+	validParams := map[string]string{
+		DIM:              "128",
+		CagraInterDegree: "128",
+		CagraGraphDegree: "64",
+		CagraBuildAlgo:   "NN_DESCENT",
+		Metric:           metric.L2,
+	}
+
+	invalidGraphDegree := copyParams(validParams)
+	invalidGraphDegree[CagraGraphDegree] = "256"
+
+	invalidInterDegree := copyParams(validParams)
+	invalidInterDegree[CagraInterDegree] = "0"
+
+	invalidBuildAlgo := copyParams(validParams)
+	invalidBuildAlgo[CagraBuildAlgo] = "HNSW"
+
+	invalidMetric := copyParams(validParams)
+	invalidMetric[Metric] = metric.COSINE
+
+	noDim := copyParams(validParams)
+	delete(noDim, DIM)
+
+	onlyMetric := map[string]string{DIM: "128", Metric: metric.IP}
+
+	cases := []struct {
+		params   map[string]string
+		errIsNil bool
+	}{
+		{validParams, true},
+		{onlyMetric, true},
+		{invalidGraphDegree, false},
+		{invalidInterDegree, false},
+		{invalidBuildAlgo, false},
+		{invalidMetric, false},
+		{noDim, false},
+	}
+
+	c := newCagraChecker()
+	for _, test := range cases {
+		if test.errIsNil {
+			assert.NoError(t, c.CheckTrain(test.params))
+		} else {
+			assert.Error(t, c.CheckTrain(test.params))
+		}
+	}
+	assert.NoError(t, c.StaticCheck(noDim))
+}
+
+func Test_cagraChecker_CheckValidDataType(t *testing.T) {
+	c := newCagraChecker()
+	assert.NoError(t, c.CheckValidDataType(schemapb.DataType_FloatVector))
+	assert.Error(t, c.CheckValidDataType(schemapb.DataType_Float16Vector))
+	assert.Error(t, c.CheckValidDataType(schemapb.DataType_BinaryVector))
+	assert.Error(t, c.CheckValidDataType(schemapb.DataType_Int64))
+}
@@ -54,6 +54,15 @@ func (mgr *indexCheckerMgrImpl) registerIndexChecker() {
 	mgr.checkers[IndexFaissBinIvfFlat] = newBinIVFFlatChecker()
 	mgr.checkers[IndexHNSW] = newHnswChecker()
 	mgr.checkers[IndexDISKANN] = newDiskannChecker()
+	mgr.checkers[IndexRaftCagra] = newCagraChecker()
+	mgr.checkers[IndexGpuBF] = newRaftBruteForceChecker()
+	mgr.checkers[IndexSparseInverted] = newSparseInvertedIndexChecker()
+	mgr.checkers[IndexSparseWand] = newSparseInvertedIndexChecker()
+	mgr.checkers[IndexINVERTED] = newInvertedChecker()
+	mgr.checkers[IndexSTLSORT] = newSTLSORTChecker()
+	mgr.checkers[IndexTrie] = newTrieChecker()
+	mgr.checkers[IndexBITMAP] = newBitmapChecker()
+	mgr.checkers[AutoIndex] = newAutoIndexChecker()
 }
 
 func newIndexCheckerMgr() *indexCheckerMgrImpl {
@@ -76,6 +76,54 @@ func Test_GetConfAdapterMgrInstance(t *testing.T) {
 	assert.NotEqual(t, nil, adapter)
 	_, ok = adapter.(*hnswChecker)
 	assert.Equal(t, true, ok)
+
+	adapter, err = adapterMgr.GetChecker(IndexRaftCagra)
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, nil, adapter)
+	_, ok = adapter.(*cagraChecker)
+	assert.Equal(t, true, ok)
+
+	adapter, err = adapterMgr.GetChecker(IndexGpuBF)
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, nil, adapter)
+	_, ok = adapter.(*raftBruteForceChecker)
+	assert.Equal(t, true, ok)
+
+	adapter, err = adapterMgr.GetChecker(IndexSparseWand)
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, nil, adapter)
+	_, ok = adapter.(*sparseInvertedIndexChecker)
+	assert.Equal(t, true, ok)
+
+	adapter, err = adapterMgr.GetChecker(IndexINVERTED)
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, nil, adapter)
+	_, ok = adapter.(*invertedChecker)
+	assert.Equal(t, true, ok)
+
+	adapter, err = adapterMgr.GetChecker(IndexSTLSORT)
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, nil, adapter)
+	_, ok = adapter.(*stlSortChecker)
+	assert.Equal(t, true, ok)
+
+	adapter, err = adapterMgr.GetChecker(IndexTrie)
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, nil, adapter)
+	_, ok = adapter.(*trieChecker)
+	assert.Equal(t, true, ok)
+
+	adapter, err = adapterMgr.GetChecker(IndexBITMAP)
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, nil, adapter)
+	_, ok = adapter.(*bitmapChecker)
+	assert.Equal(t, true, ok)
+
+	adapter, err = adapterMgr.GetChecker(AutoIndex)
+	assert.Equal(t, nil, err)
+	assert.NotEqual(t, nil, adapter)
+	_, ok = adapter.(*autoIndexChecker)
+	assert.Equal(t, true, ok)
 }
 
 func TestConfAdapterMgrImpl_GetAdapter(t *testing.T) {
@@ -36,6 +36,22 @@ const (
 
 	EFConstruction = "efConstruction"
 	HNSWM          = "M"
+
+	CagraMinDegree = 1
+	CagraMaxDegree = 2147483647
+
+	CagraInterDegree = "intermediate_graph_degree"
+	CagraGraphDegree = "graph_degree"
+	CagraBuildAlgo   = "build_algo"
+
+	// SparseDropRatioBuild is the ratio of the smallest values dropped from each sparse row, in [0, 1)
+	SparseDropRatioBuild = "drop_ratio_build"
+
+	BitmapMinCardinalityLimit = 1
+	BitmapMaxCardinalityLimit = 1000
+
+	// BitmapCardinalityLimit is the max cardinality of a field a bitmap index is built for
+	BitmapCardinalityLimit = "bitmap_cardinality_limit"
 )
 
 // METRICS is a set of all metrics types supported for float vector.
@@ -46,11 +62,16 @@ var (
 	BinIDMapMetrics           = []string{metric.HAMMING, metric.JACCARD, metric.SUBSTRUCTURE, metric.SUPERSTRUCTURE} // const
 	BinIvfMetrics             = []string{metric.HAMMING, metric.JACCARD}                                             // const
 	HnswMetrics               = []string{metric.L2, metric.IP, metric.COSINE, metric.HAMMING, metric.JACCARD}        // const
+	HnswBinaryMetrics         = []string{metric.HAMMING, metric.JACCARD}                                             // const
+	RaftMetrics               = []string{metric.L2, metric.IP}                                                       // const
+	SparseMetrics             = []string{metric.IP}                                                                  // const
+	CagraBuildAlgoTypes       = []string{"IVF_PQ", "NN_DESCENT"}                                                     // const
 	supportDimPerSubQuantizer = []int{32, 28, 24, 20, 16, 12, 10, 8, 6, 4, 3, 2, 1}                                  // const
 	supportSubQuantizer       = []int{96, 64, 56, 48, 40, 32, 28, 24, 20, 16, 12, 8, 4, 3, 2, 1}                     // const
 )
 
 const (
-	FloatVectorDefaultMetricType  = metric.COSINE
-	BinaryVectorDefaultMetricType = metric.HAMMING
+	FloatVectorDefaultMetricType       = metric.COSINE
+	BinaryVectorDefaultMetricType      = metric.HAMMING
+	SparseFloatVectorDefaultMetricType = metric.IP
 )
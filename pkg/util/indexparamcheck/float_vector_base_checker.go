@@ -5,6 +5,7 @@ import (
 
 	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
 	"github.com/xige-16/stream-read/pkg/common"
+	"github.com/xige-16/stream-read/pkg/util/typeutil"
 )
 
 type floatVectorBaseChecker struct {
@@ -28,8 +29,8 @@ func (c floatVectorBaseChecker) CheckTrain(params map[string]string) error {
 }
 
 func (c floatVectorBaseChecker) CheckValidDataType(dType schemapb.DataType) error {
-	if dType != schemapb.DataType_FloatVector {
-		return fmt.Errorf("float vector are only supported")
+	if !typeutil.IsDenseFloatVectorType(dType) {
+		return fmt.Errorf("float, float16 or bfloat16 vector are only supported")
 	}
 	return nil
 }
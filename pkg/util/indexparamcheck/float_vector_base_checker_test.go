@@ -65,6 +65,18 @@ func Test_floatVectorBaseChecker_CheckValidDataType(t *testing.T) {
 			dType:    schemapb.DataType_BinaryVector,
 			errIsNil: false,
 		},
+		{
+			dType:    schemapb.DataType_Float16Vector,
+			errIsNil: true,
+		},
+		{
+			dType:    schemapb.DataType_BFloat16Vector,
+			errIsNil: true,
+		},
+		{
+			dType:    schemapb.DataType_SparseFloatVector,
+			errIsNil: false,
+		},
 	}
 
 	c := newFloatVectorBaseChecker()
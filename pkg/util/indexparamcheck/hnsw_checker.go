@@ -4,6 +4,7 @@ import (
 	"fmt"
 
 	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/xige-16/stream-read/pkg/util/typeutil"
 )
 
 type hnswChecker struct {
@@ -31,8 +32,8 @@ func (c hnswChecker) CheckTrain(params map[string]string) error {
 }
 
 func (c hnswChecker) CheckValidDataType(dType schemapb.DataType) error {
-	if dType != schemapb.DataType_FloatVector && dType != schemapb.DataType_BinaryVector {
-		return fmt.Errorf("only support float vector or binary vector")
+	if !typeutil.IsDenseFloatVectorType(dType) && dType != schemapb.DataType_BinaryVector {
+		return fmt.Errorf("only support float, float16, bfloat16 vector or binary vector")
 	}
 	return nil
 }
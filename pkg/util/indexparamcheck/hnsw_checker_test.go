@@ -160,6 +160,18 @@ func Test_hnswChecker_CheckValidDataType(t *testing.T) {
 			dType:    schemapb.DataType_BinaryVector,
 			errIsNil: true,
 		},
+		{
+			dType:    schemapb.DataType_Float16Vector,
+			errIsNil: true,
+		},
+		{
+			dType:    schemapb.DataType_BFloat16Vector,
+			errIsNil: true,
+		},
+		{
+			dType:    schemapb.DataType_SparseFloatVector,
+			errIsNil: false,
+		},
 	}
 
 	c := newHnswChecker()
@@ -13,7 +13,11 @@ package indexparamcheck
 
 import (
 	"strconv"
+	"testing"
 
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
 	"github.com/xige-16/stream-read/pkg/util/metric"
 )
 
@@ -45,3 +49,19 @@ func copyParams(original map[string]string) map[string]string {
 	}
 	return result
 }
+
+// assertValidDataTypes asserts that c supports the allowed data types only.
+func assertValidDataTypes(t *testing.T, c IndexChecker, allowed ...schemapb.DataType) {
+	for _, value := range schemapb.DataType_value {
+		dType := schemapb.DataType(value)
+		isAllowed := false
+		for _, a := range allowed {
+			isAllowed = isAllowed || a == dType
+		}
+		if isAllowed {
+			assert.NoError(t, c.CheckValidDataType(dType), dType.String())
+		} else {
+			assert.Error(t, c.CheckValidDataType(dType), dType.String())
+		}
+	}
+}
@@ -0,0 +1,337 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package indexparamcheck
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/xige-16/stream-read/pkg/common"
+	"github.com/xige-16/stream-read/pkg/util/funcutil"
+	"github.com/xige-16/stream-read/pkg/util/metric"
+	"github.com/xige-16/stream-read/pkg/util/typeutil"
+)
+
+const (
+	DefaultNList          = 128
+	DefaultHNSWM          = 16
+	DefaultEfConstruction = 200
+)
+
+// IndexSpec is an index described by its type, metric type and build parameters.
+type IndexSpec struct {
+	IndexType  IndexType
+	MetricType metric.MetricType
+	// Params are the build parameters besides the index type and the metric type.
+	Params map[string]string
+}
+
+// NewIndexSpec parses the index parameters in the form of the create index requests, the
+// index type and the metric type are in their own keys, and the build parameters are either
+// flattened or in the json value of the params key.
+func NewIndexSpec(indexParams map[string]string) (IndexSpec, error) {
+	spec := IndexSpec{Params: make(map[string]string)}
+	for key, value := range indexParams {
+		switch key {
+		case common.IndexTypeKey:
+			spec.IndexType = value
+		case common.MetricTypeKey:
+			spec.MetricType = value
+		case common.IndexParamsKey:
+			params, err := funcutil.JSONToMap(value)
+			if err != nil {
+				return IndexSpec{}, errors.Wrapf(err, "invalid %s", common.IndexParamsKey)
+			}
+			for k, v := range params {
+				spec.Params[k] = v
+			}
+		default:
+			spec.Params[key] = value
+		}
+	}
+	if spec.IndexType == "" {
+		return IndexSpec{}, errors.New("index type not found")
+	}
+	return spec, nil
+}
+
+// ToParams returns the flattened index parameters of spec, the metric type is omitted if empty.
+func (spec IndexSpec) ToParams() map[string]string {
+	params := make(map[string]string, len(spec.Params)+2)
+	for k, v := range spec.Params {
+		params[k] = v
+	}
+	params[common.IndexTypeKey] = spec.IndexType
+	if spec.MetricType != "" {
+		params[common.MetricTypeKey] = spec.MetricType
+	}
+	return params
+}
+
+// Capabilities are the index types a target cluster supports.
+type Capabilities struct {
+	// IndexTypes are the supported index types, all the registered ones if empty.
+	IndexTypes []IndexType
+	// NoGPU excludes the gpu index types, for the targets without a gpu.
+	NoGPU bool
+}
+
+// Supports returns whether indexType is supported.
+func (c Capabilities) Supports(indexType IndexType) bool {
+	if c.NoGPU && IsGpuIndex(indexType) {
+		return false
+	}
+	if len(c.IndexTypes) == 0 {
+		_, err := GetIndexCheckerMgrInstance().GetChecker(indexType)
+		return err == nil
+	}
+	return funcutil.SliceContain(c.IndexTypes, indexType)
+}
+
+// MigrateResult is the spec migrated and the explanation of the changes made.
+type MigrateResult struct {
+	Spec    IndexSpec
+	Changes []string
+}
+
+// indexEquivalents are the index types an index is replaced by in order of preference, when
+// the target does not support it. AUTOINDEX is the last resort of all.
+var indexEquivalents = map[IndexType][]IndexType{
+	IndexRaftIvfFlat:     {IndexFaissIvfFlat, IndexHNSW},
+	IndexRaftIvfPQ:       {IndexFaissIvfPQ, IndexFaissIvfSQ8, IndexHNSW},
+	IndexRaftCagra:       {IndexHNSW},
+	IndexGpuBF:           {IndexFaissIDMap},
+	IndexFaissIvfFlat:    {IndexHNSW, IndexFaissIDMap},
+	IndexFaissIvfPQ:      {IndexFaissIvfSQ8, IndexHNSW},
+	IndexFaissIvfSQ8:     {IndexHNSW, IndexFaissIvfFlat},
+	IndexScaNN:           {IndexHNSW, IndexFaissIvfFlat},
+	IndexDISKANN:         {IndexHNSW},
+	IndexHNSW:            {IndexFaissIvfFlat, IndexFaissIDMap},
+	IndexFaissIDMap:      {IndexHNSW},
+	IndexFaissBinIvfFlat: {IndexFaissBinIDMap},
+	IndexFaissBinIDMap:   {IndexFaissBinIvfFlat},
+	IndexSparseWand:      {IndexSparseInverted},
+	IndexSparseInverted:  {IndexSparseWand},
+	IndexBITMAP:          {IndexINVERTED},
+	IndexTrie:            {IndexINVERTED},
+	IndexSTLSORT:         {IndexINVERTED},
+	IndexINVERTED:        {IndexSTLSORT, IndexTrie},
+}
+
+// indexParamKeys are the build parameters each index type accepts, the parameters of
+// the other index types are dropped when an index is replaced.
+var indexParamKeys = map[IndexType][]string{
+	IndexRaftIvfFlat:     {NLIST},
+	IndexRaftIvfPQ:       {NLIST, IVFM, NBITS},
+	IndexRaftCagra:       {CagraInterDegree, CagraGraphDegree, CagraBuildAlgo},
+	IndexFaissIvfFlat:    {NLIST},
+	IndexFaissIvfPQ:      {NLIST, IVFM, NBITS},
+	IndexFaissIvfSQ8:     {NLIST},
+	IndexScaNN:           {NLIST},
+	IndexHNSW:            {HNSWM, EFConstruction},
+	IndexFaissBinIvfFlat: {NLIST},
+	IndexSparseInverted:  {SparseDropRatioBuild},
+	IndexSparseWand:      {SparseDropRatioBuild},
+	IndexBITMAP:          {BitmapCardinalityLimit},
+}
+
+// indexParamDefaults are the defaults of the required build parameters.
+var indexParamDefaults = map[IndexType]map[string]int{
+	IndexRaftIvfFlat:     {NLIST: DefaultNList},
+	IndexRaftIvfPQ:       {NLIST: DefaultNList},
+	IndexFaissIvfFlat:    {NLIST: DefaultNList},
+	IndexFaissIvfPQ:      {NLIST: DefaultNList},
+	IndexFaissIvfSQ8:     {NLIST: DefaultNList},
+	IndexScaNN:           {NLIST: DefaultNList},
+	IndexHNSW:            {HNSWM: DefaultHNSWM, EFConstruction: DefaultEfConstruction},
+	IndexFaissBinIvfFlat: {NLIST: DefaultNList},
+}
+
+// MigrateIndexSpec translates spec of a field of dType to a validated equivalent the target supports.
+// The index type and the metric type are normalized, the defaults are filled in, and if the index type
+// is not supported, it is replaced by the first equivalent supported with the same metric type.
+// The metric type is never changed, as it changes the search results.
+func MigrateIndexSpec(dType schemapb.DataType, spec IndexSpec, target Capabilities) (*MigrateResult, error) {
+	result := &MigrateResult{}
+	spec = normalizeIndexSpec(dType, spec, result)
+
+	if target.Supports(spec.IndexType) {
+		migrated := result.fillDefaults(spec)
+		if err := CheckIndexValid(dType, migrated.IndexType, migrated.ToParams()); err != nil {
+			return nil, errors.Wrapf(err, "invalid index %s", spec.IndexType)
+		}
+		result.Spec = migrated
+		return result, nil
+	}
+
+	candidates := append(append([]IndexType{}, indexEquivalents[spec.IndexType]...), AutoIndex)
+	var errs []string
+	for _, candidate := range candidates {
+		if !target.Supports(candidate) {
+			continue
+		}
+		changes := &MigrateResult{}
+		migrated := changes.replaceIndexType(spec, candidate)
+		if err := CheckIndexValid(dType, migrated.IndexType, migrated.ToParams()); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", candidate, err.Error()))
+			continue
+		}
+		result.Changes = append(result.Changes, fmt.Sprintf("index type %s is not supported by the target, replaced by %s",
+			spec.IndexType, candidate))
+		result.Changes = append(result.Changes, changes.Changes...)
+		result.Spec = migrated
+		return result, nil
+	}
+	return nil, fmt.Errorf("index %s on %s field is not supported by the target and has no supported equivalent, tried: %v",
+		spec.IndexType, dType.String(), errs)
+}
+
+// normalizeIndexSpec copies spec with the index type and the metric type in their canonical forms,
+// and the metric type defaulted by dType for a vector field.
+func normalizeIndexSpec(dType schemapb.DataType, spec IndexSpec, result *MigrateResult) IndexSpec {
+	normalized := IndexSpec{
+		IndexType:  strings.TrimSpace(spec.IndexType),
+		MetricType: strings.ToUpper(strings.TrimSpace(spec.MetricType)),
+		Params:     make(map[string]string, len(spec.Params)),
+	}
+	for k, v := range spec.Params {
+		normalized.Params[k] = strings.TrimSpace(v)
+	}
+	if canonical, ok := canonicalIndexType(normalized.IndexType); ok && canonical != spec.IndexType {
+		result.Changes = append(result.Changes, fmt.Sprintf("index type %q normalized to %s", spec.IndexType, canonical))
+		normalized.IndexType = canonical
+	}
+	if normalized.MetricType != spec.MetricType && spec.MetricType != "" {
+		result.Changes = append(result.Changes, fmt.Sprintf("metric type %q normalized to %s", spec.MetricType, normalized.MetricType))
+	}
+
+	if !typeutil.IsVectorType(dType) {
+		if normalized.MetricType != "" {
+			result.Changes = append(result.Changes, fmt.Sprintf("metric type %s dropped, %s field has no metric type",
+				normalized.MetricType, dType.String()))
+			normalized.MetricType = ""
+		}
+		return normalized
+	}
+	if normalized.MetricType == "" {
+		normalized.MetricType = defaultMetricType(dType)
+		result.Changes = append(result.Changes, fmt.Sprintf("metric type set to default %s", normalized.MetricType))
+	}
+	return normalized
+}
+
+// canonicalIndexType returns the registered index type equal to indexType ignoring case.
+func canonicalIndexType(indexType IndexType) (IndexType, bool) {
+	if _, err := GetIndexCheckerMgrInstance().GetChecker(indexType); err == nil {
+		return indexType, true
+	}
+	for registered := range vectorIndexMetrics {
+		if strings.EqualFold(registered, indexType) {
+			return registered, true
+		}
+	}
+	for _, registered := range scalarIndexTypes {
+		if strings.EqualFold(registered, indexType) {
+			return registered, true
+		}
+	}
+	return indexType, false
+}
+
+// replaceIndexType returns spec with the index type replaced by indexType, the parameters are
+// translated to indexType, and the required ones missing are set to the defaults.
+func (r *MigrateResult) replaceIndexType(spec IndexSpec, indexType IndexType) IndexSpec {
+	replaced := IndexSpec{
+		IndexType:  indexType,
+		MetricType: spec.MetricType,
+		Params:     make(map[string]string),
+	}
+	translated, consumed := r.translateParams(spec, indexType)
+	for k, v := range translated {
+		replaced.Params[k] = v
+	}
+
+	accepted := indexParamKeys[indexType]
+	keys := make([]string, 0, len(spec.Params))
+	for k := range spec.Params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if _, ok := replaced.Params[k]; ok || funcutil.SliceContain(consumed, k) {
+			continue
+		}
+		if k == DIM || funcutil.SliceContain(accepted, k) {
+			replaced.Params[k] = spec.Params[k]
+			continue
+		}
+		r.Changes = append(r.Changes, fmt.Sprintf("param %s=%s dropped, not accepted by %s", k, spec.Params[k], indexType))
+	}
+	return r.fillDefaults(replaced)
+}
+
+// translateParams maps the parameters of spec having an equivalent in indexType,
+// returns the parameters translated and the keys of spec consumed.
+func (r *MigrateResult) translateParams(spec IndexSpec, indexType IndexType) (map[string]string, []string) {
+	translated := make(map[string]string)
+	var consumed []string
+	if spec.IndexType == IndexRaftCagra && indexType == IndexHNSW {
+		// a cagra graph of graph_degree is comparable to a hnsw graph of 2*M degree at layer 0
+		if degree, err := strconv.Atoi(spec.Params[CagraGraphDegree]); err == nil && degree >= 2*HNSWMinM {
+			m := degree / 2
+			if m > HNSWMaxM {
+				m = HNSWMaxM
+			}
+			translated[HNSWM] = strconv.Itoa(m)
+			consumed = append(consumed, CagraGraphDegree)
+			r.Changes = append(r.Changes, fmt.Sprintf("param %s=%d translated to %s=%d", CagraGraphDegree, degree, HNSWM, m))
+		}
+		if degree, err := strconv.Atoi(spec.Params[CagraInterDegree]); err == nil && degree >= HNSWMinEfConstruction {
+			translated[EFConstruction] = strconv.Itoa(degree)
+			consumed = append(consumed, CagraInterDegree)
+			r.Changes = append(r.Changes, fmt.Sprintf("param %s=%d translated to %s=%d", CagraInterDegree, degree, EFConstruction, degree))
+		}
+	}
+	return translated, consumed
+}
+
+// fillDefaults returns spec with the required parameters missing set to the defaults.
+func (r *MigrateResult) fillDefaults(spec IndexSpec) IndexSpec {
+	defaults := indexParamDefaults[spec.IndexType]
+	keys := make([]string, 0, len(defaults))
+	for k := range defaults {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	filled := IndexSpec{IndexType: spec.IndexType, MetricType: spec.MetricType, Params: make(map[string]string)}
+	for k, v := range spec.Params {
+		filled.Params[k] = v
+	}
+	for _, k := range keys {
+		if _, ok := filled.Params[k]; !ok {
+			filled.Params[k] = strconv.Itoa(defaults[k])
+			r.Changes = append(r.Changes, fmt.Sprintf("param %s set to default %d", k, defaults[k]))
+		}
+	}
+	return filled
+}
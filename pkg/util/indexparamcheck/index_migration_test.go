@@ -0,0 +1,186 @@
+package indexparamcheck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/xige-16/stream-read/pkg/common"
+	"github.com/xige-16/stream-read/pkg/util/metric"
+)
+
+func TestNewIndexSpec(t *testing.T) {
+	spec, err := NewIndexSpec(map[string]string{
+		common.IndexTypeKey:   IndexHNSW,
+		common.MetricTypeKey:  metric.L2,
+		common.IndexParamsKey: `{"M": 16, "efConstruction": 200}`,
+		"mmap.enabled":        "true",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, IndexSpec{
+		IndexType:  IndexHNSW,
+		MetricType: metric.L2,
+		Params:     map[string]string{HNSWM: "16", EFConstruction: "200", "mmap.enabled": "true"},
+	}, spec)
+	assert.Equal(t, map[string]string{
+		common.IndexTypeKey:  IndexHNSW,
+		common.MetricTypeKey: metric.L2,
+		HNSWM:                "16",
+		EFConstruction:       "200",
+		"mmap.enabled":       "true",
+	}, spec.ToParams())
+
+	_, err = NewIndexSpec(map[string]string{common.IndexTypeKey: IndexHNSW, common.IndexParamsKey: "{"})
+	assert.Error(t, err)
+	_, err = NewIndexSpec(map[string]string{common.MetricTypeKey: metric.L2})
+	assert.Error(t, err)
+
+	spec, err = NewIndexSpec(map[string]string{common.IndexTypeKey: IndexINVERTED})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{common.IndexTypeKey: IndexINVERTED}, spec.ToParams())
+}
+
+func TestCapabilities_Supports(t *testing.T) {
+	all := Capabilities{}
+	assert.True(t, all.Supports(IndexRaftCagra))
+	assert.True(t, all.Supports(IndexINVERTED))
+	assert.False(t, all.Supports("unknown"))
+
+	cpu := Capabilities{NoGPU: true}
+	assert.False(t, cpu.Supports(IndexRaftCagra))
+	assert.True(t, cpu.Supports(IndexHNSW))
+
+	limited := Capabilities{IndexTypes: []IndexType{IndexFaissIvfFlat, IndexRaftIvfFlat}, NoGPU: true}
+	assert.True(t, limited.Supports(IndexFaissIvfFlat))
+	assert.False(t, limited.Supports(IndexRaftIvfFlat))
+	assert.False(t, limited.Supports(IndexHNSW))
+}
+
+func TestMigrateIndexSpec(t *testing.T) {
+	cpu := Capabilities{NoGPU: true}
+	cases := []struct {
+		name    string
+		dType   schemapb.DataType
+		spec    IndexSpec
+		target  Capabilities
+		want    IndexSpec
+		changes int
+		wantErr bool
+	}{
+		{
+			name:  "supported as is",
+			dType: schemapb.DataType_FloatVector,
+			spec:  IndexSpec{IndexType: IndexHNSW, MetricType: metric.L2, Params: map[string]string{HNSWM: "8", EFConstruction: "64"}},
+			want:  IndexSpec{IndexType: IndexHNSW, MetricType: metric.L2, Params: map[string]string{HNSWM: "8", EFConstruction: "64"}},
+		},
+		{
+			name:    "normalized with defaults",
+			dType:   schemapb.DataType_FloatVector,
+			spec:    IndexSpec{IndexType: "ivf_flat", MetricType: " ip"},
+			want:    IndexSpec{IndexType: IndexFaissIvfFlat, MetricType: metric.IP, Params: map[string]string{NLIST: "128"}},
+			changes: 3,
+		},
+		{
+			name:    "default metric type",
+			dType:   schemapb.DataType_SparseFloatVector,
+			spec:    IndexSpec{IndexType: IndexSparseWand},
+			want:    IndexSpec{IndexType: IndexSparseWand, MetricType: metric.IP, Params: map[string]string{}},
+			changes: 1,
+		},
+		{
+			name:    "scalar metric type dropped",
+			dType:   schemapb.DataType_Int64,
+			spec:    IndexSpec{IndexType: IndexSTLSORT, MetricType: metric.L2},
+			want:    IndexSpec{IndexType: IndexSTLSORT, Params: map[string]string{}},
+			changes: 1,
+		},
+		{
+			name:    "invalid spec",
+			dType:   schemapb.DataType_FloatVector,
+			spec:    IndexSpec{IndexType: IndexHNSW, MetricType: metric.L2, Params: map[string]string{HNSWM: "0"}},
+			wantErr: true,
+		},
+		{
+			name:  "gpu ivf flat to ivf flat",
+			dType: schemapb.DataType_FloatVector,
+			spec: IndexSpec{IndexType: IndexRaftIvfFlat, MetricType: metric.L2, Params: map[string]string{
+				NLIST: "1024", DIM: "128", "cache_dataset_on_device": "true",
+			}},
+			target:  cpu,
+			want:    IndexSpec{IndexType: IndexFaissIvfFlat, MetricType: metric.L2, Params: map[string]string{NLIST: "1024", DIM: "128"}},
+			changes: 2,
+		},
+		{
+			name:  "cagra to hnsw",
+			dType: schemapb.DataType_FloatVector,
+			spec: IndexSpec{IndexType: IndexRaftCagra, MetricType: metric.IP, Params: map[string]string{
+				CagraGraphDegree: "64", CagraInterDegree: "128", CagraBuildAlgo: "IVF_PQ",
+			}},
+			target:  cpu,
+			want:    IndexSpec{IndexType: IndexHNSW, MetricType: metric.IP, Params: map[string]string{HNSWM: "32", EFConstruction: "128"}},
+			changes: 4,
+		},
+		{
+			name:    "gpu brute force to flat",
+			dType:   schemapb.DataType_FloatVector,
+			spec:    IndexSpec{IndexType: IndexGpuBF, MetricType: metric.L2},
+			target:  cpu,
+			want:    IndexSpec{IndexType: IndexFaissIDMap, MetricType: metric.L2, Params: map[string]string{}},
+			changes: 1,
+		},
+		{
+			name:    "ivf sq8 to hnsw",
+			dType:   schemapb.DataType_FloatVector,
+			spec:    IndexSpec{IndexType: IndexFaissIvfSQ8, MetricType: metric.COSINE, Params: map[string]string{NLIST: "128"}},
+			target:  Capabilities{IndexTypes: []IndexType{IndexHNSW, IndexFaissIDMap}},
+			want:    IndexSpec{IndexType: IndexHNSW, MetricType: metric.COSINE, Params: map[string]string{HNSWM: "16", EFConstruction: "200"}},
+			changes: 4,
+		},
+		{
+			name:    "equivalent skipped by metric type",
+			dType:   schemapb.DataType_BinaryVector,
+			spec:    IndexSpec{IndexType: IndexFaissBinIDMap, MetricType: metric.SUBSTRUCTURE},
+			target:  Capabilities{IndexTypes: []IndexType{IndexFaissBinIvfFlat}},
+			wantErr: true,
+		},
+		{
+			name:    "fallback to autoindex",
+			dType:   schemapb.DataType_Float16Vector,
+			spec:    IndexSpec{IndexType: IndexDISKANN, MetricType: metric.L2},
+			target:  Capabilities{IndexTypes: []IndexType{AutoIndex}},
+			want:    IndexSpec{IndexType: AutoIndex, MetricType: metric.L2, Params: map[string]string{}},
+			changes: 1,
+		},
+		{
+			name:    "scalar equivalent by data type",
+			dType:   schemapb.DataType_VarChar,
+			spec:    IndexSpec{IndexType: IndexINVERTED},
+			target:  Capabilities{IndexTypes: []IndexType{IndexSTLSORT, IndexTrie}},
+			want:    IndexSpec{IndexType: IndexTrie, Params: map[string]string{}},
+			changes: 1,
+		},
+		{
+			name:    "bitmap to inverted",
+			dType:   schemapb.DataType_Int32,
+			spec:    IndexSpec{IndexType: IndexBITMAP, Params: map[string]string{BitmapCardinalityLimit: "100"}},
+			target:  Capabilities{IndexTypes: []IndexType{IndexINVERTED}},
+			want:    IndexSpec{IndexType: IndexINVERTED, Params: map[string]string{}},
+			changes: 2,
+		},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := MigrateIndexSpec(test.dType, test.spec, test.target)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, result.Spec)
+			assert.Len(t, result.Changes, test.changes, result.Changes)
+		})
+	}
+}
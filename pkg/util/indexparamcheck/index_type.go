@@ -29,6 +29,17 @@ const (
 	IndexFaissBinIvfFlat IndexType = "BIN_IVF_FLAT"
 	IndexHNSW            IndexType = "HNSW"
 	IndexDISKANN         IndexType = "DISKANN"
+	IndexSparseInverted  IndexType = "SPARSE_INVERTED_INDEX"
+	IndexSparseWand      IndexType = "SPARSE_WAND"
+
+	// scalar indexes
+	IndexINVERTED IndexType = "INVERTED"
+	IndexSTLSORT  IndexType = "STL_SORT"
+	IndexTrie     IndexType = "Trie"
+	IndexBITMAP   IndexType = "BITMAP"
+
+	// AutoIndex lets the server pick the index by the field data type.
+	AutoIndex IndexType = "AUTOINDEX"
 )
 
 func IsGpuIndex(indexType IndexType) bool {
@@ -37,3 +48,14 @@ func IsGpuIndex(indexType IndexType) bool {
 		indexType == IndexRaftIvfPQ ||
 		indexType == IndexRaftCagra
 }
+
+var scalarIndexTypes = []IndexType{IndexINVERTED, IndexSTLSORT, IndexTrie, IndexBITMAP}
+
+func IsScalarIndex(indexType IndexType) bool {
+	for _, t := range scalarIndexTypes {
+		if t == indexType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,25 @@
+package indexparamcheck
+
+import (
+	"fmt"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/xige-16/stream-read/pkg/util/typeutil"
+)
+
+// invertedChecker checks if an INVERTED index can be built.
+type invertedChecker struct {
+	scalarIndexChecker
+}
+
+func (c invertedChecker) CheckValidDataType(dType schemapb.DataType) error {
+	if !typeutil.IsBoolType(dType) && !typeutil.IsArithmetic(dType) && !typeutil.IsStringType(dType) &&
+		!typeutil.IsArrayType(dType) {
+		return fmt.Errorf("INVERTED are not supported on %s field", dType.String())
+	}
+	return nil
+}
+
+func newInvertedChecker() IndexChecker {
+	return &invertedChecker{}
+}
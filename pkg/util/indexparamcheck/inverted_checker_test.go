@@ -0,0 +1,30 @@
+package indexparamcheck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+)
+
+func Test_invertedChecker_CheckTrain(t *testing.T) {
+	c := newInvertedChecker()
+	assert.NoError(t, c.CheckTrain(nil))
+	assert.NoError(t, c.StaticCheck(map[string]string{}))
+}
+
+func Test_invertedChecker_CheckValidDataType(t *testing.T) {
+	assertValidDataTypes(t, newInvertedChecker(),
+		schemapb.DataType_Bool,
+		schemapb.DataType_Int8,
+		schemapb.DataType_Int16,
+		schemapb.DataType_Int32,
+		schemapb.DataType_Int64,
+		schemapb.DataType_Float,
+		schemapb.DataType_Double,
+		schemapb.DataType_String,
+		schemapb.DataType_VarChar,
+		schemapb.DataType_Array,
+	)
+}
@@ -0,0 +1,37 @@
+package indexparamcheck
+
+import (
+	"fmt"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+)
+
+// raftBruteForceChecker checks if a GPU_BRUTE_FORCE index can be built.
+type raftBruteForceChecker struct {
+	floatVectorBaseChecker
+}
+
+func (c raftBruteForceChecker) StaticCheck(params map[string]string) error {
+	if !CheckStrByValues(params, Metric, RaftMetrics) {
+		return fmt.Errorf("metric type not found or not supported, supported: %v", RaftMetrics)
+	}
+	return nil
+}
+
+func (c raftBruteForceChecker) CheckTrain(params map[string]string) error {
+	if err := c.baseChecker.CheckTrain(params); err != nil {
+		return err
+	}
+	return c.StaticCheck(params)
+}
+
+func (c raftBruteForceChecker) CheckValidDataType(dType schemapb.DataType) error {
+	if dType != schemapb.DataType_FloatVector {
+		return fmt.Errorf("float vector are only supported")
+	}
+	return nil
+}
+
+func newRaftBruteForceChecker() IndexChecker {
+	return &raftBruteForceChecker{}
+}
@@ -0,0 +1,39 @@
+package indexparamcheck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/xige-16/stream-read/pkg/util/metric"
+)
+
+func Test_raftBruteForceChecker_CheckTrain(t *testing.T) {
+	cases := []struct {
+		params   map[string]string
+		errIsNil bool
+	}{
+		{map[string]string{DIM: "128", Metric: metric.L2}, true},
+		{map[string]string{DIM: "128", Metric: metric.IP}, true},
+		{map[string]string{DIM: "128", Metric: metric.COSINE}, false},
+		{map[string]string{DIM: "128"}, false},
+		{map[string]string{Metric: metric.L2}, false},
+	}
+
+	c := newRaftBruteForceChecker()
+	for _, test := range cases {
+		if test.errIsNil {
+			assert.NoError(t, c.CheckTrain(test.params))
+		} else {
+			assert.Error(t, c.CheckTrain(test.params))
+		}
+	}
+}
+
+func Test_raftBruteForceChecker_CheckValidDataType(t *testing.T) {
+	c := newRaftBruteForceChecker()
+	assert.NoError(t, c.CheckValidDataType(schemapb.DataType_FloatVector))
+	assert.Error(t, c.CheckValidDataType(schemapb.DataType_BFloat16Vector))
+	assert.Error(t, c.CheckValidDataType(schemapb.DataType_BinaryVector))
+}
@@ -1,8 +1,61 @@
 package indexparamcheck
 
-import "github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+import (
+	"fmt"
 
-// TODO: check index parameters according to the index type & data type.
-func CheckIndexValid(dType schemapb.DataType, indexType IndexType, indexParams map[string]string) error {
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/xige-16/stream-read/pkg/util/typeutil"
+)
+
+// scalarIndexChecker is the base of the scalar index checkers, scalar indexes have no dimension or metric type.
+type scalarIndexChecker struct {
+	baseChecker
+}
+
+func (c scalarIndexChecker) CheckTrain(params map[string]string) error {
+	return nil
+}
+
+func (c scalarIndexChecker) StaticCheck(params map[string]string) error {
 	return nil
 }
+
+// CheckIndexValid checks whether an index of indexType with indexParams can be built on a field of dType.
+// For a vector field, the metric type, defaulted by dType if absent, is checked against the metric table,
+// and the other parameters are checked statically unless the dimension is given. indexParams is not modified.
+func CheckIndexValid(dType schemapb.DataType, indexType IndexType, indexParams map[string]string) error {
+	checker, err := GetIndexCheckerMgrInstance().GetChecker(indexType)
+	if err != nil {
+		return err
+	}
+	if err := checker.CheckValidDataType(dType); err != nil {
+		return fmt.Errorf("invalid data type %s for index %s: %w", dType.String(), indexType, err)
+	}
+	if !typeutil.IsVectorType(dType) {
+		return checker.CheckTrain(indexParams)
+	}
+
+	params := make(map[string]string, len(indexParams)+1)
+	for k, v := range indexParams {
+		params[k] = v
+	}
+	setDefaultIfNotExist(params, Metric, defaultMetricType(dType))
+	if err := CheckMetricType(indexType, dType, params[Metric]); err != nil {
+		return err
+	}
+	if _, ok := params[DIM]; ok {
+		return checker.CheckTrain(params)
+	}
+	return checker.StaticCheck(params)
+}
+
+func defaultMetricType(dType schemapb.DataType) string {
+	switch {
+	case typeutil.IsBinaryVectorType(dType):
+		return BinaryVectorDefaultMetricType
+	case typeutil.IsSparseFloatVectorType(dType):
+		return SparseFloatVectorDefaultMetricType
+	default:
+		return FloatVectorDefaultMetricType
+	}
+}
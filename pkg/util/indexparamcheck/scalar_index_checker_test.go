@@ -1,13 +1,64 @@
 package indexparamcheck
 
 import (
+	"strconv"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/xige-16/stream-read/pkg/util/metric"
 )
 
 func TestCheckIndexValid(t *testing.T) {
-	assert.NoError(t, CheckIndexValid(schemapb.DataType_Int64, "inverted_index", nil))
+	cases := []struct {
+		name      string
+		dType     schemapb.DataType
+		indexType IndexType
+		params    map[string]string
+		errIsNil  bool
+	}{
+		{"unknown index", schemapb.DataType_Int64, "inverted_index", nil, false},
+		{"inverted on int64", schemapb.DataType_Int64, IndexINVERTED, nil, true},
+		{"inverted on json", schemapb.DataType_JSON, IndexINVERTED, nil, false},
+		{"stl_sort on double", schemapb.DataType_Double, IndexSTLSORT, nil, true},
+		{"stl_sort on varchar", schemapb.DataType_VarChar, IndexSTLSORT, nil, false},
+		{"trie on varchar", schemapb.DataType_VarChar, IndexTrie, nil, true},
+		{"trie on int64", schemapb.DataType_Int64, IndexTrie, nil, false},
+		{"bitmap on int8", schemapb.DataType_Int8, IndexBITMAP, map[string]string{BitmapCardinalityLimit: "100"}, true},
+		{"bitmap cardinality out of range", schemapb.DataType_Int8, IndexBITMAP, map[string]string{BitmapCardinalityLimit: "1001"}, false},
+		{"bitmap on float", schemapb.DataType_Float, IndexBITMAP, nil, false},
+		{"autoindex on int64", schemapb.DataType_Int64, AutoIndex, nil, true},
+		{"autoindex on json", schemapb.DataType_JSON, AutoIndex, nil, false},
+		{"autoindex on float vector", schemapb.DataType_FloatVector, AutoIndex, map[string]string{Metric: metric.L2}, true},
+		{"autoindex on binary vector", schemapb.DataType_BinaryVector, AutoIndex, nil, true},
+		{"autoindex on binary vector with float metric", schemapb.DataType_BinaryVector, AutoIndex, map[string]string{Metric: metric.L2}, false},
+		{"autoindex on sparse vector", schemapb.DataType_SparseFloatVector, AutoIndex, nil, true},
+		{"scalar index on vector", schemapb.DataType_FloatVector, IndexINVERTED, nil, false},
+		{"vector index on scalar", schemapb.DataType_Int64, IndexHNSW, nil, false},
+		{"ivf_flat default metric", schemapb.DataType_FloatVector, IndexFaissIvfFlat, map[string]string{NLIST: "128"}, true},
+		{"ivf_flat nlist out of range", schemapb.DataType_FloatVector, IndexFaissIvfFlat, map[string]string{NLIST: strconv.Itoa(MaxNList + 1)}, false},
+		{"ivf_flat with dim", schemapb.DataType_Float16Vector, IndexFaissIvfFlat, map[string]string{NLIST: "128", DIM: "128"}, true},
+		{"ivf_flat dim out of range", schemapb.DataType_FloatVector, IndexFaissIvfFlat, map[string]string{NLIST: "128", DIM: "0"}, false},
+		{"hnsw on binary vector", schemapb.DataType_BinaryVector, IndexHNSW, map[string]string{HNSWM: "16", EFConstruction: "200"}, true},
+		{"hnsw on binary vector with L2", schemapb.DataType_BinaryVector, IndexHNSW, map[string]string{HNSWM: "16", EFConstruction: "200", Metric: metric.L2}, false},
+		{"gpu index on float16 vector", schemapb.DataType_Float16Vector, IndexRaftCagra, map[string]string{Metric: metric.L2}, false},
+		{"gpu index with cosine", schemapb.DataType_FloatVector, IndexGpuBF, map[string]string{Metric: metric.COSINE}, false},
+		{"gpu brute force", schemapb.DataType_FloatVector, IndexGpuBF, map[string]string{Metric: metric.IP}, true},
+		{"sparse inverted index", schemapb.DataType_SparseFloatVector, IndexSparseInverted, map[string]string{SparseDropRatioBuild: "0.2"}, true},
+		{"sparse wand with L2", schemapb.DataType_SparseFloatVector, IndexSparseWand, map[string]string{Metric: metric.L2}, false},
+	}
+
+	for _, test := range cases {
+		t.Run(test.name, func(t *testing.T) {
+			params := copyParams(test.params)
+			err := CheckIndexValid(test.dType, test.indexType, test.params)
+			if test.errIsNil {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+			assert.Equal(t, len(params), len(test.params))
+		})
+	}
 }
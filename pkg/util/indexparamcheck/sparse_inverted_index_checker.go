@@ -0,0 +1,47 @@
+package indexparamcheck
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/xige-16/stream-read/pkg/common"
+)
+
+// sparseInvertedIndexChecker checks if a SPARSE_INVERTED_INDEX or SPARSE_WAND index can be built.
+type sparseInvertedIndexChecker struct {
+	baseChecker
+}
+
+func (c sparseInvertedIndexChecker) StaticCheck(params map[string]string) error {
+	if ratioStr, ok := params[SparseDropRatioBuild]; ok {
+		ratio, err := strconv.ParseFloat(ratioStr, 64)
+		if err != nil || ratio < 0 || ratio >= 1 {
+			return fmt.Errorf("%s must be in range [0, 1), got: %s", SparseDropRatioBuild, ratioStr)
+		}
+	}
+	if !CheckStrByValues(params, Metric, SparseMetrics) {
+		return fmt.Errorf("metric type not found or not supported, supported: %v", SparseMetrics)
+	}
+	return nil
+}
+
+// CheckTrain checks the same as StaticCheck, sparse vectors have no dimension.
+func (c sparseInvertedIndexChecker) CheckTrain(params map[string]string) error {
+	return c.StaticCheck(params)
+}
+
+func (c sparseInvertedIndexChecker) CheckValidDataType(dType schemapb.DataType) error {
+	if dType != schemapb.DataType_SparseFloatVector {
+		return fmt.Errorf("only sparse float vector is supported")
+	}
+	return nil
+}
+
+func (c sparseInvertedIndexChecker) SetDefaultMetricTypeIfNotExist(params map[string]string) {
+	setDefaultIfNotExist(params, common.MetricTypeKey, SparseFloatVectorDefaultMetricType)
+}
+
+func newSparseInvertedIndexChecker() IndexChecker {
+	return &sparseInvertedIndexChecker{}
+}
@@ -0,0 +1,46 @@
+package indexparamcheck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/xige-16/stream-read/pkg/util/metric"
+)
+
+func Test_sparseInvertedIndexChecker_CheckTrain(t *testing.T) {
+	cases := []struct {
+		params   map[string]string
+		errIsNil bool
+	}{
+		{map[string]string{Metric: metric.IP}, true},
+		{map[string]string{Metric: metric.IP, SparseDropRatioBuild: "0"}, true},
+		{map[string]string{Metric: metric.IP, SparseDropRatioBuild: "0.99"}, true},
+		{map[string]string{Metric: metric.IP, SparseDropRatioBuild: "1"}, false},
+		{map[string]string{Metric: metric.IP, SparseDropRatioBuild: "-0.1"}, false},
+		{map[string]string{Metric: metric.IP, SparseDropRatioBuild: "a"}, false},
+		{map[string]string{Metric: metric.L2}, false},
+		{map[string]string{}, false},
+	}
+
+	c := newSparseInvertedIndexChecker()
+	for _, test := range cases {
+		if test.errIsNil {
+			assert.NoError(t, c.CheckTrain(test.params))
+		} else {
+			assert.Error(t, c.CheckTrain(test.params))
+		}
+	}
+
+	params := map[string]string{}
+	c.SetDefaultMetricTypeIfNotExist(params)
+	assert.Equal(t, SparseFloatVectorDefaultMetricType, params[Metric])
+}
+
+func Test_sparseInvertedIndexChecker_CheckValidDataType(t *testing.T) {
+	c := newSparseInvertedIndexChecker()
+	assert.NoError(t, c.CheckValidDataType(schemapb.DataType_SparseFloatVector))
+	assert.Error(t, c.CheckValidDataType(schemapb.DataType_FloatVector))
+	assert.Error(t, c.CheckValidDataType(schemapb.DataType_VarChar))
+}
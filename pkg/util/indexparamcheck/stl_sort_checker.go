@@ -0,0 +1,24 @@
+package indexparamcheck
+
+import (
+	"fmt"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/xige-16/stream-read/pkg/util/typeutil"
+)
+
+// stlSortChecker checks if a STL_SORT index can be built.
+type stlSortChecker struct {
+	scalarIndexChecker
+}
+
+func (c stlSortChecker) CheckValidDataType(dType schemapb.DataType) error {
+	if !typeutil.IsArithmetic(dType) {
+		return fmt.Errorf("STL_SORT are only supported on numeric field")
+	}
+	return nil
+}
+
+func newSTLSORTChecker() IndexChecker {
+	return &stlSortChecker{}
+}
@@ -0,0 +1,26 @@
+package indexparamcheck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+)
+
+func Test_stlSortChecker_CheckTrain(t *testing.T) {
+	c := newSTLSORTChecker()
+	assert.NoError(t, c.CheckTrain(nil))
+	assert.NoError(t, c.StaticCheck(map[string]string{}))
+}
+
+func Test_stlSortChecker_CheckValidDataType(t *testing.T) {
+	assertValidDataTypes(t, newSTLSORTChecker(),
+		schemapb.DataType_Int8,
+		schemapb.DataType_Int16,
+		schemapb.DataType_Int32,
+		schemapb.DataType_Int64,
+		schemapb.DataType_Float,
+		schemapb.DataType_Double,
+	)
+}
@@ -0,0 +1,24 @@
+package indexparamcheck
+
+import (
+	"fmt"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/xige-16/stream-read/pkg/util/typeutil"
+)
+
+// trieChecker checks if a Trie index can be built.
+type trieChecker struct {
+	scalarIndexChecker
+}
+
+func (c trieChecker) CheckValidDataType(dType schemapb.DataType) error {
+	if !typeutil.IsStringType(dType) {
+		return fmt.Errorf("Trie are only supported on varchar field")
+	}
+	return nil
+}
+
+func newTrieChecker() IndexChecker {
+	return &trieChecker{}
+}
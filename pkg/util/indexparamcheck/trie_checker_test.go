@@ -0,0 +1,22 @@
+package indexparamcheck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+)
+
+func Test_trieChecker_CheckTrain(t *testing.T) {
+	c := newTrieChecker()
+	assert.NoError(t, c.CheckTrain(nil))
+	assert.NoError(t, c.StaticCheck(map[string]string{}))
+}
+
+func Test_trieChecker_CheckValidDataType(t *testing.T) {
+	assertValidDataTypes(t, newTrieChecker(),
+		schemapb.DataType_String,
+		schemapb.DataType_VarChar,
+	)
+}
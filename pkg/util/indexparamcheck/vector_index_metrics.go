@@ -0,0 +1,73 @@
+package indexparamcheck
+
+import (
+	"fmt"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/xige-16/stream-read/pkg/util/metric"
+)
+
+func denseFloatMetrics(metrics []metric.MetricType) map[schemapb.DataType][]metric.MetricType {
+	return map[schemapb.DataType][]metric.MetricType{
+		schemapb.DataType_FloatVector:    metrics,
+		schemapb.DataType_Float16Vector:  metrics,
+		schemapb.DataType_BFloat16Vector: metrics,
+	}
+}
+
+// vectorIndexMetrics is the metric types each vector index accepts for each vector data type,
+// an index does not support the vector data types not listed for it.
+var vectorIndexMetrics = map[IndexType]map[schemapb.DataType][]metric.MetricType{
+	IndexFaissIDMap:   denseFloatMetrics(METRICS),
+	IndexFaissIvfFlat: denseFloatMetrics(METRICS),
+	IndexFaissIvfPQ:   denseFloatMetrics(METRICS),
+	IndexFaissIvfSQ8:  denseFloatMetrics(METRICS),
+	IndexScaNN:        denseFloatMetrics(METRICS),
+	IndexDISKANN:      denseFloatMetrics(METRICS),
+	IndexHNSW: {
+		schemapb.DataType_FloatVector:    METRICS,
+		schemapb.DataType_Float16Vector:  METRICS,
+		schemapb.DataType_BFloat16Vector: METRICS,
+		schemapb.DataType_BinaryVector:   HnswBinaryMetrics,
+	},
+	IndexRaftIvfFlat: {schemapb.DataType_FloatVector: RaftMetrics},
+	IndexRaftIvfPQ:   {schemapb.DataType_FloatVector: RaftMetrics},
+	IndexRaftCagra:   {schemapb.DataType_FloatVector: RaftMetrics},
+	IndexGpuBF:       {schemapb.DataType_FloatVector: RaftMetrics},
+
+	IndexFaissBinIDMap:   {schemapb.DataType_BinaryVector: BinIDMapMetrics},
+	IndexFaissBinIvfFlat: {schemapb.DataType_BinaryVector: BinIvfMetrics},
+
+	IndexSparseInverted: {schemapb.DataType_SparseFloatVector: SparseMetrics},
+	IndexSparseWand:     {schemapb.DataType_SparseFloatVector: SparseMetrics},
+
+	AutoIndex: {
+		schemapb.DataType_FloatVector:       METRICS,
+		schemapb.DataType_Float16Vector:     METRICS,
+		schemapb.DataType_BFloat16Vector:    METRICS,
+		schemapb.DataType_BinaryVector:      BinIvfMetrics,
+		schemapb.DataType_SparseFloatVector: SparseMetrics,
+	},
+}
+
+// GetSupportedMetrics returns the metric types the index accepts for the vector data type,
+// false if the index does not support the data type.
+func GetSupportedMetrics(indexType IndexType, dType schemapb.DataType) ([]metric.MetricType, bool) {
+	metrics, ok := vectorIndexMetrics[indexType][dType]
+	return metrics, ok
+}
+
+// CheckMetricType checks whether the index accepts metricType on a vector field of dType.
+func CheckMetricType(indexType IndexType, dType schemapb.DataType, metricType metric.MetricType) error {
+	metrics, ok := GetSupportedMetrics(indexType, dType)
+	if !ok {
+		return fmt.Errorf("index %s is not supported on %s field", indexType, dType.String())
+	}
+	for _, m := range metrics {
+		if m == metricType {
+			return nil
+		}
+	}
+	return fmt.Errorf("metric type %s not supported by index %s on %s field, supported: %v",
+		metricType, indexType, dType.String(), metrics)
+}
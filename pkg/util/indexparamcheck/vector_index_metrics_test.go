@@ -0,0 +1,56 @@
+package indexparamcheck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/xige-16/stream-read/pkg/util/metric"
+	"github.com/xige-16/stream-read/pkg/util/typeutil"
+)
+
+func TestCheckMetricType(t *testing.T) {
+	cases := []struct {
+		indexType  IndexType
+		dType      schemapb.DataType
+		metricType metric.MetricType
+		errIsNil   bool
+	}{
+		{IndexFaissIDMap, schemapb.DataType_FloatVector, metric.COSINE, true},
+		{IndexFaissIDMap, schemapb.DataType_BFloat16Vector, metric.L2, true},
+		{IndexFaissIDMap, schemapb.DataType_FloatVector, metric.HAMMING, false},
+		{IndexFaissIDMap, schemapb.DataType_BinaryVector, metric.HAMMING, false},
+		{IndexHNSW, schemapb.DataType_BinaryVector, metric.JACCARD, true},
+		{IndexHNSW, schemapb.DataType_BinaryVector, metric.SUBSTRUCTURE, false},
+		{IndexRaftIvfFlat, schemapb.DataType_FloatVector, metric.IP, true},
+		{IndexRaftIvfFlat, schemapb.DataType_Float16Vector, metric.IP, false},
+		{IndexFaissBinIDMap, schemapb.DataType_BinaryVector, metric.SUPERSTRUCTURE, true},
+		{IndexFaissBinIvfFlat, schemapb.DataType_BinaryVector, metric.SUPERSTRUCTURE, false},
+		{IndexSparseWand, schemapb.DataType_SparseFloatVector, metric.IP, true},
+		{IndexSparseWand, schemapb.DataType_SparseFloatVector, metric.COSINE, false},
+		{IndexINVERTED, schemapb.DataType_FloatVector, metric.L2, false},
+	}
+
+	for _, test := range cases {
+		err := CheckMetricType(test.indexType, test.dType, test.metricType)
+		if test.errIsNil {
+			assert.NoError(t, err, test)
+		} else {
+			assert.Error(t, err, test)
+		}
+	}
+}
+
+func TestVectorIndexMetrics(t *testing.T) {
+	mgr := newIndexCheckerMgr()
+	for indexType, metrics := range vectorIndexMetrics {
+		checker, err := mgr.GetChecker(indexType)
+		assert.NoError(t, err, indexType)
+		for dType, supported := range metrics {
+			assert.True(t, typeutil.IsVectorType(dType))
+			assert.NotEmpty(t, supported)
+			assert.NoError(t, checker.CheckValidDataType(dType), "%s %s", indexType, dType)
+		}
+	}
+}
@@ -108,10 +108,12 @@ var (
 	ErrMetricNotFound = newMilvusError("metric not found", 1200, false)
 
 	// Message queue related
-	ErrMqTopicNotFound = newMilvusError("topic not found", 1300, false)
-	ErrMqTopicNotEmpty = newMilvusError("topic not empty", 1301, false)
-	ErrMqInternal      = newMilvusError("message queue internal error", 1302, false)
-	ErrDenyProduceMsg  = newMilvusError("deny to write the message to mq", 1303, false)
+	ErrMqTopicNotFound  = newMilvusError("topic not found", 1300, false)
+	ErrMqTopicNotEmpty  = newMilvusError("topic not empty", 1301, false)
+	ErrMqInternal       = newMilvusError("message queue internal error", 1302, false)
+	ErrDenyProduceMsg   = newMilvusError("deny to write the message to mq", 1303, false)
+	ErrMqChannelStalled = newMilvusError("channel time tick stalled", 1304, false)
+	ErrMqBadMessage     = newMilvusError("undecodable message", 1305, false)
 
 	// Privilege related
 	// this operation is denied because the user not authorized, user need to login in first
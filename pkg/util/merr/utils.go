@@ -18,8 +18,10 @@ package merr
 
 import (
 	"context"
+	"encoding/hex"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/cockroachdb/errors"
 
@@ -841,6 +843,22 @@ func WrapErrMqInternal(err error, msg ...string) error {
 	return err
 }
 
+func WrapErrMqChannelStalled(channel string, lag time.Duration, msg ...string) error {
+	err := wrapFields(ErrMqChannelStalled, value("channel", channel), value("lag", lag))
+	if len(msg) > 0 {
+		err = errors.Wrap(err, strings.Join(msg, "->"))
+	}
+	return err
+}
+
+func WrapErrMqBadMessage(channel string, msgID []byte, err error, msg ...string) error {
+	err = wrapFieldsWithDesc(ErrMqBadMessage, err.Error(), value("channel", channel), value("msgID", hex.EncodeToString(msgID)))
+	if len(msg) > 0 {
+		err = errors.Wrap(err, strings.Join(msg, "->"))
+	}
+	return err
+}
+
 func WrapErrPrivilegeNotAuthenticated(fmt string, args ...any) error {
 	err := errors.Wrapf(ErrPrivilegeNotAuthenticated, fmt, args...)
 	return err
@@ -74,6 +74,8 @@ type baseTableConfig struct {
 	skipRemote      bool
 	skipEnv         bool
 	yamlFiles       []string
+	configDirs      []string
+	secretDirs      []string
 }
 
 type Option func(*baseTableConfig)
@@ -90,6 +92,22 @@ func Interval(interval int) Option {
 	}
 }
 
+// ConfigDirs reads configs from dirs holding one file per key, like mounted kubernetes ConfigMaps,
+// the former dirs take precedence.
+func ConfigDirs(dirs []string) Option {
+	return func(bt *baseTableConfig) {
+		bt.configDirs = dirs
+	}
+}
+
+// SecretDirs reads configs from dirs as ConfigDirs does, the values are masked in logs,
+// secret dirs take precedence over config dirs.
+func SecretDirs(dirs []string) Option {
+	return func(bt *baseTableConfig) {
+		bt.secretDirs = dirs
+	}
+}
+
 func SkipRemote(skip bool) Option {
 	return func(bt *baseTableConfig) {
 		bt.skipRemote = skip
@@ -115,6 +133,8 @@ func NewBaseTable(opts ...Option) *BaseTable {
 		refreshInterval: 5,
 		skipRemote:      false,
 		skipEnv:         false,
+		configDirs:      initDirs("MILVUSCONF_DIRS"),
+		secretDirs:      initDirs("MILVUSSECRET_DIRS"),
 	}
 	for _, opt := range opts {
 		opt(defaultConfig)
@@ -144,6 +164,7 @@ func (bt *BaseTable) init() {
 		}
 	}
 	bt.initConfigsFromLocal()
+	bt.initConfigsFromDirs()
 	//if !bt.config.skipRemote {
 	//	bt.initConfigsFromRemote()
 	//}
@@ -163,6 +184,27 @@ func (bt *BaseTable) initConfigsFromLocal() {
 	}
 }
 
+func (bt *BaseTable) initConfigsFromDirs() {
+	refreshInterval := time.Duration(bt.config.refreshInterval) * time.Second
+	priority := config.DirPriority
+	addDirs := func(dirs []string, secret bool) {
+		for _, dir := range dirs {
+			err := bt.mgr.AddSource(config.NewDirSource(&config.DirInfo{
+				Dir:             dir,
+				Priority:        priority,
+				Secret:          secret,
+				RefreshInterval: refreshInterval,
+			}))
+			if err != nil {
+				log.Warn("init baseTable with dir failed", zap.String("dir", dir), zap.Error(err))
+			}
+			priority++
+		}
+	}
+	addDirs(bt.config.secretDirs, true)
+	addDirs(bt.config.configDirs, false)
+}
+
 func (bt *BaseTable) initConfigsFromRemote() {
 	refreshInterval := bt.config.refreshInterval
 	etcdConfig := EtcdConfig{}
@@ -219,6 +261,17 @@ func initConfPath() string {
 	return configDir
 }
 
+// initDirs returns the comma separated dirs set through env.
+func initDirs(env string) []string {
+	dirs := make([]string, 0)
+	for _, dir := range strings.Split(os.Getenv(env), ",") {
+		if dir = strings.TrimSpace(dir); len(dir) > 0 {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
 func (bt *BaseTable) FileConfigs() map[string]string {
 	return bt.mgr.FileConfigs()
 }
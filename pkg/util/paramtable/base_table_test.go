@@ -13,6 +13,7 @@ package paramtable
 
 import (
 	"os"
+	"path"
 	"strings"
 	"testing"
 
@@ -146,3 +147,27 @@ func TestNewBaseTableFromYamlOnly(t *testing.T) {
 	gp = NewBaseTableFromYamlOnly(yaml)
 	assert.Empty(t, gp.Get("key"))
 }
+
+func TestBaseTable_Dirs(t *testing.T) {
+	configDir := t.TempDir()
+	secretDir := t.TempDir()
+	os.WriteFile(path.Join(configDir, "replay.insertBatchRows"), []byte("100\n"), 0o600)
+	os.WriteFile(path.Join(configDir, "minio.secretAccessKey"), []byte("config"), 0o600)
+	os.WriteFile(path.Join(secretDir, "minio.secretAccessKey"), []byte("secret"), 0o600)
+	t.Setenv("MILVUSCONF_DIRS", configDir+", ")
+	t.Setenv("MILVUSSECRET_DIRS", secretDir)
+
+	bt := NewBaseTable(SkipRemote(true), Interval(0))
+	assert.Equal(t, "100", bt.Get("replay.insertBatchRows"))
+	// secret dirs take precedence
+	assert.Equal(t, "secret", bt.Get("minio.secretAccessKey"))
+
+	params := &ComponentParam{}
+	params.Init(bt)
+	assert.Equal(t, 100, params.ReplayCfg.InsertBatchRows.GetAsInt())
+	assert.Equal(t, config.MaskedValue, params.GetAllMasked()["minio.secretaccesskey"])
+	assert.Equal(t, "secret", params.GetAll()["minio.secretaccesskey"])
+
+	bt = NewBaseTable(SkipRemote(true), Interval(0), ConfigDirs(nil), SecretDirs(nil))
+	assert.Equal(t, "0", bt.Get("replay.insertBatchRows"))
+}
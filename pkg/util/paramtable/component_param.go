@@ -74,6 +74,7 @@ type ComponentParam struct {
 	HTTPCfg       httpConfig
 	LogCfg        logConfig
 	RoleCfg       roleConfig
+	ReplayCfg     replayConfig
 
 	RootCoordGrpcServerCfg  GrpcServerConfig
 	ProxyGrpcServerCfg      GrpcServerConfig
@@ -122,6 +123,7 @@ func (p *ComponentParam) init(bt *BaseTable) {
 	p.HTTPCfg.init(bt)
 	p.LogCfg.init(bt)
 	p.RoleCfg.init(bt)
+	p.ReplayCfg.init(bt)
 
 	p.RootCoordGrpcServerCfg.Init("rootCoord", bt)
 	p.ProxyGrpcServerCfg.Init("proxy", bt)
@@ -152,6 +154,11 @@ func (p *ComponentParam) GetAll() map[string]string {
 	return p.baseTable.mgr.GetConfigs()
 }
 
+// GetAllMasked returns all the configs with the secret values masked, safe to be logged.
+func (p *ComponentParam) GetAllMasked() map[string]string {
+	return p.baseTable.mgr.GetMaskedConfigs()
+}
+
 func (p *ComponentParam) Watch(key string, watcher config.EventHandler) {
 	p.baseTable.mgr.Dispatcher.Register(key, watcher)
 }
@@ -745,7 +752,7 @@ Fractions >= 1 will always sample. Fractions < 0 are treated as zero.`,
 }
 
 type logConfig struct {
-	Level        ParamItem `refreshable:"false"`
+	Level        ParamItem `refreshable:"true"`
 	RootPath     ParamItem `refreshable:"false"`
 	MaxSize      ParamItem `refreshable:"false"`
 	MaxAge       ParamItem `refreshable:"false"`
@@ -762,6 +769,9 @@ func (l *logConfig) init(base *BaseTable) {
 		Version:      "2.0.0",
 		Doc:          "Only supports debug, info, warn, error, panic, or fatal. Default 'info'.",
 		Export:       true,
+		// the levels zap accepts, in lower or upper case
+		Enum: []string{"debug", "info", "warn", "error", "dpanic", "panic", "fatal",
+			"DEBUG", "INFO", "WARN", "ERROR", "DPANIC", "PANIC", "FATAL"},
 	}
 	l.Level.Init(base.mgr)
 
@@ -0,0 +1,290 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paramtable
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/spf13/viper"
+
+	"github.com/xige-16/stream-read/pkg/config"
+)
+
+// DefaultSource is the source of the values which are not set by any config source.
+const DefaultSource = "Default"
+
+// ParamInfo is the effective value of a ParamItem.
+type ParamInfo struct {
+	Key          string
+	Value        string
+	DefaultValue string
+	// Source is the name of the config source the value comes from, DefaultSource if not set.
+	Source      string
+	Doc         string
+	Version     string
+	Refreshable bool
+	Export      bool
+	// Secret is true if the value comes from a secret source, Value is masked then.
+	Secret bool
+	// IsDefault is true if the value is not set or equals to the default value.
+	IsDefault bool
+}
+
+type paramField struct {
+	item        *ParamItem
+	refreshable bool
+}
+
+// Dump returns the effective values of the initialized ParamItems under prefixes in declaration order,
+// all of them if no prefix is given, the secret values are masked.
+// The values of some items are computed from the environment, prefixes should be given to avoid that.
+func (p *ComponentParam) Dump(prefixes ...string) []ParamInfo {
+	fields, _ := p.walk(prefixes...)
+	infos := make([]ParamInfo, 0, len(fields))
+	for _, field := range fields {
+		item := field.item
+		value, source, _ := item.getWithSource()
+		info := ParamInfo{
+			Key:          item.Key,
+			Value:        value,
+			DefaultValue: item.DefaultValue,
+			Source:       source,
+			Doc:          item.Doc,
+			Version:      item.Version,
+			Refreshable:  field.refreshable,
+			Export:       item.Export,
+			IsDefault:    source == DefaultSource || value == item.DefaultValue,
+		}
+		for _, key := range append([]string{item.Key}, item.FallbackKeys...) {
+			if item.manager.IsSecret(key) {
+				info.Secret = true
+				info.Value = config.MaskedValue
+				break
+			}
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// DiffDefaults returns the ParamItems under prefixes whose effective values differ from their defaults.
+func (p *ComponentParam) DiffDefaults(prefixes ...string) []ParamInfo {
+	infos := make([]ParamInfo, 0)
+	for _, info := range p.Dump(prefixes...) {
+		if !info.IsDefault {
+			infos = append(infos, info)
+		}
+	}
+	return infos
+}
+
+// UnknownKeys returns the keys of yamlFile which are neither the key nor a fallback key
+// of any initialized ParamItem, nor under the prefix of a ParamGroup.
+func (p *ComponentParam) UnknownKeys(yamlFile string) ([]string, error) {
+	yamlReader := viper.New()
+	yamlReader.SetConfigFile(yamlFile)
+	if err := yamlReader.ReadInConfig(); err != nil {
+		return nil, errors.Wrap(err, "Read config failed: "+yamlFile)
+	}
+
+	fields, groups := p.walk()
+	known := make(map[string]struct{})
+	for _, field := range fields {
+		for _, key := range append([]string{field.item.Key}, field.item.FallbackKeys...) {
+			known[config.FormatKey(key)] = struct{}{}
+		}
+	}
+
+	unknown := make([]string, 0)
+	for _, key := range yamlReader.AllKeys() {
+		if _, ok := known[config.FormatKey(key)]; ok {
+			continue
+		}
+		grouped := false
+		for _, group := range groups {
+			if strings.HasPrefix(key, strings.ToLower(group.KeyPrefix)) {
+				grouped = true
+				break
+			}
+		}
+		if !grouped {
+			unknown = append(unknown, key)
+		}
+	}
+	return unknown, nil
+}
+
+// WriteYaml writes the default values of the ParamItems under prefixes to w as a documented yaml,
+// all of them if no prefix is given, the items are written in declaration order.
+func (p *ComponentParam) WriteYaml(w io.Writer, prefixes ...string) error {
+	root := &yamlNode{}
+	fields, _ := p.walk(prefixes...)
+	for _, field := range fields {
+		root.insert(strings.Split(field.item.Key, "."), field.item)
+	}
+	for i, child := range root.children {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		if err := child.write(w, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walk collects the initialized ParamItems under prefixes and all the ParamGroups of p
+// in declaration order, see walkParams.
+func (p *ComponentParam) walk(prefixes ...string) ([]paramField, []*ParamGroup) {
+	return walkParams(p, prefixes...)
+}
+
+// walkParams collects the initialized ParamItems under prefixes and all the ParamGroups of the
+// struct params points to in declaration order, the items sharing a key are collected once.
+// Only the exported fields are walked, including the ones promoted from the embedded configs.
+func walkParams(params any, prefixes ...string) ([]paramField, []*ParamGroup) {
+	var fields []paramField
+	var groups []*ParamGroup
+	keys := make(map[string]struct{})
+
+	var walkStruct func(v reflect.Value)
+	walkStruct = func(v reflect.Value) {
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Field(i)
+			if !f.CanInterface() {
+				// an embedded config of an unexported type, whose fields are promoted
+				if t.Field(i).Anonymous && f.Kind() == reflect.Struct {
+					walkStruct(f)
+				}
+				continue
+			}
+			switch field := f.Addr().Interface().(type) {
+			case *ParamItem:
+				if field.manager == nil || !hasPrefix(field.Key, prefixes) {
+					continue
+				}
+				if _, ok := keys[field.Key]; ok {
+					continue
+				}
+				keys[field.Key] = struct{}{}
+				refreshable, _ := strconv.ParseBool(t.Field(i).Tag.Get("refreshable"))
+				fields = append(fields, paramField{item: field, refreshable: refreshable})
+			case *ParamGroup:
+				if field.manager != nil {
+					groups = append(groups, field)
+				}
+			default:
+				if f.Kind() == reflect.Struct {
+					walkStruct(f)
+				}
+			}
+		}
+	}
+	walkStruct(reflect.ValueOf(params).Elem())
+	return fields, groups
+}
+
+// hasPrefix returns whether key is one of prefixes or under one of them, true if prefixes is empty.
+func hasPrefix(key string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+	for _, prefix := range prefixes {
+		if key == prefix || strings.HasPrefix(key, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// yamlNode is a level of the yaml generated from the dotted keys.
+type yamlNode struct {
+	name     string
+	item     *ParamItem
+	children []*yamlNode
+}
+
+func (n *yamlNode) insert(path []string, item *ParamItem) {
+	if len(path) == 0 {
+		n.item = item
+		return
+	}
+	for _, child := range n.children {
+		if child.name == path[0] {
+			child.insert(path[1:], item)
+			return
+		}
+	}
+	child := &yamlNode{name: path[0]}
+	n.children = append(n.children, child)
+	child.insert(path[1:], item)
+}
+
+func (n *yamlNode) write(w io.Writer, depth int) error {
+	indent := strings.Repeat("  ", depth)
+	var doc string
+	if n.item != nil {
+		doc = strings.TrimSpace(n.item.Doc)
+	}
+	lines := strings.Split(doc, "\n")
+	// a multi-line doc is written above the key
+	if len(lines) > 1 || len(n.children) > 0 {
+		for _, line := range lines {
+			if line = strings.TrimSpace(line); len(line) > 0 {
+				if _, err := fmt.Fprintf(w, "%s# %s\n", indent, line); err != nil {
+					return err
+				}
+			}
+		}
+		doc = ""
+	}
+
+	line := indent + n.name + ":"
+	if len(n.children) == 0 && n.item != nil {
+		if value := yamlValue(n.item.DefaultValue); len(value) > 0 {
+			line += " " + value
+		}
+		if len(doc) > 0 {
+			line += " # " + doc
+		}
+	}
+	if _, err := io.WriteString(w, line+"\n"); err != nil {
+		return err
+	}
+	for _, child := range n.children {
+		if err := child.write(w, depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// yamlValue quotes value if it would not be read back as the same string.
+func yamlValue(value string) string {
+	if strings.ContainsAny(value, ":#{}[]&*!|>'\"%@`\n") || strings.TrimSpace(value) != value {
+		return strconv.Quote(value)
+	}
+	return value
+}
@@ -0,0 +1,115 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paramtable
+
+import (
+	"bytes"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/samber/lo"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xige-16/stream-read/pkg/config"
+)
+
+func TestComponentParam_Dump(t *testing.T) {
+	secretDir := t.TempDir()
+	os.WriteFile(path.Join(secretDir, "pulsar.authParams"), []byte("token"), 0o600)
+	t.Setenv("REPLAY_INSERTBATCHROWS", "10")
+
+	params := &ComponentParam{}
+	params.Init(NewBaseTable(SkipRemote(true), Interval(0), SecretDirs([]string{secretDir})))
+	params.Save(params.ReplayCfg.Partitions.Key, "p1")
+	defer params.Reset(params.ReplayCfg.Partitions.Key)
+
+	infos := lo.KeyBy(params.Dump("replay", "pulsar"), func(info ParamInfo) string { return info.Key })
+	assert.NotContains(t, infos, params.MQCfg.Type.Key)
+
+	info := infos[params.PulsarCfg.AuthPlugin.Key]
+	assert.Equal(t, DefaultSource, info.Source)
+	assert.True(t, info.IsDefault)
+
+	info = infos[params.ReplayCfg.RateLimitRows.Key]
+	assert.Equal(t, "FileSource", info.Source)
+	assert.True(t, info.IsDefault)
+	assert.True(t, info.Refreshable)
+	assert.True(t, info.Export)
+	assert.Equal(t, params.ReplayCfg.RateLimitRows.Doc, info.Doc)
+
+	info = infos[params.ReplayCfg.InsertBatchRows.Key]
+	assert.Equal(t, "10", info.Value)
+	assert.Equal(t, "EnvironmentSource", info.Source)
+	assert.False(t, info.IsDefault)
+
+	info = infos[params.ReplayCfg.Partitions.Key]
+	assert.Equal(t, "p1", info.Value)
+	assert.Equal(t, config.RuntimeSource, info.Source)
+
+	info = infos[params.PulsarCfg.AuthParams.Key]
+	assert.True(t, info.Secret)
+	assert.Equal(t, config.MaskedValue, info.Value)
+	assert.Equal(t, "DirSource:"+secretDir, info.Source)
+
+	// the items promoted from an embedded config of an unexported type are dumped
+	infos = lo.KeyBy(params.Dump(params.ProxyGrpcServerCfg.Domain), func(info ParamInfo) string { return info.Key })
+	assert.Contains(t, infos, params.ProxyGrpcServerCfg.Port.Key)
+
+	diff := lo.Map(params.DiffDefaults("replay"), func(info ParamInfo, _ int) string { return info.Key })
+	assert.ElementsMatch(t, []string{params.ReplayCfg.InsertBatchRows.Key, params.ReplayCfg.Partitions.Key}, diff)
+}
+
+func TestComponentParam_UnknownKeys(t *testing.T) {
+	params := &ComponentParam{}
+	params.Init(NewBaseTable(SkipRemote(true), Interval(0)))
+
+	dir := t.TempDir()
+	file := path.Join(dir, "user.yaml")
+	os.WriteFile(file, []byte("replay:\n  partitions: p1\n  partition: p2\nlog:\n  level: info\nunknown: 1\n"), 0o600)
+	unknown, err := params.UnknownKeys(file)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"replay.partition", "unknown"}, unknown)
+
+	_, err = params.UnknownKeys(path.Join(dir, "not_exist.yaml"))
+	assert.Error(t, err)
+}
+
+func TestComponentParam_WriteYaml(t *testing.T) {
+	params := &ComponentParam{}
+	params.Init(NewBaseTable(SkipRemote(true), Interval(0)))
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, params.WriteYaml(buf, "replay", "log"))
+	assert.Contains(t, buf.String(), "replay:\n  rateLimit:\n    rows: 0 # Max rows written to the target per second, 0 means unlimited\n")
+
+	file := path.Join(t.TempDir(), "milvus.yaml")
+	require.NoError(t, os.WriteFile(file, buf.Bytes(), 0o600))
+	unknown, err := params.UnknownKeys(file)
+	assert.NoError(t, err)
+	assert.Empty(t, unknown)
+
+	yamlReader := viper.New()
+	yamlReader.SetConfigFile(file)
+	require.NoError(t, yamlReader.ReadInConfig())
+	for _, info := range params.Dump("replay", "log") {
+		assert.Equal(t, info.DefaultValue, yamlReader.GetString(info.Key), info.Key)
+	}
+	assert.False(t, yamlReader.IsSet(params.MQCfg.Type.Key))
+}
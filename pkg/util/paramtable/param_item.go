@@ -34,6 +34,15 @@ type ParamItem struct {
 	Formatter func(originValue string) string
 	Forbidden bool
 
+	// Type, Min, Max, Enum and Regex are the optional constraints of the value checked by Validate,
+	// which runs at paramtable.Init only, a value refreshed later is not validated.
+	Type ParamType
+	// Min and Max are the inclusive bounds of a numeric Type
+	Min   string
+	Max   string
+	Enum  []string
+	Regex string
+
 	manager *config.Manager
 
 	// for unittest.
@@ -49,18 +58,24 @@ func (pi *ParamItem) Init(manager *config.Manager) {
 
 // Get original value with error
 func (pi *ParamItem) get() (string, error) {
+	ret, _, err := pi.getWithSource()
+	return ret, err
+}
+
+// getWithSource returns the value with the name of the source it comes from.
+func (pi *ParamItem) getWithSource() (string, string, error) {
 	// For unittest.
 	if s := pi.tempValue.Load(); s != nil {
-		return *s, nil
+		return *s, config.RuntimeSource, nil
 	}
 
 	if pi.manager == nil {
 		panic(fmt.Sprintf("manager is nil %s", pi.Key))
 	}
-	ret, err := pi.manager.GetConfig(pi.Key)
+	ret, source, err := pi.manager.GetConfigWithSource(pi.Key)
 	if err != nil {
 		for _, key := range pi.FallbackKeys {
-			ret, err = pi.manager.GetConfig(key)
+			ret, source, err = pi.manager.GetConfigWithSource(key)
 			if err == nil {
 				break
 			}
@@ -68,6 +83,7 @@ func (pi *ParamItem) get() (string, error) {
 	}
 	if err != nil {
 		ret = pi.DefaultValue
+		source = DefaultSource
 	}
 	if pi.Formatter != nil {
 		ret = pi.Formatter(ret)
@@ -75,7 +91,7 @@ func (pi *ParamItem) get() (string, error) {
 	if ret == "" && pi.PanicIfEmpty {
 		panic(fmt.Sprintf("%s is empty", pi.Key))
 	}
-	return ret, err
+	return ret, source, err
 }
 
 // SetTempValue set the value for this ParamItem,
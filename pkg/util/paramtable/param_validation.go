@@ -0,0 +1,231 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paramtable
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/errors"
+	"github.com/samber/lo"
+
+	"github.com/xige-16/stream-read/pkg/config"
+)
+
+// ParamType is the type the value of a ParamItem is validated as.
+type ParamType string
+
+const (
+	// ParamTypeAny skips the type check
+	ParamTypeAny   ParamType = ""
+	ParamTypeInt   ParamType = "int"
+	ParamTypeUint  ParamType = "uint"
+	ParamTypeFloat ParamType = "float"
+	ParamTypeBool  ParamType = "bool"
+	// ParamTypeDuration is a number of the unit the item is read in, as GetAsDuration accepts
+	ParamTypeDuration ParamType = "duration"
+)
+
+var ErrInvalidParam = errors.New("invalid param")
+
+// InvalidParam is a ParamItem whose value violates its constraints.
+type InvalidParam struct {
+	Key string
+	// Value is masked if it comes from a secret source
+	Value  string
+	Source string
+	Err    error
+}
+
+func (p *InvalidParam) Error() string {
+	return fmt.Sprintf("%s=%q from %s: %s", p.Key, p.Value, p.Source, p.Err.Error())
+}
+
+// ValidationError reports all the invalid params found by a Validate pass.
+type ValidationError struct {
+	Params []*InvalidParam
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%d invalid params: %s", len(e.Params),
+		strings.Join(lo.Map(e.Params, func(p *InvalidParam, _ int) string { return p.Error() }), "; "))
+}
+
+func (p *InvalidParam) Is(target error) bool {
+	return target == ErrInvalidParam
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidParam
+}
+
+// Validate checks the effective value of pi against its constraints, returns an *InvalidParam
+// carrying the source of the value if it is invalid.
+func (pi *ParamItem) Validate() error {
+	if !pi.hasConstraints() || pi.manager == nil {
+		return nil
+	}
+	value, source, _ := pi.getWithSource()
+	err := pi.validateValue(value)
+	if err == nil {
+		return nil
+	}
+	if pi.manager.IsSecret(pi.Key) {
+		value = config.MaskedValue
+	}
+	return &InvalidParam{Key: pi.Key, Value: value, Source: source, Err: err}
+}
+
+func (pi *ParamItem) hasConstraints() bool {
+	return pi.Type != ParamTypeAny || len(pi.Min) > 0 || len(pi.Max) > 0 || len(pi.Enum) > 0 || len(pi.Regex) > 0
+}
+
+func (pi *ParamItem) validateValue(value string) error {
+	// an empty value is the same as an empty default
+	if len(value) == 0 && len(pi.DefaultValue) == 0 {
+		return nil
+	}
+
+	var number float64
+	var err error
+	switch pi.Type {
+	case ParamTypeAny:
+	case ParamTypeInt:
+		var v int64
+		v, err = strconv.ParseInt(value, 10, 64)
+		number = float64(v)
+	case ParamTypeUint:
+		var v uint64
+		v, err = strconv.ParseUint(value, 10, 64)
+		number = float64(v)
+	case ParamTypeFloat, ParamTypeDuration:
+		number, err = strconv.ParseFloat(value, 64)
+	case ParamTypeBool:
+		_, err = strconv.ParseBool(value)
+	default:
+		return errors.Newf("unknown type %s", pi.Type)
+	}
+	if err != nil {
+		return errors.Newf("not a valid %s", pi.Type)
+	}
+
+	if len(pi.Min) > 0 || len(pi.Max) > 0 {
+		if pi.Type == ParamTypeAny || pi.Type == ParamTypeBool {
+			return errors.Newf("min and max are not supported by type %q", pi.Type)
+		}
+		if err := checkBound(number, pi.Min, func(number, bound float64) bool { return number >= bound }, "less than min"); err != nil {
+			return err
+		}
+		if err := checkBound(number, pi.Max, func(number, bound float64) bool { return number <= bound }, "greater than max"); err != nil {
+			return err
+		}
+	}
+
+	if len(pi.Enum) > 0 && !lo.Contains(pi.Enum, value) {
+		return errors.Newf("not one of %v", pi.Enum)
+	}
+
+	if len(pi.Regex) > 0 {
+		matched, err := regexp.MatchString(pi.Regex, value)
+		if err != nil {
+			return errors.Wrapf(err, "invalid regex %s", pi.Regex)
+		}
+		if !matched {
+			return errors.Newf("not matching %s", pi.Regex)
+		}
+	}
+	return nil
+}
+
+func checkBound(number float64, bound string, check func(number, bound float64) bool, msg string) error {
+	if len(bound) == 0 {
+		return nil
+	}
+	b, err := strconv.ParseFloat(bound, 64)
+	if err != nil {
+		return errors.Newf("invalid bound %s", bound)
+	}
+	if !check(number, b) {
+		return errors.Newf("%s %s", msg, bound)
+	}
+	return nil
+}
+
+// Validate checks all the initialized ParamItems with constraints, returns a ValidationError
+// reporting all the invalid ones if any.
+func (p *ComponentParam) Validate() error {
+	return validateParams(p)
+}
+
+// Validate checks all the initialized ParamItems of the services with constraints, returns a
+// ValidationError reporting all the invalid ones if any.
+func (p *ServiceParam) Validate() error {
+	return validateParams(p)
+}
+
+func validateParams(params any) error {
+	fields, _ := walkParams(params)
+	invalid := make([]*InvalidParam, 0)
+	for _, field := range fields {
+		var p *InvalidParam
+		if errors.As(field.item.Validate(), &p) {
+			invalid = append(invalid, p)
+		}
+	}
+	if len(invalid) > 0 {
+		return &ValidationError{Params: invalid}
+	}
+	return nil
+}
+
+// ValidateFile checks the values of yamlFile against the constraints of the ParamItems, returns a
+// ValidationError reporting all the invalid values and the unknown keys of the file if any.
+func (p *ComponentParam) ValidateFile(yamlFile string) error {
+	unknown, err := p.UnknownKeys(yamlFile)
+	if err != nil {
+		return err
+	}
+	configs, err := config.NewFileSource(&config.FileInfo{Files: []string{yamlFile}}).GetConfigurations()
+	if err != nil {
+		return err
+	}
+
+	invalid := make([]*InvalidParam, 0)
+	fields, _ := p.walk()
+	for _, field := range fields {
+		item := field.item
+		for _, key := range append([]string{item.Key}, item.FallbackKeys...) {
+			value, ok := configs[config.FormatKey(key)]
+			if !ok {
+				continue
+			}
+			if err := item.validateValue(value); err != nil {
+				invalid = append(invalid, &InvalidParam{Key: key, Value: value, Source: yamlFile, Err: err})
+			}
+			break
+		}
+	}
+	for _, key := range unknown {
+		invalid = append(invalid, &InvalidParam{Key: key, Value: configs[key], Source: yamlFile, Err: errors.New("unknown key")})
+	}
+	if len(invalid) > 0 {
+		return &ValidationError{Params: invalid}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paramtable
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/cockroachdb/errors"
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/xige-16/stream-read/pkg/config"
+)
+
+func TestParamItem_ValidateValue(t *testing.T) {
+	cases := []struct {
+		name  string
+		item  *ParamItem
+		value string
+		valid bool
+	}{
+		{"any", &ParamItem{}, "x", true},
+		{"int", &ParamItem{Type: ParamTypeInt}, "-1", true},
+		{"not int", &ParamItem{Type: ParamTypeInt}, "1.5", false},
+		{"uint", &ParamItem{Type: ParamTypeUint}, "1", true},
+		{"not uint", &ParamItem{Type: ParamTypeUint}, "-1", false},
+		{"float", &ParamItem{Type: ParamTypeFloat}, "1.5", true},
+		{"not float", &ParamItem{Type: ParamTypeFloat}, "abc", false},
+		{"bool", &ParamItem{Type: ParamTypeBool}, "true", true},
+		{"not bool", &ParamItem{Type: ParamTypeBool}, "yes", false},
+		{"duration", &ParamItem{Type: ParamTypeDuration}, "0.5", true},
+		{"not duration", &ParamItem{Type: ParamTypeDuration}, "10s", false},
+		{"unknown type", &ParamItem{Type: "map"}, "1", false},
+		{"min", &ParamItem{Type: ParamTypeInt, Min: "1"}, "1", true},
+		{"less than min", &ParamItem{Type: ParamTypeInt, Min: "1"}, "0", false},
+		{"max", &ParamItem{Type: ParamTypeFloat, Max: "1.5"}, "1.5", true},
+		{"greater than max", &ParamItem{Type: ParamTypeFloat, Max: "1.5"}, "1.6", false},
+		{"invalid bound", &ParamItem{Type: ParamTypeInt, Min: "x"}, "1", false},
+		{"bound of bool", &ParamItem{Type: ParamTypeBool, Min: "0"}, "true", false},
+		{"enum", &ParamItem{Enum: []string{"a", "b"}}, "b", true},
+		{"not in enum", &ParamItem{Enum: []string{"a", "b"}}, "c", false},
+		{"regex", &ParamItem{Regex: `^\d+$`}, "12", true},
+		{"not matching", &ParamItem{Regex: `^\d+$`}, "1a", false},
+		{"invalid regex", &ParamItem{Regex: `(`}, "1", false},
+		{"empty with empty default", &ParamItem{Type: ParamTypeInt}, "", true},
+		{"empty", &ParamItem{Type: ParamTypeInt, DefaultValue: "1"}, "", false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.item.validateValue(c.value)
+			if c.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestComponentParam_Validate(t *testing.T) {
+	secretDir := t.TempDir()
+	os.WriteFile(path.Join(secretDir, "pulsar.port"), []byte("70000"), 0o600)
+
+	params := &ComponentParam{}
+	params.Init(NewBaseTable(SkipRemote(true), Interval(0), SecretDirs([]string{secretDir})))
+	params.Save(params.ReplayCfg.InsertBatchRows.Key, "-1")
+	defer params.Reset(params.ReplayCfg.InsertBatchRows.Key)
+	params.Save(params.MQCfg.BadMsgPolicy.Key, "bogus")
+	defer params.Reset(params.MQCfg.BadMsgPolicy.Key)
+
+	err := params.Validate()
+	assert.ErrorIs(t, err, ErrInvalidParam)
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	invalid := lo.KeyBy(validationErr.Params, func(p *InvalidParam) string { return p.Key })
+	assert.Len(t, invalid, 3)
+
+	p := invalid[params.ReplayCfg.InsertBatchRows.Key]
+	assert.Equal(t, "-1", p.Value)
+	assert.Equal(t, config.RuntimeSource, p.Source)
+	assert.Contains(t, p.Error(), "less than min 0")
+
+	p = invalid[params.PulsarCfg.Port.Key]
+	assert.Equal(t, config.MaskedValue, p.Value)
+	assert.Equal(t, "DirSource:"+secretDir, p.Source)
+	assert.ErrorIs(t, params.PulsarCfg.Port.Validate(), ErrInvalidParam)
+
+	assert.Contains(t, invalid, params.MQCfg.BadMsgPolicy.Key)
+	assert.Error(t, params.ServiceParam.Validate())
+
+	params.Reset(params.MQCfg.BadMsgPolicy.Key)
+	params.Reset(params.ReplayCfg.InsertBatchRows.Key)
+	assert.NoError(t, params.ReplayCfg.InsertBatchRows.Validate())
+	require.True(t, errors.As(params.Validate(), &validationErr))
+	assert.Len(t, validationErr.Params, 1)
+
+	// log levels in upper case are accepted by zap
+	for level, valid := range map[string]bool{"INFO": true, "debug": true, "Info": false} {
+		params.Save(params.LogCfg.Level.Key, level)
+		if valid {
+			assert.NoError(t, params.LogCfg.Level.Validate(), level)
+		} else {
+			assert.Error(t, params.LogCfg.Level.Validate(), level)
+		}
+	}
+	params.Reset(params.LogCfg.Level.Key)
+}
+
+func TestComponentParam_ValidateFile(t *testing.T) {
+	params := &ComponentParam{}
+	params.Init(NewBaseTable(SkipRemote(true), Interval(0)))
+
+	dir := t.TempDir()
+	file := path.Join(dir, "user.yaml")
+	os.WriteFile(file, []byte("mq:\n  type: pulsr\n  foo: 1\nreplay:\n  insertBatchRows: 10\n  rateLimit:\n    rows: abc\n"), 0o600)
+	err := params.ValidateFile(file)
+	var validationErr *ValidationError
+	require.True(t, errors.As(err, &validationErr))
+	invalid := lo.KeyBy(validationErr.Params, func(p *InvalidParam) string { return p.Key })
+	assert.ElementsMatch(t, []string{"mq.type", "mq.foo", "replay.rateLimit.rows"}, lo.Keys(invalid))
+	assert.Equal(t, file, invalid["mq.type"].Source)
+	assert.Equal(t, "abc", invalid["replay.rateLimit.rows"].Value)
+
+	os.WriteFile(file, []byte("mq:\n  type: pulsar\n"), 0o600)
+	assert.NoError(t, params.ValidateFile(file))
+	assert.Error(t, params.ValidateFile(path.Join(dir, "not_exist.yaml")))
+}
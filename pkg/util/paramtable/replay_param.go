@@ -0,0 +1,83 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paramtable
+
+// replayConfig is the configuration of the replay tool, refreshed at runtime
+// without restarting a replay.
+type replayConfig struct {
+	RateLimitRows       ParamItem `refreshable:"true"`
+	RateLimitBytes      ParamItem `refreshable:"true"`
+	RateLimitMaxBackoff ParamItem `refreshable:"true"`
+	InsertBatchRows     ParamItem `refreshable:"true"`
+	Partitions          ParamItem `refreshable:"true"`
+}
+
+func (p *replayConfig) init(base *BaseTable) {
+	p.RateLimitRows = ParamItem{
+		Key:          "replay.rateLimit.rows",
+		Version:      "2.3.16",
+		DefaultValue: "0",
+		Doc:          "Max rows written to the target per second, 0 means unlimited",
+		Export:       true,
+		Type:         ParamTypeFloat,
+		Min:          "0",
+	}
+	p.RateLimitRows.Init(base.mgr)
+
+	p.RateLimitBytes = ParamItem{
+		Key:          "replay.rateLimit.bytes",
+		Version:      "2.3.16",
+		DefaultValue: "0",
+		Doc:          "Max bytes written to the target per second, 0 means unlimited",
+		Export:       true,
+		Type:         ParamTypeFloat,
+		Min:          "0",
+	}
+	p.RateLimitBytes.Init(base.mgr)
+
+	p.RateLimitMaxBackoff = ParamItem{
+		Key:          "replay.rateLimit.maxBackoff",
+		Version:      "2.3.16",
+		DefaultValue: "30",
+		Doc:          "Max backoff in seconds when the target rejects writes by rate limit",
+		Export:       true,
+		Type:         ParamTypeDuration,
+		Min:          "0",
+	}
+	p.RateLimitMaxBackoff.Init(base.mgr)
+
+	p.InsertBatchRows = ParamItem{
+		Key:          "replay.insertBatchRows",
+		Version:      "2.3.16",
+		DefaultValue: "0",
+		Doc:          "Max rows of one insert request, larger insert messages are split, 0 means one request per message",
+		Export:       true,
+		Type:         ParamTypeInt,
+		Min:          "0",
+	}
+	p.InsertBatchRows.Init(base.mgr)
+
+	p.Partitions = ParamItem{
+		Key:          "replay.partitions",
+		Version:      "2.3.16",
+		DefaultValue: "",
+		Doc:          "Comma separated partitions replayed, the DML of the other partitions is skipped, empty means all",
+		Export:       true,
+		Regex:        `^[\w\s,]*$`,
+	}
+	p.Partitions.Init(base.mgr)
+}
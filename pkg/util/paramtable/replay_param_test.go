@@ -0,0 +1,24 @@
+package paramtable
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplayConfig(t *testing.T) {
+	params := ComponentParam{}
+	params.Init(NewBaseTable(SkipRemote(true)))
+	cfg := &params.ReplayCfg
+	assert.Equal(t, 0.0, cfg.RateLimitRows.GetAsFloat())
+	assert.Equal(t, 0.0, cfg.RateLimitBytes.GetAsFloat())
+	assert.Equal(t, 30*time.Second, cfg.RateLimitMaxBackoff.GetAsDuration(time.Second))
+	assert.Equal(t, 0, cfg.InsertBatchRows.GetAsInt())
+	assert.Equal(t, "", cfg.Partitions.GetValue())
+
+	params.Save(cfg.RateLimitRows.Key, "1000")
+	params.Save(cfg.Partitions.Key, "p1,p2")
+	assert.Equal(t, 1000.0, cfg.RateLimitRows.GetAsFloat())
+	assert.Equal(t, []string{"p1", "p2"}, cfg.Partitions.GetAsStrings())
+}
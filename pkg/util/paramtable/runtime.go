@@ -34,6 +34,7 @@ func Init() {
 	once.Do(func() {
 		baseTable := NewBaseTable()
 		params.Init(baseTable)
+		mustValidate()
 		hookBaseTable := NewBaseTableFromYamlOnly(hookYamlFile)
 		hookParams.init(hookBaseTable)
 	})
@@ -42,11 +43,20 @@ func Init() {
 func InitWithBaseTable(baseTable *BaseTable) {
 	once.Do(func() {
 		params.Init(baseTable)
+		mustValidate()
 		hookBaseTable := NewBaseTableFromYamlOnly(hookYamlFile)
 		hookParams.init(hookBaseTable)
 	})
 }
 
+// mustValidate panics with all the invalid params, rather than running with silent defaults.
+// The values refreshed after Init are not validated.
+func mustValidate() {
+	if err := params.Validate(); err != nil {
+		panic(err)
+	}
+}
+
 func Get() *ComponentParam {
 	return &params
 }
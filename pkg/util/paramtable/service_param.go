@@ -435,6 +435,15 @@ type MQConfig struct {
 	MQBufSize         ParamItem `refreshable:"false"`
 	ReceiveBufSize    ParamItem `refreshable:"false"`
 	IgnoreBadPosition ParamItem `refreshable:"true"`
+
+	EnableProduceBatching          ParamItem `refreshable:"false"`
+	ProduceBatchingMaxMessages     ParamItem `refreshable:"false"`
+	ProduceBatchingMaxPublishDelay ParamItem `refreshable:"false"`
+	PayloadCodec                   ParamItem `refreshable:"false"`
+
+	TickStallTimeout ParamItem `refreshable:"true"`
+	TickStallPolicy  ParamItem `refreshable:"true"`
+	BadMsgPolicy     ParamItem `refreshable:"true"`
 }
 
 // Init initializes the MQConfig object with a BaseTable.
@@ -446,6 +455,7 @@ func (p *MQConfig) Init(base *BaseTable) {
 		Doc: `Default value: "default"
 Valid values: [default, pulsar, kafka, rocksmq, natsmq]`,
 		Export: true,
+		Enum:   []string{"default", "pulsar", "kafka", "rocksmq", "natsmq"},
 	}
 	p.Type.Init(base.mgr)
 
@@ -455,6 +465,7 @@ Valid values: [default, pulsar, kafka, rocksmq, natsmq]`,
 		DefaultValue: "true",
 		Doc:          `Default value: "true"`,
 		Export:       true,
+		Type:         ParamTypeBool,
 	}
 	p.EnablePursuitMode.Init(base.mgr)
 
@@ -464,6 +475,8 @@ Valid values: [default, pulsar, kafka, rocksmq, natsmq]`,
 		DefaultValue: "10",
 		Doc:          `time tick lag threshold to enter pursuit mode, in seconds`,
 		Export:       true,
+		Type:         ParamTypeDuration,
+		Min:          "0",
 	}
 	p.PursuitLag.Init(base.mgr)
 
@@ -473,6 +486,8 @@ Valid values: [default, pulsar, kafka, rocksmq, natsmq]`,
 		DefaultValue: "8", // 8 MB
 		Doc:          `pursuit mode buffer size in bytes`,
 		Export:       true,
+		Type:         ParamTypeInt,
+		Min:          "0",
 	}
 	p.PursuitBufferSize.Init(base.mgr)
 
@@ -482,6 +497,8 @@ Valid values: [default, pulsar, kafka, rocksmq, natsmq]`,
 		DefaultValue: "16",
 		Doc:          `MQ client consumer buffer length`,
 		Export:       true,
+		Type:         ParamTypeInt,
+		Min:          "0",
 	}
 	p.MQBufSize.Init(base.mgr)
 
@@ -490,6 +507,8 @@ Valid values: [default, pulsar, kafka, rocksmq, natsmq]`,
 		Version:      "2.3.0",
 		DefaultValue: "16",
 		Doc:          "MQ consumer chan buffer length",
+		Type:         ParamTypeInt,
+		Min:          "0",
 	}
 	p.ReceiveBufSize.Init(base.mgr)
 
@@ -498,8 +517,83 @@ Valid values: [default, pulsar, kafka, rocksmq, natsmq]`,
 		Version:      "2.3.16",
 		DefaultValue: "false",
 		Doc:          "A switch for ignoring message queue failing to parse message ID from checkpoint position. Usually caused by switching among different mq implementations. May caused data loss when used by mistake",
+		Type:         ParamTypeBool,
 	}
 	p.IgnoreBadPosition.Init(base.mgr)
+
+	p.EnableProduceBatching = ParamItem{
+		Key:          "mq.enableProduceBatching",
+		Version:      "2.3.16",
+		DefaultValue: "false",
+		Doc:          "Whether the msgstream producers batch the messages produced asynchronously",
+		Type:         ParamTypeBool,
+	}
+	p.EnableProduceBatching.Init(base.mgr)
+
+	p.ProduceBatchingMaxMessages = ParamItem{
+		Key:          "mq.produceBatchingMaxMessages",
+		Version:      "2.3.16",
+		DefaultValue: "1000",
+		Doc:          "The max number of messages in one produce batch",
+		Type:         ParamTypeInt,
+		Min:          "1",
+	}
+	p.ProduceBatchingMaxMessages.Init(base.mgr)
+
+	p.ProduceBatchingMaxPublishDelay = ParamItem{
+		Key:          "mq.produceBatchingMaxPublishDelay",
+		Version:      "2.3.16",
+		DefaultValue: "10",
+		Doc:          "The max delay in milliseconds of publishing a produce batch",
+		Type:         ParamTypeDuration,
+		Min:          "0",
+	}
+	p.ProduceBatchingMaxPublishDelay.Init(base.mgr)
+
+	p.PayloadCodec = ParamItem{
+		Key:          "mq.payloadCodec",
+		Version:      "2.3.16",
+		DefaultValue: "none",
+		Doc: `The codec of the payloads produced by msgstream, the consumers decode them by the codec property of messages.
+Milvus cannot decode them, so it is for the channels only read by this tool, reshard always produces raw payloads.
+Valid values: [none, zstd, snappy, lz4]`,
+		Enum: []string{"none", "zstd", "snappy", "lz4"},
+	}
+	p.PayloadCodec.Init(base.mgr)
+
+	p.TickStallTimeout = ParamItem{
+		Key:          "mq.tickStallTimeout",
+		Version:      "2.3.16",
+		DefaultValue: "60",
+		Doc: `A channel of a time tick msgstream is stalled if no time tick is received from it
+in the timeout while the stream waits for it, in seconds, 0 disables the detection`,
+		Type: ParamTypeDuration,
+		Min:  "0",
+	}
+	p.TickStallTimeout.Init(base.mgr)
+
+	p.TickStallPolicy = ParamItem{
+		Key:          "mq.tickStallPolicy",
+		Version:      "2.3.16",
+		DefaultValue: "wait",
+		Doc: `How a time tick msgstream handles a stalled channel, wait: keep waiting for it,
+fail: stop the stream with an error, drop: stop consuming the channel and go on with the others.
+Valid values: [wait, fail, drop]`,
+		Enum: []string{"wait", "fail", "drop"},
+	}
+	p.TickStallPolicy.Init(base.mgr)
+
+	p.BadMsgPolicy = ParamItem{
+		Key:          "mq.badMsgPolicy",
+		Version:      "2.3.16",
+		DefaultValue: "fail",
+		Doc: `How a msgstream handles an undecodable message, fail: stop the stream with an error
+carrying the message id, skip: log and skip it, quarantine: pass it to the quarantine callback
+of the stream and go on, the streams without a callback fail instead.
+Valid values: [fail, skip, quarantine]`,
+		Enum: []string{"fail", "skip", "quarantine"},
+	}
+	p.BadMsgPolicy.Init(base.mgr)
 }
 
 // /////////////////////////////////////////////////////////////////////////////
@@ -533,6 +627,9 @@ func (p *PulsarConfig) Init(base *BaseTable) {
 		DefaultValue: "6650",
 		Doc:          "Port of Pulsar",
 		Export:       true,
+		Type:         ParamTypeInt,
+		Min:          "1",
+		Max:          "65535",
 	}
 	p.Port.Init(base.mgr)
 
@@ -562,6 +659,9 @@ func (p *PulsarConfig) Init(base *BaseTable) {
 		DefaultValue: "80",
 		Doc:          "Web port of pulsar, if you connect direcly without proxy, should use 8080",
 		Export:       true,
+		Type:         ParamTypeInt,
+		Min:          "1",
+		Max:          "65535",
 	}
 	p.WebPort.Init(base.mgr)
 
@@ -586,6 +686,8 @@ func (p *PulsarConfig) Init(base *BaseTable) {
 		DefaultValue: strconv.Itoa(SuggestPulsarMaxMessageSize),
 		Doc:          "5 * 1024 * 1024 Bytes, Maximum size of each message in pulsar.",
 		Export:       true,
+		Type:         ParamTypeInt,
+		Min:          "1",
 	}
 	p.MaxMessageSize.Init(base.mgr)
 
@@ -635,6 +737,8 @@ func (p *PulsarConfig) Init(base *BaseTable) {
 		Version:      "2.3.0",
 		DefaultValue: "60",
 		Export:       true,
+		Type:         ParamTypeDuration,
+		Min:          "0",
 	}
 	p.RequestTimeout.Init(base.mgr)
 
@@ -643,6 +747,7 @@ func (p *PulsarConfig) Init(base *BaseTable) {
 		Version:      "2.3.0",
 		DefaultValue: "false",
 		Export:       true,
+		Type:         ParamTypeBool,
 	}
 	p.EnableClientMetrics.Init(base.mgr)
 }
@@ -0,0 +1,178 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimitutil
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit defines the maximum frequency of some events.
+// Limit is represented as number of events per second.
+// A zero Limit allows no events.
+type Limit float64
+
+// Inf is the infinite rate limit; it allows all events.
+const Inf = Limit(math.MaxFloat64)
+
+// Limiter implements a token bucket rate limiter.
+// Unlike golang.org/x/time/rate, a single request may consume more tokens than
+// the burst size; the bucket then goes into debt and following requests have to
+// wait until the debt is paid off. This fits byte based limits, where one
+// message may be larger than the configured burst.
+type Limiter struct {
+	mu     sync.Mutex
+	limit  Limit
+	burst  float64
+	tokens float64
+	// last is the last time the limiter's tokens field was updated
+	last time.Time
+}
+
+// NewLimiter returns a new Limiter that allows events up to rate r and permits
+// bursts of at most b tokens.
+func NewLimiter(r Limit, b float64) *Limiter {
+	return &Limiter{
+		limit:  r,
+		burst:  b,
+		tokens: b,
+	}
+}
+
+// Limit returns the maximum overall event rate.
+func (lim *Limiter) Limit() Limit {
+	lim.mu.Lock()
+	defer lim.mu.Unlock()
+	return lim.limit
+}
+
+// Burst returns the maximum burst size.
+func (lim *Limiter) Burst() float64 {
+	lim.mu.Lock()
+	defer lim.mu.Unlock()
+	return lim.burst
+}
+
+// SetLimit sets a new Limit for the limiter. Tokens accumulated so far are
+// kept, so changing the limit does not cause an extra burst.
+func (lim *Limiter) SetLimit(newLimit Limit) {
+	lim.mu.Lock()
+	defer lim.mu.Unlock()
+	now := time.Now()
+	lim.tokens = lim.advance(now)
+	lim.last = now
+	lim.limit = newLimit
+}
+
+// SetBurst sets a new burst size for the limiter.
+func (lim *Limiter) SetBurst(newBurst float64) {
+	lim.mu.Lock()
+	defer lim.mu.Unlock()
+	now := time.Now()
+	lim.tokens = lim.advance(now)
+	lim.last = now
+	lim.burst = newBurst
+	if lim.tokens > newBurst {
+		lim.tokens = newBurst
+	}
+}
+
+// AllowN reports whether n events may happen at time now.
+// The tokens are consumed only if the events are allowed.
+func (lim *Limiter) AllowN(now time.Time, n int) bool {
+	lim.mu.Lock()
+	defer lim.mu.Unlock()
+	if lim.limit == Inf {
+		return true
+	}
+	tokens := lim.advance(now) - float64(n)
+	if tokens < 0 {
+		return false
+	}
+	lim.tokens = tokens
+	lim.last = now
+	return true
+}
+
+// ReserveN consumes n tokens at time now and returns how long the caller has
+// to wait before the events may happen.
+func (lim *Limiter) ReserveN(now time.Time, n int) time.Duration {
+	lim.mu.Lock()
+	defer lim.mu.Unlock()
+	if lim.limit == Inf {
+		return 0
+	}
+	tokens := lim.advance(now) - float64(n)
+	lim.tokens = tokens
+	lim.last = now
+	if tokens >= 0 {
+		return 0
+	}
+	if lim.limit <= 0 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(-tokens / float64(lim.limit) * float64(time.Second))
+}
+
+// WaitN blocks until n events may happen or ctx is done.
+// If ctx is done before the wait finishes, the reserved tokens are returned.
+func (lim *Limiter) WaitN(ctx context.Context, n int) error {
+	wait := lim.ReserveN(time.Now(), n)
+	if wait == 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		lim.Cancel(n)
+		return ctx.Err()
+	}
+}
+
+// Cancel returns n tokens back to the bucket.
+func (lim *Limiter) Cancel(n int) {
+	lim.mu.Lock()
+	defer lim.mu.Unlock()
+	lim.tokens += float64(n)
+	if lim.tokens > lim.burst {
+		lim.tokens = lim.burst
+	}
+}
+
+// advance calculates the number of tokens available at time now.
+// advance requires that lim.mu is held.
+func (lim *Limiter) advance(now time.Time) float64 {
+	last := lim.last
+	if now.Before(last) {
+		last = now
+	}
+	if last.IsZero() {
+		return lim.tokens
+	}
+	elapsed := now.Sub(last)
+	delta := float64(lim.limit) * elapsed.Seconds()
+	tokens := lim.tokens + delta
+	if tokens > lim.burst {
+		tokens = lim.burst
+	}
+	return tokens
+}
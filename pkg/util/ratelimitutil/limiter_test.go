@@ -0,0 +1,92 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ratelimitutil
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter(t *testing.T) {
+	t.Run("test inf", func(t *testing.T) {
+		lim := NewLimiter(Inf, 0)
+		assert.True(t, lim.AllowN(time.Now(), 1e9))
+		assert.Equal(t, time.Duration(0), lim.ReserveN(time.Now(), 1e9))
+		assert.NoError(t, lim.WaitN(context.Background(), 1e9))
+	})
+
+	t.Run("test allow", func(t *testing.T) {
+		lim := NewLimiter(10, 10)
+		now := time.Now()
+		assert.True(t, lim.AllowN(now, 10))
+		assert.False(t, lim.AllowN(now, 1))
+		// one token per 100ms
+		assert.True(t, lim.AllowN(now.Add(100*time.Millisecond), 1))
+		assert.False(t, lim.AllowN(now.Add(100*time.Millisecond), 1))
+		// never exceed burst
+		assert.False(t, lim.AllowN(now.Add(time.Hour), 11))
+		assert.True(t, lim.AllowN(now.Add(time.Hour), 10))
+	})
+
+	t.Run("test reserve over burst", func(t *testing.T) {
+		lim := NewLimiter(100, 100)
+		now := time.Now()
+		assert.Equal(t, time.Duration(0), lim.ReserveN(now, 100))
+		// goes into debt for 200 tokens, 2 seconds at 100 tokens per second
+		assert.Equal(t, 2*time.Second, lim.ReserveN(now, 200))
+		// the debt is paid off after 2 seconds
+		assert.Equal(t, time.Duration(0), lim.ReserveN(now.Add(2*time.Second), 0))
+	})
+
+	t.Run("test zero limit", func(t *testing.T) {
+		lim := NewLimiter(0, 0)
+		assert.False(t, lim.AllowN(time.Now(), 1))
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.Error(t, lim.WaitN(ctx, 1))
+	})
+
+	t.Run("test set limit", func(t *testing.T) {
+		lim := NewLimiter(10, 10)
+		lim.SetLimit(20)
+		assert.Equal(t, Limit(20), lim.Limit())
+		lim.SetBurst(5)
+		assert.Equal(t, float64(5), lim.Burst())
+		assert.False(t, lim.AllowN(time.Now(), 6))
+	})
+
+	t.Run("test cancel", func(t *testing.T) {
+		lim := NewLimiter(1, 5)
+		now := time.Now()
+		assert.True(t, lim.AllowN(now, 5))
+		lim.Cancel(3)
+		assert.True(t, lim.AllowN(now, 3))
+		assert.False(t, lim.AllowN(now, 1))
+	})
+
+	t.Run("test wait", func(t *testing.T) {
+		lim := NewLimiter(1000, 1)
+		start := time.Now()
+		for i := 0; i < 21; i++ {
+			assert.NoError(t, lim.WaitN(context.Background(), 1))
+		}
+		assert.GreaterOrEqual(t, time.Since(start), 15*time.Millisecond)
+	})
+}
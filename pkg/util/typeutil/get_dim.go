@@ -9,10 +9,14 @@ import (
 )
 
 // GetDim get dimension of field. Maybe also helpful outside.
+// A sparse float vector field has no dimension, its rows vary in dimension.
 func GetDim(field *schemapb.FieldSchema) (int64, error) {
 	if !IsVectorType(field.GetDataType()) {
 		return 0, fmt.Errorf("%s is not of vector type", field.GetDataType())
 	}
+	if IsSparseFloatVectorType(field.GetDataType()) {
+		return 0, fmt.Errorf("%s has no fixed dimension", field.GetDataType())
+	}
 	h := NewKvPairs(append(field.GetIndexParams(), field.GetTypeParams()...))
 	dimStr, err := h.Get(common.DimKey)
 	if err != nil {
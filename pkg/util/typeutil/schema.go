@@ -33,6 +33,9 @@ import (
 
 const DynamicFieldMaxLength = 512
 
+// sparseFloatEstimatedRowSize is the estimated size of a sparse float row of 600 non-zero elements.
+const sparseFloatEstimatedRowSize = 600 * sparseFloatElementSize
+
 func GetAvgLengthOfVarLengthField(fieldSchema *schemapb.FieldSchema) (int, error) {
 	maxLength := 0
 	var err error
@@ -107,6 +110,21 @@ func EstimateSizePerRecord(schema *schemapb.CollectionSchema) (int, error) {
 					break
 				}
 			}
+		case schemapb.DataType_Float16Vector, schemapb.DataType_BFloat16Vector:
+			for _, kv := range fs.TypeParams {
+				if kv.Key == common.DimKey {
+					v, err := strconv.Atoi(kv.Value)
+					if err != nil {
+						return -1, err
+					}
+					res += v * 2
+					break
+				}
+			}
+		case schemapb.DataType_SparseFloatVector:
+			// the size of a sparse row depends on its non-zero elements, which are
+			// hundreds for the usual learned sparse embeddings
+			res += sparseFloatEstimatedRowSize
 		}
 	}
 	return res, nil
@@ -144,6 +162,18 @@ func CalcColumnSize(column *schemapb.FieldData) int {
 		for _, str := range column.GetScalars().GetJsonData().GetData() {
 			res += len(str)
 		}
+	case schemapb.DataType_FloatVector:
+		res += len(column.GetVectors().GetFloatVector().GetData()) * 4
+	case schemapb.DataType_BinaryVector:
+		res += len(column.GetVectors().GetBinaryVector())
+	case schemapb.DataType_Float16Vector:
+		res += len(column.GetVectors().GetFloat16Vector())
+	case schemapb.DataType_BFloat16Vector:
+		res += len(column.GetVectors().GetBfloat16Vector())
+	case schemapb.DataType_SparseFloatVector:
+		for _, row := range column.GetVectors().GetSparseFloatVector().GetContents() {
+			res += len(row)
+		}
 	}
 	return res
 }
@@ -183,6 +213,13 @@ func EstimateEntitySize(fieldsData []*schemapb.FieldData, rowOffset int) (int, e
 			res += int(fs.GetVectors().GetDim())
 		case schemapb.DataType_FloatVector:
 			res += int(fs.GetVectors().GetDim() * 4)
+		case schemapb.DataType_Float16Vector, schemapb.DataType_BFloat16Vector:
+			res += int(fs.GetVectors().GetDim() * 2)
+		case schemapb.DataType_SparseFloatVector:
+			if rowOffset >= len(fs.GetVectors().GetSparseFloatVector().GetContents()) {
+				return 0, fmt.Errorf("offset out range of field datas")
+			}
+			res += len(fs.GetVectors().GetSparseFloatVector().GetContents()[rowOffset])
 		}
 	}
 	return res, nil
@@ -307,13 +344,40 @@ func (helper *SchemaHelper) GetVectorDimFromID(fieldID int64) (int, error) {
 // IsVectorType returns true if input is a vector type, otherwise false
 func IsVectorType(dataType schemapb.DataType) bool {
 	switch dataType {
-	case schemapb.DataType_FloatVector, schemapb.DataType_BinaryVector:
+	case schemapb.DataType_FloatVector, schemapb.DataType_BinaryVector,
+		schemapb.DataType_Float16Vector, schemapb.DataType_BFloat16Vector,
+		schemapb.DataType_SparseFloatVector:
 		return true
 	default:
 		return false
 	}
 }
 
+// IsDenseFloatVectorType returns true if input is a dense vector of floating elements, otherwise false
+func IsDenseFloatVectorType(dataType schemapb.DataType) bool {
+	switch dataType {
+	case schemapb.DataType_FloatVector, schemapb.DataType_Float16Vector, schemapb.DataType_BFloat16Vector:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsSparseFloatVectorType returns true if input is a sparse float vector type, otherwise false
+func IsSparseFloatVectorType(dataType schemapb.DataType) bool {
+	return dataType == schemapb.DataType_SparseFloatVector
+}
+
+// IsFloatVectorType returns true if input is a dense or sparse float vector type, otherwise false
+func IsFloatVectorType(dataType schemapb.DataType) bool {
+	return IsDenseFloatVectorType(dataType) || IsSparseFloatVectorType(dataType)
+}
+
+// IsBinaryVectorType returns true if input is a binary vector type, otherwise false
+func IsBinaryVectorType(dataType schemapb.DataType) bool {
+	return dataType == schemapb.DataType_BinaryVector
+}
+
 // IsIntegerType returns true if input is an integer type, otherwise false
 func IsIntegerType(dataType schemapb.DataType) bool {
 	switch dataType {
@@ -460,6 +524,21 @@ func PrepareResultFieldData(sample []*schemapb.FieldData, topK int64) []*schemap
 				vectors.Vectors.Data = &schemapb.VectorField_BinaryVector{
 					BinaryVector: make([]byte, 0, topK*dim/8),
 				}
+			case *schemapb.VectorField_Float16Vector:
+				vectors.Vectors.Data = &schemapb.VectorField_Float16Vector{
+					Float16Vector: make([]byte, 0, topK*dim*2),
+				}
+			case *schemapb.VectorField_Bfloat16Vector:
+				vectors.Vectors.Data = &schemapb.VectorField_Bfloat16Vector{
+					Bfloat16Vector: make([]byte, 0, topK*dim*2),
+				}
+			case *schemapb.VectorField_SparseFloatVector:
+				vectors.Vectors.Data = &schemapb.VectorField_SparseFloatVector{
+					SparseFloatVector: &schemapb.SparseFloatArray{
+						Contents: make([][]byte, 0, topK),
+						Dim:      dim,
+					},
+				}
 			}
 			fd.Field = vectors
 		}
@@ -629,6 +708,46 @@ func AppendFieldData(dst []*schemapb.FieldData, src []*schemapb.FieldData, idx i
 				}
 				/* #nosec G103 */
 				appendSize += int64(unsafe.Sizeof(srcVector.FloatVector.Data[idx*dim : (idx+1)*dim]))
+			case *schemapb.VectorField_Float16Vector:
+				srcToCopy := srcVector.Float16Vector[idx*(dim*2) : (idx+1)*(dim*2)]
+				if dstVector.GetFloat16Vector() == nil {
+					dstVector.Data = &schemapb.VectorField_Float16Vector{
+						Float16Vector: make([]byte, len(srcToCopy)),
+					}
+					copy(dstVector.Data.(*schemapb.VectorField_Float16Vector).Float16Vector, srcToCopy)
+				} else {
+					dstFloat16Vector := dstVector.Data.(*schemapb.VectorField_Float16Vector)
+					dstFloat16Vector.Float16Vector = append(dstFloat16Vector.Float16Vector, srcToCopy...)
+				}
+				/* #nosec G103 */
+				appendSize += int64(unsafe.Sizeof(srcToCopy))
+			case *schemapb.VectorField_Bfloat16Vector:
+				srcToCopy := srcVector.Bfloat16Vector[idx*(dim*2) : (idx+1)*(dim*2)]
+				if dstVector.GetBfloat16Vector() == nil {
+					dstVector.Data = &schemapb.VectorField_Bfloat16Vector{
+						Bfloat16Vector: make([]byte, len(srcToCopy)),
+					}
+					copy(dstVector.Data.(*schemapb.VectorField_Bfloat16Vector).Bfloat16Vector, srcToCopy)
+				} else {
+					dstBfloat16Vector := dstVector.Data.(*schemapb.VectorField_Bfloat16Vector)
+					dstBfloat16Vector.Bfloat16Vector = append(dstBfloat16Vector.Bfloat16Vector, srcToCopy...)
+				}
+				/* #nosec G103 */
+				appendSize += int64(unsafe.Sizeof(srcToCopy))
+			case *schemapb.VectorField_SparseFloatVector:
+				row := srcVector.SparseFloatVector.Contents[idx]
+				if dstVector.GetSparseFloatVector() == nil {
+					dstVector.Data = &schemapb.VectorField_SparseFloatVector{
+						SparseFloatVector: &schemapb.SparseFloatArray{},
+					}
+				}
+				dstSparseVector := dstVector.GetSparseFloatVector()
+				dstSparseVector.Contents = append(dstSparseVector.Contents, row)
+				// the dim of a sparse vector field is the max dim of its rows
+				dstSparseVector.Dim = max(dstSparseVector.Dim, SparseFloatRowDim(row))
+				dstVector.Dim = dstSparseVector.Dim
+				/* #nosec G103 */
+				appendSize += int64(unsafe.Sizeof(row))
 			default:
 				log.Error("Not supported field type", zap.String("field type", fieldData.Type.String()))
 			}
@@ -679,6 +798,15 @@ func DeleteFieldData(dst []*schemapb.FieldData) {
 				dstBinaryVector.BinaryVector = dstBinaryVector.BinaryVector[:len(dstBinaryVector.BinaryVector)-int(dim/8)]
 			case *schemapb.VectorField_FloatVector:
 				dstVector.GetFloatVector().Data = dstVector.GetFloatVector().Data[:len(dstVector.GetFloatVector().Data)-int(dim)]
+			case *schemapb.VectorField_Float16Vector:
+				dstFloat16Vector := dstVector.Data.(*schemapb.VectorField_Float16Vector)
+				dstFloat16Vector.Float16Vector = dstFloat16Vector.Float16Vector[:len(dstFloat16Vector.Float16Vector)-int(dim*2)]
+			case *schemapb.VectorField_Bfloat16Vector:
+				dstBfloat16Vector := dstVector.Data.(*schemapb.VectorField_Bfloat16Vector)
+				dstBfloat16Vector.Bfloat16Vector = dstBfloat16Vector.Bfloat16Vector[:len(dstBfloat16Vector.Bfloat16Vector)-int(dim*2)]
+			case *schemapb.VectorField_SparseFloatVector:
+				dstSparseVector := dstVector.GetSparseFloatVector()
+				dstSparseVector.Contents = dstSparseVector.Contents[:len(dstSparseVector.Contents)-1]
 			default:
 				log.Error("wrong field type added", zap.String("field type", fieldData.Type.String()))
 			}
@@ -841,6 +969,38 @@ func MergeFieldData(dst []*schemapb.FieldData, src []*schemapb.FieldData) error
 				} else {
 					dstVector.GetFloatVector().Data = append(dstVector.GetFloatVector().Data, srcVector.FloatVector.Data...)
 				}
+			case *schemapb.VectorField_Float16Vector:
+				if dstVector.GetFloat16Vector() == nil {
+					dstVector.Data = &schemapb.VectorField_Float16Vector{
+						Float16Vector: srcVector.Float16Vector,
+					}
+				} else {
+					dstFloat16Vector := dstVector.Data.(*schemapb.VectorField_Float16Vector)
+					dstFloat16Vector.Float16Vector = append(dstFloat16Vector.Float16Vector, srcVector.Float16Vector...)
+				}
+			case *schemapb.VectorField_Bfloat16Vector:
+				if dstVector.GetBfloat16Vector() == nil {
+					dstVector.Data = &schemapb.VectorField_Bfloat16Vector{
+						Bfloat16Vector: srcVector.Bfloat16Vector,
+					}
+				} else {
+					dstBfloat16Vector := dstVector.Data.(*schemapb.VectorField_Bfloat16Vector)
+					dstBfloat16Vector.Bfloat16Vector = append(dstBfloat16Vector.Bfloat16Vector, srcVector.Bfloat16Vector...)
+				}
+			case *schemapb.VectorField_SparseFloatVector:
+				if dstVector.GetSparseFloatVector() == nil {
+					dstVector.Data = &schemapb.VectorField_SparseFloatVector{
+						SparseFloatVector: &schemapb.SparseFloatArray{
+							Contents: srcVector.SparseFloatVector.Contents,
+							Dim:      srcVector.SparseFloatVector.Dim,
+						},
+					}
+				} else {
+					dstSparseVector := dstVector.GetSparseFloatVector()
+					dstSparseVector.Contents = append(dstSparseVector.Contents, srcVector.SparseFloatVector.Contents...)
+					dstSparseVector.Dim = max(dstSparseVector.Dim, srcVector.SparseFloatVector.Dim)
+				}
+				dstVector.Dim = dstVector.GetSparseFloatVector().Dim
 			default:
 				log.Error("Not supported data type", zap.String("data type", srcFieldData.Type.String()))
 				return errors.New("unsupported data type: " + srcFieldData.Type.String())
@@ -1031,6 +1191,14 @@ func GetData(field *schemapb.FieldData, idx int) interface{} {
 		dim := int(field.GetVectors().GetDim())
 		dataBytes := dim / 8
 		return field.GetVectors().GetBinaryVector()[idx*dataBytes : (idx+1)*dataBytes]
+	case schemapb.DataType_Float16Vector:
+		dataBytes := int(field.GetVectors().GetDim()) * 2
+		return field.GetVectors().GetFloat16Vector()[idx*dataBytes : (idx+1)*dataBytes]
+	case schemapb.DataType_BFloat16Vector:
+		dataBytes := int(field.GetVectors().GetDim()) * 2
+		return field.GetVectors().GetBfloat16Vector()[idx*dataBytes : (idx+1)*dataBytes]
+	case schemapb.DataType_SparseFloatVector:
+		return field.GetVectors().GetSparseFloatVector().GetContents()[idx]
 	}
 	return nil
 }
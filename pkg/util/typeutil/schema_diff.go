@@ -0,0 +1,429 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package typeutil
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/cockroachdb/errors"
+	"github.com/golang/protobuf/proto"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/xige-16/stream-read/pkg/common"
+)
+
+// SchemaChangeLevel is how the data of an old schema fits a new schema.
+type SchemaChangeLevel int
+
+const (
+	// SchemaCompatible means the old data is accepted by the new schema as is
+	SchemaCompatible SchemaChangeLevel = iota
+	// SchemaConvertible means the old data is accepted after being converted by the ConversionPlan
+	SchemaConvertible
+	// SchemaBreaking means the old data can not be written into the new schema
+	SchemaBreaking
+)
+
+func (l SchemaChangeLevel) String() string {
+	switch l {
+	case SchemaCompatible:
+		return "Compatible"
+	case SchemaConvertible:
+		return "Convertible"
+	case SchemaBreaking:
+		return "Breaking"
+	default:
+		return "Unknown"
+	}
+}
+
+// SchemaChange is a change of a field or of the collection between two schemas.
+type SchemaChange struct {
+	// FieldName is empty for a change of the collection
+	FieldName string
+	// OldField is nil for an added field, NewField is nil for a dropped one
+	OldField *schemapb.FieldSchema
+	NewField *schemapb.FieldSchema
+	Level    SchemaChangeLevel
+	Reason   string
+}
+
+func (c *SchemaChange) String() string {
+	if len(c.FieldName) == 0 {
+		return fmt.Sprintf("[%s] collection: %s", c.Level, c.Reason)
+	}
+	return fmt.Sprintf("[%s] field %s: %s", c.Level, c.FieldName, c.Reason)
+}
+
+// SchemaDiff is the changes from an old schema to a new one.
+type SchemaDiff struct {
+	Changes []*SchemaChange
+	// Level is the worst level of the changes, SchemaCompatible if nothing changes
+	Level SchemaChangeLevel
+	// Plan converts the FieldData of the old schema to the new one,
+	// the fields with breaking changes can not be converted.
+	Plan *ConversionPlan
+}
+
+// Breaking returns the breaking changes of d.
+func (d *SchemaDiff) Breaking() []*SchemaChange {
+	changes := make([]*SchemaChange, 0)
+	for _, change := range d.Changes {
+		if change.Level == SchemaBreaking {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
+
+func (d *SchemaDiff) add(change *SchemaChange) {
+	d.Changes = append(d.Changes, change)
+	if change.Level > d.Level {
+		d.Level = change.Level
+	}
+}
+
+// ConversionAction is how the FieldData of an old field is converted.
+type ConversionAction int
+
+const (
+	// ConversionKeep keeps the data, only the field id is changed to the one of the new field
+	ConversionKeep ConversionAction = iota
+	// ConversionDrop drops the data of a field which is not in the new schema
+	ConversionDrop
+	// ConversionCast casts the data to the widened type of the new field
+	ConversionCast
+)
+
+func (a ConversionAction) String() string {
+	switch a {
+	case ConversionKeep:
+		return "Keep"
+	case ConversionDrop:
+		return "Drop"
+	case ConversionCast:
+		return "Cast"
+	default:
+		return "Unknown"
+	}
+}
+
+// FieldConversion converts the FieldData of an old field.
+type FieldConversion struct {
+	Action ConversionAction
+	// Field is the new field, nil if dropped
+	Field *schemapb.FieldSchema
+}
+
+// ConversionPlan converts the FieldData of an old schema to a new one, by field name.
+type ConversionPlan struct {
+	Fields map[string]*FieldConversion
+}
+
+// widenings are the types each type can be cast to without losing precision
+var widenings = map[schemapb.DataType][]schemapb.DataType{
+	schemapb.DataType_Int8:    {schemapb.DataType_Int16, schemapb.DataType_Int32, schemapb.DataType_Int64, schemapb.DataType_Float, schemapb.DataType_Double},
+	schemapb.DataType_Int16:   {schemapb.DataType_Int32, schemapb.DataType_Int64, schemapb.DataType_Float, schemapb.DataType_Double},
+	schemapb.DataType_Int32:   {schemapb.DataType_Int64, schemapb.DataType_Double},
+	schemapb.DataType_Float:   {schemapb.DataType_Double},
+	schemapb.DataType_String:  {schemapb.DataType_VarChar},
+	schemapb.DataType_VarChar: {schemapb.DataType_String},
+}
+
+func isWidening(from, to schemapb.DataType) bool {
+	for _, dataType := range widenings[from] {
+		if dataType == to {
+			return true
+		}
+	}
+	return false
+}
+
+// DiffSchema compares the fields of oldSchema and newSchema by name, and classifies each change
+// by how the data written with oldSchema fits newSchema:
+//   - an added field is compatible if it has a default value, or breaking as the old data lacks it,
+//     this version of the schema has no nullable fields;
+//   - a dropped field and disabling the dynamic field are convertible, the data of them is dropped;
+//   - a widened type, e.g. Int32 to Int64 or Float to Double, is convertible;
+//   - a changed primary key, a narrowed type, a changed dimension or element type, and a shortened
+//     max length or capacity are breaking.
+func DiffSchema(oldSchema, newSchema *schemapb.CollectionSchema) *SchemaDiff {
+	diff := &SchemaDiff{Plan: &ConversionPlan{Fields: make(map[string]*FieldConversion)}}
+
+	newFields := make(map[string]*schemapb.FieldSchema)
+	for _, field := range newSchema.GetFields() {
+		if !field.GetIsDynamic() {
+			newFields[field.GetName()] = field
+		}
+	}
+	oldFields := make(map[string]*schemapb.FieldSchema)
+	for _, field := range oldSchema.GetFields() {
+		if field.GetIsDynamic() {
+			continue
+		}
+		oldFields[field.GetName()] = field
+		newField, ok := newFields[field.GetName()]
+		if !ok && field.GetIsPrimaryKey() {
+			diff.add(&SchemaChange{FieldName: field.GetName(), OldField: field, Level: SchemaBreaking, Reason: "primary key is dropped"})
+			continue
+		}
+		if !ok {
+			diff.add(&SchemaChange{
+				FieldName: field.GetName(),
+				OldField:  field,
+				Level:     SchemaConvertible,
+				Reason:    "dropped, the data of it is dropped",
+			})
+			diff.Plan.Fields[field.GetName()] = &FieldConversion{Action: ConversionDrop}
+			continue
+		}
+		diffField(diff, field, newField)
+	}
+
+	for _, field := range newSchema.GetFields() {
+		if _, ok := oldFields[field.GetName()]; ok || field.GetIsDynamic() {
+			continue
+		}
+		change := &SchemaChange{FieldName: field.GetName(), NewField: field}
+		switch {
+		case field.GetIsPrimaryKey():
+			change.Level, change.Reason = SchemaBreaking, "added as the primary key"
+		case field.GetDefaultValue() != nil:
+			change.Level, change.Reason = SchemaCompatible, "added with a default value"
+		default:
+			change.Level, change.Reason = SchemaBreaking, "added without a default value, the old data lacks it"
+		}
+		diff.add(change)
+	}
+
+	diffDynamicField(diff, oldSchema, newSchema)
+	return diff
+}
+
+// diffField compares a field existing in both schemas.
+func diffField(diff *SchemaDiff, oldField, newField *schemapb.FieldSchema) {
+	name := oldField.GetName()
+	add := func(level SchemaChangeLevel, format string, args ...any) {
+		diff.add(&SchemaChange{
+			FieldName: name,
+			OldField:  oldField,
+			NewField:  newField,
+			Level:     level,
+			Reason:    fmt.Sprintf(format, args...),
+		})
+	}
+	breaking := false
+	breakf := func(format string, args ...any) {
+		breaking = true
+		add(SchemaBreaking, format, args...)
+	}
+
+	oldType, newType := oldField.GetDataType(), newField.GetDataType()
+	switch {
+	case oldField.GetIsPrimaryKey() != newField.GetIsPrimaryKey():
+		breakf("primary key is changed")
+	case oldField.GetIsPrimaryKey() && oldType != newType:
+		breakf("primary key type is changed from %s to %s", oldType, newType)
+	case oldField.GetIsPrimaryKey() && !oldField.GetAutoID() && newField.GetAutoID():
+		breakf("primary key becomes auto id, the replayed deletes would not match the generated ids")
+	case oldField.GetIsPrimaryKey() && oldField.GetAutoID() && !newField.GetAutoID():
+		add(SchemaCompatible, "primary key is no longer auto id, the ids in the old data are kept")
+	}
+
+	cast := false
+	switch {
+	case breaking:
+	case oldType == newType:
+	case isWidening(oldType, newType):
+		cast = true
+		add(SchemaConvertible, "type is widened from %s to %s", oldType, newType)
+	default:
+		breakf("type is changed from %s to %s", oldType, newType)
+	}
+
+	if !breaking && oldType == newType {
+		if IsVectorType(oldType) && !IsSparseFloatVectorType(oldType) {
+			oldDim, oldErr := GetDim(oldField)
+			newDim, newErr := GetDim(newField)
+			switch {
+			case oldErr != nil:
+				breakf("dimension of the old field is invalid, %s", oldErr.Error())
+			case newErr != nil:
+				breakf("dimension of the new field is invalid, %s", newErr.Error())
+			case oldDim != newDim:
+				breakf("dimension is changed from %d to %d", oldDim, newDim)
+			}
+		}
+		if IsArrayType(oldType) && oldField.GetElementType() != newField.GetElementType() {
+			breakf("element type is changed from %s to %s", oldField.GetElementType(), newField.GetElementType())
+		}
+		for _, key := range []string{common.MaxLengthKey, common.MaxCapacityKey} {
+			oldLimit, oldOk := getTypeParamInt(oldField, key)
+			newLimit, newOk := getTypeParamInt(newField, key)
+			switch {
+			case !oldOk || !newOk || oldLimit == newLimit:
+			case newLimit < oldLimit:
+				breakf("%s is shortened from %d to %d", key, oldLimit, newLimit)
+			default:
+				add(SchemaCompatible, "%s is extended from %d to %d", key, oldLimit, newLimit)
+			}
+		}
+	}
+
+	if oldField.GetIsPartitionKey() != newField.GetIsPartitionKey() {
+		add(SchemaCompatible, "partition key is changed to %t", newField.GetIsPartitionKey())
+	}
+	if !proto.Equal(oldField.GetDefaultValue(), newField.GetDefaultValue()) {
+		add(SchemaCompatible, "default value is changed")
+	}
+
+	if breaking {
+		return
+	}
+	if cast {
+		diff.Plan.Fields[name] = &FieldConversion{Action: ConversionCast, Field: newField}
+	} else {
+		diff.Plan.Fields[name] = &FieldConversion{Action: ConversionKeep, Field: newField}
+	}
+}
+
+func diffDynamicField(diff *SchemaDiff, oldSchema, newSchema *schemapb.CollectionSchema) {
+	oldDynamic, newDynamic := dynamicField(oldSchema), dynamicField(newSchema)
+	switch {
+	case oldDynamic == nil && newDynamic != nil:
+		diff.add(&SchemaChange{NewField: newDynamic, Level: SchemaCompatible, Reason: "dynamic field is enabled"})
+	case oldDynamic != nil && newDynamic == nil:
+		diff.add(&SchemaChange{OldField: oldDynamic, Level: SchemaConvertible, Reason: "dynamic field is disabled, the dynamic keys are dropped"})
+		diff.Plan.Fields[oldDynamic.GetName()] = &FieldConversion{Action: ConversionDrop}
+	case oldDynamic != nil:
+		diff.Plan.Fields[oldDynamic.GetName()] = &FieldConversion{Action: ConversionKeep, Field: newDynamic}
+	}
+}
+
+// dynamicField returns the dynamic field of schema, nil if it is not enabled.
+func dynamicField(schema *schemapb.CollectionSchema) *schemapb.FieldSchema {
+	for _, field := range schema.GetFields() {
+		if field.GetIsDynamic() {
+			return field
+		}
+	}
+	if schema.GetEnableDynamicField() {
+		return &schemapb.FieldSchema{Name: common.MetaFieldName, DataType: schemapb.DataType_JSON, IsDynamic: true}
+	}
+	return nil
+}
+
+func getTypeParamInt(field *schemapb.FieldSchema, key string) (int64, bool) {
+	value, err := NewKvPairs(field.GetTypeParams()).Get(key)
+	if err != nil {
+		return 0, false
+	}
+	v, err := strconv.ParseInt(value, 10, 64)
+	return v, err == nil
+}
+
+// Convert converts fd of the old schema to the new one, returns nil if the field is dropped.
+// fd is not modified, the converted data may share the slices with it.
+func (p *ConversionPlan) Convert(fd *schemapb.FieldData) (*schemapb.FieldData, error) {
+	name := fd.GetFieldName()
+	if fd.GetIsDynamic() && len(name) == 0 {
+		name = common.MetaFieldName
+	}
+	conversion, ok := p.Fields[name]
+	if !ok {
+		return nil, errors.Newf("field %s can not be converted to the new schema", name)
+	}
+	if conversion.Action == ConversionDrop {
+		return nil, nil
+	}
+
+	converted := &schemapb.FieldData{
+		Type:      conversion.Field.GetDataType(),
+		FieldName: conversion.Field.GetName(),
+		Field:     fd.GetField(),
+		FieldId:   conversion.Field.GetFieldID(),
+		IsDynamic: fd.GetIsDynamic(),
+	}
+	if conversion.Action == ConversionCast {
+		scalars, err := castScalars(fd.GetScalars(), fd.GetType(), conversion.Field.GetDataType())
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to cast field %s", name)
+		}
+		converted.Field = &schemapb.FieldData_Scalars{Scalars: scalars}
+	}
+	return converted, nil
+}
+
+// ConvertAll converts the fields of a message of the old schema, the dropped fields are removed.
+func (p *ConversionPlan) ConvertAll(fields []*schemapb.FieldData) ([]*schemapb.FieldData, error) {
+	converted := make([]*schemapb.FieldData, 0, len(fields))
+	for _, fd := range fields {
+		c, err := p.Convert(fd)
+		if err != nil {
+			return nil, err
+		}
+		if c != nil {
+			converted = append(converted, c)
+		}
+	}
+	return converted, nil
+}
+
+// castScalars casts the data of scalars from a type to the widened one.
+func castScalars(scalars *schemapb.ScalarField, from, to schemapb.DataType) (*schemapb.ScalarField, error) {
+	if !isWidening(from, to) {
+		return nil, errors.Newf("%s can not be cast to %s", from, to)
+	}
+	switch to {
+	case schemapb.DataType_Int16, schemapb.DataType_Int32, schemapb.DataType_VarChar, schemapb.DataType_String:
+		// the same storage
+		return scalars, nil
+	case schemapb.DataType_Int64:
+		data := scalars.GetIntData().GetData()
+		longs := make([]int64, len(data))
+		for i, v := range data {
+			longs[i] = int64(v)
+		}
+		return &schemapb.ScalarField{Data: &schemapb.ScalarField_LongData{LongData: &schemapb.LongArray{Data: longs}}}, nil
+	case schemapb.DataType_Float:
+		data := scalars.GetIntData().GetData()
+		floats := make([]float32, len(data))
+		for i, v := range data {
+			floats[i] = float32(v)
+		}
+		return &schemapb.ScalarField{Data: &schemapb.ScalarField_FloatData{FloatData: &schemapb.FloatArray{Data: floats}}}, nil
+	case schemapb.DataType_Double:
+		var doubles []float64
+		if from == schemapb.DataType_Float {
+			data := scalars.GetFloatData().GetData()
+			doubles = make([]float64, len(data))
+			for i, v := range data {
+				doubles[i] = float64(v)
+			}
+		} else {
+			data := scalars.GetIntData().GetData()
+			doubles = make([]float64, len(data))
+			for i, v := range data {
+				doubles[i] = float64(v)
+			}
+		}
+		return &schemapb.ScalarField{Data: &schemapb.ScalarField_DoubleData{DoubleData: &schemapb.DoubleArray{Data: doubles}}}, nil
+	default:
+		return nil, errors.Newf("%s can not be cast to %s", from, to)
+	}
+}
@@ -0,0 +1,284 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package typeutil
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/milvus-io/milvus-proto/go-api/v2/commonpb"
+	"github.com/milvus-io/milvus-proto/go-api/v2/schemapb"
+	"github.com/xige-16/stream-read/pkg/common"
+)
+
+func newDiffTestSchema(fields ...*schemapb.FieldSchema) *schemapb.CollectionSchema {
+	return &schemapb.CollectionSchema{
+		Name: "test",
+		Fields: append([]*schemapb.FieldSchema{
+			{FieldID: 100, Name: "pk", DataType: schemapb.DataType_Int64, IsPrimaryKey: true},
+		}, fields...),
+	}
+}
+
+func vectorField(id int64, dim string) *schemapb.FieldSchema {
+	return &schemapb.FieldSchema{
+		FieldID:    id,
+		Name:       "vec",
+		DataType:   schemapb.DataType_FloatVector,
+		TypeParams: []*commonpb.KeyValuePair{{Key: common.DimKey, Value: dim}},
+	}
+}
+
+func TestDiffSchema(t *testing.T) {
+	t.Run("unchanged", func(t *testing.T) {
+		schema := newDiffTestSchema(vectorField(101, "8"))
+		diff := DiffSchema(schema, schema)
+		assert.Equal(t, SchemaCompatible, diff.Level)
+		assert.Empty(t, diff.Changes)
+		assert.Equal(t, ConversionKeep, diff.Plan.Fields["pk"].Action)
+		assert.Equal(t, ConversionKeep, diff.Plan.Fields["vec"].Action)
+	})
+
+	cases := []struct {
+		name     string
+		old      *schemapb.CollectionSchema
+		new      *schemapb.CollectionSchema
+		level    SchemaChangeLevel
+		reason   string
+		expected map[string]ConversionAction
+	}{
+		{
+			name: "added field with default value",
+			old:  newDiffTestSchema(),
+			new: newDiffTestSchema(&schemapb.FieldSchema{
+				FieldID: 101, Name: "age", DataType: schemapb.DataType_Int32,
+				DefaultValue: &schemapb.ValueField{Data: &schemapb.ValueField_IntData{IntData: 1}},
+			}),
+			level:    SchemaCompatible,
+			reason:   "[Compatible] field age: added with a default value",
+			expected: map[string]ConversionAction{"pk": ConversionKeep},
+		},
+		{
+			name:     "added field without default value",
+			old:      newDiffTestSchema(),
+			new:      newDiffTestSchema(&schemapb.FieldSchema{FieldID: 101, Name: "age", DataType: schemapb.DataType_Int32}),
+			level:    SchemaBreaking,
+			reason:   "[Breaking] field age: added without a default value, the old data lacks it",
+			expected: map[string]ConversionAction{"pk": ConversionKeep},
+		},
+		{
+			name:     "dropped field",
+			old:      newDiffTestSchema(&schemapb.FieldSchema{FieldID: 101, Name: "age", DataType: schemapb.DataType_Int32}),
+			new:      newDiffTestSchema(),
+			level:    SchemaConvertible,
+			reason:   "[Convertible] field age: dropped, the data of it is dropped",
+			expected: map[string]ConversionAction{"pk": ConversionKeep, "age": ConversionDrop},
+		},
+		{
+			name:     "widened type",
+			old:      newDiffTestSchema(&schemapb.FieldSchema{FieldID: 101, Name: "age", DataType: schemapb.DataType_Int16}),
+			new:      newDiffTestSchema(&schemapb.FieldSchema{FieldID: 201, Name: "age", DataType: schemapb.DataType_Int64}),
+			level:    SchemaConvertible,
+			reason:   "[Convertible] field age: type is widened from Int16 to Int64",
+			expected: map[string]ConversionAction{"pk": ConversionKeep, "age": ConversionCast},
+		},
+		{
+			name:     "narrowed type",
+			old:      newDiffTestSchema(&schemapb.FieldSchema{FieldID: 101, Name: "age", DataType: schemapb.DataType_Double}),
+			new:      newDiffTestSchema(&schemapb.FieldSchema{FieldID: 101, Name: "age", DataType: schemapb.DataType_Float}),
+			level:    SchemaBreaking,
+			reason:   "[Breaking] field age: type is changed from Double to Float",
+			expected: map[string]ConversionAction{"pk": ConversionKeep},
+		},
+		{
+			name:     "dimension",
+			old:      newDiffTestSchema(vectorField(101, "8")),
+			new:      newDiffTestSchema(vectorField(101, "16")),
+			level:    SchemaBreaking,
+			reason:   "[Breaking] field vec: dimension is changed from 8 to 16",
+			expected: map[string]ConversionAction{"pk": ConversionKeep},
+		},
+		{
+			name:     "invalid new dimension",
+			old:      newDiffTestSchema(vectorField(101, "8")),
+			new:      newDiffTestSchema(vectorField(101, "abc")),
+			level:    SchemaBreaking,
+			reason:   "[Breaking] field vec: dimension of the new field is invalid, invalid dimension: abc",
+			expected: map[string]ConversionAction{"pk": ConversionKeep},
+		},
+		{
+			name: "missing old dimension",
+			old: newDiffTestSchema(&schemapb.FieldSchema{
+				FieldID: 101, Name: "vec", DataType: schemapb.DataType_FloatVector,
+			}),
+			new:      newDiffTestSchema(vectorField(101, "8")),
+			level:    SchemaBreaking,
+			reason:   "[Breaking] field vec: dimension of the old field is invalid, dim not found",
+			expected: map[string]ConversionAction{"pk": ConversionKeep},
+		},
+		{
+			name: "pk type",
+			old:  newDiffTestSchema(),
+			new: &schemapb.CollectionSchema{Fields: []*schemapb.FieldSchema{
+				{FieldID: 100, Name: "pk", DataType: schemapb.DataType_VarChar, IsPrimaryKey: true},
+			}},
+			level:    SchemaBreaking,
+			reason:   "[Breaking] field pk: primary key type is changed from Int64 to VarChar",
+			expected: map[string]ConversionAction{},
+		},
+		{
+			name: "pk becomes auto id",
+			old:  newDiffTestSchema(),
+			new: &schemapb.CollectionSchema{Fields: []*schemapb.FieldSchema{
+				{FieldID: 100, Name: "pk", DataType: schemapb.DataType_Int64, IsPrimaryKey: true, AutoID: true},
+			}},
+			level:    SchemaBreaking,
+			reason:   "[Breaking] field pk: primary key becomes auto id, the replayed deletes would not match the generated ids",
+			expected: map[string]ConversionAction{},
+		},
+		{
+			name: "shortened max length",
+			old: newDiffTestSchema(&schemapb.FieldSchema{
+				FieldID: 101, Name: "name", DataType: schemapb.DataType_VarChar,
+				TypeParams: []*commonpb.KeyValuePair{{Key: common.MaxLengthKey, Value: "64"}},
+			}),
+			new: newDiffTestSchema(&schemapb.FieldSchema{
+				FieldID: 101, Name: "name", DataType: schemapb.DataType_VarChar,
+				TypeParams: []*commonpb.KeyValuePair{{Key: common.MaxLengthKey, Value: "32"}},
+			}),
+			level:    SchemaBreaking,
+			reason:   "[Breaking] field name: max_length is shortened from 64 to 32",
+			expected: map[string]ConversionAction{"pk": ConversionKeep},
+		},
+		{
+			name: "array element type",
+			old: newDiffTestSchema(&schemapb.FieldSchema{
+				FieldID: 101, Name: "tags", DataType: schemapb.DataType_Array, ElementType: schemapb.DataType_Int64,
+			}),
+			new: newDiffTestSchema(&schemapb.FieldSchema{
+				FieldID: 101, Name: "tags", DataType: schemapb.DataType_Array, ElementType: schemapb.DataType_VarChar,
+			}),
+			level:    SchemaBreaking,
+			reason:   "[Breaking] field tags: element type is changed from Int64 to VarChar",
+			expected: map[string]ConversionAction{"pk": ConversionKeep},
+		},
+		{
+			name: "dynamic field enabled",
+			old:  newDiffTestSchema(),
+			new: func() *schemapb.CollectionSchema {
+				schema := newDiffTestSchema()
+				schema.EnableDynamicField = true
+				return schema
+			}(),
+			level:    SchemaCompatible,
+			reason:   "[Compatible] collection: dynamic field is enabled",
+			expected: map[string]ConversionAction{"pk": ConversionKeep},
+		},
+		{
+			name: "dynamic field disabled",
+			old: newDiffTestSchema(&schemapb.FieldSchema{
+				FieldID: 101, Name: common.MetaFieldName, DataType: schemapb.DataType_JSON, IsDynamic: true,
+			}),
+			new:      newDiffTestSchema(),
+			level:    SchemaConvertible,
+			reason:   "[Convertible] collection: dynamic field is disabled, the dynamic keys are dropped",
+			expected: map[string]ConversionAction{"pk": ConversionKeep, common.MetaFieldName: ConversionDrop},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			diff := DiffSchema(c.old, c.new)
+			assert.Equal(t, c.level, diff.Level)
+			require.Len(t, diff.Changes, 1)
+			assert.Equal(t, c.reason, diff.Changes[0].String())
+			actions := make(map[string]ConversionAction)
+			for name, conversion := range diff.Plan.Fields {
+				actions[name] = conversion.Action
+			}
+			assert.Equal(t, c.expected, actions)
+			if c.level == SchemaBreaking {
+				assert.Len(t, diff.Breaking(), 1)
+			} else {
+				assert.Empty(t, diff.Breaking())
+			}
+		})
+	}
+}
+
+func TestConversionPlan(t *testing.T) {
+	oldSchema := newDiffTestSchema(
+		&schemapb.FieldSchema{FieldID: 101, Name: "age", DataType: schemapb.DataType_Int32},
+		&schemapb.FieldSchema{FieldID: 102, Name: "score", DataType: schemapb.DataType_Float},
+		&schemapb.FieldSchema{FieldID: 103, Name: "level", DataType: schemapb.DataType_Int8},
+		&schemapb.FieldSchema{FieldID: 104, Name: "dropped", DataType: schemapb.DataType_Bool},
+		&schemapb.FieldSchema{FieldID: 105, Name: "tag", DataType: schemapb.DataType_Int8},
+	)
+	newSchema := &schemapb.CollectionSchema{Fields: []*schemapb.FieldSchema{
+		{FieldID: 200, Name: "pk", DataType: schemapb.DataType_Int64, IsPrimaryKey: true},
+		{FieldID: 201, Name: "age", DataType: schemapb.DataType_Int64},
+		{FieldID: 202, Name: "score", DataType: schemapb.DataType_Double},
+		{FieldID: 203, Name: "level", DataType: schemapb.DataType_Float},
+		{FieldID: 205, Name: "tag", DataType: schemapb.DataType_VarChar},
+	}}
+	diff := DiffSchema(oldSchema, newSchema)
+	assert.Equal(t, SchemaBreaking, diff.Level)
+
+	scalars := func(data *schemapb.ScalarField) *schemapb.FieldData_Scalars {
+		return &schemapb.FieldData_Scalars{Scalars: data}
+	}
+	fields := []*schemapb.FieldData{
+		{Type: schemapb.DataType_Int64, FieldName: "pk", FieldId: 100, Field: scalars(&schemapb.ScalarField{
+			Data: &schemapb.ScalarField_LongData{LongData: &schemapb.LongArray{Data: []int64{1, 2}}},
+		})},
+		{Type: schemapb.DataType_Int32, FieldName: "age", FieldId: 101, Field: scalars(&schemapb.ScalarField{
+			Data: &schemapb.ScalarField_IntData{IntData: &schemapb.IntArray{Data: []int32{10, 20}}},
+		})},
+		{Type: schemapb.DataType_Float, FieldName: "score", FieldId: 102, Field: scalars(&schemapb.ScalarField{
+			Data: &schemapb.ScalarField_FloatData{FloatData: &schemapb.FloatArray{Data: []float32{0.5, 1.5}}},
+		})},
+		{Type: schemapb.DataType_Int8, FieldName: "level", FieldId: 103, Field: scalars(&schemapb.ScalarField{
+			Data: &schemapb.ScalarField_IntData{IntData: &schemapb.IntArray{Data: []int32{1, -1}}},
+		})},
+		{Type: schemapb.DataType_Bool, FieldName: "dropped", FieldId: 104, Field: scalars(&schemapb.ScalarField{
+			Data: &schemapb.ScalarField_BoolData{BoolData: &schemapb.BoolArray{Data: []bool{true, false}}},
+		})},
+	}
+
+	converted, err := diff.Plan.ConvertAll(fields)
+	require.NoError(t, err)
+	require.Len(t, converted, 4)
+	assert.Equal(t, int64(200), converted[0].GetFieldId())
+	assert.Equal(t, []int64{1, 2}, converted[0].GetScalars().GetLongData().GetData())
+	assert.Equal(t, schemapb.DataType_Int64, converted[1].GetType())
+	assert.Equal(t, int64(201), converted[1].GetFieldId())
+	assert.Equal(t, []int64{10, 20}, converted[1].GetScalars().GetLongData().GetData())
+	assert.Equal(t, []float64{0.5, 1.5}, converted[2].GetScalars().GetDoubleData().GetData())
+	assert.Equal(t, []float32{1, -1}, converted[3].GetScalars().GetFloatData().GetData())
+	// the old data is not modified
+	assert.Equal(t, schemapb.DataType_Int32, fields[1].GetType())
+
+	// the field with a breaking change can not be converted
+	_, err = diff.Plan.ConvertAll(append(fields, &schemapb.FieldData{
+		Type: schemapb.DataType_Int8, FieldName: "tag", FieldId: 105, Field: scalars(&schemapb.ScalarField{
+			Data: &schemapb.ScalarField_IntData{IntData: &schemapb.IntArray{Data: []int32{1, 2}}},
+		}),
+	}))
+	assert.Error(t, err)
+}
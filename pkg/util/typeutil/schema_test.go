@@ -1614,3 +1614,174 @@ func (s *FieldDataSuite) TestPrepareFieldData() {
 func TestFieldData(t *testing.T) {
 	suite.Run(t, new(FieldDataSuite))
 }
+
+// newVectorFieldData returns the field data of rows vectors of dataType, the rows differ from each other.
+func newVectorFieldData(dataType schemapb.DataType, dim int64, rows int) *schemapb.FieldData {
+	vectors := &schemapb.VectorField{Dim: dim}
+	switch dataType {
+	case schemapb.DataType_FloatVector:
+		data := make([]float32, 0, rows*int(dim))
+		for i := 0; i < rows*int(dim); i++ {
+			data = append(data, float32(i))
+		}
+		vectors.Data = &schemapb.VectorField_FloatVector{FloatVector: &schemapb.FloatArray{Data: data}}
+	case schemapb.DataType_BinaryVector:
+		data := make([]byte, 0, rows*int(dim)/8)
+		for i := 0; i < rows*int(dim)/8; i++ {
+			data = append(data, byte(i))
+		}
+		vectors.Data = &schemapb.VectorField_BinaryVector{BinaryVector: data}
+	case schemapb.DataType_Float16Vector, schemapb.DataType_BFloat16Vector:
+		data := make([]byte, 0, rows*int(dim)*2)
+		for i := 0; i < rows*int(dim)*2; i++ {
+			data = append(data, byte(i))
+		}
+		if dataType == schemapb.DataType_Float16Vector {
+			vectors.Data = &schemapb.VectorField_Float16Vector{Float16Vector: data}
+		} else {
+			vectors.Data = &schemapb.VectorField_Bfloat16Vector{Bfloat16Vector: data}
+		}
+	case schemapb.DataType_SparseFloatVector:
+		contents := make([][]byte, 0, rows)
+		for i := 0; i < rows; i++ {
+			// row i has i+1 elements, the max index is 10*i
+			indices := make([]uint32, 0, i+1)
+			values := make([]float32, 0, i+1)
+			for j := 0; j <= i; j++ {
+				indices = append(indices, uint32(10*j))
+				values = append(values, float32(j+1))
+			}
+			contents = append(contents, CreateSparseFloatRow(indices, values))
+		}
+		vectors.Dim = SparseFloatRowsDim(contents)
+		vectors.Data = &schemapb.VectorField_SparseFloatVector{SparseFloatVector: &schemapb.SparseFloatArray{
+			Contents: contents,
+			Dim:      vectors.Dim,
+		}}
+	}
+	return &schemapb.FieldData{
+		Type:      dataType,
+		FieldName: "vec",
+		FieldId:   100,
+		Field:     &schemapb.FieldData_Vectors{Vectors: vectors},
+	}
+}
+
+func TestVectorTypes(t *testing.T) {
+	const dim = 16
+	const rows = 3
+	cases := []struct {
+		dataType schemapb.DataType
+		// rowSize is the size of a row in bytes, the rows of sparse vectors vary in size
+		rowSize    int
+		dense      bool
+		sparse     bool
+		floating   bool
+		recordSize int
+	}{
+		{schemapb.DataType_FloatVector, dim * 4, true, false, true, dim * 4},
+		{schemapb.DataType_BinaryVector, dim / 8, false, false, false, dim / 8},
+		{schemapb.DataType_Float16Vector, dim * 2, true, false, true, dim * 2},
+		{schemapb.DataType_BFloat16Vector, dim * 2, true, false, true, dim * 2},
+		{schemapb.DataType_SparseFloatVector, -1, false, true, true, sparseFloatEstimatedRowSize},
+	}
+	for _, c := range cases {
+		t.Run(c.dataType.String(), func(t *testing.T) {
+			fieldSchema := &schemapb.FieldSchema{
+				FieldID:  100,
+				Name:     "vec",
+				DataType: c.dataType,
+			}
+			if !c.sparse {
+				fieldSchema.TypeParams = []*commonpb.KeyValuePair{{Key: common.DimKey, Value: "16"}}
+			}
+			src := newVectorFieldData(c.dataType, dim, rows)
+			rowSize := func(i int) int {
+				if c.sparse {
+					return len(src.GetVectors().GetSparseFloatVector().GetContents()[i])
+				}
+				return c.rowSize
+			}
+
+			t.Run("type", func(t *testing.T) {
+				assert.True(t, IsVectorType(c.dataType))
+				assert.Equal(t, c.dense, IsDenseFloatVectorType(c.dataType))
+				assert.Equal(t, c.sparse, IsSparseFloatVectorType(c.dataType))
+				assert.Equal(t, c.floating, IsFloatVectorType(c.dataType))
+				assert.Equal(t, !c.floating, IsBinaryVectorType(c.dataType))
+			})
+
+			t.Run("dim", func(t *testing.T) {
+				d, err := GetDim(fieldSchema)
+				if c.sparse {
+					assert.Error(t, err)
+					return
+				}
+				assert.NoError(t, err)
+				assert.EqualValues(t, dim, d)
+			})
+
+			t.Run("gen empty field data", func(t *testing.T) {
+				fd, err := GenEmptyFieldData(fieldSchema)
+				require.NoError(t, err)
+				assert.Equal(t, c.dataType, fd.GetType())
+				assert.Equal(t, "vec", fd.GetFieldName())
+				assert.Equal(t, int64(100), fd.GetFieldId())
+				assert.Equal(t, reflect.TypeOf(src.GetVectors().GetData()), reflect.TypeOf(fd.GetVectors().GetData()))
+				assert.Equal(t, 0, CalcColumnSize(fd))
+			})
+
+			t.Run("size", func(t *testing.T) {
+				total := 0
+				for i := 0; i < rows; i++ {
+					size, err := EstimateEntitySize([]*schemapb.FieldData{src}, i)
+					assert.NoError(t, err)
+					if c.dataType == schemapb.DataType_BinaryVector {
+						// EstimateEntitySize counts a bit as a byte
+						assert.Equal(t, dim, size)
+					} else {
+						assert.Equal(t, rowSize(i), size)
+					}
+					total += rowSize(i)
+				}
+				assert.Equal(t, total, CalcColumnSize(src))
+
+				size, err := EstimateSizePerRecord(&schemapb.CollectionSchema{Fields: []*schemapb.FieldSchema{fieldSchema}})
+				assert.NoError(t, err)
+				assert.Equal(t, c.recordSize, size)
+			})
+
+			t.Run("append and delete", func(t *testing.T) {
+				dst := make([]*schemapb.FieldData, 1)
+				for i := rows - 1; i >= 0; i-- {
+					appendSize := AppendFieldData(dst, []*schemapb.FieldData{src}, int64(i))
+					assert.Greater(t, appendSize, int64(0))
+				}
+				assert.Equal(t, c.dataType, dst[0].GetType())
+				assert.Equal(t, src.GetVectors().GetDim(), dst[0].GetVectors().GetDim())
+				for i := 0; i < rows; i++ {
+					assert.Equal(t, GetData(src, rows-1-i), GetData(dst[0], i))
+				}
+
+				DeleteFieldData(dst)
+				assert.Equal(t, CalcColumnSize(src)-rowSize(0), CalcColumnSize(dst[0]))
+			})
+
+			t.Run("merge", func(t *testing.T) {
+				dst := []*schemapb.FieldData{newVectorFieldData(c.dataType, dim, 1)}
+				require.NoError(t, MergeFieldData(dst, []*schemapb.FieldData{src}))
+				assert.Equal(t, rowSize(0)+CalcColumnSize(src), CalcColumnSize(dst[0]))
+				assert.Equal(t, GetData(src, 0), GetData(dst[0], 0))
+				assert.Equal(t, GetData(src, rows-1), GetData(dst[0], rows))
+				assert.Equal(t, src.GetVectors().GetDim(), dst[0].GetVectors().GetDim())
+			})
+
+			t.Run("prepare result", func(t *testing.T) {
+				result := PrepareResultFieldData([]*schemapb.FieldData{src}, 10)
+				require.Len(t, result, 1)
+				assert.Equal(t, reflect.TypeOf(src.GetVectors().GetData()), reflect.TypeOf(result[0].GetVectors().GetData()))
+				assert.Equal(t, 0, CalcColumnSize(result[0]))
+			})
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package typeutil
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// A sparse float row is a list of (uint32 index, float32 value) pairs in little endian,
+// sorted by index without duplicates.
+const sparseFloatElementSize = 8
+
+// CreateSparseFloatRow encodes the pairs of indices and values into a sparse float row.
+func CreateSparseFloatRow(indices []uint32, values []float32) []byte {
+	order := make([]int, len(indices))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return indices[order[i]] < indices[order[j]]
+	})
+	row := make([]byte, len(indices)*sparseFloatElementSize)
+	for i, j := range order {
+		binary.LittleEndian.PutUint32(row[i*sparseFloatElementSize:], indices[j])
+		binary.LittleEndian.PutUint32(row[i*sparseFloatElementSize+4:], math.Float32bits(values[j]))
+	}
+	return row
+}
+
+// SparseFloatRowElementCount returns the number of the non-zero elements of row.
+func SparseFloatRowElementCount(row []byte) int {
+	return len(row) / sparseFloatElementSize
+}
+
+// SparseFloatRowIndexAt returns the index of the i-th element of row.
+func SparseFloatRowIndexAt(row []byte, i int) uint32 {
+	return binary.LittleEndian.Uint32(row[i*sparseFloatElementSize:])
+}
+
+// SparseFloatRowValueAt returns the value of the i-th element of row.
+func SparseFloatRowValueAt(row []byte, i int) float32 {
+	return math.Float32frombits(binary.LittleEndian.Uint32(row[i*sparseFloatElementSize+4:]))
+}
+
+// SparseFloatRowDim returns the dim of row, which is the max index plus one, 0 for an empty row.
+func SparseFloatRowDim(row []byte) int64 {
+	n := SparseFloatRowElementCount(row)
+	if n == 0 {
+		return 0
+	}
+	return int64(SparseFloatRowIndexAt(row, n-1)) + 1
+}
+
+// SparseFloatRowsDim returns the max dim of rows.
+func SparseFloatRowsDim(rows [][]byte) int64 {
+	var dim int64
+	for _, row := range rows {
+		dim = max(dim, SparseFloatRowDim(row))
+	}
+	return dim
+}
+
+// ValidateSparseFloatRows checks that rows are well formed, with strictly increasing indices
+// and finite values.
+func ValidateSparseFloatRows(rows ...[]byte) error {
+	for i, row := range rows {
+		if len(row)%sparseFloatElementSize != 0 {
+			return fmt.Errorf("invalid data length %d of sparse float row %d", len(row), i)
+		}
+		for j := 0; j < SparseFloatRowElementCount(row); j++ {
+			index := SparseFloatRowIndexAt(row, j)
+			if index == math.MaxUint32 {
+				return fmt.Errorf("invalid index %d of sparse float row %d", index, i)
+			}
+			if j > 0 && index <= SparseFloatRowIndexAt(row, j-1) {
+				return fmt.Errorf("unordered or duplicated index %d of sparse float row %d", index, i)
+			}
+			if err := VerifyFloat(float64(SparseFloatRowValueAt(row, j))); err != nil {
+				return fmt.Errorf("invalid value of sparse float row %d: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
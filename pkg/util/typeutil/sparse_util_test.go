@@ -0,0 +1,62 @@
+// Licensed to the LF AI & Data foundation under one
+// or more contributor license agreements. See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership. The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License. You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package typeutil
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSparseFloatRow(t *testing.T) {
+	row := CreateSparseFloatRow([]uint32{10, 1, 5}, []float32{0.1, 0.2, 0.3})
+	assert.Equal(t, 3, SparseFloatRowElementCount(row))
+	assert.Equal(t, uint32(1), SparseFloatRowIndexAt(row, 0))
+	assert.Equal(t, float32(0.2), SparseFloatRowValueAt(row, 0))
+	assert.Equal(t, uint32(10), SparseFloatRowIndexAt(row, 2))
+	assert.Equal(t, float32(0.1), SparseFloatRowValueAt(row, 2))
+	assert.Equal(t, int64(11), SparseFloatRowDim(row))
+	assert.Equal(t, int64(0), SparseFloatRowDim(nil))
+	assert.Equal(t, int64(21), SparseFloatRowsDim([][]byte{row, CreateSparseFloatRow([]uint32{20}, []float32{1})}))
+}
+
+func TestValidateSparseFloatRows(t *testing.T) {
+	cases := []struct {
+		name  string
+		row   []byte
+		valid bool
+	}{
+		{"empty", nil, true},
+		{"sorted", CreateSparseFloatRow([]uint32{1, 2, 3}, []float32{1, 2, 3}), true},
+		{"bad length", []byte{1, 2, 3}, false},
+		{"duplicated index", CreateSparseFloatRow([]uint32{1, 1}, []float32{1, 2}), false},
+		{"max index", CreateSparseFloatRow([]uint32{math.MaxUint32}, []float32{1}), false},
+		{"nan", CreateSparseFloatRow([]uint32{1}, []float32{float32(math.NaN())}), false},
+		{"inf", CreateSparseFloatRow([]uint32{1}, []float32{float32(math.Inf(1))}), false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := ValidateSparseFloatRows(c.row)
+			if c.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}